@@ -0,0 +1,73 @@
+package auditoria
+
+import (
+    "context"
+    "errors"
+    "time"
+)
+
+// Acciones auditadas sobre documentos.
+const (
+    AccionSubir     = "subir"     // Carga de un documento
+    AccionListar    = "listar"    // Listado de documentos
+    AccionDescargar = "descargar" // Descarga del contenido de un documento
+    AccionBuscar    = "buscar"    // Búsqueda en el índice
+    AccionEliminar  = "eliminar"  // Eliminación lógica o definitiva
+)
+
+// Resultados posibles de una acción auditada.
+const (
+    ResultadoExito = "exito" // La acción se completó
+    ResultadoError = "error" // La acción falló
+)
+
+// ErrSecuenciaOcupada indica que otra réplica insertó primero un registro con la misma secuencia.
+var ErrSecuenciaOcupada = errors.New("secuencia de auditoría ocupada")
+
+// Registro es una entrada inmutable del registro de auditoría.
+// Cada registro incluye el hash del anterior, de modo que modificar o borrar uno rompe la cadena.
+type Registro struct {
+    ID           string    `json:"id" bson:"_id"`                                        // Identificador del registro
+    Secuencia    int64     `json:"secuencia" bson:"secuencia"`                           // Posición en la cadena, empezando en 1
+    Fecha        time.Time `json:"fecha" bson:"fecha"`                                   // Instante de la acción (UTC)
+    Actor        string    `json:"actor" bson:"actor"`                                   // Identidad de quien origina la solicitud
+    Rol          string    `json:"rol" bson:"rol"`                                       // Rol del actor
+    Accion       string    `json:"accion" bson:"accion"`                                 // subir, listar, descargar, buscar o eliminar
+    DocumentoID  string    `json:"documento_id,omitempty" bson:"documento_id,omitempty"` // ID del documento en Alfresco
+    RUTCliente   string    `json:"rut_cliente,omitempty" bson:"rut_cliente,omitempty"`   // RUT del cliente dueño del documento
+    IP           string    `json:"ip" bson:"ip"`                                         // IP de origen
+    IDSolicitud  string    `json:"id_solicitud" bson:"id_solicitud"`                     // ID de la solicitud HTTP
+    Resultado    string    `json:"resultado" bson:"resultado"`                           // exito o error
+    Detalle      string    `json:"detalle,omitempty" bson:"detalle,omitempty"`           // Causa del error, si lo hubo
+    HashAnterior string    `json:"hash_anterior" bson:"hash_anterior"`                   // Hash del registro anterior (vacío en el primero)
+    Hash         string    `json:"hash" bson:"hash"`                                     // SHA-256 de este registro encadenado al anterior
+}
+
+// Filtro define los criterios de consulta del registro de auditoría.
+type Filtro struct {
+    RUTCliente  string // Filtra por RUT del cliente
+    DocumentoID string // Filtra por ID del documento
+    Actor       string // Filtra por actor
+    Limite      int    // Cantidad máxima de registros
+}
+
+// Verificacion resume el resultado de recorrer la cadena de hashes.
+type Verificacion struct {
+    Registros     int    `json:"registros"`                // Registros recorridos
+    Integra       bool   `json:"integra"`                  // true si la cadena no presenta alteraciones
+    SecuenciaRota int64  `json:"secuencia_rota,omitempty"` // Primera secuencia donde la cadena no coincide
+    Motivo        string `json:"motivo,omitempty"`         // Descripción de la alteración detectada
+}
+
+// Repositorio persiste el registro de auditoría. Solo permite agregar y consultar.
+type Repositorio interface {
+    // Ultimo retorna el registro con mayor secuencia, o nil si la colección está vacía.
+    Ultimo(ctx context.Context) (*Registro, error)
+    // InsertarLote agrega los registros en orden y retorna cuántos quedaron insertados. Si uno choca con una
+    // secuencia existente se detiene ahí y retorna ErrSecuenciaOcupada.
+    InsertarLote(ctx context.Context, registros []Registro) (int, error)
+    // Buscar retorna los registros más recientes que cumplen el filtro.
+    Buscar(ctx context.Context, filtro Filtro) ([]Registro, error)
+    // Recorrer entrega todos los registros en orden de secuencia.
+    Recorrer(ctx context.Context, visitar func(Registro) error) error
+}
@@ -0,0 +1,213 @@
+package auditoria
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/google/uuid"
+)
+
+// intentosInsercion limita los reintentos cuando varias réplicas compiten por la misma secuencia.
+const intentosInsercion = 5
+
+// limiteConsultaDefecto acota las consultas sin límite explícito.
+const limiteConsultaDefecto = 100
+
+// ServicioAuditoria agrega registros encadenados y permite consultarlos y verificarlos.
+// Las solicitudes concurrentes no esperan cada una su propia inserción: los registros que llegan mientras se
+// escribe un lote se encadenan y se insertan juntos en el lote siguiente.
+type ServicioAuditoria struct {
+    repositorio Repositorio         // Persistencia del registro
+    log         *logger.Registrador // Logger para registrar eventos y errores
+
+    mu          sync.Mutex  // Protege la cola de pendientes y el indicador de escritura
+    pendientes  []pendiente // Registros a la espera del próximo lote
+    escribiendo bool        // Si una solicitud está insertando lotes
+    ultimo      *Registro   // Último registro insertado por esta réplica; nil obliga a leerlo del repositorio
+}
+
+// pendiente es un registro en cola junto con el canal por el que se informa su resultado.
+type pendiente struct {
+    registro  Registro
+    resultado chan error
+}
+
+// NuevoServicioAuditoria construye el servicio con sus dependencias.
+func NuevoServicioAuditoria(repositorio Repositorio, log *logger.Registrador) *ServicioAuditoria {
+    return &ServicioAuditoria{repositorio: repositorio, log: log}
+}
+
+// Registrar agrega un registro al final de la cadena y espera a que quede insertado.
+// Completa ID, fecha, secuencia y hashes; los demás campos los aporta quien llama.
+func (s *ServicioAuditoria) Registrar(ctx context.Context, registro Registro) error {
+    if registro.Fecha.IsZero() {
+        registro.Fecha = time.Now().UTC()
+    }
+    registro.Fecha = registro.Fecha.UTC().Truncate(time.Millisecond) // Precisión que conserva MongoDB
+
+    // 1. Encolar; si nadie está escribiendo, esta solicitud inserta los lotes hasta vaciar la cola
+    espera := pendiente{registro: registro, resultado: make(chan error, 1)}
+    s.mu.Lock()
+    s.pendientes = append(s.pendientes, espera)
+    escribir := !s.escribiendo
+    s.escribiendo = true
+    s.mu.Unlock()
+
+    if escribir {
+        // El lote incluye registros de otras solicitudes: no debe cortarse si esta se cancela
+        s.escribirPendientes(context.WithoutCancel(ctx))
+    }
+    return <-espera.resultado
+}
+
+// escribirPendientes inserta lotes con los registros en cola hasta que no queden pendientes.
+func (s *ServicioAuditoria) escribirPendientes(ctx context.Context) {
+    for {
+        s.mu.Lock()
+        lote := s.pendientes
+        s.pendientes = nil
+        if len(lote) == 0 {
+            s.escribiendo = false
+            s.mu.Unlock()
+            return
+        }
+        s.mu.Unlock()
+        s.insertarLote(ctx, lote)
+    }
+}
+
+// insertarLote encadena los registros tras el último insertado y los agrega. Si otra réplica tomó la
+// secuencia, vuelve a leer el último registro y encadena de nuevo los que no alcanzaron a insertarse.
+// Informa el resultado a cada solicitud del lote.
+func (s *ServicioAuditoria) insertarLote(ctx context.Context, lote []pendiente) {
+    for intento := 1; intento <= intentosInsercion; intento++ {
+        // 1. Enlazar con el último registro; se lee del repositorio solo si no se conoce
+        if s.ultimo == nil {
+            ultimo, err := s.repositorio.Ultimo(ctx)
+            if err != nil {
+                informar(lote, fmt.Errorf("error al leer el último registro de auditoría: %w", err))
+                return
+            }
+            s.ultimo = ultimo
+        }
+        registros := make([]Registro, len(lote))
+        anterior := s.ultimo
+        for i := range lote {
+            registro := lote[i].registro
+            registro.Secuencia, registro.HashAnterior = 1, ""
+            if anterior != nil {
+                registro.Secuencia, registro.HashAnterior = anterior.Secuencia+1, anterior.Hash
+            }
+            registro.ID = uuid.New().String()
+            registro.Hash = CalcularHash(registro)
+            registros[i] = registro
+            anterior = &registros[i]
+        }
+
+        // 2. Insertar; los registros que alcanzaron a insertarse quedan confirmados
+        insertados, err := s.repositorio.InsertarLote(ctx, registros)
+        if insertados > 0 {
+            s.ultimo = &registros[insertados-1]
+            informar(lote[:insertados], nil)
+            lote = lote[insertados:]
+        }
+        if err == nil {
+            return
+        }
+
+        // 3. Ante cualquier error el último registro conocido deja de ser confiable
+        s.ultimo = nil
+        if !errors.Is(err, ErrSecuenciaOcupada) {
+            informar(lote, fmt.Errorf("error al insertar registro de auditoría: %w", err))
+            return
+        }
+    }
+    informar(lote, fmt.Errorf("no se pudo asignar secuencia de auditoría tras %d intentos", intentosInsercion))
+}
+
+// informar entrega el mismo resultado a todas las solicitudes indicadas.
+func informar(lote []pendiente, err error) {
+    for _, espera := range lote {
+        espera.resultado <- err
+    }
+}
+
+// Buscar consulta el registro de auditoría.
+func (s *ServicioAuditoria) Buscar(ctx context.Context, filtro Filtro) ([]Registro, error) {
+    if filtro.Limite <= 0 {
+        filtro.Limite = limiteConsultaDefecto
+    }
+    return s.repositorio.Buscar(ctx, filtro)
+}
+
+// Verificar recorre la cadena completa y reporta el primer registro alterado, borrado o fuera de orden.
+func (s *ServicioAuditoria) Verificar(ctx context.Context) (Verificacion, error) {
+    resultado := Verificacion{Integra: true}
+    var anterior *Registro
+
+    errCorte := errors.New("cadena rota")
+    err := s.repositorio.Recorrer(ctx, func(registro Registro) error {
+        resultado.Registros++
+
+        secuenciaEsperada, hashEsperado := int64(1), ""
+        if anterior != nil {
+            secuenciaEsperada, hashEsperado = anterior.Secuencia+1, anterior.Hash
+        }
+
+        switch {
+        case registro.Secuencia != secuenciaEsperada:
+            resultado.Motivo = fmt.Sprintf("se esperaba la secuencia %d", secuenciaEsperada)
+        case registro.HashAnterior != hashEsperado:
+            resultado.Motivo = "el hash anterior no coincide con el registro previo"
+        case registro.Hash != CalcularHash(registro):
+            resultado.Motivo = "el contenido del registro no coincide con su hash"
+        default:
+            anterior = &registro
+            return nil
+        }
+
+        resultado.Integra = false
+        resultado.SecuenciaRota = registro.Secuencia
+        return errCorte
+    })
+    if err != nil && !errors.Is(err, errCorte) {
+        return Verificacion{}, fmt.Errorf("error al recorrer el registro de auditoría: %w", err)
+    }
+
+    if !resultado.Integra {
+        s.log.ConContexto(ctx).Warn("Cadena de auditoría alterada", map[string]interface{}{
+            "secuencia": resultado.SecuenciaRota,
+            "motivo":    resultado.Motivo,
+        })
+    }
+    return resultado, nil
+}
+
+// CalcularHash obtiene el SHA-256 de los campos del registro junto con el hash anterior.
+// Los campos se separan con un carácter de control para que no puedan confundirse entre sí.
+func CalcularHash(registro Registro) string {
+    campos := []string{
+        registro.ID,
+        strconv.FormatInt(registro.Secuencia, 10),
+        registro.Fecha.UTC().Format(time.RFC3339Nano),
+        registro.Actor,
+        registro.Rol,
+        registro.Accion,
+        registro.DocumentoID,
+        registro.RUTCliente,
+        registro.IP,
+        registro.IDSolicitud,
+        registro.Resultado,
+        registro.Detalle,
+        registro.HashAnterior,
+    }
+    suma := sha256.Sum256([]byte(strings.Join(campos, "\x1f")))
+    return hex.EncodeToString(suma[:])
+}
@@ -0,0 +1,30 @@
+package documento
+
+import (
+    "fmt"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+)
+
+// RegistroIndiceDesdeDocumento construye el registro del índice a partir del documento subido a Alfresco.
+// Retorna un error si el documento carece de ID.
+func RegistroIndiceDesdeDocumento(doc documentos.Documento) (documentos.RegistroIndice, error) {
+    // 1. Validar campos críticos antes de indexar
+    if doc.ID == "" {
+        return documentos.RegistroIndice{}, fmt.Errorf("documento sin ID válido")
+    }
+
+    // 2. Convertir las propiedades de Alfresco al registro del dominio
+    propiedades := doc.Propiedades
+    return documentos.RegistroIndice{
+        ID:                   doc.ID,
+        NombreArchivo:        doc.Nombre,
+        TipoArchivo:          doc.Tipo,
+        FechaCarga:           textoMetadato(propiedades, "tanner:fecha-carga"),
+        NombreDocumento:      textoMetadato(propiedades, "tanner:nombre-doc"),
+        TipoDocumento:        textoMetadato(propiedades, "tanner:tipo-documento"),
+        RazonSocialCliente:   textoMetadato(propiedades, "tanner:razon-social-cliente"),
+        RUTCliente:           textoMetadato(propiedades, "tanner:rut-cliente"),
+        EstadoVigencia:       textoMetadato(propiedades, "tanner:estado-vigencia"),
+        FechaTerminoVigencia: textoMetadato(propiedades, "tanner:fecha-termino-vigencia"),
+    }, nil
+}
@@ -1,86 +1,351 @@
 package documento
 
 import (
-    "github.com/gin-gonic/gin"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "github.com/CamiloScript/REGAPIGO/application/auditoria"
     "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/domain/eventos"
     "github.com/CamiloScript/REGAPIGO/shared/utils"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/metricas"
+    "github.com/CamiloScript/REGAPIGO/shared/solicitud"
     "fmt"
     "errors"
-    "encoding/json"
-    
+    "sort"
+    "strings"
+    "sync"
+    "time"
 )
 
 // ErrDocumentoNoEncontrado es un error que se produce cuando un documento no se encuentra.
-var ErrDocumentoNoEncontrado = errors.New("documento no encontrado")
+// Es la misma categoría que reporta el repositorio documental.
+var ErrDocumentoNoEncontrado = documentos.ErrDocumentoNoEncontrado
+
+// ErrRespuestaAlfrescoInvalida se produce cuando Alfresco responde con un cuerpo que no tiene el formato esperado.
+var ErrRespuestaAlfrescoInvalida = errors.New("respuesta de Alfresco inválida")
+
+// ErrAuditoriaNoDisponible se produce cuando una descarga no puede quedar registrada en la auditoría.
+var ErrAuditoriaNoDisponible = errors.New("registro de auditoría no disponible")
+
+// TiposPermitidosPorDefecto son los tipos de archivo aceptados si la configuración no indica otros.
+var TiposPermitidosPorDefecto = []string{"application/pdf", "image/jpeg", "image/png"}
+
+// Auditor registra las acciones sobre documentos en el registro de auditoría.
+type Auditor interface {
+    Registrar(ctx context.Context, registro auditoria.Registro) error
+}
 
 // ImplementacionServicioDocumentos maneja la lógica de documentos.
 type ImplementacionServicioDocumentos struct {
     almacenamiento documentos.AlmacenamientoDocumentos // Almacenamiento inyectado para manejar la persistencia de documentos
     log            *logger.Registrador               // Logger para registrar eventos y errores
     apiKey         string                            // API Key desde configuración para autenticación
+    retencion      documentos.ReglasRetencion        // Reglas de retención aplicadas antes de eliminar
+    publicador     eventos.EventPublisher            // Publicador de eventos del ciclo de vida
+    auditor        Auditor                           // Registro de auditoría de accesos
+
+    muTipos         sync.RWMutex    // Protege los tipos permitidos, que pueden cambiar sin reiniciar
+    tiposPermitidos map[string]bool // Tipos MIME aceptados al subir documentos
 }
 
 // NuevoServicioDocumentos construye el servicio con dependencias.
 func NuevoServicioDocumentos(
     almacenamiento documentos.AlmacenamientoDocumentos, // Almacenamiento de documentos
     log *logger.Registrador,                           // Logger para registro de eventos
-    apiKey string,                                     // API Key para autenticación
+    apiKey string,                                     // API Key fija; vacía delega en el almacenamiento la API Key vigente
 ) *ImplementacionServicioDocumentos {
     return &ImplementacionServicioDocumentos{
         almacenamiento: almacenamiento, // Inicializa el almacenamiento
         log:            log,            // Inicializa el logger
         apiKey:         apiKey,         // Inicializa la API Key
+        publicador:     publicadorNulo{}, // Sin publicador hasta que se configure uno
+        auditor:        auditorNulo{},    // Sin auditoría hasta que se configure una
+        tiposPermitidos: conjuntoTipos(TiposPermitidosPorDefecto),
+    }
+}
+
+// EstablecerTiposPermitidos reemplaza los tipos MIME aceptados al subir documentos.
+// Puede llamarse con el servicio en uso; las cargas siguientes validan contra la nueva lista.
+func (s *ImplementacionServicioDocumentos) EstablecerTiposPermitidos(tipos []string) {
+    conjunto := conjuntoTipos(tipos)
+    s.muTipos.Lock()
+    defer s.muTipos.Unlock()
+    s.tiposPermitidos = conjunto
+}
+
+// TiposPermitidos retorna los tipos MIME aceptados, en orden.
+func (s *ImplementacionServicioDocumentos) TiposPermitidos() []string {
+    s.muTipos.RLock()
+    defer s.muTipos.RUnlock()
+    tipos := make([]string, 0, len(s.tiposPermitidos))
+    for tipo := range s.tiposPermitidos {
+        tipos = append(tipos, tipo)
+    }
+    sort.Strings(tipos)
+    return tipos
+}
+
+// tipoPermitido indica si el tipo MIME detectado puede subirse.
+func (s *ImplementacionServicioDocumentos) tipoPermitido(tipo string) bool {
+    s.muTipos.RLock()
+    defer s.muTipos.RUnlock()
+    return s.tiposPermitidos[tipo]
+}
+
+// conjuntoTipos normaliza la lista de tipos MIME a un conjunto en minúsculas.
+func conjuntoTipos(tipos []string) map[string]bool {
+    conjunto := make(map[string]bool, len(tipos))
+    for _, tipo := range tipos {
+        conjunto[strings.ToLower(strings.TrimSpace(tipo))] = true
     }
+    return conjunto
+}
+
+// credenciales combina el ticket de la solicitud con la API Key configurada.
+func (s *ImplementacionServicioDocumentos) credenciales(ticket string) documentos.Credenciales {
+    return documentos.Credenciales{Ticket: ticket, APIKey: s.apiKey}
 }
 
 // SubirDocumento maneja la subida de un documento, validando el tipo de archivo.
 func (s *ImplementacionServicioDocumentos) SubirDocumento(
-    c *gin.Context,
-    fileBytes []byte,
-    metadatos map[string]interface{},
-    ticket string,
-) (map[string]interface{}, error) {
+    ctx context.Context,      // Contexto de la operación (cancelación y datos de la solicitud)
+    solicitud SolicitudCarga, // Archivo, metadatos y ticket
+) (*documentos.Documento, error) {
     // 1. Validar el tipo de archivo
-    mimeType := utils.DetectMimeTypeFromContent(fileBytes)
-    if mimeType != "image/jpeg" && mimeType != "image/png" && mimeType != "application/pdf" {
-        return nil, fmt.Errorf("tipo de archivo no soportado: %s", mimeType)
+    rutCliente := solicitud.Metadatos.RUTCliente
+    mimeType := utils.DetectMimeTypeFromContent(solicitud.Contenido)
+    if !s.tipoPermitido(mimeType) {
+        err := fmt.Errorf("tipo de archivo no soportado: %s", mimeType)
+        s.auditar(ctx, auditoria.AccionSubir, "", rutCliente, err)
+        return nil, err
     }
 
-    // 2. Convertir metadatos a JSON
-    metadatosJSON, err := json.Marshal(metadatos)
+    // 2. Delegar la operación de subida al almacenamiento
+    doc, err := s.almacenamiento.SubirDocumento(ctx, documentos.SolicitudSubida{
+        Credenciales: s.credenciales(solicitud.Ticket),
+        Contenido:    solicitud.Contenido,
+        Propiedades:  solicitud.Metadatos,
+    })
     if err != nil {
-        return nil, fmt.Errorf("error al convertir metadatos a JSON: %v", err)
+        s.log.ConContexto(ctx).Error("Error en el servicio", map[string]interface{}{"error": err.Error()})
+        s.auditar(ctx, auditoria.AccionSubir, "", rutCliente, err)
+        return nil, fmt.Errorf("error interno: %w", err)
     }
+    s.auditar(ctx, auditoria.AccionSubir, doc.ID, rutCliente, nil)
+    metricas.SumarBytes(metricas.DireccionSubida, len(solicitud.Contenido))
 
-    // 3. Delegar la operación de subida al almacenamiento
-    respuesta, err := s.almacenamiento.SubirDocumento(c, fileBytes, string(metadatosJSON), ticket, s.apiKey)
-    if err != nil {
-        s.log.Error("Error en el servicio", map[string]interface{}{"error": err.Error()})
-        return nil, fmt.Errorf("error interno: %v", err)
+    // 3. Publicar evento de carga (versionado si la etiqueta de versión no es la inicial)
+    tipoEvento := eventos.TipoDocumentoSubido
+    if etiqueta := solicitud.Metadatos.CmVersionLabel; etiqueta != "" && etiqueta != "1.0" {
+        tipoEvento = eventos.TipoDocumentoVersionado
     }
+    hash := sha256.Sum256(solicitud.Contenido)
+    s.publicar(ctx, tipoEvento, eventos.DatosDocumento{
+        DocumentoID:   doc.ID,
+        RUTCliente:    rutCliente,
+        TipoDocumento: solicitud.Metadatos.TipoDocumento,
+        Hash:          hex.EncodeToString(hash[:]),
+    })
 
-    return respuesta, nil
+    return doc, nil
 }
 
 // ListarDocumentos maneja la lista de documentos, delegando la operación al almacenamiento.
 func (s *ImplementacionServicioDocumentos) ListarDocumentos(
-    c *gin.Context,                  // Contexto de Gin para manejar la solicitud HTTP
-    filtros map[string]interface{},  // Filtros para la búsqueda de documentos
-    ticket string,                   // Ticket obtenido del cliente para autenticación/autorización
-) (map[string]interface{}, error) {  // Retorna un mapa con la respuesta o un error
+    ctx context.Context,              // Contexto de la operación
+    filtros documentos.FiltroListado, // Filtros para la búsqueda de documentos
+    ticket string,                    // Ticket obtenido del cliente para autenticación/autorización
+) ([]documentos.Documento, error) {   // Retorna los documentos encontrados o un error
 
     // Delegar la operación de listado al almacenamiento
-    return s.almacenamiento.ListarDocumentos(c, filtros, ticket, s.apiKey)
+    resultado, err := s.almacenamiento.ListarDocumentos(ctx, documentos.SolicitudListado{
+        Credenciales: s.credenciales(ticket),
+        Filtros:      filtros,
+    })
+    s.auditar(ctx, auditoria.AccionListar, "", filtros.RUTCliente, err)
+    return resultado, err
 }
 
 // DescargarDocumento maneja la descarga de un documento, delegando la operación al almacenamiento.
 func (s *ImplementacionServicioDocumentos) DescargarDocumento(
-    c *gin.Context,                  // Contexto de Gin para manejar la solicitud HTTP
-    idFile string,                   // ID del archivo a descargar
-    ticket string,                   // Ticket obtenido del cliente para autenticación/autorización
-) ([]byte, string, error) {          // Retorna el contenido del archivo, el tipo MIME y un error si lo hubiera
+    ctx context.Context,                  // Contexto de la operación
+    idFile string,                        // ID del archivo a descargar
+    rutCliente string,                    // RUT del cliente dueño del documento, según el índice (vacío si no está indexado)
+    ticket string,                        // Ticket obtenido del cliente para autenticación/autorización
+) (*documentos.ArchivoDocumento, error) { // Retorna el archivo descargado o un error si lo hubiera
 
     // Delegar la operación de descarga al almacenamiento
-    return s.almacenamiento.DescargarDocumento(c, idFile, ticket, s.apiKey)
-}
\ No newline at end of file
+    archivo, err := s.almacenamiento.DescargarDocumento(ctx, documentos.SolicitudDocumento{
+        Credenciales: s.credenciales(ticket),
+        IDArchivo:    idFile,
+    })
+
+    // Una descarga exitosa que no queda auditada no se entrega al cliente
+    if errAuditoria := s.auditar(ctx, auditoria.AccionDescargar, idFile, rutCliente, err); errAuditoria != nil && err == nil {
+        return nil, ErrAuditoriaNoDisponible
+    }
+    if err == nil {
+        metricas.SumarBytes(metricas.DireccionDescarga, len(archivo.Contenido))
+    }
+    return archivo, err
+}
+
+// RegistrarBusqueda deja constancia en la auditoría de una búsqueda en el índice y su resultado.
+func (s *ImplementacionServicioDocumentos) RegistrarBusqueda(
+    ctx context.Context, // Contexto de la operación
+    rutCliente string,   // RUT usado como criterio de búsqueda
+    idFile string,       // ID del documento encontrado (vacío si no hubo resultado)
+    errBusqueda error,   // Error de la búsqueda, si lo hubo
+) {
+    s.auditar(ctx, auditoria.AccionBuscar, idFile, rutCliente, errBusqueda)
+}
+
+// EstablecerReglasRetencion configura las reglas de retención que protegen documentos de ser eliminados.
+func (s *ImplementacionServicioDocumentos) EstablecerReglasRetencion(reglas documentos.ReglasRetencion) {
+    s.retencion = reglas
+}
+
+// EliminarDocumento elimina un documento validando previamente sus reglas de retención.
+// La eliminación lógica marca tanner:estado-vigencia como eliminado en Alfresco; la definitiva borra el archivo.
+func (s *ImplementacionServicioDocumentos) EliminarDocumento(
+    ctx context.Context,            // Contexto de la operación
+    solicitud SolicitudEliminacion, // Documento, metadatos indexados y tipo de eliminación
+) error {
+    idFile, metadatos := solicitud.IDArchivo, solicitud.Metadatos
+
+    // 1. Validar reglas de retención
+    if err := s.retencion.ValidarEliminacion(metadatos, time.Now()); err != nil {
+        s.log.ConContexto(ctx).Warn("Eliminación rechazada por retención", map[string]interface{}{"idFile": idFile, "error": err.Error()})
+        s.auditar(ctx, auditoria.AccionEliminar, idFile, metadatos.RUTCliente, err)
+        return err
+    }
+
+    datos := eventos.DatosDocumento{
+        DocumentoID:   idFile,
+        RUTCliente:    metadatos.RUTCliente,
+        TipoDocumento: metadatos.TipoDocumento,
+    }
+
+    // 2. Eliminación definitiva
+    if solicitud.Definitivo {
+        err := s.almacenamiento.EliminarDocumento(ctx, documentos.SolicitudDocumento{
+            Credenciales: s.credenciales(solicitud.Ticket),
+            IDArchivo:    idFile,
+        })
+        s.auditar(ctx, auditoria.AccionEliminar, idFile, metadatos.RUTCliente, err)
+        if err != nil {
+            return err
+        }
+        s.publicar(ctx, eventos.TipoDocumentoEliminado, datos)
+        return nil
+    }
+
+    // 3. Eliminación lógica
+    err := s.almacenamiento.ActualizarPropiedades(ctx, documentos.SolicitudActualizacion{
+        Credenciales: s.credenciales(solicitud.Ticket),
+        IDArchivo:    idFile,
+        Propiedades:  documentos.DocumentMetadata{EstadoVigencia: documentos.EstadoEliminado},
+    })
+    s.auditar(ctx, auditoria.AccionEliminar, idFile, metadatos.RUTCliente, err)
+    if err != nil {
+        return err
+    }
+    datos.EstadoVigencia = documentos.EstadoEliminado
+    s.publicar(ctx, eventos.TipoDocumentoActualizado, datos)
+    return nil
+}
+
+// ActualizarEstadoVigencia cambia tanner:estado-vigencia de un documento y publica el evento de actualización.
+// Lo usan las tareas en segundo plano, por lo que no exige datos de una solicitud HTTP en el contexto.
+func (s *ImplementacionServicioDocumentos) ActualizarEstadoVigencia(ctx context.Context, solicitud SolicitudEstadoVigencia) error {
+    err := s.almacenamiento.ActualizarPropiedades(ctx, documentos.SolicitudActualizacion{
+        Credenciales: s.credenciales(solicitud.Ticket),
+        IDArchivo:    solicitud.IDArchivo,
+        Propiedades:  documentos.DocumentMetadata{EstadoVigencia: solicitud.Estado},
+    })
+    if err != nil {
+        return err
+    }
+    s.publicar(ctx, eventos.TipoDocumentoActualizado, eventos.DatosDocumento{
+        DocumentoID:    solicitud.IDArchivo,
+        RUTCliente:     solicitud.RUTCliente,
+        TipoDocumento:  solicitud.TipoDocumento,
+        EstadoVigencia: solicitud.Estado,
+    })
+    return nil
+}
+
+// EstablecerPublicador configura el publicador de eventos del ciclo de vida de documentos.
+func (s *ImplementacionServicioDocumentos) EstablecerPublicador(publicador eventos.EventPublisher) {
+    s.publicador = publicador
+}
+
+// publicar emite un evento; un fallo se registra pero no revierte la operación ya realizada en Alfresco.
+func (s *ImplementacionServicioDocumentos) publicar(ctx context.Context, tipo string, datos eventos.DatosDocumento) {
+    idSolicitud := solicitud.DesdeContexto(ctx).ID
+    evento := eventos.NuevoEvento(tipo, idSolicitud, datos)
+    if err := s.publicador.Publicar(ctx, evento); err != nil {
+        s.log.ConContexto(ctx).Error("Error al publicar evento", map[string]interface{}{
+            "tipo":         tipo,
+            "id_evento":    evento.ID,
+            "id_solicitud": idSolicitud,
+            "error":        err.Error(),
+        })
+    }
+}
+
+// EstablecerAuditor configura el registro de auditoría de accesos a documentos.
+func (s *ImplementacionServicioDocumentos) EstablecerAuditor(auditor Auditor) {
+    s.auditor = auditor
+}
+
+// auditar registra una acción con la identidad del solicitante y su resultado.
+// Un fallo se registra en el log y se retorna para que quien llama decida si la operación puede continuar.
+func (s *ImplementacionServicioDocumentos) auditar(ctx context.Context, accion, idDocumento, rutCliente string, errOperacion error) error {
+    datos := solicitud.DesdeContexto(ctx)
+    registro := auditoria.Registro{
+        Actor:       datos.Actor,
+        Rol:         datos.Rol,
+        Accion:      accion,
+        DocumentoID: idDocumento,
+        RUTCliente:  rutCliente,
+        IP:          datos.IP,
+        IDSolicitud: datos.ID,
+        Resultado:   auditoria.ResultadoExito,
+    }
+    if errOperacion != nil {
+        registro.Resultado = auditoria.ResultadoError
+        registro.Detalle = errOperacion.Error()
+    }
+
+    if err := s.auditor.Registrar(ctx, registro); err != nil {
+        s.log.ConContexto(ctx).Error("Error al registrar auditoría", map[string]interface{}{
+            "accion":       accion,
+            "idFile":       idDocumento,
+            "id_solicitud": registro.IDSolicitud,
+            "error":        err.Error(),
+        })
+        return err
+    }
+    return nil
+}
+
+// auditorNulo descarta los registros cuando no hay auditoría configurada.
+type auditorNulo struct{}
+
+// Registrar no realiza ninguna acción.
+func (auditorNulo) Registrar(context.Context, auditoria.Registro) error { return nil }
+
+// publicadorNulo descarta los eventos cuando no hay un publicador configurado.
+type publicadorNulo struct{}
+
+// Publicar no realiza ninguna acción.
+func (publicadorNulo) Publicar(context.Context, eventos.Evento) error { return nil }
+
+// textoMetadato retorna una propiedad del documento leído de Alfresco como string, o vacío si no existe o no es texto.
+func textoMetadato(metadatos map[string]interface{}, clave string) string {
+    valor, _ := metadatos[clave].(string)
+    return valor
+}
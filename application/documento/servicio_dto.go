@@ -1,5 +1,7 @@
 package documento
 
+import "github.com/CamiloScript/REGAPIGO/domain/documentos"
+
 // AlfrescoDocumentDTO representa la respuesta de subida y detalles de un documento en Alfresco.
 type AlfrescoDocumentDTO struct {
     Entry struct {
@@ -57,4 +59,16 @@ type AlfrescoDownloadResponse struct {
     Content        []byte   `json:"-"` // Contenido binario del archivo (no se serializa en JSON)
     FileName       string   `json:"fileName"` // Nombre del archivo obtenido del header Content-Disposition
     MimeType       string   `json:"mimeType"` // Tipo MIME del archivo obtenido del header
-}
\ No newline at end of file
+}
+// Documento convierte la entrada de Alfresco en la entidad del dominio.
+func (d AlfrescoDocumentDTO) Documento() documentos.Documento {
+    return documentos.Documento{
+        ID:           d.Entry.ID,
+        Nombre:       d.Entry.Name,
+        Tipo:         d.Entry.Content.MimeType,
+        Tamano:       d.Entry.Content.SizeInBytes,
+        Propiedades:  d.Entry.Properties,
+        Autor:        d.Entry.ModifiedByUser.DisplayName,
+        ModificadoEn: d.Entry.ModifiedAt,
+    }
+}
@@ -0,0 +1,29 @@
+package documento
+
+import (
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+)
+
+// SolicitudCarga describe un documento a subir a través del servicio.
+type SolicitudCarga struct {
+    Contenido []byte                      // Bytes del archivo
+    Metadatos documentos.DocumentMetadata // Propiedades del documento
+    Ticket    string                      // Ticket de Alfresco
+}
+
+// SolicitudEliminacion describe la eliminación de un documento indexado.
+type SolicitudEliminacion struct {
+    IDArchivo  string                      // ID del archivo a eliminar
+    Metadatos  documentos.DocumentMetadata // Metadatos indexados usados para evaluar la retención
+    Definitivo bool                        // true para eliminar el archivo, false para eliminación lógica
+    Ticket     string                      // Ticket de Alfresco
+}
+
+// SolicitudEstadoVigencia describe un cambio de tanner:estado-vigencia originado por una tarea en segundo plano.
+type SolicitudEstadoVigencia struct {
+    IDArchivo     string // ID del archivo a actualizar
+    RUTCliente    string // RUT del cliente, informado en el evento
+    TipoDocumento string // Tipo de documento, informado en el evento
+    Estado        string // Nuevo estado de vigencia
+    Ticket        string // Ticket de Alfresco
+}
@@ -0,0 +1,278 @@
+package documento
+
+import (
+    "context"
+    "errors"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+)
+
+// DocumentoVencido resume un documento indexado cuya fecha de término de vigencia ya pasó.
+type DocumentoVencido struct {
+    ID                   string // ID del documento en Alfresco
+    RUTCliente           string // RUT del cliente
+    TipoDocumento        string // Tipo de documento
+    FechaTerminoVigencia string // Valor de tanner:fecha-termino-vigencia
+    Intentos             int    // Ejecuciones anteriores que fallaron al procesarlo
+}
+
+// FalloVigencia registra un documento que no pudo marcarse como no vigente, para reintentarlo más tarde.
+type FalloVigencia struct {
+    Intentos       int       // Ejecuciones que fallaron, incluida esta
+    ProximoIntento time.Time // Instante desde el que vuelve a ser candidato
+    Error          string    // Causa del último fallo
+}
+
+// IndiceVigencia abstrae las consultas del índice necesarias para controlar la vigencia.
+type IndiceVigencia interface {
+    // BuscarVencidos retorna los candidatos más antiguos, excluidos los que esperan un reintento posterior a ahora.
+    BuscarVencidos(ctx context.Context, ahora time.Time, limite int) ([]DocumentoVencido, error)
+    // ActualizarEstadoVigencia cambia el estado indexado y descarta los fallos registrados.
+    ActualizarEstadoVigencia(ctx context.Context, idFile string, estado string) error
+    // RegistrarFalloVigencia guarda el fallo de un documento para que no bloquee a los siguientes.
+    RegistrarFalloVigencia(ctx context.Context, idFile string, fallo FalloVigencia) error
+}
+
+// ActualizadorVigencia cambia el estado de vigencia de documentos en el repositorio documental.
+// ImplementacionServicioDocumentos la satisface, de modo que el programador reutiliza el mismo servicio que la API.
+type ActualizadorVigencia interface {
+    ActualizarEstadoVigencia(ctx context.Context, solicitud SolicitudEstadoVigencia) error
+}
+
+// BloqueoLider permite que una sola réplica ejecute una tarea periódica.
+type BloqueoLider interface {
+    Adquirir(ctx context.Context, ttl time.Duration) (bool, error)
+    Liberar(ctx context.Context) error
+}
+
+// AutenticadorInterno obtiene un ticket de Alfresco con las credenciales de la aplicación.
+// Invalidar descarta el ticket en caché cuando Alfresco lo rechaza.
+type AutenticadorInterno interface {
+    AutenticarInternamente() (string, error)
+    Invalidar()
+}
+
+// tamanoLoteVigencia limita los documentos procesados por ejecución.
+const tamanoLoteVigencia = 100
+
+// esperaMaximaVigencia acota la espera entre reintentos de un documento que falla repetidamente.
+const esperaMaximaVigencia = 24 * time.Hour
+
+// errFechaNoReconocida indica que tanner:fecha-termino-vigencia no tiene un formato aceptado.
+var errFechaNoReconocida = errors.New("fecha de término de vigencia no reconocida")
+
+// ProgramadorVigencia marca periódicamente como no vigentes los documentos cuya fecha de término ya pasó.
+// Un documento que falla se registra con una espera creciente antes del próximo intento, para que los fallos
+// permanentes no ocupen el lote de cada ejecución.
+type ProgramadorVigencia struct {
+    indice        IndiceVigencia          // Índice de documentos (MongoDB)
+    documentos    ActualizadorVigencia    // Servicio de documentos (Alfresco y eventos)
+    bloqueo       BloqueoLider            // Bloqueo para elegir la réplica líder
+    autenticador  AutenticadorInterno     // Proveedor de tickets de Alfresco
+    intervalo     time.Duration           // Tiempo entre ejecuciones
+    log           *logger.Registrador     // Logger para registrar eventos y errores
+    reloj         func() time.Time        // Fuente de tiempo; reemplazable en pruebas
+}
+
+// NuevoProgramadorVigencia construye el programador con sus dependencias.
+func NuevoProgramadorVigencia(
+    indice IndiceVigencia,
+    servicioDocumentos ActualizadorVigencia,
+    bloqueo BloqueoLider,
+    autenticador AutenticadorInterno,
+    intervalo time.Duration,
+    log *logger.Registrador,
+) *ProgramadorVigencia {
+    return &ProgramadorVigencia{
+        indice:       indice,
+        documentos:   servicioDocumentos,
+        bloqueo:      bloqueo,
+        autenticador: autenticador,
+        intervalo:    intervalo,
+        log:          log,
+        reloj:        time.Now,
+    }
+}
+
+// EstablecerReloj reemplaza la fuente de tiempo, para pruebas.
+func (p *ProgramadorVigencia) EstablecerReloj(reloj func() time.Time) {
+    p.reloj = reloj
+}
+
+// Iniciar ejecuta el programador hasta que el contexto se cancele.
+// Al terminar libera el bloqueo para que otra réplica pueda tomarlo de inmediato.
+func (p *ProgramadorVigencia) Iniciar(ctx context.Context) {
+    p.log.Info("Programador de vigencia iniciado", map[string]interface{}{"intervalo": p.intervalo.String()})
+
+    ticker := time.NewTicker(p.intervalo)
+    defer ticker.Stop()
+
+    for {
+        p.Ejecutar(ctx)
+
+        select {
+        case <-ctx.Done():
+            ctxLiberar, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+            if err := p.bloqueo.Liberar(ctxLiberar); err != nil {
+                p.log.Warn("No se pudo liberar el bloqueo de vigencia", map[string]interface{}{"error": err.Error()})
+            }
+            cancel()
+            p.log.Info("Programador de vigencia detenido", nil)
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Ejecutar procesa un lote de documentos vencidos si esta réplica es la líder.
+// Retorna la cantidad de documentos marcados como no vigentes.
+func (p *ProgramadorVigencia) Ejecutar(ctx context.Context) int {
+    // 1. Solo la réplica líder procesa; el bloqueo dura dos intervalos para tolerar retrasos
+    lider, err := p.bloqueo.Adquirir(ctx, p.duracionBloqueo())
+    if err != nil {
+        p.log.Error("Error al adquirir bloqueo de vigencia", map[string]interface{}{"error": err.Error()})
+        return 0
+    }
+    if !lider {
+        p.log.Debug("Programador de vigencia en espera: otra réplica es líder", nil)
+        return 0
+    }
+
+    // 2. Buscar candidatos en el índice
+    ahora := p.reloj()
+    candidatos, err := p.indice.BuscarVencidos(ctx, ahora, tamanoLoteVigencia)
+    if err != nil {
+        p.log.Error("Error al buscar documentos vencidos", map[string]interface{}{"error": err.Error()})
+        return 0
+    }
+    if len(candidatos) == 0 {
+        return 0
+    }
+
+    // 3. Autenticación interna
+    ticket, err := p.autenticador.AutenticarInternamente()
+    if err != nil {
+        p.log.Error("Error de autenticación en programador de vigencia", map[string]interface{}{"error": err.Error()})
+        return 0
+    }
+
+    // 4. Cambiar el estado en Alfresco (el servicio publica el evento) y luego en MongoDB, renovando el bloqueo
+    //    antes de cada documento para que un lote lento no se solape con otra réplica
+    procesados, fallidos := 0, 0
+    for i, doc := range candidatos {
+        if i > 0 && !p.renovarBloqueo(ctx) {
+            break
+        }
+        // Una fecha sin hora del día en curso aún no vence; una fecha ilegible no vencerá nunca
+        if _, ok := documentos.FinVigencia(doc.FechaTerminoVigencia); !ok {
+            p.registrarFallo(ctx, doc, ahora, errFechaNoReconocida)
+            fallidos++
+            continue
+        }
+        if !documentos.Vencido(doc.FechaTerminoVigencia, ahora) {
+            continue
+        }
+
+        err := p.actualizarEnAlfresco(ctx, doc, &ticket)
+        if err != nil {
+            p.log.Error("Error al actualizar vigencia en Alfresco", map[string]interface{}{"idFile": doc.ID, "error": err.Error()})
+            p.registrarFallo(ctx, doc, ahora, err)
+            fallidos++
+            continue
+        }
+        if err := p.indice.ActualizarEstadoVigencia(ctx, doc.ID, documentos.EstadoNoVigente); err != nil {
+            p.log.Error("Error al actualizar vigencia en MongoDB", map[string]interface{}{"idFile": doc.ID, "error": err.Error()})
+            p.registrarFallo(ctx, doc, ahora, err)
+            fallidos++
+            continue
+        }
+
+        procesados++
+        p.log.Info("Documento vencido", map[string]interface{}{
+            "idFile":                 doc.ID,
+            "rut_cliente":            doc.RUTCliente,
+            "tipo_documento":         doc.TipoDocumento,
+            "fecha_termino_vigencia": doc.FechaTerminoVigencia,
+            "estado_vigencia":        documentos.EstadoNoVigente,
+        })
+    }
+
+    p.log.Info("Ejecución de vigencia finalizada", map[string]interface{}{
+        "candidatos": len(candidatos),
+        "procesados": procesados,
+        "fallidos":   fallidos,
+    })
+    return procesados
+}
+
+// actualizarEnAlfresco marca el documento como no vigente en Alfresco. Si Alfresco rechaza el ticket, lo renueva
+// una vez, reintenta y deja el ticket nuevo para el resto del lote.
+func (p *ProgramadorVigencia) actualizarEnAlfresco(ctx context.Context, doc DocumentoVencido, ticket *string) error {
+    solicitud := SolicitudEstadoVigencia{
+        IDArchivo:     doc.ID,
+        RUTCliente:    doc.RUTCliente,
+        TipoDocumento: doc.TipoDocumento,
+        Estado:        documentos.EstadoNoVigente,
+        Ticket:        *ticket,
+    }
+    err := p.documentos.ActualizarEstadoVigencia(ctx, solicitud)
+    if !errors.Is(err, documentos.ErrTicketInvalido) {
+        return err
+    }
+
+    p.log.Warn("Alfresco rechazó el ticket del programador de vigencia; se renueva", map[string]interface{}{"idFile": doc.ID})
+    p.autenticador.Invalidar()
+    nuevo, err := p.autenticador.AutenticarInternamente()
+    if err != nil {
+        return err
+    }
+    *ticket = nuevo
+    solicitud.Ticket = nuevo
+    return p.documentos.ActualizarEstadoVigencia(ctx, solicitud)
+}
+
+// duracionBloqueo es la vigencia del bloqueo de líder en cada adquisición o renovación.
+func (p *ProgramadorVigencia) duracionBloqueo() time.Duration {
+    return 2 * p.intervalo
+}
+
+// renovarBloqueo extiende el bloqueo de líder durante el lote. Retorna false si no pudo renovarse o si otra
+// réplica lo tomó tras expirar, en cuyo caso el lote debe detenerse.
+func (p *ProgramadorVigencia) renovarBloqueo(ctx context.Context) bool {
+    lider, err := p.bloqueo.Adquirir(ctx, p.duracionBloqueo())
+    if err != nil {
+        p.log.Error("Lote de vigencia interrumpido: no se pudo renovar el bloqueo", map[string]interface{}{"error": err.Error()})
+        return false
+    }
+    if !lider {
+        p.log.Warn("Lote de vigencia interrumpido: otra réplica tomó el bloqueo", nil)
+        return false
+    }
+    return true
+}
+
+// registrarFallo guarda en el índice el fallo de un documento con la espera hasta su próximo intento, que se
+// duplica en cada fallo a partir de un intervalo y se acota a esperaMaximaVigencia.
+func (p *ProgramadorVigencia) registrarFallo(ctx context.Context, doc DocumentoVencido, ahora time.Time, causa error) {
+    fallo := FalloVigencia{Intentos: doc.Intentos + 1, Error: causa.Error()}
+    espera := p.intervalo
+    for i := 1; i < fallo.Intentos && espera < esperaMaximaVigencia; i++ {
+        espera *= 2
+    }
+    if espera > esperaMaximaVigencia {
+        espera = esperaMaximaVigencia
+    }
+    fallo.ProximoIntento = ahora.Add(espera)
+
+    if err := p.indice.RegistrarFalloVigencia(ctx, doc.ID, fallo); err != nil {
+        p.log.Error("Error al registrar fallo de vigencia", map[string]interface{}{"idFile": doc.ID, "error": err.Error()})
+        return
+    }
+    p.log.Warn("Documento vencido pendiente de reintento", map[string]interface{}{
+        "idFile":          doc.ID,
+        "intentos":        fallo.Intentos,
+        "proximo_intento": fallo.ProximoIntento.UTC().Format(time.RFC3339),
+        "error":           fallo.Error,
+    })
+}
@@ -0,0 +1,192 @@
+package limites
+
+import (
+    "context"
+    "math"
+    "time"
+)
+
+// Direcciones de transferencia con cuota diaria.
+const (
+    DireccionSubida   = "subida"
+    DireccionDescarga = "descarga"
+)
+
+// Heredar indica en una cuota de Politica que se usa la del nivel superior (cliente, luego global).
+const Heredar int64 = -1
+
+// Politica define la tasa de solicitudes y las cuotas diarias de un cliente, una ruta o el valor global.
+type Politica struct {
+    SolicitudesMinuto int   // Tokens que se reponen por minuto
+    Rafaga            int   // Capacidad del bucket: solicitudes seguidas permitidas
+    CuotaSubida       int64 // Bytes diarios de subida; 0 sin cuota; Heredar usa la global
+    CuotaDescarga     int64 // Bytes diarios de descarga; 0 sin cuota; Heredar usa la global
+}
+
+// Reglas agrupa las políticas configuradas. Para la tasa se usa la primera que exista entre cliente@ruta,
+// ruta, cliente y global: las reglas por ruta protegen las operaciones costosas de todos los clientes, y
+// cliente@ruta permite excepciones puntuales. Las cuotas se toman del cliente o, en su defecto, de la global.
+type Reglas struct {
+    Global     Politica            // Política por defecto
+    PorRuta    map[string]Politica // Por plantilla de ruta (ej. /documentos/subir-lote)
+    PorCliente map[string]Politica // Por cliente (clave:<huella>, cert:<sujeto>, ip:<dirección>) o cliente@ruta
+}
+
+// Bucket son los parámetros de un token bucket.
+type Bucket struct {
+    Capacidad int     // Tokens máximos (ráfaga)
+    Tasa      float64 // Tokens repuestos por segundo
+}
+
+// Decision es el resultado de pedir un token.
+type Decision struct {
+    Permitida bool          // Si la solicitud puede continuar
+    Limite    int           // Capacidad del bucket
+    Restantes int           // Tokens enteros disponibles tras la solicitud
+    Reinicio  time.Duration // Tiempo hasta que el bucket vuelva a estar lleno
+    Espera    time.Duration // Si no se permitió, tiempo hasta el próximo token
+}
+
+// EstadoCuota es el consumo diario de un cliente en una dirección.
+type EstadoCuota struct {
+    Limite    int64         // Bytes diarios permitidos; 0 sin cuota
+    Consumido int64         // Bytes consumidos en el día (UTC)
+    Excedida  bool          // Si la transferencia solicitada supera la cuota
+    Reinicio  time.Duration // Tiempo hasta el inicio del próximo día (UTC)
+}
+
+// Almacen guarda los buckets y los consumos diarios. La implementación en memoria sirve para una réplica;
+// la de MongoDB comparte los contadores entre réplicas.
+type Almacen interface {
+    // Tomar repone los tokens transcurridos desde el último uso y consume uno si hay disponible.
+    Tomar(ctx context.Context, clave string, bucket Bucket, ahora time.Time) (Decision, error)
+    // ConsumoDiario retorna los bytes acumulados de la clave en el día indicado (AAAA-MM-DD).
+    ConsumoDiario(ctx context.Context, clave, dia string) (int64, error)
+    // SumarConsumo acumula bytes en el día indicado.
+    SumarConsumo(ctx context.Context, clave, dia string, bytes int64) error
+}
+
+// Limitador aplica las políticas por cliente y ruta sobre un almacén de contadores.
+type Limitador struct {
+    almacen Almacen
+    reglas  Reglas
+}
+
+// NuevoLimitador crea un limitador.
+// Parámetros:
+//   - almacen: Almacén de buckets y consumos (memoria o MongoDB).
+//   - reglas: Políticas global, por ruta y por cliente.
+func NuevoLimitador(almacen Almacen, reglas Reglas) *Limitador {
+    return &Limitador{almacen: almacen, reglas: reglas}
+}
+
+// Politica retorna la política efectiva de un cliente en una ruta.
+func (l *Limitador) Politica(cliente, ruta string) Politica {
+    // 1. Tasa y ráfaga: la regla más específica
+    efectiva := l.reglas.Global
+    if politica, existe := l.reglas.PorCliente[cliente+"@"+ruta]; existe {
+        efectiva.SolicitudesMinuto, efectiva.Rafaga = politica.SolicitudesMinuto, politica.Rafaga
+    } else if politica, existe := l.reglas.PorRuta[ruta]; existe {
+        efectiva.SolicitudesMinuto, efectiva.Rafaga = politica.SolicitudesMinuto, politica.Rafaga
+    } else if politica, existe := l.reglas.PorCliente[cliente]; existe {
+        efectiva.SolicitudesMinuto, efectiva.Rafaga = politica.SolicitudesMinuto, politica.Rafaga
+    }
+
+    // 2. Cuotas: las del cliente, si no las hereda
+    if politica, existe := l.reglas.PorCliente[cliente]; existe {
+        if politica.CuotaSubida != Heredar {
+            efectiva.CuotaSubida = politica.CuotaSubida
+        }
+        if politica.CuotaDescarga != Heredar {
+            efectiva.CuotaDescarga = politica.CuotaDescarga
+        }
+    }
+    return efectiva
+}
+
+// Tomar consume un token del bucket del cliente en la ruta.
+func (l *Limitador) Tomar(ctx context.Context, cliente, ruta string, ahora time.Time) (Decision, error) {
+    politica := l.Politica(cliente, ruta)
+    bucket := Bucket{Capacidad: politica.Rafaga, Tasa: float64(politica.SolicitudesMinuto) / 60}
+    return l.almacen.Tomar(ctx, "bucket|"+cliente+"|"+ruta, bucket, ahora)
+}
+
+// VerificarCuota indica si el cliente puede transferir bytes más en la dirección indicada sin superar su cuota diaria.
+func (l *Limitador) VerificarCuota(ctx context.Context, cliente, direccion string, bytes int64, ahora time.Time) (EstadoCuota, error) {
+    estado := EstadoCuota{Limite: l.cuota(cliente, direccion), Reinicio: hastaManana(ahora)}
+    if estado.Limite == 0 {
+        return estado, nil
+    }
+    consumido, err := l.almacen.ConsumoDiario(ctx, claveCuota(cliente, direccion), dia(ahora))
+    if err != nil {
+        return estado, err
+    }
+    estado.Consumido = consumido
+    estado.Excedida = consumido >= estado.Limite || consumido+bytes > estado.Limite
+    return estado, nil
+}
+
+// RegistrarConsumo acumula bytes transferidos por el cliente, si tiene cuota en esa dirección.
+func (l *Limitador) RegistrarConsumo(ctx context.Context, cliente, direccion string, bytes int64, ahora time.Time) error {
+    if bytes <= 0 || l.cuota(cliente, direccion) == 0 {
+        return nil
+    }
+    return l.almacen.SumarConsumo(ctx, claveCuota(cliente, direccion), dia(ahora), bytes)
+}
+
+// cuota retorna la cuota diaria del cliente en la dirección indicada.
+func (l *Limitador) cuota(cliente, direccion string) int64 {
+    politica := l.Politica(cliente, "")
+    if direccion == DireccionSubida {
+        return politica.CuotaSubida
+    }
+    return politica.CuotaDescarga
+}
+
+// claveCuota identifica el contador diario de un cliente y dirección.
+func claveCuota(cliente, direccion string) string {
+    return "cuota|" + cliente + "|" + direccion
+}
+
+// dia retorna el día UTC de un instante, que delimita las cuotas diarias.
+func dia(ahora time.Time) string {
+    return ahora.UTC().Format("2006-01-02")
+}
+
+// hastaManana retorna el tiempo que falta para el inicio del próximo día UTC.
+func hastaManana(ahora time.Time) time.Duration {
+    ahora = ahora.UTC()
+    return time.Date(ahora.Year(), ahora.Month(), ahora.Day()+1, 0, 0, 0, 0, time.UTC).Sub(ahora)
+}
+
+// Reponer retorna los tokens de un bucket tras el tiempo transcurrido, sin superar su capacidad.
+func Reponer(tokens float64, transcurrido time.Duration, bucket Bucket) float64 {
+    if transcurrido > 0 {
+        tokens += transcurrido.Seconds() * bucket.Tasa
+    }
+    return math.Min(tokens, float64(bucket.Capacidad))
+}
+
+// Decidir construye la decisión a partir de los tokens disponibles antes de consumir. Los almacenes la usan
+// para que el cálculo de encabezados sea el mismo en memoria y en MongoDB.
+func Decidir(disponibles float64, bucket Bucket) Decision {
+    decision := Decision{Limite: bucket.Capacidad}
+    restantes := disponibles
+    if disponibles >= 1 {
+        decision.Permitida = true
+        restantes = disponibles - 1
+    } else {
+        decision.Espera = duracionTokens(1-disponibles, bucket.Tasa)
+    }
+    decision.Restantes = int(math.Floor(restantes))
+    decision.Reinicio = duracionTokens(float64(bucket.Capacidad)-restantes, bucket.Tasa)
+    return decision
+}
+
+// duracionTokens retorna el tiempo que tarda el bucket en reponer la cantidad de tokens indicada.
+func duracionTokens(tokens, tasa float64) time.Duration {
+    if tokens <= 0 || tasa <= 0 {
+        return 0
+    }
+    return time.Duration(tokens / tasa * float64(time.Second))
+}
@@ -0,0 +1,117 @@
+package notificacion
+
+import (
+    "context"
+    "errors"
+    "time"
+)
+
+// Estados posibles de una entrega de webhook.
+const (
+    EstadoEntregado = "ENTREGADO" // El receptor respondió 2xx
+    EstadoFallido   = "FALLIDO"   // Se agotaron los reintentos del envío sin respuesta 2xx
+)
+
+// TipoEventoPorVencer identifica las notificaciones de documentos próximos a vencer.
+const TipoEventoPorVencer = "documento.por_vencer"
+
+// ErrSuscripcionNoEncontrada indica que la suscripción solicitada no existe.
+var ErrSuscripcionNoEncontrada = errors.New("suscripción no encontrada")
+
+// ErrSuscripcionInvalida indica que los datos de una suscripción nueva no son válidos.
+var ErrSuscripcionInvalida = errors.New("suscripción inválida")
+
+// Suscripcion representa un receptor de webhooks de vencimiento.
+type Suscripcion struct {
+    ID             string    `json:"id" bson:"_id"`                                          // Identificador de la suscripción
+    Nombre         string    `json:"nombre" bson:"nombre"`                                   // Nombre descriptivo (ej. equipo comercial)
+    URL            string    `json:"url" bson:"url"`                                         // URL del receptor
+    Secreto        string    `json:"secreto,omitempty" bson:"secreto"`                       // Clave HMAC para firmar los payloads
+    DiasAntes      []int     `json:"dias_antes" bson:"dias_antes"`                           // Umbrales de aviso en días (ej. 30, 15, 7)
+    TiposDocumento []string  `json:"tipos_documento,omitempty" bson:"tipos_documento,omitempty"` // Tipos de documento a notificar (vacío = todos)
+    Activa         bool      `json:"activa" bson:"activa"`                                   // Indica si la suscripción recibe notificaciones
+    CreadaEn       time.Time `json:"creada_en" bson:"creada_en"`                             // Fecha de creación
+}
+
+// AplicaATipo indica si la suscripción debe notificar documentos del tipo indicado.
+func (s Suscripcion) AplicaATipo(tipoDocumento string) bool {
+    if len(s.TiposDocumento) == 0 {
+        return true
+    }
+    for _, tipo := range s.TiposDocumento {
+        if tipo == tipoDocumento {
+            return true
+        }
+    }
+    return false
+}
+
+// UmbralAplicable retorna el menor umbral de días que cubre los días restantes.
+// Retorna false si el documento aún no entra en ningún umbral.
+func (s Suscripcion) UmbralAplicable(diasRestantes int) (int, bool) {
+    umbral, encontrado := 0, false
+    for _, dias := range s.DiasAntes {
+        if diasRestantes <= dias && (!encontrado || dias < umbral) {
+            umbral, encontrado = dias, true
+        }
+    }
+    return umbral, encontrado
+}
+
+// Entrega registra cada envío de una notificación en el log de entregas.
+// Un aviso fallido se vuelve a enviar en ejecuciones posteriores, con esperas crecientes, hasta un máximo de envíos.
+type Entrega struct {
+    ID            string     `json:"id" bson:"_id"`                                          // Identificador de la entrega (también enviado al receptor)
+    SuscripcionID string     `json:"suscripcion_id" bson:"suscripcion_id"`                   // Suscripción destino
+    DocumentoID   string     `json:"documento_id" bson:"documento_id"`                       // Documento notificado
+    UmbralDias    int        `json:"umbral_dias" bson:"umbral_dias"`                         // Umbral que originó la notificación
+    Estado        string     `json:"estado" bson:"estado"`                                   // ENTREGADO o FALLIDO
+    Envio         int        `json:"envio" bson:"envio"`                                     // Número de envío del aviso (1 el primero)
+    Intentos      int        `json:"intentos" bson:"intentos"`                               // Intentos HTTP realizados en este envío
+    CodigoHTTP    int        `json:"codigo_http,omitempty" bson:"codigo_http,omitempty"`     // Último código HTTP recibido
+    Error         string     `json:"error,omitempty" bson:"error,omitempty"`                 // Último error, si lo hubo
+    ProximoEnvio  *time.Time `json:"proximo_envio,omitempty" bson:"proximo_envio,omitempty"` // Cuándo se reenviará un aviso fallido; nil si no habrá más envíos
+    Fecha         time.Time  `json:"fecha" bson:"fecha"`                                     // Fecha de la entrega
+}
+
+// DocumentoPorVencer resume un documento indexado cuya vigencia termina próximamente.
+type DocumentoPorVencer struct {
+    ID                   string // ID del documento en Alfresco
+    RUTCliente           string // RUT del cliente
+    RazonSocialCliente   string // Razón social del cliente
+    TipoDocumento        string // Tipo de documento
+    NombreDocumento      string // Nombre del documento
+    FechaTerminoVigencia string // Valor de tanner:fecha-termino-vigencia
+}
+
+// ResultadoEnvio describe el resultado de enviar un webhook, incluidos los reintentos.
+type ResultadoEnvio struct {
+    Intentos   int   // Intentos realizados
+    CodigoHTTP int   // Último código HTTP recibido (0 si no hubo respuesta)
+    Error      error // Error final; nil si el receptor respondió 2xx
+}
+
+// RepositorioSuscripciones persiste las suscripciones de webhooks.
+type RepositorioSuscripciones interface {
+    Crear(ctx context.Context, suscripcion Suscripcion) error
+    Listar(ctx context.Context) ([]Suscripcion, error)
+    ListarActivas(ctx context.Context) ([]Suscripcion, error)
+    Eliminar(ctx context.Context, id string) error
+}
+
+// RepositorioEntregas persiste el log de entregas de webhooks.
+type RepositorioEntregas interface {
+    Registrar(ctx context.Context, entrega Entrega) error
+    Ultima(ctx context.Context, suscripcionID, documentoID string, umbralDias int) (*Entrega, error)
+    Listar(ctx context.Context, suscripcionID string, limite int) ([]Entrega, error)
+}
+
+// IndiceVencimientos consulta el índice por tanner:fecha-termino-vigencia.
+type IndiceVencimientos interface {
+    BuscarPorVencer(ctx context.Context, desde, hasta time.Time) ([]DocumentoPorVencer, error)
+}
+
+// Emisor envía payloads firmados a un receptor HTTP.
+type Emisor interface {
+    Enviar(ctx context.Context, url, secreto, idEntrega string, cuerpo []byte) ResultadoEnvio
+}
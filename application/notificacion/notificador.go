@@ -0,0 +1,277 @@
+package notificacion
+
+import (
+    "context"
+    "encoding/json"
+    "math"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/google/uuid"
+)
+
+// PayloadPorVencer es el cuerpo JSON enviado a los receptores de webhooks.
+type PayloadPorVencer struct {
+    Tipo          string             `json:"tipo"`           // Siempre "documento.por_vencer"
+    IDEntrega     string             `json:"id_entrega"`     // Identificador de la entrega (idempotencia en el receptor)
+    SuscripcionID string             `json:"suscripcion_id"` // Suscripción que originó el aviso
+    UmbralDias    int                `json:"umbral_dias"`    // Umbral configurado (ej. 30, 15, 7)
+    DiasRestantes int                `json:"dias_restantes"` // Días que faltan para el vencimiento
+    FechaEmision  time.Time          `json:"fecha_emision"`  // Fecha de generación del aviso
+    Documento     DocumentoPayload   `json:"documento"`      // Datos del documento
+}
+
+// DocumentoPayload resume el documento notificado.
+type DocumentoPayload struct {
+    ID                   string `json:"id"`
+    RUTCliente           string `json:"rut_cliente"`
+    RazonSocialCliente   string `json:"razon_social_cliente,omitempty"`
+    TipoDocumento        string `json:"tipo_documento"`
+    NombreDocumento      string `json:"nombre_documento,omitempty"`
+    FechaTerminoVigencia string `json:"fecha_termino_vigencia"`
+}
+
+// enviosMaximosDefecto es la cantidad de envíos de un aviso antes de abandonarlo, si no se configura otra.
+const enviosMaximosDefecto = 5
+
+// esperaMaximaEnvio acota la espera entre envíos de un aviso que falla repetidamente.
+const esperaMaximaEnvio = 24 * time.Hour
+
+// NotificadorVencimientos envía webhooks a las suscripciones antes de que venzan los documentos.
+type NotificadorVencimientos struct {
+    suscripciones RepositorioSuscripciones // Suscripciones configuradas
+    entregas      RepositorioEntregas      // Log de entregas
+    indice        IndiceVencimientos       // Índice por fecha de término de vigencia
+    emisor        Emisor                   // Cliente HTTP con firma y reintentos
+    bloqueo       documento.BloqueoLider   // Bloqueo para elegir la réplica líder
+    intervalo     time.Duration            // Tiempo entre ejecuciones
+    enviosMaximos int                      // Envíos de un aviso antes de abandonarlo
+    log           *logger.Registrador      // Logger para registrar eventos y errores
+}
+
+// NuevoNotificadorVencimientos construye el notificador con sus dependencias.
+func NuevoNotificadorVencimientos(
+    suscripciones RepositorioSuscripciones,
+    entregas RepositorioEntregas,
+    indice IndiceVencimientos,
+    emisor Emisor,
+    bloqueo documento.BloqueoLider,
+    intervalo time.Duration,
+    log *logger.Registrador,
+) *NotificadorVencimientos {
+    return &NotificadorVencimientos{
+        suscripciones: suscripciones,
+        entregas:      entregas,
+        indice:        indice,
+        emisor:        emisor,
+        bloqueo:       bloqueo,
+        intervalo:     intervalo,
+        enviosMaximos: enviosMaximosDefecto,
+        log:           log,
+    }
+}
+
+// EstablecerEnviosMaximos configura cuántas veces se envía un aviso fallido antes de abandonarlo.
+// Un valor menor a 1 conserva el valor por defecto.
+func (n *NotificadorVencimientos) EstablecerEnviosMaximos(envios int) {
+    if envios > 0 {
+        n.enviosMaximos = envios
+    }
+}
+
+// Iniciar ejecuta el notificador hasta que el contexto se cancele.
+func (n *NotificadorVencimientos) Iniciar(ctx context.Context) {
+    n.log.Info("Notificador de vencimientos iniciado", map[string]interface{}{"intervalo": n.intervalo.String()})
+
+    ticker := time.NewTicker(n.intervalo)
+    defer ticker.Stop()
+
+    for {
+        n.Ejecutar(ctx, time.Now())
+
+        select {
+        case <-ctx.Done():
+            ctxLiberar, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+            if err := n.bloqueo.Liberar(ctxLiberar); err != nil {
+                n.log.Warn("No se pudo liberar el bloqueo de notificaciones", map[string]interface{}{"error": err.Error()})
+            }
+            cancel()
+            n.log.Info("Notificador de vencimientos detenido", nil)
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Ejecutar revisa las suscripciones activas y envía los avisos pendientes.
+// Cada documento recibe un aviso por umbral; si varios umbrales aplican solo se envía el más cercano.
+// Retorna la cantidad de entregas exitosas.
+func (n *NotificadorVencimientos) Ejecutar(ctx context.Context, ahora time.Time) int {
+    // 1. Solo la réplica líder notifica
+    lider, err := n.bloqueo.Adquirir(ctx, 2*n.intervalo)
+    if err != nil {
+        n.log.Error("Error al adquirir bloqueo de notificaciones", map[string]interface{}{"error": err.Error()})
+        return 0
+    }
+    if !lider {
+        return 0
+    }
+
+    // 2. Cargar suscripciones activas y el horizonte máximo de aviso
+    suscripciones, err := n.suscripciones.ListarActivas(ctx)
+    if err != nil {
+        n.log.Error("Error al listar suscripciones", map[string]interface{}{"error": err.Error()})
+        return 0
+    }
+    maxDias := 0
+    for _, s := range suscripciones {
+        for _, dias := range s.DiasAntes {
+            if dias > maxDias {
+                maxDias = dias
+            }
+        }
+    }
+    if maxDias == 0 {
+        return 0
+    }
+
+    // 3. Buscar documentos que vencen dentro del horizonte
+    porVencer, err := n.indice.BuscarPorVencer(ctx, ahora, ahora.AddDate(0, 0, maxDias))
+    if err != nil {
+        n.log.Error("Error al buscar documentos por vencer", map[string]interface{}{"error": err.Error()})
+        return 0
+    }
+
+    // 4. Notificar cada combinación suscripción/documento pendiente
+    entregados := 0
+    for _, doc := range porVencer {
+        fin, ok := documentos.FinVigencia(doc.FechaTerminoVigencia)
+        if !ok || !fin.After(ahora) {
+            continue
+        }
+        diasRestantes := int(math.Ceil(fin.Sub(ahora).Hours() / 24))
+
+        for _, s := range suscripciones {
+            if !s.AplicaATipo(doc.TipoDocumento) {
+                continue
+            }
+            umbral, aplica := s.UmbralAplicable(diasRestantes)
+            if !aplica {
+                continue
+            }
+            if n.notificar(ctx, s, doc, umbral, diasRestantes, ahora) {
+                entregados++
+            }
+        }
+    }
+
+    if entregados > 0 {
+        n.log.Info("Avisos de vencimiento enviados", map[string]interface{}{"entregados": entregados})
+    }
+    return entregados
+}
+
+// notificar envía un aviso pendiente y registra el resultado en el log de entregas.
+// Un aviso entregado no se repite; uno fallido se reenvía cuando se cumple su espera, hasta enviosMaximos envíos.
+func (n *NotificadorVencimientos) notificar(
+    ctx context.Context,
+    s Suscripcion,
+    doc DocumentoPorVencer,
+    umbral int,
+    diasRestantes int,
+    ahora time.Time,
+) bool {
+    // 1. Evitar avisos duplicados, reenvíos antes de tiempo y reenvíos de avisos abandonados
+    ultima, err := n.entregas.Ultima(ctx, s.ID, doc.ID, umbral)
+    if err != nil {
+        n.log.Error("Error al consultar log de entregas", map[string]interface{}{"suscripcion": s.ID, "error": err.Error()})
+        return false
+    }
+    envio := 1
+    if ultima != nil {
+        if ultima.Estado == EstadoEntregado || ultima.Envio >= n.enviosMaximos {
+            return false
+        }
+        if ultima.ProximoEnvio != nil && ahora.Before(*ultima.ProximoEnvio) {
+            return false
+        }
+        envio = ultima.Envio + 1
+    }
+
+    // 2. Construir el payload
+    payload := PayloadPorVencer{
+        Tipo:          TipoEventoPorVencer,
+        IDEntrega:     uuid.New().String(),
+        SuscripcionID: s.ID,
+        UmbralDias:    umbral,
+        DiasRestantes: diasRestantes,
+        FechaEmision:  ahora.UTC(),
+        Documento: DocumentoPayload{
+            ID:                   doc.ID,
+            RUTCliente:           doc.RUTCliente,
+            RazonSocialCliente:   doc.RazonSocialCliente,
+            TipoDocumento:        doc.TipoDocumento,
+            NombreDocumento:      doc.NombreDocumento,
+            FechaTerminoVigencia: doc.FechaTerminoVigencia,
+        },
+    }
+    cuerpo, err := json.Marshal(payload)
+    if err != nil {
+        n.log.Error("Error al serializar payload", map[string]interface{}{"error": err.Error()})
+        return false
+    }
+
+    // 3. Enviar con firma y reintentos
+    resultado := n.emisor.Enviar(ctx, s.URL, s.Secreto, payload.IDEntrega, cuerpo)
+
+    // 4. Registrar la entrega y, si falló, cuándo se reenviará
+    entrega := Entrega{
+        ID:            payload.IDEntrega,
+        SuscripcionID: s.ID,
+        DocumentoID:   doc.ID,
+        UmbralDias:    umbral,
+        Estado:        EstadoEntregado,
+        Envio:         envio,
+        Intentos:      resultado.Intentos,
+        CodigoHTTP:    resultado.CodigoHTTP,
+        Fecha:         time.Now().UTC(),
+    }
+    if resultado.Error != nil {
+        entrega.Estado = EstadoFallido
+        entrega.Error = resultado.Error.Error()
+        campos := map[string]interface{}{
+            "suscripcion": s.ID,
+            "idFile":      doc.ID,
+            "envio":       envio,
+            "intentos":    resultado.Intentos,
+            "error":       entrega.Error,
+        }
+        if envio < n.enviosMaximos {
+            proximo := ahora.Add(n.esperaReenvio(envio))
+            entrega.ProximoEnvio = &proximo
+            campos["proximo_envio"] = proximo
+            n.log.Warn("Entrega de webhook fallida", campos)
+        } else {
+            n.log.Error("Entrega de webhook abandonada tras agotar los envíos", campos)
+        }
+    }
+    if err := n.entregas.Registrar(ctx, entrega); err != nil {
+        n.log.Error("Error al registrar entrega", map[string]interface{}{"suscripcion": s.ID, "error": err.Error()})
+    }
+
+    return resultado.Error == nil
+}
+
+// esperaReenvio retorna la espera antes de reenviar un aviso tras su envío fallido número envio: el intervalo
+// del notificador, duplicado en cada fallo y acotado a esperaMaximaEnvio.
+func (n *NotificadorVencimientos) esperaReenvio(envio int) time.Duration {
+    espera := n.intervalo
+    for i := 1; i < envio && espera < esperaMaximaEnvio; i++ {
+        espera *= 2
+    }
+    if espera > esperaMaximaEnvio {
+        espera = esperaMaximaEnvio
+    }
+    return espera
+}
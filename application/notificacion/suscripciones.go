@@ -0,0 +1,83 @@
+package notificacion
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "net/url"
+    "time"
+    "github.com/google/uuid"
+)
+
+// ServicioSuscripciones gestiona el alta, consulta y baja de suscripciones de webhooks.
+type ServicioSuscripciones struct {
+    repositorio   RepositorioSuscripciones // Persistencia de suscripciones
+    entregas      RepositorioEntregas      // Log de entregas
+    diasDefecto   []int                    // Umbrales usados cuando la suscripción no define los suyos
+}
+
+// NuevoServicioSuscripciones construye el servicio con sus dependencias.
+func NuevoServicioSuscripciones(repositorio RepositorioSuscripciones, entregas RepositorioEntregas, diasDefecto []int) *ServicioSuscripciones {
+    return &ServicioSuscripciones{repositorio: repositorio, entregas: entregas, diasDefecto: diasDefecto}
+}
+
+// Crear valida y registra una nueva suscripción.
+// Si no se indica un secreto se genera uno aleatorio; el secreto solo se retorna en esta operación.
+func (s *ServicioSuscripciones) Crear(ctx context.Context, suscripcion Suscripcion) (Suscripcion, error) {
+    // 1. Validar la URL del receptor
+    destino, err := url.Parse(suscripcion.URL)
+    if err != nil || (destino.Scheme != "http" && destino.Scheme != "https") || destino.Host == "" {
+        return Suscripcion{}, fmt.Errorf("%w: url %q no es http ni https", ErrSuscripcionInvalida, suscripcion.URL)
+    }
+
+    // 2. Validar umbrales
+    if len(suscripcion.DiasAntes) == 0 {
+        suscripcion.DiasAntes = s.diasDefecto
+    }
+    for _, dias := range suscripcion.DiasAntes {
+        if dias <= 0 {
+            return Suscripcion{}, fmt.Errorf("%w: umbral de días %d no es positivo", ErrSuscripcionInvalida, dias)
+        }
+    }
+
+    // 3. Completar identificador y secreto
+    if suscripcion.Secreto == "" {
+        secreto := make([]byte, 32)
+        if _, err := rand.Read(secreto); err != nil {
+            return Suscripcion{}, fmt.Errorf("error al generar secreto: %v", err)
+        }
+        suscripcion.Secreto = hex.EncodeToString(secreto)
+    }
+    suscripcion.ID = uuid.New().String()
+    suscripcion.Activa = true
+    suscripcion.CreadaEn = time.Now().UTC()
+
+    // 4. Persistir
+    if err := s.repositorio.Crear(ctx, suscripcion); err != nil {
+        return Suscripcion{}, err
+    }
+    return suscripcion, nil
+}
+
+// Listar retorna las suscripciones sin exponer sus secretos.
+func (s *ServicioSuscripciones) Listar(ctx context.Context) ([]Suscripcion, error) {
+    suscripciones, err := s.repositorio.Listar(ctx)
+    if err != nil {
+        return nil, err
+    }
+    for i := range suscripciones {
+        suscripciones[i].Secreto = ""
+    }
+    return suscripciones, nil
+}
+
+// Eliminar da de baja una suscripción.
+func (s *ServicioSuscripciones) Eliminar(ctx context.Context, id string) error {
+    return s.repositorio.Eliminar(ctx, id)
+}
+
+// Entregas retorna el log de entregas más reciente de una suscripción.
+func (s *ServicioSuscripciones) Entregas(ctx context.Context, id string, limite int) ([]Entrega, error) {
+    return s.entregas.Listar(ctx, id, limite)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+    "context"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/application/auditoria"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/application/limites"
+    "github.com/CamiloScript/REGAPIGO/domain/auth"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    infraeventos "github.com/CamiloScript/REGAPIGO/infraestructure/eventos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/routes"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/salud"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/servidor"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/metricas"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/memoria"
+    "github.com/gin-gonic/gin"
+    ginSwagger "github.com/swaggo/gin-swagger"
+    swaggerFiles "github.com/swaggo/files"
+    mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// construirDependencias construye los servicios compartidos por la API y las tareas en segundo plano.
+// Todos los clientes de Alfresco usan la misma conexión: un transporte, una política de reintentos y un circuito.
+func construirDependencias(cfg *config.Config, log *logger.Registrador, clienteMongo *mongodriver.Client, cifrador *cifrado.Cifrador) routes.Dependencias {
+    // 1. Publicador de eventos del ciclo de vida de documentos
+    outbox := mongo.NuevoOutbox(clienteMongo, cfg)
+    outbox.EstablecerCifrador(cifrador)
+    publicador, err := infraeventos.NuevoPublicador(cfg, log, outbox)
+    if err != nil {
+        log.Fatal("Configuración de eventos inválida", map[string]interface{}{"error": err.Error()})
+    }
+
+    // 2. Conexión y autenticación con Alfresco; un solo ticket interno para la API y las tareas
+    conexionAlfresco, err := servicio.NuevaConexionAlfresco(cfg, log)
+    if err != nil {
+        log.Fatal("Configuración TLS hacia Alfresco inválida", map[string]interface{}{"error": err.Error()})
+    }
+    conexionAlfresco.PublicarMetricas()
+    servicioAuth := auth.NewAuthService(servicio.NewAuthClient(conexionAlfresco, log), log)
+    autenticacionInterna := handlers.NewInternalAuth(servicioAuth, log, cfg)
+
+    // 3. Auditoría y servicio de documentos
+    repositorioAuditoria := mongo.NuevoRepositorioAuditoria(clienteMongo, cfg)
+    repositorioAuditoria.EstablecerCifrador(cifrador)
+    servicioAuditoria := auditoria.NuevoServicioAuditoria(repositorioAuditoria, log)
+    // Sin API Key fija: la conexión aporta la vigente, que puede rotar sin reiniciar
+    servicioNegocio := documento.NuevoServicioDocumentos(servicio.NuevoServicioDocumentos(conexionAlfresco, log), log, "")
+    servicioNegocio.EstablecerReglasRetencion(documentos.ReglasRetencion{AniosPorTipo: cfg.RetencionPorTipo})
+    servicioNegocio.EstablecerPublicador(publicador)
+    servicioNegocio.EstablecerAuditor(servicioAuditoria)
+    servicioNegocio.EstablecerTiposPermitidos(cfg.TiposMimePermitidos)
+
+    // 4. Verificación de dependencias para /health/ready
+    monitorSalud := salud.NuevoMonitor(cfg.SaludTimeout, cfg.SaludCache,
+        salud.ComprobacionMongo(clienteMongo),
+        salud.ComprobacionAlfresco(conexionAlfresco),
+        salud.ComprobacionTicket(autenticacionInterna),
+    )
+
+    return routes.Dependencias{
+        ServicioDocumentos: servicioNegocio,
+        ServicioAuditoria:  servicioAuditoria,
+        ServicioAuth:       servicioAuth,
+        AuthInterna:        autenticacionInterna,
+        ConexionAlfresco:   conexionAlfresco,
+        ClienteMongo:       clienteMongo,
+        MonitorSalud:       monitorSalud,
+        Limitador:          nuevoLimitador(cfg, log, clienteMongo),
+        Cifrador:           cifrador,
+    }
+}
+
+// construirServidor configura el router con sus middlewares y rutas y construye el servidor HTTP.
+// MongoDB se desconecta al apagar, después de las solicitudes y las tareas.
+func construirServidor(cfg *config.Config, log *logger.Registrador, deps routes.Dependencias) *servidor.Servidor {
+    // 1. Router Gin con middlewares
+    router := gin.Default()
+    router.Use(
+        middleware.MiddlewareTrazas("API_DOC"), // Span de la solicitud; antes del logging para incluir el trace_id
+        middleware.MiddlewareRegistro(log), // Middleware de logging
+        middleware.MiddlewareIdentidad(cfg), // Middleware de rol del cliente
+    )
+
+    // Configurar CORS y encabezados de seguridad; los preflight de orígenes permitidos se responden aquí
+    router.Use(
+        middleware.MiddlewareSeguridad(cfg),
+        middleware.MiddlewareCORS(cfg),
+    )
+
+    // 2. Rutas de la API, archivos estáticos, Swagger y métricas de Prometheus;
+    // el estado del circuito de Alfresco se informa en /health/ready
+    routes.RegistrarRutas(router, log, cfg, deps)
+    router.Static("/docs", "./docs")
+    router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+    router.GET("/metrics", gin.WrapH(metricas.Manejador()))
+
+    // 3. Servidor HTTP
+    srv := servidor.Nuevo(cfg, router, log)
+    srv.AlCerrar("mongodb", deps.ClienteMongo.Disconnect)
+
+    // 3.1 TLS nativo (y mTLS) para clientes que llaman sin ingress; los certificados se recargan al cambiar
+    if cfg.TLSHabilitado {
+        if err := srv.ConfigurarTLS(cfg); err != nil {
+            log.Fatal("Configuración TLS inválida", map[string]interface{}{"error": err.Error()})
+        }
+    }
+    return srv
+}
+
+// nuevoLimitador construye el rate limiting y las cuotas por cliente, o nil si están deshabilitados.
+// Con varias réplicas los contadores deben estar en MongoDB; en memoria cada réplica aplica el límite completo.
+func nuevoLimitador(cfg *config.Config, log *logger.Registrador, clienteMongo *mongodriver.Client) *limites.Limitador {
+    if !cfg.LimitesHabilitado {
+        return nil
+    }
+
+    // 1. Almacén de contadores
+    var almacen limites.Almacen = memoria.NuevoLimitesMemoria()
+    if cfg.LimitesAlmacen == "mongo" {
+        almacenMongo := mongo.NuevoLimitesMongo(clienteMongo, cfg)
+        if err := almacenMongo.CrearIndices(context.Background()); err != nil {
+            log.Warn("No se pudo crear el índice de límites", map[string]interface{}{"error": err.Error()})
+        }
+        almacen = almacenMongo
+    }
+
+    // 2. Reglas: MB a bytes; las cuotas no indicadas por cliente se heredan de las globales
+    reglas := limites.Reglas{
+        Global:     politicaLimite(config.ReglaLimite{SolicitudesMinuto: cfg.LimitesSolicitudesMinuto, Rafaga: cfg.LimitesRafaga, CuotaSubidaMB: cfg.LimitesCuotaSubidaMB, CuotaDescargaMB: cfg.LimitesCuotaDescargaMB}),
+        PorRuta:    make(map[string]limites.Politica, len(cfg.LimitesRutas)),
+        PorCliente: make(map[string]limites.Politica, len(cfg.LimitesClientes)),
+    }
+    for ruta, regla := range cfg.LimitesRutas {
+        reglas.PorRuta[ruta] = politicaLimite(regla)
+    }
+    for cliente, regla := range cfg.LimitesClientes {
+        reglas.PorCliente[cliente] = politicaLimite(regla)
+    }
+    log.Info("Límites por cliente habilitados", map[string]interface{}{
+        "almacen":  cfg.LimitesAlmacen,
+        "rutas":    len(reglas.PorRuta),
+        "clientes": len(reglas.PorCliente),
+    })
+    return limites.NuevoLimitador(almacen, reglas)
+}
+
+// politicaLimite convierte una regla de configuración en una política del limitador.
+func politicaLimite(regla config.ReglaLimite) limites.Politica {
+    return limites.Politica{
+        SolicitudesMinuto: regla.SolicitudesMinuto,
+        Rafaga:            regla.Rafaga,
+        CuotaSubida:       bytesCuota(regla.CuotaSubidaMB),
+        CuotaDescarga:     bytesCuota(regla.CuotaDescargaMB),
+    }
+}
+
+// bytesCuota convierte una cuota en MB a bytes, conservando la herencia.
+func bytesCuota(mb int64) int64 {
+    if mb == config.SinCuota {
+        return limites.Heredar
+    }
+    return mb * 1024 * 1024
+}
+
+// nuevoCifrador construye el cifrado de los datos sensibles del índice, o nil si CIFRADO_CLAVES está vacía.
+// Las claves solo se leen al iniciar: rotarlas requiere reiniciar y luego ejecutar --recifrar.
+func nuevoCifrador(cfg *config.Config, log *logger.Registrador) *cifrado.Cifrador {
+    if !cfg.CifradoHabilitado() {
+        return nil
+    }
+    cifrador, err := cifrado.NuevoCifrador(cfg.CifradoClaves, cfg.CifradoClaveActiva, cfg.CifradoIndiceClave)
+    if err != nil {
+        log.Fatal("Configuración de cifrado inválida", map[string]interface{}{"error": err.Error()})
+    }
+    log.Info("Cifrado del índice habilitado", map[string]interface{}{
+        "clave_activa": cfg.CifradoClaveActiva,
+        "claves":       len(cfg.CifradoClaves),
+    })
+    return cifrador
+}
+
+// recifrarColecciones vuelve a cifrar con la clave activa el índice, la auditoría, el outbox y los secretos de
+// las suscripciones de webhooks (--recifrar) y registra
+// el resultado de cada colección. Termina con error si el cifrado no está configurado o algún registro no pudo recifrarse.
+func recifrarColecciones(clienteMongo *mongodriver.Client, cfg *config.Config, cifrador *cifrado.Cifrador, log *logger.Registrador) {
+    indice := mongo.NuevoRepositorioIndice(clienteMongo, cfg)
+    indice.EstablecerCifrador(cifrador)
+    repositorioAuditoria := mongo.NuevoRepositorioAuditoria(clienteMongo, cfg)
+    repositorioAuditoria.EstablecerCifrador(cifrador)
+    outbox := mongo.NuevoOutbox(clienteMongo, cfg)
+    outbox.EstablecerCifrador(cifrador)
+    suscripciones := mongo.NuevoRepositorioSuscripciones(clienteMongo, cfg)
+    suscripciones.EstablecerCifrador(cifrador)
+
+    colecciones := []struct {
+        nombre   string
+        recifrar func(context.Context) (mongo.ResultadoRecifrado, error)
+    }{
+        {cfg.MongoCollection, indice.Recifrar},
+        {cfg.MongoCollectionAuditoria, repositorioAuditoria.Recifrar},
+        {cfg.MongoCollectionOutbox, outbox.Recifrar},
+        {cfg.MongoCollectionWebhooks, suscripciones.Recifrar},
+    }
+    incompleto := false
+    for _, coleccion := range colecciones {
+        log.Info("Recifrando colección", map[string]interface{}{"coleccion": coleccion.nombre, "clave_activa": cfg.CifradoClaveActiva})
+        resultado, err := coleccion.recifrar(context.Background())
+        campos := map[string]interface{}{
+            "coleccion":  coleccion.nombre,
+            "revisados":  resultado.Revisados,
+            "recifrados": resultado.Recifrados,
+            "fallidos":   len(resultado.Fallidos),
+        }
+        if err != nil {
+            campos["error"] = err.Error()
+            log.Fatal("Error al recifrar la colección", campos)
+        }
+        if len(resultado.Fallidos) > 0 {
+            log.Error("Registros que no pudieron recifrarse", map[string]interface{}{"coleccion": coleccion.nombre, "id": resultado.Fallidos})
+            incompleto = true
+            continue
+        }
+        log.Info("Colección recifrada", campos)
+    }
+    if incompleto {
+        log.Fatal("Recifrado incompleto; corrija las claves y vuelva a ejecutar --recifrar", nil)
+    }
+}
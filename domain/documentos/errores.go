@@ -0,0 +1,47 @@
+package documentos
+
+import (
+    "errors"
+    "fmt"
+)
+
+// Categorías de error del repositorio documental. Se comparan con errors.Is.
+var (
+    ErrDocumentoNoEncontrado   = errors.New("documento no encontrado")
+    ErrConflicto               = errors.New("conflicto con el estado del documento")
+    ErrTicketInvalido          = errors.New("ticket del repositorio documental inválido o expirado")
+    ErrCuotaExcedida           = errors.New("cuota del repositorio documental excedida")
+    ErrValidacion              = errors.New("solicitud rechazada por el repositorio documental")
+    ErrRepositorioNoDisponible = errors.New("repositorio documental no disponible")
+)
+
+// ErrorRepositorio describe un fallo del repositorio documental con su categoría y el detalle recibido.
+type ErrorRepositorio struct {
+    Categoria error  // Una de las categorías anteriores
+    Estado    int    // Código HTTP de la respuesta (0 si no hubo respuesta)
+    Detalle   string // Mensaje o cuerpo devuelto por el repositorio
+    Causa     error  // Error de transporte subyacente, si lo hubo
+}
+
+// Error implementa la interfaz error.
+func (e *ErrorRepositorio) Error() string {
+    mensaje := e.Categoria.Error()
+    if e.Estado != 0 {
+        mensaje = fmt.Sprintf("%s (HTTP %d)", mensaje, e.Estado)
+    }
+    if e.Detalle != "" {
+        mensaje += ": " + e.Detalle
+    }
+    if e.Causa != nil {
+        mensaje += ": " + e.Causa.Error()
+    }
+    return mensaje
+}
+
+// Unwrap permite que errors.Is reconozca tanto la categoría como la causa.
+func (e *ErrorRepositorio) Unwrap() []error {
+    if e.Causa == nil {
+        return []error{e.Categoria}
+    }
+    return []error{e.Categoria, e.Causa}
+}
@@ -0,0 +1,50 @@
+package documentos
+
+import (
+    "context"
+    "errors"
+)
+
+// ErrRegistroNoEncontrado indica que el índice no tiene un registro para el documento solicitado.
+var ErrRegistroNoEncontrado = errors.New("registro no encontrado en el índice")
+
+// RegistroIndice es la copia indexada de un documento de Alfresco usada para búsquedas y reglas de vigencia.
+type RegistroIndice struct {
+    ID                   string // ID del documento en Alfresco
+    NombreArchivo        string // Nombre del archivo
+    TipoArchivo          string // Tipo MIME del archivo
+    FechaCarga           string // Valor de tanner:fecha-carga
+    NombreDocumento      string // Valor de tanner:nombre-doc
+    TipoDocumento        string // Valor de tanner:tipo-documento
+    RazonSocialCliente   string // Valor de tanner:razon-social-cliente
+    RUTCliente           string // Valor de tanner:rut-cliente
+    EstadoVigencia       string // Valor de tanner:estado-vigencia
+    FechaTerminoVigencia string // Valor de tanner:fecha-termino-vigencia
+    Eliminado            bool   // Indica si el documento fue eliminado lógicamente
+    FechaEliminacion     string // Fecha de la eliminación lógica
+}
+
+// FiltroIndice define los criterios de búsqueda en el índice. Los campos vacíos no filtran.
+type FiltroIndice struct {
+    RUTCliente         string // RUT del cliente
+    TipoDocumento      string // Tipo de documento
+    NombreDocumento    string // Nombre del documento
+    FechaCarga         string // Fecha de carga
+    RazonSocialCliente string // Razón social del cliente
+    EstadoVigencia     string // Estado de vigencia
+}
+
+// RepositorioIndice define las operaciones sobre el índice de documentos.
+// Las implementaciones no deben retornar registros eliminados lógicamente en Buscar.
+type RepositorioIndice interface {
+    // Guardar indexa un documento recién subido a Alfresco.
+    Guardar(ctx context.Context, registro RegistroIndice) error
+    // Buscar retorna el ID del primer documento vigente que cumple el filtro, o ErrRegistroNoEncontrado.
+    Buscar(ctx context.Context, filtro FiltroIndice) (string, error)
+    // Obtener retorna el registro de un documento, incluidos los eliminados lógicamente.
+    Obtener(ctx context.Context, idFile string) (*RegistroIndice, error)
+    // MarcarEliminado registra la eliminación lógica de un documento.
+    MarcarEliminado(ctx context.Context, idFile string, estadoVigencia string) error
+    // Eliminar borra definitivamente el registro de un documento.
+    Eliminar(ctx context.Context, idFile string) error
+}
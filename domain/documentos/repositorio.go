@@ -1,61 +1,78 @@
 package documentos
 
 import (
-    "github.com/gin-gonic/gin" // Framework web para manejar solicitudes HTTP
+    "context"
 )
 
+// Credenciales agrupa la autenticación requerida por el repositorio documental en cada operación.
+type Credenciales struct {
+    Ticket string // Ticket de autenticación para Alfresco
+    APIKey string // API Key de Alfresco para autorización
+}
+
+// SolicitudSubida describe un archivo a subir junto a sus propiedades.
+type SolicitudSubida struct {
+    Credenciales
+    Contenido   []byte           // Bytes del archivo a subir
+    Propiedades DocumentMetadata // Propiedades del documento; los campos vacíos no se envían
+}
+
+// FiltroListado describe los criterios de un listado de documentos en el repositorio documental.
+// Solo se filtra por los campos informados; sin campos se listan todos los documentos visibles.
+type FiltroListado struct {
+    TipoDocumento      string `json:"tanner:tipo-documento"`        // Tipo de documento
+    RazonSocialCliente string `json:"tanner:razon-social-cliente"`  // Razón social del cliente
+    RUTCliente         string `json:"tanner:rut-cliente"`           // RUT del cliente
+    EstadoVisado       string `json:"tanner:estado-visado"`         // Estado de visado
+    EstadoVigencia     string `json:"tanner:estado-vigencia"`       // Estado de vigencia
+    NombreDoc          string `json:"tanner:nombre-doc"`            // Nombre del documento
+    FechaCarga         string `json:"tanner:fecha-carga"`           // Fecha de carga
+    Origen             string `json:"tanner:origen"`                // Origen del documento
+    Relacion           string `json:"tanner:relacion"`              // Relación del documento
+    CmTitle            string `json:"cm:title"`                     // Título del documento
+}
+
+// SolicitudListado describe los filtros de un listado de documentos.
+type SolicitudListado struct {
+    Credenciales
+    Filtros FiltroListado // Filtros por propiedad del documento
+}
+
+// SolicitudDocumento identifica un documento existente para descargarlo o eliminarlo.
+type SolicitudDocumento struct {
+    Credenciales
+    IDArchivo string // ID del archivo en el repositorio documental
+}
+
+// SolicitudActualizacion describe las propiedades a modificar en un documento existente.
+type SolicitudActualizacion struct {
+    Credenciales
+    IDArchivo   string           // ID del archivo a actualizar
+    Propiedades DocumentMetadata // Propiedades a modificar; los campos vacíos se conservan (ej. solo EstadoVigencia)
+}
+
+// ArchivoDocumento es el contenido descargado de un documento.
+type ArchivoDocumento struct {
+    Nombre    string // Nombre del archivo
+    Contenido []byte // Contenido binario del archivo
+}
+
 // AlmacenamientoDocumentos define las operaciones necesarias para interactuar con Alfresco.
-// Esta interfaz es stateless, lo que significa que no almacena estado y requiere credenciales en cada solicitud.
+// Esta interfaz es stateless: las credenciales viajan en cada solicitud y el contexto
+// controla la cancelación, de modo que puede usarse desde handlers HTTP, lotes o tareas en segundo plano.
 type AlmacenamientoDocumentos interface {
-    // SubirDocumento maneja la subida de un documento a Alfresco.
-    // Recibe:
-    // - c: Contexto de Gin para manejar la solicitud HTTP.
-    // - fileBytes: Bytes del archivo a subir.
-    // - metadatos: Metadatos asociados al archivo en formato string.
-    // - ticket: Ticket de autenticación para Alfresco.
-    // - apiKey: API Key de Alfresco para autorización.
-    // Retorna:
-    // - Un mapa con la respuesta de Alfresco.
-    // - Un error en caso de fallo.
-    SubirDocumento(
-        c *gin.Context,
-        fileBytes []byte,
-        metadatos string,
-        ticket string,
-        apiKey string,
-    ) (map[string]interface{}, error)
-
-
-    // ListarDocumentos maneja la obtención de un listado de documentos desde Alfresco.
-    // Recibe:
-    // - c: Contexto de Gin para manejar la solicitud HTTP.
-    // - filtros: Mapa de filtros para la búsqueda de documentos.
-    // - ticket: Ticket de autenticación para Alfresco.
-    // - nombreArchivo: Nombre del archivo para filtrar (opcional).
-    // Retorna:
-    // - Un mapa con la respuesta de Alfresco.
-    // - Un error en caso de fallo.
-    ListarDocumentos(
-        c *gin.Context,
-        filtros map[string]interface{},
-        ticket string,
-        nombreArchivo string,
-    ) (map[string]interface{}, error)
-
-    // DescargarDocumento maneja la descarga de un documento desde Alfresco.
-    // Recibe:
-    // - c: Contexto de Gin para manejar la solicitud HTTP.
-    // - idFile: ID del archivo a descargar.
-    // - ticket: Ticket de autenticación para Alfresco.
-    // - apiKey: API Key de Alfresco para autorización.
-    // Retorna:
-    // - El contenido binario del archivo.
-    // - El tipo MIME del archivo.
-    // - Un error en caso de fallo.
-    DescargarDocumento(
-        c *gin.Context,
-        idFile string,
-        ticket string,
-        apiKey string,
-    ) ([]byte, string, error)
-}
\ No newline at end of file
+    // SubirDocumento sube un archivo y retorna el documento creado.
+    SubirDocumento(ctx context.Context, solicitud SolicitudSubida) (*Documento, error)
+
+    // ListarDocumentos retorna los documentos que cumplen los filtros.
+    ListarDocumentos(ctx context.Context, solicitud SolicitudListado) ([]Documento, error)
+
+    // DescargarDocumento retorna el contenido y nombre del archivo.
+    DescargarDocumento(ctx context.Context, solicitud SolicitudDocumento) (*ArchivoDocumento, error)
+
+    // ActualizarPropiedades modifica las propiedades de un documento existente.
+    ActualizarPropiedades(ctx context.Context, solicitud SolicitudActualizacion) error
+
+    // EliminarDocumento elimina definitivamente un documento.
+    EliminarDocumento(ctx context.Context, solicitud SolicitudDocumento) error
+}
@@ -0,0 +1,104 @@
+package documentos
+
+import (
+    "errors"
+    "fmt"
+    "time"
+)
+
+// Valores posibles de tanner:estado-vigencia gestionados por la API.
+const (
+    EstadoVigente    = "Vigente"    // Documento activo
+    EstadoNoVigente  = "No Vigente" // Documento cuya fecha de término de vigencia ya pasó
+    EstadoEliminado  = "Eliminado"  // Documento eliminado lógicamente
+)
+
+// ErrDocumentoEnRetencion se produce al intentar eliminar un documento protegido por una regla de retención.
+var ErrDocumentoEnRetencion = errors.New("documento bajo regla de retención")
+
+// formatosFecha lista los formatos aceptados para las fechas de metadatos.
+var formatosFecha = []string{
+    time.RFC3339Nano,
+    "2006-01-02T15:04:05.000-0700",
+    "2006-01-02T15:04:05",
+    "2006-01-02",
+}
+
+// ParsearFecha interpreta una fecha de metadatos en cualquiera de los formatos aceptados.
+// Retorna false si el valor está vacío o no tiene un formato reconocido.
+func ParsearFecha(valor string) (time.Time, bool) {
+    for _, formato := range formatosFecha {
+        if fecha, err := time.Parse(formato, valor); err == nil {
+            return fecha, true
+        }
+    }
+    return time.Time{}, false
+}
+
+// FinVigencia interpreta tanner:fecha-termino-vigencia y retorna el instante en que el documento deja de estar vigente.
+// Una fecha sin hora se considera vigente durante todo ese día.
+func FinVigencia(valor string) (time.Time, bool) {
+    fecha, ok := ParsearFecha(valor)
+    if !ok {
+        return time.Time{}, false
+    }
+    if len(valor) == len("2006-01-02") {
+        fecha = fecha.AddDate(0, 0, 1)
+    }
+    return fecha, true
+}
+
+// Vencido indica si la fecha de término de vigencia ya pasó en el instante indicado.
+func Vencido(fechaTerminoVigencia string, ahora time.Time) bool {
+    fin, ok := FinVigencia(fechaTerminoVigencia)
+    return ok && !ahora.Before(fin)
+}
+
+// ReglasRetencion define los periodos mínimos de conservación de documentos.
+type ReglasRetencion struct {
+    AniosPorTipo map[string]int // Años de retención contados desde tanner:fecha-carga, por tipo de documento
+}
+
+// RetenidoHasta calcula hasta cuándo un documento debe conservarse.
+// Un documento queda retenido mientras su tanner:fecha-termino-vigencia no haya pasado
+// o mientras no se cumpla el periodo definido para su tipo de documento.
+// Retorna la fecha más lejana entre ambas reglas, o la fecha cero si ninguna aplica.
+// Si una regla aplica pero su fecha no puede leerse, retorna ErrDocumentoEnRetencion:
+// ante la duda el documento se conserva.
+func (r ReglasRetencion) RetenidoHasta(metadatos DocumentMetadata) (time.Time, error) {
+    var hasta time.Time
+
+    // 1. Retención por fecha de término de vigencia
+    if metadatos.FechaTerminoVigencia != "" {
+        termino, ok := FinVigencia(metadatos.FechaTerminoVigencia)
+        if !ok {
+            return time.Time{}, fmt.Errorf("%w: fecha de término de vigencia ilegible %q", ErrDocumentoEnRetencion, metadatos.FechaTerminoVigencia)
+        }
+        hasta = termino
+    }
+
+    // 2. Retención por tipo de documento
+    if anios, ok := r.AniosPorTipo[metadatos.TipoDocumento]; ok && anios > 0 {
+        carga, ok := ParsearFecha(metadatos.FechaCarga)
+        if !ok {
+            return time.Time{}, fmt.Errorf("%w: fecha de carga ilegible %q", ErrDocumentoEnRetencion, metadatos.FechaCarga)
+        }
+        if limite := carga.AddDate(anios, 0, 0); limite.After(hasta) {
+            hasta = limite
+        }
+    }
+
+    return hasta, nil
+}
+
+// ValidarEliminacion retorna ErrDocumentoEnRetencion si el documento no puede eliminarse en el instante indicado.
+func (r ReglasRetencion) ValidarEliminacion(metadatos DocumentMetadata, ahora time.Time) error {
+    hasta, err := r.RetenidoHasta(metadatos)
+    if err != nil {
+        return err
+    }
+    if ahora.Before(hasta) {
+        return fmt.Errorf("%w hasta %s", ErrDocumentoEnRetencion, hasta.Format("2006-01-02"))
+    }
+    return nil
+}
@@ -0,0 +1,69 @@
+package eventos
+
+import (
+    "context"
+    "time"
+    "github.com/google/uuid"
+)
+
+// Tipos de eventos del ciclo de vida de un documento.
+const (
+    TipoDocumentoSubido      = "cl.tanner.documento.subido"      // Primera versión cargada
+    TipoDocumentoVersionado  = "cl.tanner.documento.versionado"  // Carga con cm:versionLabel distinta de 1.0
+    TipoDocumentoActualizado = "cl.tanner.documento.actualizado" // Cambio de propiedades (ej. estado de vigencia)
+    TipoDocumentoEliminado   = "cl.tanner.documento.eliminado"   // Eliminación definitiva
+)
+
+// Valores fijos de los atributos CloudEvents emitidos por la API.
+const (
+    VersionCloudEvents = "1.0"
+    OrigenEventos      = "/regapigo/documentos"
+)
+
+// Evento sigue la estructura de CloudEvents 1.0 en formato JSON estructurado.
+// IDSolicitud es un atributo de extensión con el ID asignado por MiddlewareRegistro.
+type Evento struct {
+    SpecVersion     string         `json:"specversion" bson:"specversion"`
+    ID              string         `json:"id" bson:"id"`
+    Source          string         `json:"source" bson:"source"`
+    Type            string         `json:"type" bson:"type"`
+    Subject         string         `json:"subject" bson:"subject"`
+    Time            time.Time      `json:"time" bson:"time"`
+    DataContentType string         `json:"datacontenttype" bson:"datacontenttype"`
+    IDSolicitud     string         `json:"idsolicitud,omitempty" bson:"idsolicitud,omitempty"`
+    Data            DatosDocumento `json:"data" bson:"data"`
+}
+
+// DatosDocumento contiene la información del documento afectado.
+type DatosDocumento struct {
+    DocumentoID    string `json:"documento_id" bson:"documento_id"`                             // ID del documento en Alfresco
+    RUTCliente     string `json:"rut_cliente,omitempty" bson:"rut_cliente,omitempty"`           // RUT del cliente
+    TipoDocumento  string `json:"tipo_documento,omitempty" bson:"tipo_documento,omitempty"`     // Tipo de documento
+    Hash           string `json:"hash,omitempty" bson:"hash,omitempty"`                         // SHA-256 del contenido (solo en cargas)
+    EstadoVigencia string `json:"estado_vigencia,omitempty" bson:"estado_vigencia,omitempty"`   // Estado de vigencia resultante
+}
+
+// EventPublisher publica eventos del ciclo de vida de documentos.
+// Las implementaciones deben ser seguras para uso concurrente.
+type EventPublisher interface {
+    Publicar(ctx context.Context, evento Evento) error
+}
+
+// NuevoEvento construye un evento con ID y fecha generados.
+// Parámetros:
+//   - tipo: Tipo del evento (ej. TipoDocumentoSubido).
+//   - idSolicitud: ID de la solicitud HTTP que lo originó (vacío en tareas en segundo plano).
+//   - datos: Datos del documento afectado.
+func NuevoEvento(tipo, idSolicitud string, datos DatosDocumento) Evento {
+    return Evento{
+        SpecVersion:     VersionCloudEvents,
+        ID:              uuid.New().String(),
+        Source:          OrigenEventos,
+        Type:            tipo,
+        Subject:         datos.DocumentoID,
+        Time:            time.Now().UTC(),
+        DataContentType: "application/json",
+        IDSolicitud:     idSolicitud,
+        Data:            datos,
+    }
+}
@@ -0,0 +1,115 @@
+package test_documentos
+
+import (
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/stretchr/testify/assert"
+)
+
+// TestParsearFecha verifica los formatos de fecha aceptados en los metadatos.
+func TestParsearFecha(t *testing.T) {
+    casos := []struct {
+        valor  string
+        fecha  time.Time
+        valida bool
+    }{
+        {"2026-05-10", time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC), true},
+        {"2026-05-10T08:30:00", time.Date(2026, 5, 10, 8, 30, 0, 0, time.UTC), true},
+        {"2026-05-10T08:30:00Z", time.Date(2026, 5, 10, 8, 30, 0, 0, time.UTC), true},
+        {"2026-05-10T08:30:00.250-04:00", time.Date(2026, 5, 10, 12, 30, 0, 250000000, time.UTC), true},
+        {"2026-05-10T08:30:00.250-0400", time.Date(2026, 5, 10, 12, 30, 0, 250000000, time.UTC), true},
+        {"", time.Time{}, false},
+        {"10/05/2026", time.Time{}, false},
+        {"2026-13-01", time.Time{}, false},
+    }
+    for _, caso := range casos {
+        t.Run(caso.valor, func(t *testing.T) {
+            fecha, valida := documentos.ParsearFecha(caso.valor)
+            assert.Equal(t, caso.valida, valida)
+            assert.True(t, caso.fecha.Equal(fecha), "fecha %v, se esperaba %v", fecha, caso.fecha)
+        })
+    }
+}
+
+// TestFinVigencia verifica que una fecha sin hora cubre el día completo y una con hora termina en ese instante.
+func TestFinVigencia(t *testing.T) {
+    casos := []struct {
+        valor  string
+        fin    time.Time
+        valida bool
+    }{
+        {"2026-05-10", time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC), true},
+        {"2026-12-31", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), true},
+        {"2026-05-10T15:00:00Z", time.Date(2026, 5, 10, 15, 0, 0, 0, time.UTC), true},
+        {"", time.Time{}, false},
+        {"mañana", time.Time{}, false},
+    }
+    for _, caso := range casos {
+        t.Run(caso.valor, func(t *testing.T) {
+            fin, valida := documentos.FinVigencia(caso.valor)
+            assert.Equal(t, caso.valida, valida)
+            assert.True(t, caso.fin.Equal(fin), "fin %v, se esperaba %v", fin, caso.fin)
+        })
+    }
+}
+
+// TestVencido verifica el vencimiento en los bordes de la fecha de término.
+func TestVencido(t *testing.T) {
+    ahora := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+    casos := []struct {
+        fecha   string
+        vencido bool
+    }{
+        {"2026-05-09", true},
+        {"2026-05-10", false}, // Vigente durante todo el día de término
+        {"2026-05-11", false},
+        {"2026-05-10T11:59:59Z", true},
+        {"2026-05-10T12:00:00Z", true}, // Vence en el instante de término
+        {"2026-05-10T12:00:01Z", false},
+        {"", false},
+        {"no es fecha", false},
+    }
+    for _, caso := range casos {
+        t.Run(caso.fecha, func(t *testing.T) {
+            assert.Equal(t, caso.vencido, documentos.Vencido(caso.fecha, ahora))
+        })
+    }
+}
+
+// TestValidarEliminacion verifica que la retención aplica la más lejana entre la vigencia y el periodo por tipo.
+func TestValidarEliminacion(t *testing.T) {
+    reglas := documentos.ReglasRetencion{AniosPorTipo: map[string]int{"contrato": 5, "boleta": 0}}
+    ahora := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+    casos := []struct {
+        nombre    string
+        metadatos documentos.DocumentMetadata
+        detalle   string // Detalle informado en el error; vacío si la eliminación se permite
+    }{
+        {"sin reglas aplicables", documentos.DocumentMetadata{TipoDocumento: "otro"}, ""},
+        {"vigencia futura", documentos.DocumentMetadata{FechaTerminoVigencia: "2026-06-01"}, "hasta 2026-06-02"},
+        {"vence hoy", documentos.DocumentMetadata{FechaTerminoVigencia: "2026-05-10"}, "hasta 2026-05-11"},
+        {"vigencia pasada", documentos.DocumentMetadata{FechaTerminoVigencia: "2026-05-09"}, ""},
+        {"vigencia ilegible", documentos.DocumentMetadata{FechaTerminoVigencia: "31/12/2030"}, "vigencia ilegible"},
+        {"periodo por tipo vigente", documentos.DocumentMetadata{TipoDocumento: "contrato", FechaCarga: "2022-01-15"}, "hasta 2027-01-15"},
+        {"periodo por tipo cumplido", documentos.DocumentMetadata{TipoDocumento: "contrato", FechaCarga: "2021-05-10T12:00:00Z"}, ""},
+        {"periodo cero", documentos.DocumentMetadata{TipoDocumento: "boleta", FechaCarga: "2026-05-01"}, ""},
+        {"tipo sin fecha de carga", documentos.DocumentMetadata{TipoDocumento: "contrato"}, "carga ilegible"},
+        {"tipo con fecha de carga ilegible", documentos.DocumentMetadata{TipoDocumento: "contrato", FechaCarga: "15/01/2022"}, "carga ilegible"},
+        {"fecha de carga ilegible sin regla", documentos.DocumentMetadata{TipoDocumento: "otro", FechaCarga: "15/01/2022"}, ""},
+        {"prevalece el periodo", documentos.DocumentMetadata{TipoDocumento: "contrato", FechaCarga: "2024-01-01", FechaTerminoVigencia: "2026-06-01"}, "hasta 2029-01-01"},
+        {"prevalece la vigencia", documentos.DocumentMetadata{TipoDocumento: "contrato", FechaCarga: "2022-01-01", FechaTerminoVigencia: "2030-12-31"}, "hasta 2031-01-01"},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            err := reglas.ValidarEliminacion(caso.metadatos, ahora)
+            if caso.detalle == "" {
+                assert.NoError(t, err)
+                return
+            }
+            assert.ErrorIs(t, err, documentos.ErrDocumentoEnRetencion)
+            assert.Contains(t, err.Error(), caso.detalle)
+        })
+    }
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+    "net/http"
+    "github.com/CamiloScript/REGAPIGO/application/auditoria"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+)
+
+// ManejadorAuditoria expone la consulta y verificación del registro de auditoría.
+type ManejadorAuditoria struct {
+    servicio *auditoria.ServicioAuditoria // Servicio de auditoría
+    log      *logger.Registrador          // Logger para registro de eventos
+}
+
+// NuevoManejadorAuditoria inicializa el manejador con dependencias.
+func NuevoManejadorAuditoria(servicio *auditoria.ServicioAuditoria, log *logger.Registrador) *ManejadorAuditoria {
+    return &ManejadorAuditoria{servicio: servicio, log: log}
+}
+
+// RespuestaAuditoria es el cuerpo de GET /auditoria.
+type RespuestaAuditoria struct {
+    Data []auditoria.Registro `json:"data"` // Registros más recientes primero
+    Meta MetaListado          `json:"meta"`
+}
+
+// ManejadorConsultarAuditoria retorna los registros de auditoría más recientes.
+// @Summary Consultar registro de auditoría
+// @Description Filtra por RUT del cliente, documento o actor. Requiere rol administrador.
+// @Tags Auditoria
+// @Produce json
+// @Param rut_cliente query string false "RUT del cliente"
+// @Param documento_id query string false "ID del documento en Alfresco"
+// @Param actor query string false "Actor que originó la acción"
+// @Param limite query int false "Cantidad máxima de registros (por defecto 100, máximo 500)"
+// @Success 200 {object} RespuestaAuditoria "Registros más recientes primero"
+// @Failure 400 {object} Problema "Límite fuera de rango (SOLICITUD_INVALIDA)"
+// @Failure 403 {object} Problema "Rol insuficiente (ROL_INSUFICIENTE)"
+// @Failure 500 {object} Problema "Error interno (ERROR_INTERNO)"
+// @Router /auditoria [get]
+func (h *ManejadorAuditoria) ManejadorConsultarAuditoria(c *gin.Context) {
+    // 1. Validar rol
+    if middleware.RolSolicitante(c) != middleware.RolAdministrador {
+        responderProblema(c, http.StatusForbidden, CodigoRolInsuficiente, "La consulta de auditoría requiere rol administrador")
+        return
+    }
+
+    // 2. Construir filtro
+    limite, ok := leerLimite(c, 100)
+    if !ok {
+        return
+    }
+    filtro := auditoria.Filtro{
+        RUTCliente:  c.Query("rut_cliente"),
+        DocumentoID: c.Query("documento_id"),
+        Actor:       c.Query("actor"),
+        Limite:      limite,
+    }
+
+    // 3. Consultar
+    registros, err := h.servicio.Buscar(c.Request.Context(), filtro)
+    if err != nil {
+        h.log.ConContexto(c.Request.Context()).Error("Error al consultar auditoría", map[string]interface{}{"error": err.Error()})
+        responderProblema(c, http.StatusInternalServerError, CodigoErrorInterno, "Error al consultar la auditoría")
+        return
+    }
+    c.JSON(http.StatusOK, RespuestaAuditoria{Data: registros, Meta: MetaListado{Total: len(registros)}})
+}
+
+// ManejadorVerificarAuditoria recorre la cadena de hashes y reporta si fue alterada.
+// @Summary Verificar integridad del registro de auditoría
+// @Tags Auditoria
+// @Produce json
+// @Success 200 {object} auditoria.Verificacion "Cadena íntegra"
+// @Failure 403 {object} Problema "Rol insuficiente (ROL_INSUFICIENTE)"
+// @Failure 409 {object} auditoria.Verificacion "Cadena alterada"
+// @Failure 500 {object} Problema "Error interno (ERROR_INTERNO)"
+// @Router /auditoria/verificacion [get]
+func (h *ManejadorAuditoria) ManejadorVerificarAuditoria(c *gin.Context) {
+    if middleware.RolSolicitante(c) != middleware.RolAdministrador {
+        responderProblema(c, http.StatusForbidden, CodigoRolInsuficiente, "La consulta de auditoría requiere rol administrador")
+        return
+    }
+
+    verificacion, err := h.servicio.Verificar(c.Request.Context())
+    if err != nil {
+        h.log.ConContexto(c.Request.Context()).Error("Error al verificar auditoría", map[string]interface{}{"error": err.Error()})
+        responderProblema(c, http.StatusInternalServerError, CodigoErrorInterno, "Error al verificar la auditoría")
+        return
+    }
+    if !verificacion.Integra {
+        c.JSON(http.StatusConflict, verificacion)
+        return
+    }
+    c.JSON(http.StatusOK, verificacion)
+}
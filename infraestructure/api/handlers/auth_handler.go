@@ -1,8 +1,10 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "github.com/CamiloScript/REGAPIGO/domain/auth"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
     "github.com/gin-gonic/gin"
 )
@@ -37,27 +39,44 @@ func (h *AuthHandler) Login(c *gin.Context) {
     // Vincular JSON a la estructura
     // Si el JSON no coincide con la estructura o es inválido, se devuelve un error 400 (Bad Request).
     if err := c.ShouldBindJSON(&credenciales); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de JSON inválido"})
+        responderSolicitudInvalida(c, "Formato de JSON inválido", err)
         return
     }
 
-    if credenciales.UserId == "" || credenciales.Password == "" {
-        h.log.Error("Credenciales faltantes", nil)
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Credenciales faltantes"})
+    if faltantes := camposCredencialesFaltantes(credenciales.UserId, credenciales.Password); len(faltantes) > 0 {
+        h.log.ConContexto(c.Request.Context()).Error("Credenciales faltantes", nil)
+        responderProblema(c, http.StatusBadRequest, CodigoSolicitudInvalida, "Credenciales faltantes", faltantes...)
         return
     }
 
     // Autenticar con el servicio de autenticación
     // Se llama al servicio de autenticación para validar las credenciales.
-    // Si el servicio devuelve un error, se registra el error y se devuelve un error 401 (Unauthorized).
+    // Si Alfresco no está disponible se devuelve 503; cualquier otro error se responde como 401 (Unauthorized).
     ticket, err := h.servicio.Authenticate(credenciales.UserId, credenciales.Password)
+    if errors.Is(err, documentos.ErrRepositorioNoDisponible) {
+        h.log.ConContexto(c.Request.Context()).Error("Repositorio no disponible al autenticar", map[string]interface{}{"usuario": credenciales.UserId, "error": err.Error()})
+        responderProblema(c, http.StatusServiceUnavailable, CodigoRepositorioNoDisponible, "repositorio documental no disponible")
+        return
+    }
     if err != nil {
-        h.log.Error("Credenciales inválidas", map[string]interface{}{"usuario": credenciales.UserId})
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "Credenciales incorrectas"})
+        h.log.ConContexto(c.Request.Context()).Error("Credenciales inválidas", map[string]interface{}{"usuario": credenciales.UserId})
+        responderProblema(c, http.StatusUnauthorized, CodigoCredencialesInvalidas, "Credenciales incorrectas")
         return
     }
 
     // Respuesta exitosa con ticket (sin almacenar en sesión)
     // Si la autenticación es exitosa, se devuelve un código 200 (OK) junto con el ticket.
     c.JSON(http.StatusOK, gin.H{"ticket": ticket})
-}
\ No newline at end of file
+}
+
+// camposCredencialesFaltantes retorna un error de campo por cada credencial vacía.
+func camposCredencialesFaltantes(usuario, password string) []ErrorCampo {
+    var faltantes []ErrorCampo
+    if usuario == "" {
+        faltantes = append(faltantes, ErrorCampo{Campo: "userId", Codigo: CodigoValidacion, Detalle: "campo requerido"})
+    }
+    if password == "" {
+        faltantes = append(faltantes, ErrorCampo{Campo: "password", Codigo: CodigoValidacion, Detalle: "campo requerido"})
+    }
+    return faltantes
+}
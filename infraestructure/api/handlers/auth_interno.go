@@ -1,17 +1,30 @@
 package handlers
 
 import (
+    "crypto/sha256"
+    "errors"
     "fmt"
+    "sync"
+    "time"
     "github.com/CamiloScript/REGAPIGO/domain/auth"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/metricas"
     "github.com/CamiloScript/REGAPIGO/shared/config"
 )
 
 // InternalAuth es una estructura que encapsula la lógica de autenticación interna.
+// Reutiliza el ticket mientras esté vigente y las credenciales no cambien. Se construye una sola vez en main y
+// se comparte entre los manejadores y las tareas en segundo plano, de modo que todos usan el mismo ticket.
 type InternalAuth struct {
     authServicio auth.AuthService // Servicio de autenticación
     log          *logger.Registrador // Logger para registro de eventos
     cfg          *config.Config // Configuración de la aplicación
+
+    mu         sync.Mutex // Protege el ticket en caché
+    ticket     string     // Último ticket obtenido
+    huella     [32]byte   // Huella de las credenciales con que se obtuvo el ticket
+    expiracion time.Time  // Momento en que el ticket deja de reutilizarse
 }
 
 // NewInternalAuth crea una nueva instancia de InternalAuth.
@@ -24,24 +37,72 @@ func NewInternalAuth(authServicio auth.AuthService, log *logger.Registrador, cfg
 }
 
 // AutenticarInternamente genera un ticket de autenticación con Alfresco.
+// El ticket se reutiliza durante AUTH_TICKET_VIGENCIA; si la contraseña rota se inicia sesión de nuevo.
 // Retorna el ticket y un error en caso de fallo.
 func (ia *InternalAuth) AutenticarInternamente() (string, error) {
-    // Obtener credenciales desde la configuración
+    // 1. Obtener credenciales vigentes desde la configuración
     user := ia.cfg.AuthUser
-    password := ia.cfg.AuthPassword
+    password := ia.cfg.ContrasenaAuth()
+    huella := sha256.Sum256([]byte(user + "\x00" + password))
+
+    ia.mu.Lock()
+    defer ia.mu.Unlock()
+
+    // 2. Reutilizar el ticket si sigue vigente y las credenciales no cambiaron
+    if ia.ticket != "" {
+        if huella == ia.huella && time.Now().Before(ia.expiracion) {
+            return ia.ticket, nil
+        }
+        if huella != ia.huella {
+            ia.log.Info("Credenciales internas rotadas; se renueva el ticket", map[string]interface{}{"user": user})
+        }
+    }
 
-    // Llamar al servicio de autenticación
+    // 3. Llamar al servicio de autenticación
     ticket, err := ia.authServicio.Authenticate(user, password)
+    metricas.ContarRenovacionTicket(err == nil)
     if err != nil {
+        ia.ticket = ""
         ia.log.Error("Error de autenticación interna", map[string]interface{}{
             "error": err.Error(),
             "user":  user,
         })
-        return "", fmt.Errorf("error al autenticar internamente: %v", err)
+        return "", fmt.Errorf("error al autenticar internamente: %w", err)
     }
 
+    ia.ticket = ticket
+    ia.huella = huella
+    ia.expiracion = time.Now().Add(ia.cfg.AuthTicketVigencia)
     ia.log.Info("Autenticación interna exitosa", map[string]interface{}{
         "user": user,
     })
     return ticket, nil
-}
\ No newline at end of file
+}
+
+// Invalidar descarta el ticket en caché para que la próxima autenticación inicie sesión de nuevo.
+// Se usa cuando Alfresco rechaza el ticket antes de su vencimiento (ej. reinicio o cierre de sesión).
+func (ia *InternalAuth) Invalidar() {
+    ia.mu.Lock()
+    defer ia.mu.Unlock()
+    ia.ticket = ""
+}
+
+// ConTicket ejecuta la operación con el ticket interno. Si Alfresco rechaza el ticket, lo invalida y
+// reintenta una sola vez con uno nuevo.
+func (ia *InternalAuth) ConTicket(operacion func(ticket string) error) error {
+    ticket, err := ia.AutenticarInternamente()
+    if err != nil {
+        return err
+    }
+    err = operacion(ticket)
+    if !errors.Is(err, documentos.ErrTicketInvalido) {
+        return err
+    }
+
+    ia.log.Warn("Alfresco rechazó el ticket interno; se renueva y se reintenta", nil)
+    ia.Invalidar()
+    if ticket, err = ia.AutenticarInternamente(); err != nil {
+        return err
+    }
+    return operacion(ticket)
+}
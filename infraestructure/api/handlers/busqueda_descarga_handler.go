@@ -2,13 +2,13 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "github.com/CamiloScript/REGAPIGO/application/documento"
-    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
     "github.com/CamiloScript/REGAPIGO/shared/config"
     "github.com/gin-gonic/gin"
-    "github.com/CamiloScript/REGAPIGO/domain/auth"
     "github.com/CamiloScript/REGAPIGO/shared/utils"
 )
 
@@ -19,6 +19,7 @@ import (
 // ManejadorBusquedaDescarga gestiona operaciones de búsqueda y descarga.
 type ManejadorBusquedaDescarga struct {
     servicio     *documento.ImplementacionServicioDocumentos // Servicio de documentos
+    indice       documentos.RepositorioIndice // Índice de documentos
     log          *logger.Registrador // Logger para registro de eventos
     cfg          *config.Config // Configuración de la aplicación
     internalAuth *InternalAuth // Autenticación interna
@@ -27,15 +28,17 @@ type ManejadorBusquedaDescarga struct {
 // NuevoManejadorBusquedaDescarga crea una instancia del manejador con dependencias inyectadas.
 func NuevoManejadorBusquedaDescarga(
     servicio *documento.ImplementacionServicioDocumentos,
+    indice documentos.RepositorioIndice, // Índice de documentos
     log *logger.Registrador,
     cfg *config.Config,
-    authServicio auth.AuthService, // Servicio de autenticación
+    internalAuth *InternalAuth, // Autenticación interna compartida
 ) *ManejadorBusquedaDescarga {
     return &ManejadorBusquedaDescarga{
         servicio:     servicio,
+        indice:       indice,
         log:          log,
         cfg:          cfg,
-        internalAuth: NewInternalAuth(authServicio, log, cfg), // Inicializar autenticación interna
+        internalAuth: internalAuth,
     }
 }
 
@@ -62,47 +65,27 @@ type SolicitudBusqueda struct {
 }
 
 
-// construirFiltro construye un filtro de búsqueda para MongoDB basado en la solicitud
-func construirFiltro(solicitud SolicitudBusqueda) map[string]interface{} {
-    filtro := make(map[string]interface{})
-
-    // Campos obligatorios
-    if solicitud.RUTCliente != "" {
-        filtro["metadatos.rut_cliente"] = solicitud.RUTCliente
-    } else if solicitud.TannerRUTCliente != "" {
-        filtro["metadatos.rut_cliente"] = solicitud.TannerRUTCliente
-    }
-
-    // Campos opcionales
-    if solicitud.TipoDocumento != "" {
-        filtro["metadatos.tipo_documento"] = solicitud.TipoDocumento
-    } else if solicitud.TannerTipoDocumento != "" {
-        filtro["metadatos.tipo_documento"] = solicitud.TannerTipoDocumento
-    }
-
-    if solicitud.NombreDocumento != "" {
-        filtro["metadatos.nombre_documento"] = solicitud.NombreDocumento
-    } else if solicitud.TannerNombreDoc != "" {
-        filtro["metadatos.nombre_documento"] = solicitud.TannerNombreDoc
-    }
-
-    if solicitud.FechaCarga != "" {
-        filtro["metadatos.fecha_carga"] = solicitud.FechaCarga
-    } else if solicitud.TannerFechaCarga != "" {
-        filtro["metadatos.fecha_carga"] = solicitud.TannerFechaCarga
-    }
-
-    if solicitud.RazonSocial != "" {
-        filtro["metadatos.razon_social_cliente"] = solicitud.RazonSocial
-    } else if solicitud.TannerRazonSocial != "" {
-        filtro["metadatos.razon_social_cliente"] = solicitud.TannerRazonSocial
+// construirFiltro construye el filtro del índice a partir de la solicitud.
+// Cada criterio acepta el formato del índice o el formato del servicio externo; el primero tiene prioridad.
+func construirFiltro(solicitud SolicitudBusqueda) documentos.FiltroIndice {
+    return documentos.FiltroIndice{
+        RUTCliente:         primerNoVacio(solicitud.RUTCliente, solicitud.TannerRUTCliente),
+        TipoDocumento:      primerNoVacio(solicitud.TipoDocumento, solicitud.TannerTipoDocumento),
+        NombreDocumento:    primerNoVacio(solicitud.NombreDocumento, solicitud.TannerNombreDoc),
+        FechaCarga:         primerNoVacio(solicitud.FechaCarga, solicitud.TannerFechaCarga),
+        RazonSocialCliente: primerNoVacio(solicitud.RazonSocial, solicitud.TannerRazonSocial),
+        EstadoVigencia:     solicitud.TannerEstadoVigencia,
     }
+}
 
-    if solicitud.TannerEstadoVigencia != "" {
-        filtro["metadatos.estado_vigencia"] = solicitud.TannerEstadoVigencia
+// primerNoVacio retorna el primer valor no vacío.
+func primerNoVacio(valores ...string) string {
+    for _, valor := range valores {
+        if valor != "" {
+            return valor
+        }
     }
-
-    return filtro
+    return ""
 }
 
 
@@ -120,52 +103,60 @@ func construirFiltro(solicitud SolicitudBusqueda) map[string]interface{} {
 // @Param solicitud body SolicitudBusqueda true "Criterios de búsqueda"
 // @Security ApiKeyAuth
 // @Success 200 {file} binary "Archivo descargado"
-// @Failure 400 {object} map[string]string "Error en formato de solicitud"
-// @Failure 404 {object} map[string]string "Documento no encontrado"
-// @Failure 500 {object} map[string]string "Error interno"
+// @Failure 400 {object} Problema "Error en formato de solicitud"
+// @Failure 404 {object} Problema "Sin resultados en el índice (SIN_RESULTADOS) o documento no encontrado en el repositorio"
+// @Failure 500 {object} Problema "Error interno"
 // @Router /documentos/buscar-descargar [post]
 // busqueda_descarga_handler.go
 
 func (h *ManejadorBusquedaDescarga) BuscarYDescargarDocumento(c *gin.Context) {
-    // 1. Autenticación interna
-    ticket, err := h.internalAuth.AutenticarInternamente()
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno de autenticación"})
-        return
-    }
-
-    // 2. Validar y parsear solicitud
+    // 1. Validar y parsear solicitud
     var solicitud SolicitudBusqueda
     if err := c.ShouldBindJSON(&solicitud); err != nil {
-        h.log.Error("Solicitud inválida", map[string]interface{}{"error": err.Error()})
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de solicitud incorrecto"})
+        h.log.ConContexto(c.Request.Context()).Error("Solicitud inválida", map[string]interface{}{"error": err.Error()})
+        responderSolicitudInvalida(c, "Formato de solicitud incorrecto", err)
         return
     }
 
-    // 3. Construir filtro y buscar en MongoDB
+    // 2. Construir filtro y buscar en el índice
     filtro := construirFiltro(solicitud)
-    idFile, err := mongo.BuscarDocumento(filtro)
+    idFile, err := h.indice.Buscar(c.Request.Context(), filtro)
+    h.servicio.RegistrarBusqueda(c.Request.Context(), filtro.RUTCliente, idFile, err)
+    if errors.Is(err, documentos.ErrRegistroNoEncontrado) {
+        h.log.ConContexto(c.Request.Context()).Warn("Documento no encontrado en el índice", map[string]interface{}{"filtro": filtro})
+        responderProblema(c, http.StatusNotFound, CodigoSinResultados, "No se encontraron documentos con los criterios proporcionados")
+        return
+    }
     if err != nil {
-        h.log.Error("Documento no encontrado en MongoDB", map[string]interface{}{"filtro": filtro, "error": err.Error()})
-        c.JSON(http.StatusNotFound, gin.H{"error": "No se encontraron documentos con los criterios proporcionados"})
+        h.log.ConContexto(c.Request.Context()).Error("Error al buscar en el índice", map[string]interface{}{"filtro": filtro, "error": err.Error()})
+        responderErrorDocumento(c, err, "Error al buscar en el índice de documentos")
         return
     }
 
-    // 4. Descargar desde Alfresco
-    contenido, nombreArchivo, err := h.servicio.DescargarDocumento(c, idFile, ticket)
+    // 3. Descargar desde Alfresco con el ticket interno
+    rutCliente := filtro.RUTCliente
+    if rutCliente == "" {
+        rutCliente = rutIndexado(c.Request.Context(), h.indice, h.log, idFile)
+    }
+    var archivo *documentos.ArchivoDocumento
+    err = h.internalAuth.ConTicket(func(ticket string) error {
+        var err error
+        archivo, err = h.servicio.DescargarDocumento(c.Request.Context(), idFile, rutCliente, ticket)
+        return err
+    })
     if err != nil {
-        h.log.Error("Fallo en descarga desde Alfresco", map[string]interface{}{"idFile": idFile, "error": err.Error()})
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al recuperar el archivo desde el repositorio"})
+        h.log.ConContexto(c.Request.Context()).Error("Fallo en descarga desde Alfresco", map[string]interface{}{"idFile": idFile, "error": err.Error()})
+        responderErrorDocumento(c, err, "Error al recuperar el archivo desde el repositorio")
         return
     }
 
-    // 5. Codificar el archivo a base64
-    base64File := utils.EncodeToBase64(contenido)
+    // 4. Codificar el archivo a base64
+    base64File := utils.EncodeToBase64(archivo.Contenido)
 
-    // 6. Configurar respuesta
+    // 5. Configurar respuesta
     c.JSON(http.StatusOK, gin.H{
-        "fileName": nombreArchivo,
+        "fileName": archivo.Nombre,
         "base64":   base64File,
     })
-    h.log.Info("Descarga exitosa", map[string]interface{}{"idFile": idFile, "nombreArchivo": nombreArchivo})
+    h.log.ConContexto(c.Request.Context()).Info("Descarga exitosa", map[string]interface{}{"idFile": idFile, "nombreArchivo": archivo.Nombre})
 }
\ No newline at end of file
@@ -1,19 +1,25 @@
 package handlers
 
 import (
+    "context"
+    "errors"
     "net/http"
     "github.com/CamiloScript/REGAPIGO/application/documento"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
     "github.com/CamiloScript/REGAPIGO/shared/config"
     "github.com/gin-gonic/gin"
-    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
-    "github.com/CamiloScript/REGAPIGO/domain/auth" 
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
     "github.com/CamiloScript/REGAPIGO/shared/utils"
+    "github.com/CamiloScript/REGAPIGO/shared/trazas"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/trace"
 )
 
 // ManejadorDocumentos controla las operaciones con documentos.
 type ManejadorDocumentos struct {
     servicio     *documento.ImplementacionServicioDocumentos // Servicio de documentos
+    indice       documentos.RepositorioIndice                // Índice de documentos para búsquedas y vigencia
     log          *logger.Registrador                         // Logger para registro de eventos
     cfg          *config.Config                              // Configuración de la aplicación
     internalAuth *InternalAuth                               // Autenticación interna
@@ -22,137 +28,198 @@ type ManejadorDocumentos struct {
 // NuevoManejadorDocumentos inicializa el manejador con dependencias.
 func NuevoManejadorDocumentos(
     servicio *documento.ImplementacionServicioDocumentos,
+    indice documentos.RepositorioIndice, // Índice de documentos
     log *logger.Registrador,
     cfg *config.Config,
-    authServicio auth.AuthService, // Servicio de autenticación
+    internalAuth *InternalAuth, // Autenticación interna compartida
 ) *ManejadorDocumentos {
     return &ManejadorDocumentos{
         servicio:     servicio,
+        indice:       indice,
         log:          log,
         cfg:          cfg,
-        internalAuth: NewInternalAuth(authServicio, log, cfg), // Inicializar autenticación interna
+        internalAuth: internalAuth,
     }
 }
 
+// indexarDocumento registra en el índice un documento recién subido a Alfresco.
+func (h *ManejadorDocumentos) indexarDocumento(ctx context.Context, doc documentos.Documento) error {
+    registro, err := documento.RegistroIndiceDesdeDocumento(doc)
+    if err != nil {
+        h.log.ConContexto(ctx).Warn("Documento de Alfresco no indexable", map[string]interface{}{"error": err.Error()})
+        return err
+    }
+    if err := h.indice.Guardar(ctx, registro); err != nil {
+        h.log.ConContexto(ctx).Error("Fallo al guardar en el índice", map[string]interface{}{"error": err.Error(), "doc_id": registro.ID})
+        return err
+    }
+    h.log.ConContexto(ctx).Info("Documento persistido exitosamente", map[string]interface{}{"id": registro.ID})
+    return nil
+}
 
-// ManejadorSubirDocumento maneja la subida de documentos en formato base64.
-func (h *ManejadorDocumentos) ManejadorSubirDocumento(c *gin.Context) {
-// 1. Autenticación interna
-ticket, err := h.internalAuth.AutenticarInternamente()
-if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno de autenticación"})
-    return
+// entradaDocumento conserva el formato {"entry": {...}} de Alfresco en la respuesta de subida.
+func entradaDocumento(doc *documentos.Documento) gin.H {
+    return gin.H{
+        "entry": gin.H{
+            "id":             doc.ID,
+            "name":           doc.Nombre,
+            "modifiedAt":     doc.ModificadoEn,
+            "modifiedByUser": gin.H{"displayName": doc.Autor},
+            "content":        gin.H{"mimeType": doc.Tipo, "sizeInBytes": doc.Tamano},
+            "properties":     doc.Propiedades,
+        },
+    }
 }
 
-// 2. Extraer archivo en base64 y metadatos del cuerpo de la solicitud
+// ManejadorSubirDocumento maneja la subida de documentos en formato base64.
+func (h *ManejadorDocumentos) ManejadorSubirDocumento(c *gin.Context) {
+// 1. Extraer archivo en base64 y metadatos del cuerpo de la solicitud
 var solicitud struct {
-    Base64    string                 `json:"base64"`    // Archivo en formato base64
-    Metadatos map[string]interface{} `json:"metadatos"` // Metadatos en formato JSON
+    Base64    string                      `json:"base64"`    // Archivo en formato base64
+    Metadatos documentos.DocumentMetadata `json:"metadatos"` // Metadatos del documento
 }
 if err := c.ShouldBindJSON(&solicitud); err != nil {
-    h.log.Error("Solicitud inválida", map[string]interface{}{"error": err.Error()})
-    c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de solicitud incorrecto"})
+    h.log.ConContexto(c.Request.Context()).Error("Solicitud inválida", map[string]interface{}{"error": err.Error()})
+    responderSolicitudInvalida(c, "Formato de solicitud incorrecto", err)
     return
 }
 
-// 3. Decodificar el archivo base64 a bytes
-fileBytes, err := utils.DecodeBase64(solicitud.Base64)
+// 2. Decodificar el archivo base64 a bytes
+fileBytes, err := decodificarBase64(c.Request.Context(), solicitud.Base64)
 if err != nil {
-    h.log.Error("Error al decodificar base64", map[string]interface{}{"error": err.Error()})
-    c.JSON(http.StatusBadRequest, gin.H{"error": "Archivo base64 inválido"})
+    h.log.ConContexto(c.Request.Context()).Error("Error al decodificar base64", map[string]interface{}{"error": err.Error()})
+    responderProblema(c, http.StatusBadRequest, CodigoSolicitudInvalida, "Archivo base64 inválido",
+        ErrorCampo{Campo: "base64", Codigo: CodigoValidacion, Detalle: err.Error()})
     return
 }
 
-// 4. Delegar al servicio de documentos
-respuesta, err := h.servicio.SubirDocumento(c, fileBytes, solicitud.Metadatos, ticket)
+// 3. Delegar al servicio de documentos con el ticket interno
+var doc *documentos.Documento
+err = h.internalAuth.ConTicket(func(ticket string) error {
+    var err error
+    doc, err = h.servicio.SubirDocumento(c.Request.Context(), documento.SolicitudCarga{
+        Contenido: fileBytes,
+        Metadatos: solicitud.Metadatos,
+        Ticket:    ticket,
+    })
+    return err
+})
 if err != nil {
-    h.log.Error("Error interno", map[string]interface{}{"error": err.Error()})
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar el documento"})
+    h.log.ConContexto(c.Request.Context()).Error("Error al subir documento", map[string]interface{}{"error": err.Error()})
+    responderErrorDocumento(c, err, "Error al procesar el documento")
     return
 }
 
-// 5. Responder con éxito
-c.JSON(http.StatusOK, respuesta)
-h.log.Info("Documento subido", map[string]interface{}{"id": respuesta["entry"].(map[string]interface{})["id"]})
+// 4. Responder con éxito
+c.JSON(http.StatusOK, entradaDocumento(doc))
+h.log.ConContexto(c.Request.Context()).Info("Documento subido", map[string]interface{}{"id": doc.ID})
 
-// 6. Persistir en MongoDB
-if err := mongo.GuardarEnMongoDB(respuesta, h.log); err != nil {
-    h.log.Error("Error en persistencia MongoDB", map[string]interface{}{"error": err.Error()})
+// 5. Persistir en el índice
+if err := h.indexarDocumento(c.Request.Context(), *doc); err != nil {
+    h.log.ConContexto(c.Request.Context()).Error("Error en persistencia del índice", map[string]interface{}{"error": err.Error()})
 }
 }
 
 // ManejadorListarDocumentos procesa el listado de documentos.
 func (h *ManejadorDocumentos) ManejadorListarDocumentos(c *gin.Context) {
-    // 1. Autenticación interna
-    ticket, err := h.internalAuth.AutenticarInternamente()
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno de autenticación"})
-        return
-    }
-
-    // 2. Extraer filtros del cuerpo
-    var filtros map[string]interface{}
+    // 1. Extraer filtros del cuerpo
+    var filtros documentos.FiltroListado
     if err := c.ShouldBindJSON(&filtros); err != nil {
-        h.log.Error("Error al analizar filtros", map[string]interface{}{"error": err.Error()})
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de filtros inválido"})
+        h.log.ConContexto(c.Request.Context()).Error("Error al analizar filtros", map[string]interface{}{"error": err.Error()})
+        responderSolicitudInvalida(c, "Formato de filtros inválido", err)
         return
     }
 
-    // 3. Delegar al servicio de documentos
-    documentos, err := h.servicio.ListarDocumentos(c, filtros, ticket)
+    // 2. Delegar al servicio de documentos con el ticket interno
+    var resultado []documentos.Documento
+    err := h.internalAuth.ConTicket(func(ticket string) error {
+        var err error
+        resultado, err = h.servicio.ListarDocumentos(c.Request.Context(), filtros, ticket)
+        return err
+    })
     if err != nil {
-        h.log.Error("Error al listar documentos", map[string]interface{}{"error": err.Error()})
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al listar documentos"})
+        h.log.ConContexto(c.Request.Context()).Error("Error al listar documentos", map[string]interface{}{"error": err.Error()})
+        responderErrorDocumento(c, err, "Error al listar documentos")
         return
     }
 
-    // 4. Responder con éxito
+    // La respuesta mantiene los documentos indexados por ID
+    documentosPorID := make(map[string]documentos.Documento, len(resultado))
+    for _, doc := range resultado {
+        documentosPorID[doc.ID] = doc
+    }
+
+    // 3. Responder con éxito
     c.JSON(http.StatusOK, gin.H{
-        "data": documentos,
+        "data": documentosPorID,
         "meta": map[string]interface{}{
-            "total": len(documentos),
+            "total": len(documentosPorID),
         },
     })
-    h.log.Info("Documentos listados", map[string]interface{}{"total": len(documentos)})
+    h.log.ConContexto(c.Request.Context()).Info("Documentos listados", map[string]interface{}{"total": len(documentosPorID)})
 }
 
 // ManejadorDescargarDocumento maneja la descarga de documentos y los devuelve en formato base64.
 func (h *ManejadorDocumentos) ManejadorDescargarDocumento(c *gin.Context) {
-    // 1. Autenticación interna
-    ticket, err := h.internalAuth.AutenticarInternamente()
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno de autenticación"})
-        return
-    }
-
-    // 2. Extraer ID del archivo desde parámetros de consulta
+    // 1. Extraer ID del archivo desde parámetros de consulta
     idFile := c.Query("idFile")
     if idFile == "" {
-        h.log.Error("ID de archivo faltante", nil)
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere el parámetro idFile"})
+        h.log.ConContexto(c.Request.Context()).Error("ID de archivo faltante", nil)
+        responderProblema(c, http.StatusBadRequest, CodigoSolicitudInvalida, "Se requiere el parámetro idFile",
+            ErrorCampo{Campo: "idFile", Codigo: CodigoValidacion, Detalle: "parámetro requerido"})
         return
     }
 
-    // 3. Delegar al servicio de documentos
-    contenido, nombreArchivo, err := h.servicio.DescargarDocumento(c, idFile, ticket)
+    // 2. Delegar al servicio de documentos, con el RUT indexado para la auditoría
+    rutCliente := rutIndexado(c.Request.Context(), h.indice, h.log, idFile)
+    var archivo *documentos.ArchivoDocumento
+    err := h.internalAuth.ConTicket(func(ticket string) error {
+        var err error
+        archivo, err = h.servicio.DescargarDocumento(c.Request.Context(), idFile, rutCliente, ticket)
+        return err
+    })
     if err != nil {
-        if err == documento.ErrDocumentoNoEncontrado {
-            h.log.Warn("Documento no encontrado", map[string]interface{}{"idFile": idFile})
-            c.JSON(http.StatusNotFound, gin.H{"error": "documento no encontrado"})
+        if errors.Is(err, documento.ErrDocumentoNoEncontrado) {
+            h.log.ConContexto(c.Request.Context()).Warn("Documento no encontrado", map[string]interface{}{"idFile": idFile})
         } else {
-            h.log.Error("Error interno", map[string]interface{}{"error": err.Error()})
-            c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al descargar el documento"})
+            h.log.ConContexto(c.Request.Context()).Error("Error al descargar documento", map[string]interface{}{"idFile": idFile, "error": err.Error()})
         }
+        responderErrorDocumento(c, err, "Error al descargar el documento")
         return
     }
 
-    // 4. Codificar el archivo a base64
-    base64File := utils.EncodeToBase64(contenido)
+    // 3. Codificar el archivo a base64
+    base64File := utils.EncodeToBase64(archivo.Contenido)
 
-    // 5. Configurar respuesta
+    // 4. Configurar respuesta
     c.JSON(http.StatusOK, gin.H{
-        "fileName": nombreArchivo,
+        "fileName": archivo.Nombre,
         "base64":   base64File,
     })
-    h.log.Info("Documento descargado", map[string]interface{}{"idFile": idFile, "nombreArchivo": nombreArchivo})
-}
\ No newline at end of file
+    h.log.ConContexto(c.Request.Context()).Info("Documento descargado", map[string]interface{}{"idFile": idFile, "nombreArchivo": archivo.Nombre})
+}
+
+// rutIndexado retorna el RUT del cliente dueño de un documento según el índice, para registrarlo en la auditoría.
+// Un documento no indexado o un índice no disponible no impiden la descarga: se retorna vacío.
+func rutIndexado(ctx context.Context, indice documentos.RepositorioIndice, log *logger.Registrador, idFile string) string {
+    registro, err := indice.Obtener(ctx, idFile)
+    if err != nil {
+        if !errors.Is(err, documentos.ErrRegistroNoEncontrado) {
+            log.ConContexto(ctx).Warn("No se pudo obtener el RUT indexado para la auditoría", map[string]interface{}{"idFile": idFile, "error": err.Error()})
+        }
+        return ""
+    }
+    return registro.RUTCliente
+}
+
+// decodificarBase64 decodifica el contenido de un documento dentro de un span propio, para distinguir en la traza
+// el tiempo de decodificación del de Alfresco y MongoDB.
+func decodificarBase64(ctx context.Context, contenido string) ([]byte, error) {
+    _, span := trazas.Iniciar(ctx, "base64.decodificar", trace.WithAttributes(attribute.Int("base64.longitud", len(contenido))))
+    defer span.End()
+    bytesArchivo, err := utils.DecodeBase64(contenido)
+    if err != nil {
+        span.SetStatus(codes.Error, err.Error())
+    }
+    return bytesArchivo, err
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+)
+
+// ManejadorEliminarDocumento elimina un documento de forma lógica o definitiva.
+// @Summary Eliminar un documento
+// @Description Por defecto marca el documento como eliminado en MongoDB y Alfresco. Con definitivo=true lo borra (requiere rol administrador).
+// @Tags Documentos
+// @Produce json
+// @Param id path string true "ID del documento en Alfresco"
+// @Param definitivo query bool false "Eliminación definitiva"
+// @Success 200 {object} map[string]string "Documento eliminado"
+// @Failure 403 {object} Problema "Rol insuficiente para eliminación definitiva"
+// @Failure 404 {object} Problema "Documento no encontrado"
+// @Failure 409 {object} Problema "Documento bajo regla de retención o ya eliminado"
+// @Failure 500 {object} Problema "Error interno, o INDICE_NO_ACTUALIZADO si Alfresco aplicó la eliminación y el índice no"
+// @Router /documentos/{id} [delete]
+func (h *ManejadorDocumentos) ManejadorEliminarDocumento(c *gin.Context) {
+    idFile := c.Param("id")
+    definitivo := c.Query("definitivo") == "true"
+
+    // 1. Validar rol para eliminación definitiva
+    if definitivo && middleware.RolSolicitante(c) != middleware.RolAdministrador {
+        h.log.ConContexto(c.Request.Context()).Warn("Eliminación definitiva sin rol administrador", map[string]interface{}{"idFile": idFile})
+        responderProblema(c, http.StatusForbidden, CodigoRolInsuficiente, "La eliminación definitiva requiere rol administrador")
+        return
+    }
+
+    // 2. Recuperar el registro indexado para evaluar la retención
+    registro, err := h.indice.Obtener(c.Request.Context(), idFile)
+    if err != nil {
+        if errors.Is(err, documentos.ErrRegistroNoEncontrado) {
+            responderProblema(c, http.StatusNotFound, CodigoDocumentoNoEncontrado, "documento no encontrado")
+            return
+        }
+        h.log.ConContexto(c.Request.Context()).Error("Error al consultar el índice", map[string]interface{}{"idFile": idFile, "error": err.Error()})
+        responderProblema(c, http.StatusInternalServerError, CodigoErrorInterno, "Error al eliminar el documento")
+        return
+    }
+    if registro.Eliminado && !definitivo {
+        responderProblema(c, http.StatusConflict, CodigoDocumentoEliminado, "El documento ya fue eliminado")
+        return
+    }
+
+    // 3. Delegar al servicio de documentos (valida retención) con el ticket interno
+    metadatos := documentos.DocumentMetadata{
+        TipoDocumento:        registro.TipoDocumento,
+        RUTCliente:           registro.RUTCliente,
+        FechaCarga:           registro.FechaCarga,
+        FechaTerminoVigencia: registro.FechaTerminoVigencia,
+    }
+    err = h.internalAuth.ConTicket(func(ticket string) error {
+        return h.servicio.EliminarDocumento(c.Request.Context(), documento.SolicitudEliminacion{
+            IDArchivo:  idFile,
+            Metadatos:  metadatos,
+            Definitivo: definitivo,
+            Ticket:     ticket,
+        })
+    })
+    if definitivo && errors.Is(err, documentos.ErrDocumentoNoEncontrado) {
+        // Un intento anterior borró el archivo pero no su registro: completar la eliminación en el índice
+        h.log.ConContexto(c.Request.Context()).Warn("Documento ya eliminado en Alfresco, se elimina del índice", map[string]interface{}{"idFile": idFile})
+        err = nil
+    }
+    if err != nil {
+        h.log.ConContexto(c.Request.Context()).Error("Error al eliminar en Alfresco", map[string]interface{}{"idFile": idFile, "error": err.Error()})
+        responderErrorDocumento(c, err, "Error al eliminar el documento")
+        return
+    }
+
+    // 4. Reflejar la eliminación en el índice
+    tipoEliminacion := "logica"
+    if definitivo {
+        tipoEliminacion = "definitiva"
+        err = h.indice.Eliminar(c.Request.Context(), idFile)
+    } else {
+        err = h.indice.MarcarEliminado(c.Request.Context(), idFile, documentos.EstadoEliminado)
+    }
+    if err != nil {
+        // Alfresco ya aplicó la eliminación: informar que el índice quedó desfasado para que se reintente
+        h.log.ConContexto(c.Request.Context()).Error("Error en persistencia del índice", map[string]interface{}{"idFile": idFile, "eliminacion": tipoEliminacion, "error": err.Error()})
+        responderProblema(c, http.StatusInternalServerError, CodigoIndiceNoActualizado,
+            "El documento se eliminó en Alfresco pero el índice no se actualizó; repita la solicitud para completarla")
+        return
+    }
+
+    // 5. Responder con éxito
+    c.JSON(http.StatusOK, gin.H{"id": idFile, "eliminacion": tipoEliminacion})
+    h.log.ConContexto(c.Request.Context()).Info("Documento eliminado", map[string]interface{}{"idFile": idFile, "eliminacion": tipoEliminacion})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/gin-gonic/gin"
+)
+
+// TipoContenidoProblema es el Content-Type de las respuestas de error (RFC 7807).
+const TipoContenidoProblema = "application/problem+json"
+
+// Códigos de error estables incluidos en el campo code de las respuestas.
+const (
+    CodigoSolicitudInvalida       = "SOLICITUD_INVALIDA"
+    CodigoCredencialesInvalidas   = "CREDENCIALES_INVALIDAS"
+    CodigoRolInsuficiente         = "ROL_INSUFICIENTE"
+    CodigoSinResultados           = "SIN_RESULTADOS"
+    CodigoDocumentoNoEncontrado   = "DOCUMENTO_NO_ENCONTRADO"
+    CodigoDocumentoEliminado      = "DOCUMENTO_ELIMINADO"
+    CodigoDocumentoEnRetencion    = "DOCUMENTO_EN_RETENCION"
+    CodigoConflicto               = "CONFLICTO"
+    CodigoTicketInvalido          = "TICKET_INVALIDO"
+    CodigoCuotaExcedida           = "CUOTA_EXCEDIDA"
+    CodigoLimiteExcedido          = "LIMITE_SOLICITUDES_EXCEDIDO"
+    CodigoCuotaDiariaExcedida     = "CUOTA_DIARIA_EXCEDIDA"
+    CodigoValidacion              = "VALIDACION"
+    CodigoRepositorioNoDisponible = "REPOSITORIO_NO_DISPONIBLE"
+    CodigoRespuestaInvalida       = "RESPUESTA_REPOSITORIO_INVALIDA"
+    CodigoAuditoriaNoDisponible   = "AUDITORIA_NO_DISPONIBLE"
+    CodigoSuscripcionInvalida     = "SUSCRIPCION_INVALIDA"
+    CodigoSuscripcionNoEncontrada = "SUSCRIPCION_NO_ENCONTRADA"
+    CodigoIndiceNoActualizado     = "INDICE_NO_ACTUALIZADO"
+    CodigoErrorInterno            = "ERROR_INTERNO"
+)
+
+// Problema es el cuerpo de error de la API, según RFC 7807.
+type Problema struct {
+    Tipo      string       `json:"type"`               // Siempre about:blank; el código identifica el problema
+    Titulo    string       `json:"title"`              // Texto del estado HTTP
+    Estado    int          `json:"status"`             // Código HTTP de la respuesta
+    Codigo    string       `json:"code"`               // Código estable para clientes
+    Detalle   string       `json:"detail,omitempty"`   // Explicación legible de esta ocurrencia
+    Instancia string       `json:"instance,omitempty"` // ID de la solicitud, para correlacionar con los logs
+    Errores   []ErrorCampo `json:"errors,omitempty"`   // Errores por campo en fallos de validación
+}
+
+// ErrorCampo describe un error asociado a un campo de la solicitud.
+type ErrorCampo struct {
+    Campo   string `json:"field"`          // Ruta del campo, por ejemplo documentos[2].base64
+    Codigo  string `json:"code,omitempty"` // Código estable del error
+    Detalle string `json:"detail"`         // Explicación legible
+}
+
+// responderProblema escribe un Problema como application/problem+json.
+func responderProblema(c *gin.Context, estado int, codigo, detalle string, errores ...ErrorCampo) {
+    c.Header("Content-Type", TipoContenidoProblema)
+    c.JSON(estado, Problema{
+        Tipo:      "about:blank",
+        Titulo:    http.StatusText(estado),
+        Estado:    estado,
+        Codigo:    codigo,
+        Detalle:   detalle,
+        Instancia: c.GetString("idSolicitud"),
+        Errores:   errores,
+    })
+}
+
+// responderSolicitudInvalida responde 400 con los errores de campo que se puedan extraer del error de binding.
+func responderSolicitudInvalida(c *gin.Context, detalle string, err error) {
+    responderProblema(c, http.StatusBadRequest, CodigoSolicitudInvalida, detalle, erroresDeBinding(err)...)
+}
+
+// erroresDeBinding traduce los errores de decodificación JSON a errores de campo.
+func erroresDeBinding(err error) []ErrorCampo {
+    var errTipo *json.UnmarshalTypeError
+    var errSintaxis *json.SyntaxError
+    switch {
+    case errors.As(err, &errTipo):
+        return []ErrorCampo{{Campo: errTipo.Field, Codigo: CodigoValidacion, Detalle: fmt.Sprintf("se esperaba un valor de tipo %s", errTipo.Type)}}
+    case errors.As(err, &errSintaxis):
+        return []ErrorCampo{{Campo: "$", Codigo: CodigoValidacion, Detalle: fmt.Sprintf("JSON mal formado en la posición %d", errSintaxis.Offset)}}
+    case errors.Is(err, io.EOF):
+        return []ErrorCampo{{Campo: "$", Codigo: CodigoValidacion, Detalle: "el cuerpo de la solicitud está vacío"}}
+    default:
+        return nil
+    }
+}
+
+// mapeoError asocia una categoría de error con su respuesta HTTP.
+type mapeoError struct {
+    categoria error
+    estado    int
+    codigo    string
+    detalle   string
+}
+
+// mapeosError se evalúa en orden con errors.Is; el primero que coincide define la respuesta.
+// Un detalle vacío expone el texto del error, reservado para errores del dominio sin detalle de Alfresco.
+var mapeosError = []mapeoError{
+    {documentos.ErrDocumentoNoEncontrado, http.StatusNotFound, CodigoDocumentoNoEncontrado, "documento no encontrado"},
+    {documentos.ErrDocumentoEnRetencion, http.StatusConflict, CodigoDocumentoEnRetencion, ""},
+    {documentos.ErrConflicto, http.StatusConflict, CodigoConflicto, "el documento fue modificado o está bloqueado en el repositorio"},
+    {documentos.ErrTicketInvalido, http.StatusBadGateway, CodigoTicketInvalido, "el repositorio documental rechazó las credenciales de la API"},
+    {documentos.ErrCuotaExcedida, http.StatusTooManyRequests, CodigoCuotaExcedida, "cuota del repositorio documental excedida"},
+    {documentos.ErrValidacion, http.StatusUnprocessableEntity, CodigoValidacion, "el repositorio documental rechazó la solicitud"},
+    {documentos.ErrRepositorioNoDisponible, http.StatusServiceUnavailable, CodigoRepositorioNoDisponible, "repositorio documental no disponible"},
+    {documento.ErrRespuestaAlfrescoInvalida, http.StatusBadGateway, CodigoRespuestaInvalida, "respuesta inválida del repositorio documental"},
+    {documento.ErrAuditoriaNoDisponible, http.StatusServiceUnavailable, CodigoAuditoriaNoDisponible, "no se pudo registrar la operación en la auditoría"},
+}
+
+// clasificarError retorna el estado, código y detalle que corresponden a err.
+// Los errores sin categoría se clasifican como 500 con detalleDefecto, sin exponer su texto.
+func clasificarError(err error, detalleDefecto string) (int, string, string) {
+    for _, mapeo := range mapeosError {
+        if errors.Is(err, mapeo.categoria) {
+            if mapeo.detalle == "" {
+                return mapeo.estado, mapeo.codigo, err.Error()
+            }
+            return mapeo.estado, mapeo.codigo, mapeo.detalle
+        }
+    }
+    return http.StatusInternalServerError, CodigoErrorInterno, detalleDefecto
+}
+
+// responderErrorDocumento responde con el problema que corresponde a un error de las operaciones con documentos.
+func responderErrorDocumento(c *gin.Context, err error, detalleDefecto string) {
+    estado, codigo, detalle := clasificarError(err, detalleDefecto)
+    responderProblema(c, estado, codigo, detalle)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+    "fmt"
+    "io"
+    "math"
+    "net/http"
+    "strconv"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/limites"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/metricas"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+)
+
+// Encabezados de límites (draft-ietf-httpapi-ratelimit-headers).
+const (
+    EncabezadoLimite     = "RateLimit-Limit"     // Capacidad del bucket del cliente en la ruta
+    EncabezadoRestantes  = "RateLimit-Remaining" // Solicitudes disponibles tras la actual
+    EncabezadoReinicio   = "RateLimit-Reset"     // Segundos hasta que el bucket vuelva a estar lleno
+    EncabezadoReintentar = "Retry-After"         // Segundos hasta que una solicitud rechazada pueda reintentarse
+)
+
+// motivoTasa identifica en las métricas los rechazos por tasa de solicitudes.
+const motivoTasa = "tasa"
+
+// ManejadorLimites aplica el rate limiting por cliente y ruta y las cuotas diarias de transferencia.
+// Si el almacén de contadores falla, la solicitud continúa: un límite no disponible no debe dejar sin servicio la API.
+type ManejadorLimites struct {
+    limitador *limites.Limitador
+    log       *logger.Registrador
+    reloj     func() time.Time
+}
+
+// NuevoManejadorLimites inicializa el manejador con el limitador configurado.
+func NuevoManejadorLimites(limitador *limites.Limitador, log *logger.Registrador) *ManejadorLimites {
+    return &ManejadorLimites{limitador: limitador, log: log, reloj: time.Now}
+}
+
+// EstablecerReloj reemplaza la fuente de tiempo (usado en pruebas).
+func (h *ManejadorLimites) EstablecerReloj(reloj func() time.Time) {
+    h.reloj = reloj
+}
+
+// Limitar consume un token del bucket del cliente en la ruta y responde 429 si no hay disponibles.
+func (h *ManejadorLimites) Limitar(c *gin.Context) {
+    ruta := c.FullPath()
+    cliente := middleware.ClienteSolicitante(c)
+
+    // 1. Consumir un token; ante un error del almacén se deja pasar la solicitud
+    decision, err := h.limitador.Tomar(c.Request.Context(), cliente, ruta, h.reloj())
+    if err != nil {
+        h.log.ConContexto(c.Request.Context()).Warn("No se pudo verificar el límite de solicitudes", map[string]interface{}{
+            "error": err.Error(),
+            "ruta":  ruta,
+        })
+        c.Next()
+        return
+    }
+
+    // 2. Informar el estado del bucket en todas las respuestas
+    c.Header(EncabezadoLimite, strconv.Itoa(decision.Limite))
+    c.Header(EncabezadoRestantes, strconv.Itoa(decision.Restantes))
+    c.Header(EncabezadoReinicio, segundos(decision.Reinicio))
+
+    // 3. Rechazar si no quedan tokens
+    if !decision.Permitida {
+        metricas.ContarRechazoLimite(ruta, motivoTasa)
+        c.Header(EncabezadoReintentar, segundos(decision.Espera))
+        responderProblema(c, http.StatusTooManyRequests, CodigoLimiteExcedido,
+            fmt.Sprintf("se superó el límite de solicitudes en %s; reintente en %s segundos", ruta, segundos(decision.Espera)))
+        c.Abort()
+        return
+    }
+    c.Next()
+}
+
+// Cuota verifica la cuota diaria del cliente en la dirección indicada antes de atender la solicitud,
+// y registra los bytes transferidos al terminar: el cuerpo recibido en subidas y el respondido en descargas.
+// Una subida cuyo Content-Length supera lo que queda se rechaza por adelantado; el tamaño de una descarga
+// no se conoce antes, así que la que supera lo restante se completa y las siguientes del día se rechazan.
+func (h *ManejadorLimites) Cuota(direccion string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        ctx := c.Request.Context()
+        cliente := middleware.ClienteSolicitante(c)
+
+        // 1. En subidas se conoce el tamaño por Content-Length; en descargas solo el consumo previo
+        var solicitado int64
+        if direccion == limites.DireccionSubida && c.Request.ContentLength > 0 {
+            solicitado = c.Request.ContentLength
+        }
+        estado, err := h.limitador.VerificarCuota(ctx, cliente, direccion, solicitado, h.reloj())
+        if err != nil {
+            h.log.ConContexto(ctx).Warn("No se pudo verificar la cuota diaria", map[string]interface{}{
+                "error":     err.Error(),
+                "direccion": direccion,
+            })
+        } else if estado.Excedida {
+            metricas.ContarRechazoLimite(c.FullPath(), direccion)
+            c.Header(EncabezadoReintentar, segundos(estado.Reinicio))
+            responderProblema(c, http.StatusTooManyRequests, CodigoCuotaDiariaExcedida,
+                fmt.Sprintf("cuota diaria de %s excedida: %d de %d bytes consumidos", direccion, estado.Consumido, estado.Limite))
+            c.Abort()
+            return
+        }
+
+        // 2. Contar los bytes realmente transferidos
+        var cuerpo *lectorContado
+        if direccion == limites.DireccionSubida && c.Request.Body != nil {
+            cuerpo = &lectorContado{ReadCloser: c.Request.Body}
+            c.Request.Body = cuerpo
+        }
+        c.Next()
+
+        transferidos := int64(c.Writer.Size())
+        if cuerpo != nil {
+            transferidos = cuerpo.leidos
+        }
+        if transferidos <= 0 || c.Writer.Status() >= http.StatusBadRequest {
+            return
+        }
+        if err := h.limitador.RegistrarConsumo(ctx, cliente, direccion, transferidos, h.reloj()); err != nil {
+            h.log.ConContexto(ctx).Warn("No se pudo registrar el consumo de cuota", map[string]interface{}{
+                "error":     err.Error(),
+                "direccion": direccion,
+                "bytes":     transferidos,
+            })
+        }
+    }
+}
+
+// lectorContado cuenta los bytes leídos del cuerpo de la solicitud.
+type lectorContado struct {
+    io.ReadCloser
+    leidos int64
+}
+
+func (l *lectorContado) Read(p []byte) (int, error) {
+    n, err := l.ReadCloser.Read(p)
+    l.leidos += int64(n)
+    return n, err
+}
+
+// segundos redondea una duración hacia arriba a segundos enteros, como exigen Retry-After y RateLimit-Reset.
+func segundos(duracion time.Duration) string {
+    return strconv.FormatInt(int64(math.Ceil(duracion.Seconds())), 10)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+    "strconv"
+    "github.com/gin-gonic/gin"
+)
+
+// LimiteMaximoListado acota el parámetro limite de los listados paginados por cantidad.
+const LimiteMaximoListado = 500
+
+// MetaListado acompaña a los listados con la cantidad de elementos retornados.
+type MetaListado struct {
+    Total int `json:"total"` // Elementos incluidos en data
+}
+
+// leerLimite interpreta el parámetro limite de la consulta. Sin parámetro retorna porDefecto.
+// Si el valor no es un entero entre 1 y LimiteMaximoListado responde 400 y retorna false.
+func leerLimite(c *gin.Context, porDefecto int) (int, bool) {
+    valor, presente := c.GetQuery("limite")
+    if !presente {
+        return porDefecto, true
+    }
+    limite, err := strconv.Atoi(valor)
+    if err != nil || limite <= 0 || limite > LimiteMaximoListado {
+        detalle := fmt.Sprintf("debe ser un entero entre 1 y %d", LimiteMaximoListado)
+        responderProblema(c, http.StatusBadRequest, CodigoSolicitudInvalida, "Parámetro limite inválido",
+            ErrorCampo{Campo: "limite", Codigo: CodigoValidacion, Detalle: detalle})
+        return 0, false
+    }
+    return limite, true
+}
@@ -3,8 +3,9 @@ package handlers
 import (
 	"fmt"
 	"net/http"
-	"github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
-	"github.com/CamiloScript/REGAPIGO/shared/utils"
+	servicioDocumento "github.com/CamiloScript/REGAPIGO/application/documento"
+	"github.com/CamiloScript/REGAPIGO/domain/documentos"
+	"github.com/CamiloScript/REGAPIGO/shared/metricas"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,7 +20,7 @@ type LoteDocumentos struct {
 // Contiene el archivo del documento en base64 y los metadatos específicos para ese documento.
 type SolicitudIndividual struct {
 	Base64    string                 `json:"base64"`    // Archivo del documento en base64.
-	Metadatos map[string]interface{} `json:"metadatos"` // Metadatos específicos del documento.
+	Metadatos documentos.DocumentMetadata `json:"metadatos"` // Metadatos específicos del documento.
 }
 
 // ResultadoCarga representa el resultado de la carga de cada documento.
@@ -42,61 +43,83 @@ type ResultadoCarga struct {
 // @Produce json
 // @Param lote body LoteDocumentos true "Lote de documentos a subir"
 // @Success 200 {object} gin.H "Respuesta con el resultado de la carga"
-// @Failure 400 {object} gin.H "Error en el formato de la solicitud"
-// @Failure 500 {object} gin.H "Error interno del servidor"
+// @Failure 400 {object} Problema "Error en el formato de la solicitud"
+// @Failure 500 {object} Problema "Error interno del servidor"
 // @Router /subir-lote [post]
 func (h *ManejadorDocumentos) ManejadorLoteDocumentos(c *gin.Context) {
-    // 1. Autenticación interna
-    ticket, err := h.internalAuth.AutenticarInternamente()
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno de autenticación"})
+    // 1. Autenticación interna: sin ticket no se procesa ningún documento del lote
+    if _, err := h.internalAuth.AutenticarInternamente(); err != nil {
+        responderErrorDocumento(c, err, "Error interno de autenticación")
         return
     }
 
     // 2. Parsear solicitud JSON
     var lote LoteDocumentos
     if err := c.ShouldBindJSON(&lote); err != nil {
-        h.log.Error("Solicitud inválida", map[string]interface{}{"error": err.Error()})
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de solicitud incorrecto"})
+        h.log.ConContexto(c.Request.Context()).Error("Solicitud inválida", map[string]interface{}{"error": err.Error()})
+        responderSolicitudInvalida(c, "Formato de solicitud incorrecto", err)
         return
     }
 
+    metricas.ObservarLote(len(lote.Documentos))
+
     // 3. Inicializar slices para respuestas y errores
     resultados := make([]ResultadoCarga, 0, len(lote.Documentos))
-    errores := make([]string, 0)
+    errores := make([]ErrorCampo, 0)
 
     // 4. Procesar cada documento en el lote
-    for _, documento := range lote.Documentos {
+    for i, documento := range lote.Documentos {
+        nombreDoc := documento.Metadatos.NombreDoc
+
         // Decodificar el archivo base64 a bytes
-        fileBytes, err := utils.DecodeBase64(documento.Base64)
+        fileBytes, err := decodificarBase64(c.Request.Context(), documento.Base64)
         if err != nil {
-            errores = append(errores, fmt.Sprintf("Error al decodificar base64 para %s: %v", documento.Metadatos["tanner:nombre-doc"], err))
+            errores = append(errores, ErrorCampo{
+                Campo:   fmt.Sprintf("documentos[%d].base64", i),
+                Codigo:  CodigoValidacion,
+                Detalle: fmt.Sprintf("base64 inválido para %s: %v", nombreDoc, err),
+            })
             continue
         }
 
-        // Llamar al servicio para subir el documento
-        respuesta, err := h.servicio.SubirDocumento(c, fileBytes, documento.Metadatos, ticket)
+        // Llamar al servicio para subir el documento; un ticket rechazado se renueva una vez
+        var doc *documentos.Documento
+        err = h.internalAuth.ConTicket(func(ticket string) error {
+            var err error
+            doc, err = h.servicio.SubirDocumento(c.Request.Context(), servicioDocumento.SolicitudCarga{
+                Contenido: fileBytes,
+                Metadatos: documento.Metadatos,
+                Ticket:    ticket,
+            })
+            return err
+        })
         if err != nil {
-            errores = append(errores, fmt.Sprintf("Error al subir documento %s: %v", documento.Metadatos["tanner:nombre-doc"], err))
+            h.log.ConContexto(c.Request.Context()).Error("Error al subir documento del lote", map[string]interface{}{"indice": i, "error": err.Error()})
+            _, codigo, detalle := clasificarError(err, "Error al subir el documento")
+            errores = append(errores, ErrorCampo{
+                Campo:   fmt.Sprintf("documentos[%d]", i),
+                Codigo:  codigo,
+                Detalle: fmt.Sprintf("%s: %s", nombreDoc, detalle),
+            })
             continue
         }
 
         // Construir resultado de carga
         resultado := ResultadoCarga{
-            NombreArchivo: documento.Metadatos["tanner:nombre-doc"].(string),
+            NombreArchivo: nombreDoc,
             Estado:        "EXITOSO",
-            IdArchivo:     respuesta["entry"].(map[string]interface{})["id"].(string),
-            RazonSocial:   documento.Metadatos["tanner:razon-social-cliente"].(string),
-            Rut:           documento.Metadatos["tanner:rut-cliente"].(string),
+            IdArchivo:     doc.ID,
+            RazonSocial:   documento.Metadatos.RazonSocialCliente,
+            Rut:           documento.Metadatos.RUTCliente,
             Base64:        documento.Base64,
         }
 
         // Agregar a resultados
         resultados = append(resultados, resultado)
 
-        // Guardar en MongoDB
-        if err := mongo.GuardarEnMongoDB(respuesta, h.log); err != nil {
-            h.log.Error("Error en persistencia MongoDB", map[string]interface{}{"error": err.Error()})
+        // Guardar en el índice
+        if err := h.indexarDocumento(c.Request.Context(), *doc); err != nil {
+            h.log.ConContexto(c.Request.Context()).Error("Error en persistencia del índice", map[string]interface{}{"error": err.Error()})
         }
     }
 
@@ -112,7 +135,7 @@ func (h *ManejadorDocumentos) ManejadorLoteDocumentos(c *gin.Context) {
     }
 
     c.JSON(http.StatusOK, respuestaCliente)
-    h.log.Info("Lote procesado", map[string]interface{}{
+    h.log.ConContexto(c.Request.Context()).Info("Lote procesado", map[string]interface{}{
         "total_procesados": len(resultados),
         "total_errores":    len(errores),
     })
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+    "net/http"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/salud"
+    "github.com/gin-gonic/gin"
+)
+
+// ManejadorSalud expone las sondas de liveness y readiness.
+type ManejadorSalud struct {
+    monitor *salud.Monitor // Verifica las dependencias y guarda el último reporte
+}
+
+// NuevoManejadorSalud inicializa el manejador con el monitor de dependencias.
+func NuevoManejadorSalud(monitor *salud.Monitor) *ManejadorSalud {
+    return &ManejadorSalud{monitor: monitor}
+}
+
+// ManejadorVivo indica que el proceso atiende solicitudes. No consulta dependencias, para que una caída de
+// MongoDB o Alfresco no provoque reinicios del contenedor.
+// @Summary Liveness
+// @Tags Salud
+// @Produce json
+// @Success 200 {object} map[string]string "Proceso activo"
+// @Router /health/live [get]
+func (h *ManejadorSalud) ManejadorVivo(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{"estado": "activo"})
+}
+
+// ManejadorListo verifica las dependencias e indica si el servicio puede recibir tráfico.
+// @Summary Readiness
+// @Description Verifica MongoDB, Alfresco y el ticket interno (con caché y tiempo máximo por dependencia). Responde 503 si falla una dependencia crítica.
+// @Tags Salud
+// @Produce json
+// @Success 200 {object} salud.Reporte "Listo o degradado"
+// @Failure 503 {object} salud.Reporte "Falla una dependencia crítica"
+// @Router /health/ready [get]
+func (h *ManejadorSalud) ManejadorListo(c *gin.Context) {
+    reporte := h.monitor.Verificar(c.Request.Context())
+    estado := http.StatusOK
+    if !reporte.Listo() {
+        estado = http.StatusServiceUnavailable
+    }
+    c.JSON(estado, reporte)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+)
+
+// ManejadorWebhooks gestiona las suscripciones a avisos de vencimiento.
+type ManejadorWebhooks struct {
+    servicio *notificacion.ServicioSuscripciones // Servicio de suscripciones
+    log      *logger.Registrador                 // Logger para registro de eventos
+}
+
+// NuevoManejadorWebhooks inicializa el manejador con dependencias.
+func NuevoManejadorWebhooks(servicio *notificacion.ServicioSuscripciones, log *logger.Registrador) *ManejadorWebhooks {
+    return &ManejadorWebhooks{servicio: servicio, log: log}
+}
+
+// SolicitudSuscripcion define el formato esperado para crear una suscripción.
+type SolicitudSuscripcion struct {
+    Nombre         string   `json:"nombre"`                    // Nombre descriptivo
+    URL            string   `json:"url"`                       // URL del receptor
+    Secreto        string   `json:"secreto,omitempty"`         // Clave HMAC (opcional, se genera si falta)
+    DiasAntes      []int    `json:"dias_antes,omitempty"`      // Umbrales de aviso (opcional)
+    TiposDocumento []string `json:"tipos_documento,omitempty"` // Tipos de documento a notificar (opcional)
+}
+
+// ManejadorCrearSuscripcion registra una suscripción de webhooks.
+// @Summary Crear suscripción de avisos de vencimiento
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param solicitud body SolicitudSuscripcion true "Datos de la suscripción"
+// @Success 201 {object} notificacion.Suscripcion "Suscripción creada (incluye el secreto)"
+// @Failure 400 {object} Problema "Solicitud inválida (SOLICITUD_INVALIDA, SUSCRIPCION_INVALIDA)"
+// @Failure 403 {object} Problema "Rol insuficiente (ROL_INSUFICIENTE)"
+// @Failure 500 {object} Problema "Error interno (ERROR_INTERNO)"
+// @Router /webhooks/suscripciones [post]
+func (h *ManejadorWebhooks) ManejadorCrearSuscripcion(c *gin.Context) {
+    // 1. Validar rol
+    if middleware.RolSolicitante(c) != middleware.RolAdministrador {
+        responderProblema(c, http.StatusForbidden, CodigoRolInsuficiente, "La gestión de webhooks requiere rol administrador")
+        return
+    }
+
+    // 2. Parsear solicitud
+    var solicitud SolicitudSuscripcion
+    if err := c.ShouldBindJSON(&solicitud); err != nil {
+        h.log.ConContexto(c.Request.Context()).Error("Solicitud inválida", map[string]interface{}{"error": err.Error()})
+        responderSolicitudInvalida(c, "Formato de solicitud incorrecto", err)
+        return
+    }
+
+    // 3. Delegar al servicio
+    suscripcion, err := h.servicio.Crear(c.Request.Context(), notificacion.Suscripcion{
+        Nombre:         solicitud.Nombre,
+        URL:            solicitud.URL,
+        Secreto:        solicitud.Secreto,
+        DiasAntes:      solicitud.DiasAntes,
+        TiposDocumento: solicitud.TiposDocumento,
+    })
+    if err != nil {
+        if errors.Is(err, notificacion.ErrSuscripcionInvalida) {
+            h.log.ConContexto(c.Request.Context()).Warn("Suscripción rechazada", map[string]interface{}{"error": err.Error()})
+            responderProblema(c, http.StatusBadRequest, CodigoSuscripcionInvalida, err.Error())
+            return
+        }
+        h.log.ConContexto(c.Request.Context()).Error("Error al crear suscripción", map[string]interface{}{"error": err.Error()})
+        responderProblema(c, http.StatusInternalServerError, CodigoErrorInterno, "Error al crear la suscripción")
+        return
+    }
+
+    c.JSON(http.StatusCreated, suscripcion)
+    h.log.ConContexto(c.Request.Context()).Info("Suscripción creada", map[string]interface{}{"id": suscripcion.ID, "url": suscripcion.URL})
+}
+
+// RespuestaSuscripciones es el cuerpo de GET /webhooks/suscripciones.
+type RespuestaSuscripciones struct {
+    Data []notificacion.Suscripcion `json:"data"` // Suscripciones registradas, sin sus secretos
+    Meta MetaListado                `json:"meta"`
+}
+
+// RespuestaEntregas es el cuerpo de GET /webhooks/suscripciones/{id}/entregas.
+type RespuestaEntregas struct {
+    Data []notificacion.Entrega `json:"data"` // Entregas más recientes primero
+    Meta MetaListado            `json:"meta"`
+}
+
+// ManejadorListarSuscripciones lista las suscripciones sin sus secretos. Requiere rol administrador.
+// @Summary Listar suscripciones de webhooks
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {object} RespuestaSuscripciones "Suscripciones registradas"
+// @Failure 403 {object} Problema "Rol insuficiente (ROL_INSUFICIENTE)"
+// @Failure 500 {object} Problema "Error interno (ERROR_INTERNO)"
+// @Router /webhooks/suscripciones [get]
+func (h *ManejadorWebhooks) ManejadorListarSuscripciones(c *gin.Context) {
+    if middleware.RolSolicitante(c) != middleware.RolAdministrador {
+        responderProblema(c, http.StatusForbidden, CodigoRolInsuficiente, "La gestión de webhooks requiere rol administrador")
+        return
+    }
+
+    suscripciones, err := h.servicio.Listar(c.Request.Context())
+    if err != nil {
+        h.log.ConContexto(c.Request.Context()).Error("Error al listar suscripciones", map[string]interface{}{"error": err.Error()})
+        responderProblema(c, http.StatusInternalServerError, CodigoErrorInterno, "Error al listar suscripciones")
+        return
+    }
+    c.JSON(http.StatusOK, RespuestaSuscripciones{Data: suscripciones, Meta: MetaListado{Total: len(suscripciones)}})
+}
+
+// ManejadorEliminarSuscripcion da de baja una suscripción.
+// @Summary Eliminar suscripción de webhooks
+// @Tags Webhooks
+// @Param id path string true "ID de la suscripción"
+// @Success 204 "Suscripción eliminada"
+// @Failure 403 {object} Problema "Rol insuficiente (ROL_INSUFICIENTE)"
+// @Failure 404 {object} Problema "Suscripción no encontrada (SUSCRIPCION_NO_ENCONTRADA)"
+// @Failure 500 {object} Problema "Error interno (ERROR_INTERNO)"
+// @Router /webhooks/suscripciones/{id} [delete]
+func (h *ManejadorWebhooks) ManejadorEliminarSuscripcion(c *gin.Context) {
+    if middleware.RolSolicitante(c) != middleware.RolAdministrador {
+        responderProblema(c, http.StatusForbidden, CodigoRolInsuficiente, "La gestión de webhooks requiere rol administrador")
+        return
+    }
+
+    id := c.Param("id")
+    if err := h.servicio.Eliminar(c.Request.Context(), id); err != nil {
+        if errors.Is(err, notificacion.ErrSuscripcionNoEncontrada) {
+            responderProblema(c, http.StatusNotFound, CodigoSuscripcionNoEncontrada, "suscripción no encontrada")
+            return
+        }
+        h.log.ConContexto(c.Request.Context()).Error("Error al eliminar suscripción", map[string]interface{}{"id": id, "error": err.Error()})
+        responderProblema(c, http.StatusInternalServerError, CodigoErrorInterno, "Error al eliminar la suscripción")
+        return
+    }
+    c.Status(http.StatusNoContent)
+    h.log.ConContexto(c.Request.Context()).Info("Suscripción eliminada", map[string]interface{}{"id": id})
+}
+
+// ManejadorListarEntregas retorna el log de entregas de una suscripción. Requiere rol administrador.
+// @Summary Log de entregas de una suscripción
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "ID de la suscripción"
+// @Param limite query int false "Cantidad máxima de entregas (por defecto 50, máximo 500)"
+// @Success 200 {object} RespuestaEntregas "Entregas más recientes"
+// @Failure 400 {object} Problema "Límite fuera de rango (SOLICITUD_INVALIDA)"
+// @Failure 403 {object} Problema "Rol insuficiente (ROL_INSUFICIENTE)"
+// @Failure 500 {object} Problema "Error interno (ERROR_INTERNO)"
+// @Router /webhooks/suscripciones/{id}/entregas [get]
+func (h *ManejadorWebhooks) ManejadorListarEntregas(c *gin.Context) {
+    if middleware.RolSolicitante(c) != middleware.RolAdministrador {
+        responderProblema(c, http.StatusForbidden, CodigoRolInsuficiente, "La gestión de webhooks requiere rol administrador")
+        return
+    }
+
+    limite, ok := leerLimite(c, 50)
+    if !ok {
+        return
+    }
+
+    entregas, err := h.servicio.Entregas(c.Request.Context(), c.Param("id"), limite)
+    if err != nil {
+        h.log.ConContexto(c.Request.Context()).Error("Error al listar entregas", map[string]interface{}{"error": err.Error()})
+        responderProblema(c, http.StatusInternalServerError, CodigoErrorInterno, "Error al listar entregas")
+        return
+    }
+    c.JSON(http.StatusOK, RespuestaEntregas{Data: entregas, Meta: MetaListado{Total: len(entregas)}})
+}
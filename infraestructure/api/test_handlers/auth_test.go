@@ -1,13 +1,21 @@
 package test_handlers
 
 import (
+    "encoding/json"
     "net/http"
     "net/http/httptest"
     "testing"
     "bytes"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
     "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
     "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/secretos"
     "github.com/gin-gonic/gin"
     "github.com/stretchr/testify/assert"
 )
@@ -75,4 +83,107 @@ func TestLoginErrorServicio(t *testing.T) {
 
     // Verificar que el código de estado HTTP sea 401 (Unauthorized)
     assert.Equal(t, http.StatusUnauthorized, w.Code)
-}
\ No newline at end of file
+}
+// TestLoginCredencialesFaltantes - Prueba que las credenciales vacías se reportan como problem+json con un error por campo.
+func TestLoginCredencialesFaltantes(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioAuth := handlers.NewAuthHandler(&servicio.MockAuthClient{Log: log}, log)
+
+    router := gin.Default()
+    router.POST("/auth/login", servicioAuth.Login)
+
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBufferString(`{"userId": "admin"}`))
+    req.Header.Set("Content-Type", "application/json")
+    router.ServeHTTP(w, req)
+
+    var problema handlers.Problema
+    assert.Equal(t, http.StatusBadRequest, w.Code)
+    assert.Equal(t, handlers.TipoContenidoProblema, w.Header().Get("Content-Type"))
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problema))
+    assert.Equal(t, handlers.CodigoSolicitudInvalida, problema.Codigo)
+    assert.Equal(t, []handlers.ErrorCampo{{Campo: "password", Codigo: handlers.CodigoValidacion, Detalle: "campo requerido"}}, problema.Errores)
+}
+
+// authContador es un servicio de autenticación que cuenta los inicios de sesión.
+type authContador struct {
+    inicios   int
+    passwords []string
+}
+
+// Authenticate retorna un ticket distinto por cada inicio de sesión.
+func (a *authContador) Authenticate(usuario, password string) (string, error) {
+    a.inicios++
+    a.passwords = append(a.passwords, password)
+    return fmt.Sprintf("TICKET_%d", a.inicios), nil
+}
+
+// TestAutenticacionInternaReutilizaTicket verifica que el ticket se reutiliza hasta que la contraseña rota.
+func TestAutenticacionInternaReutilizaTicket(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    ruta := filepath.Join(t.TempDir(), "appsettings.json")
+    contenido := `{"ALFRESCO_BASE_URL": "https://alfresco.local", "ALFRESCO_API_KEY": "clave", "MONGODB_URI": "mongodb://localhost",
+        "MONGODB_DATABASE": "db", "MONGODB_COLLECTION": "docs", "AUTH_USER": "api", "AUTH_PASSWORD": "env:PRUEBA_PASSWORD"}`
+    if err := os.WriteFile(ruta, []byte(contenido), 0o600); err != nil {
+        t.Fatalf("Error al escribir el archivo de configuración: %v", err)
+    }
+    proveedor := secretos.NuevoProveedorMemoria(map[string]string{"env:PRUEBA_PASSWORD": "v1"})
+    cfg, err := config.CargarConfiguracionConSecretos(ruta, proveedor)
+    if !assert.NoError(t, err) {
+        return
+    }
+    servicioAuth := &authContador{}
+    interna := handlers.NewInternalAuth(servicioAuth, log, cfg)
+
+    // 1. Dos solicitudes con la misma contraseña comparten el ticket
+    primero, err := interna.AutenticarInternamente()
+    assert.NoError(t, err)
+    segundo, err := interna.AutenticarInternamente()
+    assert.NoError(t, err)
+    assert.Equal(t, primero, segundo)
+    assert.Equal(t, 1, servicioAuth.inicios)
+
+    // 2. Tras rotar la contraseña se inicia sesión con la nueva
+    proveedor.Establecer("env:PRUEBA_PASSWORD", "v2")
+    _, err = cfg.RecargarSecretos()
+    assert.NoError(t, err)
+    tercero, err := interna.AutenticarInternamente()
+    assert.NoError(t, err)
+    assert.NotEqual(t, primero, tercero)
+    assert.Equal(t, []string{"v1", "v2"}, servicioAuth.passwords)
+}
+
+// TestAutenticacionInternaReintentaTicketRechazado verifica que un ticket rechazado por Alfresco se descarta y la
+// operación se reintenta una sola vez con un ticket nuevo.
+func TestAutenticacionInternaReintentaTicketRechazado(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioAuth := &authContador{}
+    interna := handlers.NewInternalAuth(servicioAuth, log, &config.Config{AuthTicketVigencia: time.Hour})
+
+    // 1. El primer ticket es rechazado: se obtiene otro y la operación se completa
+    var tickets []string
+    err := interna.ConTicket(func(ticket string) error {
+        tickets = append(tickets, ticket)
+        if ticket == "TICKET_1" {
+            return fmt.Errorf("error al descargar: %w", documentos.ErrTicketInvalido)
+        }
+        return nil
+    })
+    assert.NoError(t, err)
+    assert.Equal(t, []string{"TICKET_1", "TICKET_2"}, tickets)
+
+    // 2. El ticket renovado queda en caché para las siguientes solicitudes
+    ticket, err := interna.AutenticarInternamente()
+    assert.NoError(t, err)
+    assert.Equal(t, "TICKET_2", ticket)
+
+    // 3. Si el ticket nuevo también es rechazado no se reintenta otra vez
+    intentos := 0
+    err = interna.ConTicket(func(string) error {
+        intentos++
+        return documentos.ErrTicketInvalido
+    })
+    assert.ErrorIs(t, err, documentos.ErrTicketInvalido)
+    assert.Equal(t, 2, intentos)
+    assert.Equal(t, 3, servicioAuth.inicios)
+}
@@ -0,0 +1,64 @@
+package test_handlers
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/memoria"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// indiceCaido simula un índice que no responde a las búsquedas.
+type indiceCaido struct {
+    *memoria.IndiceMemoria
+}
+
+func (indiceCaido) Buscar(context.Context, documentos.FiltroIndice) (string, error) {
+    return "", errors.New("mongo no disponible")
+}
+
+// TestBuscarDescargarErroresIndice verifica que solo la ausencia de resultados responde 404 SIN_RESULTADOS;
+// una falla del índice es un error interno.
+func TestBuscarDescargarErroresIndice(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs := documento.NuevoServicioDocumentos(servicio.NuevoMockClienteAlfresco(log), log, "mock-key")
+
+    casos := []struct {
+        nombre string
+        indice documentos.RepositorioIndice
+        estado int
+        codigo string
+    }{
+        {"sin resultados", memoria.NuevoIndiceMemoria(), http.StatusNotFound, handlers.CodigoSinResultados},
+        {"índice caído", indiceCaido{memoria.NuevoIndiceMemoria()}, http.StatusInternalServerError, handlers.CodigoErrorInterno},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            manejador := handlers.NuevoManejadorBusquedaDescarga(servicioDocs, caso.indice, log, &config.Config{}, handlers.NewInternalAuth(&servicio.MockAuthClient{Log: log}, log, &config.Config{}))
+            router := gin.New()
+            router.POST("/buscar-descargar", manejador.BuscarYDescargarDocumento)
+
+            w := httptest.NewRecorder()
+            req, _ := http.NewRequest("POST", "/buscar-descargar", bytes.NewBufferString(`{"rut_cliente":"11111111-1"}`))
+            req.Header.Set("Content-Type", "application/json")
+            router.ServeHTTP(w, req)
+
+            assert.Equal(t, caso.estado, w.Code)
+            assert.Equal(t, handlers.TipoContenidoProblema, w.Header().Get("Content-Type"))
+            var problema handlers.Problema
+            assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problema))
+            assert.Equal(t, caso.codigo, problema.Codigo)
+        })
+    }
+}
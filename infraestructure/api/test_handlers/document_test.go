@@ -1,10 +1,10 @@
 package test_handlers
 
 import (
+    "context"
     "bytes"
+    "encoding/base64"
     "encoding/json"
-    "io"
-    "mime/multipart"
     "net/http"
     "net/http/httptest"
     "os"
@@ -13,6 +13,7 @@ import (
     "github.com/CamiloScript/REGAPIGO/application/documento"
     "github.com/CamiloScript/REGAPIGO/domain/documentos"
     "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/memoria"
     "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
     "github.com/gin-gonic/gin"
@@ -30,7 +31,7 @@ func TestSubirDocumentoExitoso(t *testing.T) {
     mockCliente := servicio.NuevoMockClienteAlfresco(log)
 
     // Crear el servicio de documentos utilizando el mock
-    servicio := documento.NuevoServicioDocumentos(mockCliente, log, "mock-key")
+    servicioDocs := documento.NuevoServicioDocumentos(mockCliente, log, "mock-key")
 
     // Crear la configuración mock para las pruebas
     config := &config.Config{}
@@ -40,35 +41,22 @@ func TestSubirDocumentoExitoso(t *testing.T) {
     mockAuth := &servicio.MockAuthClient{Log: log}
 
     // Crear el manejador de documentos utilizando el servicio y el mock de autenticación
-    manejador := handlers.NuevoManejadorDocumentos(servicio, log, config, mockAuth)
+    indice := memoria.NuevoIndiceMemoria()
+    manejador := handlers.NuevoManejadorDocumentos(servicioDocs, indice, log, config, handlers.NewInternalAuth(mockAuth, log, config))
 
     // Configurar el router de Gin para la prueba
     router := gin.Default()
     router.POST("/subir", manejador.ManejadorSubirDocumento)
 
-    // Crear un buffer para almacenar el cuerpo de la solicitud multipart
-    body := &bytes.Buffer{}
-    writer := multipart.NewWriter(body)
-    log.Debug("Formulario multipart creado", map[string]interface{}{"boundary": writer.Boundary()})
-
-    // Agregar archivo al formulario
+    // Leer el archivo de prueba y codificarlo en base64, formato que espera el manejador
     filePath := filepath.Join("testdata", "sample1.pdf")
-    file, err := os.Open(filePath)
+    contenido, err := os.ReadFile(filePath)
     if err != nil {
         t.Fatalf("Error al abrir el archivo de prueba: %v", err)
     }
-    defer file.Close()
-
-    part, err := writer.CreateFormFile("documento", "sample1.pdf")
-    if err != nil {
-        t.Fatalf("Error al crear el campo de archivo en el formulario: %v", err)
-    }
-    if _, err := io.Copy(part, file); err != nil {
-        t.Fatalf("Error al copiar el contenido del archivo: %v", err)
-    }
-    log.Info("Archivo agregado al formulario", map[string]interface{}{"ruta": filePath})
+    log.Info("Archivo de prueba leído", map[string]interface{}{"ruta": filePath})
 
-    // Agregar metadatos al formulario
+    // Metadatos del documento
     metadatos := documentos.DocumentMetadata{
         CmTitle:        "Test",
         RUTCliente:    "20218874-5",
@@ -84,18 +72,25 @@ func TestSubirDocumentoExitoso(t *testing.T) {
     if err != nil {
         t.Fatalf("Error al serializar los metadatos: %v", err)
     }
-    if err := writer.WriteField("propiedades", string(jsonMeta)); err != nil {
-        t.Fatalf("Error al agregar los metadatos al formulario: %v", err)
+    var mapaMetadatos map[string]interface{}
+    if err := json.Unmarshal(jsonMeta, &mapaMetadatos); err != nil {
+        t.Fatalf("Error al convertir los metadatos: %v", err)
+    }
+    body, err := json.Marshal(map[string]interface{}{
+        "base64":    base64.StdEncoding.EncodeToString(contenido),
+        "metadatos": mapaMetadatos,
+    })
+    if err != nil {
+        t.Fatalf("Error al serializar la solicitud: %v", err)
     }
-    writer.Close()
 
     // Crear la solicitud HTTP POST
     w := httptest.NewRecorder()
-    req, err := http.NewRequest("POST", "/subir", body)
+    req, err := http.NewRequest("POST", "/subir", bytes.NewReader(body))
     if err != nil {
         t.Fatalf("Error al crear la solicitud HTTP: %v", err)
     }
-    req.Header.Set("Content-Type", writer.FormDataContentType())
+    req.Header.Set("Content-Type", "application/json")
     req.Header.Set("Authorization", "Basic TICKET_mock_123")
 
     // Ejecutar la solicitud
@@ -103,6 +98,11 @@ func TestSubirDocumentoExitoso(t *testing.T) {
 
     // Verificar que el código de estado HTTP sea 200 (OK)
     assert.Equal(t, http.StatusOK, w.Code)
+
+    // Verificar que el documento quedó indexado y es buscable por RUT
+    idFile, err := indice.Buscar(req.Context(), documentos.FiltroIndice{RUTCliente: "20218874-5"})
+    assert.NoError(t, err)
+    assert.Equal(t, "mock-456", idFile)
 }
 
 // TestListarDocumentos_DeAlfresco - Prueba el caso exitoso de listar documentos desde Alfresco.
@@ -120,13 +120,13 @@ func TestListarDocumentos_DeAlfresco(t *testing.T) {
     mockAuth := &servicio.MockAuthClient{Log: log}
     
     // Crear el servicio de documentos utilizando el mock
-    servicio := documento.NuevoServicioDocumentos(repo, log, "mock-key")
+    servicioDocs := documento.NuevoServicioDocumentos(repo, log, "mock-key")
     
     // Crear la configuración mock para las pruebas
     config := &config.Config{}
     
     // Crear el manejador de documentos utilizando el servicio y el mock de autenticación
-    manejador := handlers.NuevoManejadorDocumentos(servicio, log, config, mockAuth)
+    manejador := handlers.NuevoManejadorDocumentos(servicioDocs, memoria.NuevoIndiceMemoria(), log, config, handlers.NewInternalAuth(mockAuth, log, config))
     
     // Configurar el router de Gin para la prueba
     router := gin.Default()
@@ -169,10 +169,10 @@ func TestDescargarDocumentoNoExistente(t *testing.T) {
     config := &config.Config{}
     
     // Crear el servicio de documentos utilizando el mock
-    servicio := documento.NuevoServicioDocumentos(mockCliente, log, "mock-key")
+    servicioDocs := documento.NuevoServicioDocumentos(mockCliente, log, "mock-key")
     
     // Crear el manejador de documentos utilizando el servicio y el mock de autenticación
-    manejador := handlers.NuevoManejadorDocumentos(servicio, log, config, mockAuth)
+    manejador := handlers.NuevoManejadorDocumentos(servicioDocs, memoria.NuevoIndiceMemoria(), log, config, handlers.NewInternalAuth(mockAuth, log, config))
     
     // Configurar el router de Gin para la prueba
     router := gin.Default()
@@ -198,10 +198,108 @@ func TestDescargarDocumentoNoExistente(t *testing.T) {
     // Verificar que el código de estado HTTP sea 404 (Not Found)
     assert.Equal(t, http.StatusNotFound, resp.Code)
     
-    // Verificar que el tipo de contenido de la respuesta sea JSON
-    assert.Equal(t, "application/json; charset=utf-8", resp.Header().Get("Content-Type"))
+    // Verificar que el tipo de contenido de la respuesta sea problem+json (RFC 7807)
+    assert.Equal(t, handlers.TipoContenidoProblema, resp.Header().Get("Content-Type"))
     
     // Verificar que la respuesta contenga el mensaje de error esperado
     assert.Contains(t, resp.Body.String(), "documento no encontrado")
     log.Info("Afirmaciones completadas", nil)
-}
\ No newline at end of file
+}
+
+// TestLoteDocumentosErroresPorCampo - Prueba que los documentos fallidos del lote se reportan con su índice y código.
+func TestLoteDocumentosErroresPorCampo(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs := documento.NuevoServicioDocumentos(servicio.NuevoMockClienteAlfresco(log), log, "mock-key")
+    manejador := handlers.NuevoManejadorDocumentos(servicioDocs, memoria.NuevoIndiceMemoria(), log, &config.Config{}, handlers.NewInternalAuth(&servicio.MockAuthClient{Log: log}, log, &config.Config{}))
+
+    router := gin.Default()
+    router.POST("/subir-lote", manejador.ManejadorLoteDocumentos)
+
+    body := `{"documentos":[{"base64":"%%%","metadatos":{"tanner:nombre-doc":"roto.pdf"}}]}`
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("POST", "/subir-lote", bytes.NewBufferString(body))
+    req.Header.Set("Content-Type", "application/json")
+    router.ServeHTTP(w, req)
+
+    assert.Equal(t, http.StatusOK, w.Code)
+    var respuesta struct {
+        Errores []handlers.ErrorCampo `json:"errores"`
+    }
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &respuesta))
+    if assert.Len(t, respuesta.Errores, 1) {
+        assert.Equal(t, "documentos[0].base64", respuesta.Errores[0].Campo)
+        assert.Equal(t, handlers.CodigoValidacion, respuesta.Errores[0].Codigo)
+    }
+}
+
+// TestLoteDocumentosMetadatosIncompletos - Prueba que un lote con metadatos faltantes se sube y que uno con
+// metadatos no textuales se rechaza al validar la solicitud.
+func TestLoteDocumentosMetadatosIncompletos(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs := documento.NuevoServicioDocumentos(servicio.NuevoMockClienteAlfresco(log), log, "mock-key")
+    manejador := handlers.NuevoManejadorDocumentos(servicioDocs, memoria.NuevoIndiceMemoria(), log, &config.Config{}, handlers.NewInternalAuth(&servicio.MockAuthClient{Log: log}, log, &config.Config{}))
+
+    router := gin.Default()
+    router.POST("/subir-lote", manejador.ManejadorLoteDocumentos)
+
+    contenido, err := os.ReadFile(filepath.Join("testdata", "sample1.pdf"))
+    if err != nil {
+        t.Fatalf("Error al abrir el archivo de prueba: %v", err)
+    }
+    enviar := func(metadatos map[string]interface{}) *httptest.ResponseRecorder {
+        body, err := json.Marshal(map[string]interface{}{
+            "documentos": []map[string]interface{}{{
+                "base64":    base64.StdEncoding.EncodeToString(contenido),
+                "metadatos": metadatos,
+            }},
+        })
+        if err != nil {
+            t.Fatalf("Error al serializar la solicitud: %v", err)
+        }
+        w := httptest.NewRecorder()
+        req, _ := http.NewRequest("POST", "/subir-lote", bytes.NewReader(body))
+        req.Header.Set("Content-Type", "application/json")
+        router.ServeHTTP(w, req)
+        return w
+    }
+
+    // 1. Sin razón social ni RUT el documento se sube igualmente
+    w := enviar(map[string]interface{}{"tanner:nombre-doc": "sample1.pdf"})
+    assert.Equal(t, http.StatusOK, w.Code)
+    var respuesta struct {
+        Documentos []handlers.ResultadoCarga `json:"documentos"`
+    }
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &respuesta))
+    if assert.Len(t, respuesta.Documentos, 1) {
+        assert.Equal(t, "mock-456", respuesta.Documentos[0].IdArchivo)
+        assert.Equal(t, "sample1.pdf", respuesta.Documentos[0].NombreArchivo)
+        assert.Empty(t, respuesta.Documentos[0].Rut)
+    }
+
+    // 2. Un RUT numérico en vez de texto se rechaza antes de llegar a Alfresco
+    w = enviar(map[string]interface{}{"tanner:nombre-doc": "sample1.pdf", "tanner:rut-cliente": 20218874})
+    assert.Equal(t, http.StatusBadRequest, w.Code)
+    var problema handlers.Problema
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problema))
+    assert.Equal(t, handlers.CodigoSolicitudInvalida, problema.Codigo)
+}
+
+// TestTiposPermitidosRecargables verifica que los tipos MIME aceptados pueden cambiar con el servicio en uso.
+func TestTiposPermitidosRecargables(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs := documento.NuevoServicioDocumentos(servicio.NuevoMockClienteAlfresco(log), log, "mock-key")
+    gif := documento.SolicitudCarga{Contenido: []byte("GIF89a\x01\x00\x01\x00"), Ticket: "TICKET_mock_123"}
+
+    // 1. Por defecto solo se aceptan PDF, JPEG y PNG
+    _, err := servicioDocs.SubirDocumento(context.Background(), gif)
+    if assert.Error(t, err) {
+        assert.Contains(t, err.Error(), "image/gif")
+    }
+
+    // 2. Tras recargar la configuración el GIF se acepta y el PDF ya no
+    servicioDocs.EstablecerTiposPermitidos([]string{"image/gif"})
+    _, err = servicioDocs.SubirDocumento(context.Background(), gif)
+    assert.NoError(t, err)
+    _, err = servicioDocs.SubirDocumento(context.Background(), documento.SolicitudCarga{Contenido: []byte("%PDF-1.4"), Ticket: "TICKET_mock_123"})
+    assert.Error(t, err)
+}
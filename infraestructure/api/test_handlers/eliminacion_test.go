@@ -0,0 +1,178 @@
+package test_handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/memoria"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+const (
+    claveOperador = "clave-operador"
+    claveAdmin    = "clave-admin"
+)
+
+// indiceSinEscritura simula un índice que responde las lecturas pero no acepta actualizaciones.
+type indiceSinEscritura struct {
+    *memoria.IndiceMemoria
+}
+
+func (indiceSinEscritura) MarcarEliminado(context.Context, string, string) error {
+    return errors.New("mongo no disponible")
+}
+
+func (indiceSinEscritura) Eliminar(context.Context, string) error {
+    return errors.New("mongo no disponible")
+}
+
+// entornoEliminacion agrupa el router de eliminación y sus dobles de prueba.
+type entornoEliminacion struct {
+    router   *gin.Engine
+    alfresco *servicio.MockClienteAlfresco
+    indice   *memoria.IndiceMemoria
+}
+
+// nuevoEntornoEliminacion indexa doc-123 (presente en el mock de Alfresco) con los metadatos indicados y arma
+// el router con retención de 5 años para contratos. envolver permite sustituir el índice visto por el manejador.
+func nuevoEntornoEliminacion(t *testing.T, registro documentos.RegistroIndice, envolver func(*memoria.IndiceMemoria) documentos.RepositorioIndice) entornoEliminacion {
+    log := logger.NuevoRegistrador("TEST", "|")
+    cfg := &config.Config{ApiKey: claveOperador, AdminApiKey: claveAdmin}
+    alfresco := servicio.NuevoMockClienteAlfresco(log)
+    servicioDocs := documento.NuevoServicioDocumentos(alfresco, log, "mock-key")
+    servicioDocs.EstablecerReglasRetencion(documentos.ReglasRetencion{AniosPorTipo: map[string]int{"contrato": 5}})
+
+    indice := memoria.NuevoIndiceMemoria()
+    registro.ID = "doc-123"
+    if err := indice.Guardar(context.Background(), registro); err != nil {
+        t.Fatal(err)
+    }
+    var repositorio documentos.RepositorioIndice = indice
+    if envolver != nil {
+        repositorio = envolver(indice)
+    }
+
+    manejador := handlers.NuevoManejadorDocumentos(servicioDocs, repositorio, log, cfg, handlers.NewInternalAuth(&servicio.MockAuthClient{Log: log}, log, cfg))
+    router := gin.New()
+    router.Use(middleware.MiddlewareIdentidad(cfg))
+    router.DELETE("/documentos/:id", manejador.ManejadorEliminarDocumento)
+    return entornoEliminacion{router: router, alfresco: alfresco, indice: indice}
+}
+
+// eliminar envía la solicitud de eliminación con la API Key indicada.
+func (e entornoEliminacion) eliminar(clave string, definitivo bool) *httptest.ResponseRecorder {
+    ruta := "/documentos/doc-123"
+    if definitivo {
+        ruta += "?definitivo=true"
+    }
+    req := httptest.NewRequest("DELETE", ruta, nil)
+    req.Header.Set("ADFTannerServices", clave)
+    w := httptest.NewRecorder()
+    e.router.ServeHTTP(w, req)
+    return w
+}
+
+// codigoProblema retorna el código de una respuesta problem+json.
+func codigoProblema(t *testing.T, w *httptest.ResponseRecorder) string {
+    assert.Equal(t, handlers.TipoContenidoProblema, w.Header().Get("Content-Type"))
+    var problema handlers.Problema
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problema))
+    return problema.Codigo
+}
+
+// TestEliminarDocumentoRechazos verifica los rechazos por rol y por retención, sin modificar Alfresco ni el índice.
+func TestEliminarDocumentoRechazos(t *testing.T) {
+    casos := []struct {
+        nombre     string
+        registro   documentos.RegistroIndice
+        clave      string
+        definitivo bool
+        estado     int
+        codigo     string
+    }{
+        {"definitiva sin rol administrador", documentos.RegistroIndice{}, claveOperador, true, http.StatusForbidden, handlers.CodigoRolInsuficiente},
+        {"vigencia futura", documentos.RegistroIndice{FechaTerminoVigencia: "2999-12-31"}, claveOperador, false, http.StatusConflict, handlers.CodigoDocumentoEnRetencion},
+        {"periodo por tipo", documentos.RegistroIndice{TipoDocumento: "contrato", FechaCarga: "2999-01-01"}, claveAdmin, true, http.StatusConflict, handlers.CodigoDocumentoEnRetencion},
+        {"ya eliminado", documentos.RegistroIndice{Eliminado: true}, claveOperador, false, http.StatusConflict, handlers.CodigoDocumentoEliminado},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            entorno := nuevoEntornoEliminacion(t, caso.registro, nil)
+            w := entorno.eliminar(caso.clave, caso.definitivo)
+
+            assert.Equal(t, caso.estado, w.Code)
+            assert.Equal(t, caso.codigo, codigoProblema(t, w))
+            assert.Contains(t, entorno.alfresco.DocumentosDB, "doc-123")
+            registro, err := entorno.indice.Obtener(context.Background(), "doc-123")
+            assert.NoError(t, err)
+            assert.Equal(t, caso.registro.Eliminado, registro.Eliminado)
+        })
+    }
+}
+
+// TestEliminarDocumentoLogica verifica que la eliminación lógica marca el documento en Alfresco y en el índice.
+func TestEliminarDocumentoLogica(t *testing.T) {
+    entorno := nuevoEntornoEliminacion(t, documentos.RegistroIndice{FechaTerminoVigencia: "2020-01-01"}, nil)
+    w := entorno.eliminar(claveOperador, false)
+
+    assert.Equal(t, http.StatusOK, w.Code)
+    assert.JSONEq(t, `{"id":"doc-123","eliminacion":"logica"}`, w.Body.String())
+    propiedades := entorno.alfresco.DocumentosDB["doc-123"]["properties"].(map[string]interface{})
+    assert.Equal(t, documentos.EstadoEliminado, propiedades["tanner:estado-vigencia"])
+    registro, err := entorno.indice.Obtener(context.Background(), "doc-123")
+    assert.NoError(t, err)
+    assert.True(t, registro.Eliminado)
+    assert.Equal(t, documentos.EstadoEliminado, registro.EstadoVigencia)
+}
+
+// TestEliminarDocumentoDefinitiva verifica que el administrador borra el archivo y su registro, incluso si un
+// intento anterior ya lo había marcado como eliminado.
+func TestEliminarDocumentoDefinitiva(t *testing.T) {
+    entorno := nuevoEntornoEliminacion(t, documentos.RegistroIndice{Eliminado: true}, nil)
+    w := entorno.eliminar(claveAdmin, true)
+
+    assert.Equal(t, http.StatusOK, w.Code)
+    assert.JSONEq(t, `{"id":"doc-123","eliminacion":"definitiva"}`, w.Body.String())
+    assert.NotContains(t, entorno.alfresco.DocumentosDB, "doc-123")
+    _, err := entorno.indice.Obtener(context.Background(), "doc-123")
+    assert.ErrorIs(t, err, documentos.ErrRegistroNoEncontrado)
+}
+
+// TestEliminarDocumentoIndiceNoActualizado verifica que una falla del índice después de modificar Alfresco no
+// se informa como éxito, y que repetir la eliminación definitiva completa el borrado del registro.
+func TestEliminarDocumentoIndiceNoActualizado(t *testing.T) {
+    for _, definitivo := range []bool{false, true} {
+        entorno := nuevoEntornoEliminacion(t, documentos.RegistroIndice{}, func(indice *memoria.IndiceMemoria) documentos.RepositorioIndice {
+            return indiceSinEscritura{indice}
+        })
+        w := entorno.eliminar(claveAdmin, definitivo)
+
+        assert.Equal(t, http.StatusInternalServerError, w.Code)
+        assert.Equal(t, handlers.CodigoIndiceNoActualizado, codigoProblema(t, w))
+    }
+
+    // El archivo ya no está en Alfresco pero sigue indexado: el reintento borra el registro
+    entorno := nuevoEntornoEliminacion(t, documentos.RegistroIndice{}, nil)
+    delete(entorno.alfresco.DocumentosDB, "doc-123")
+    w := entorno.eliminar(claveAdmin, true)
+    assert.Equal(t, http.StatusOK, w.Code)
+    _, err := entorno.indice.Obtener(context.Background(), "doc-123")
+    assert.ErrorIs(t, err, documentos.ErrRegistroNoEncontrado)
+
+    // Sin el parámetro definitivo un archivo inexistente sigue siendo 404
+    entorno = nuevoEntornoEliminacion(t, documentos.RegistroIndice{}, nil)
+    delete(entorno.alfresco.DocumentosDB, "doc-123")
+    w = entorno.eliminar(claveOperador, false)
+    assert.Equal(t, http.StatusNotFound, w.Code)
+}
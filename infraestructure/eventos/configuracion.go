@@ -0,0 +1,44 @@
+package eventos
+
+import (
+    "fmt"
+    "github.com/CamiloScript/REGAPIGO/domain/eventos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/webhook"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+)
+
+// Implementaciones de EventPublisher seleccionables por configuración.
+const (
+    PublicadorTipoMemoria = "memoria"
+    PublicadorTipoWebhook = "webhook"
+    PublicadorTipoOutbox  = "outbox"
+)
+
+// NuevoPublicador construye el EventPublisher indicado en EVENTOS_PUBLICADOR.
+// Parámetros:
+//   - cfg: Configuración de la aplicación.
+//   - log: Logger para registrar eventos y errores.
+//   - outbox: Outbox persistente usado cuando EVENTOS_PUBLICADOR es "outbox".
+// Retorna el publicador o un error si la configuración es inválida.
+func NuevoPublicador(cfg *config.Config, log *logger.Registrador, outbox eventos.EventPublisher) (eventos.EventPublisher, error) {
+    switch cfg.EventosPublicador {
+    case PublicadorTipoMemoria, "":
+        return NuevoPublicadorMemoria(), nil
+    case PublicadorTipoWebhook:
+        return NuevoPublicadorWebhookDesdeConfiguracion(cfg, log)
+    case PublicadorTipoOutbox:
+        return outbox, nil
+    default:
+        return nil, fmt.Errorf("EVENTOS_PUBLICADOR desconocido: %q", cfg.EventosPublicador)
+    }
+}
+
+// NuevoPublicadorWebhookDesdeConfiguracion construye el publicador webhook usado directamente o como destino del relay.
+func NuevoPublicadorWebhookDesdeConfiguracion(cfg *config.Config, log *logger.Registrador) (*PublicadorWebhook, error) {
+    if cfg.EventosWebhookURL == "" {
+        return nil, fmt.Errorf("EVENTOS_WEBHOOK_URL es obligatorio para publicar eventos por webhook")
+    }
+    cliente := webhook.NuevoClienteWebhook(cfg.WebhookReintentos, cfg.WebhookEsperaBase, log)
+    return NuevoPublicadorWebhook(cliente, cfg.EventosWebhookURL, cfg.EventosWebhookSecreto), nil
+}
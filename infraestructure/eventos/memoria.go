@@ -0,0 +1,47 @@
+package eventos
+
+import (
+    "context"
+    "sync"
+    "github.com/CamiloScript/REGAPIGO/domain/eventos"
+)
+
+// PublicadorMemoria guarda los eventos en memoria y los entrega a suscriptores del mismo proceso.
+// Útil para pruebas y para despliegues de una sola réplica.
+type PublicadorMemoria struct {
+    mu          sync.RWMutex
+    eventos     []eventos.Evento        // Eventos publicados, en orden
+    suscriptores []func(eventos.Evento) // Funciones invocadas en cada publicación
+}
+
+// NuevoPublicadorMemoria crea un publicador en memoria vacío.
+func NuevoPublicadorMemoria() *PublicadorMemoria {
+    return &PublicadorMemoria{}
+}
+
+// Publicar almacena el evento y lo entrega a los suscriptores de forma síncrona.
+func (p *PublicadorMemoria) Publicar(ctx context.Context, evento eventos.Evento) error {
+    p.mu.Lock()
+    p.eventos = append(p.eventos, evento)
+    suscriptores := append([]func(eventos.Evento){}, p.suscriptores...)
+    p.mu.Unlock()
+
+    for _, suscriptor := range suscriptores {
+        suscriptor(evento)
+    }
+    return nil
+}
+
+// Suscribir registra una función que recibirá cada evento publicado.
+func (p *PublicadorMemoria) Suscribir(suscriptor func(eventos.Evento)) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.suscriptores = append(p.suscriptores, suscriptor)
+}
+
+// Eventos retorna una copia de los eventos publicados.
+func (p *PublicadorMemoria) Eventos() []eventos.Evento {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+    return append([]eventos.Evento{}, p.eventos...)
+}
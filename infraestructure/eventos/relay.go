@@ -0,0 +1,107 @@
+package eventos
+
+import (
+    "context"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/eventos"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+)
+
+// Outbox es la bandeja de eventos pendientes que el relay debe publicar.
+type Outbox interface {
+    Pendientes(ctx context.Context, limite int) ([]eventos.Evento, error)
+    MarcarPublicado(ctx context.Context, id string) error
+    RegistrarFallo(ctx context.Context, id string, causa error) error
+}
+
+// tamanoLoteRelay limita los eventos publicados por ejecución.
+const tamanoLoteRelay = 100
+
+// RelayOutbox drena periódicamente el outbox hacia un publicador destino.
+// La entrega es "al menos una vez": los receptores deben deduplicar por ID de evento.
+type RelayOutbox struct {
+    outbox    Outbox                   // Bandeja de eventos pendientes
+    destino   eventos.EventPublisher   // Publicador final (ej. webhook)
+    bloqueo   documento.BloqueoLider   // Bloqueo para que una sola réplica drene
+    intervalo time.Duration            // Tiempo entre ejecuciones
+    log       *logger.Registrador      // Logger para registrar eventos y errores
+}
+
+// NuevoRelayOutbox construye el relay con sus dependencias.
+func NuevoRelayOutbox(
+    outbox Outbox,
+    destino eventos.EventPublisher,
+    bloqueo documento.BloqueoLider,
+    intervalo time.Duration,
+    log *logger.Registrador,
+) *RelayOutbox {
+    return &RelayOutbox{outbox: outbox, destino: destino, bloqueo: bloqueo, intervalo: intervalo, log: log}
+}
+
+// Iniciar ejecuta el relay hasta que el contexto se cancele.
+func (r *RelayOutbox) Iniciar(ctx context.Context) {
+    r.log.Info("Relay de outbox iniciado", map[string]interface{}{"intervalo": r.intervalo.String()})
+
+    ticker := time.NewTicker(r.intervalo)
+    defer ticker.Stop()
+
+    for {
+        r.Drenar(ctx)
+
+        select {
+        case <-ctx.Done():
+            ctxLiberar, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+            if err := r.bloqueo.Liberar(ctxLiberar); err != nil {
+                r.log.Warn("No se pudo liberar el bloqueo del relay", map[string]interface{}{"error": err.Error()})
+            }
+            cancel()
+            r.log.Info("Relay de outbox detenido", nil)
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Drenar publica un lote de eventos pendientes si esta réplica es la líder.
+// Retorna la cantidad de eventos publicados.
+func (r *RelayOutbox) Drenar(ctx context.Context) int {
+    // 1. Solo la réplica líder drena
+    lider, err := r.bloqueo.Adquirir(ctx, 2*r.intervalo)
+    if err != nil {
+        r.log.Error("Error al adquirir bloqueo del relay", map[string]interface{}{"error": err.Error()})
+        return 0
+    }
+    if !lider {
+        return 0
+    }
+
+    // 2. Leer pendientes en orden de creación
+    pendientes, err := r.outbox.Pendientes(ctx, tamanoLoteRelay)
+    if err != nil {
+        r.log.Error("Error al leer outbox", map[string]interface{}{"error": err.Error()})
+        return 0
+    }
+
+    // 3. Publicar; un fallo detiene el lote para conservar el orden
+    publicados := 0
+    for _, evento := range pendientes {
+        if err := r.destino.Publicar(ctx, evento); err != nil {
+            r.log.Warn("Error al publicar evento del outbox", map[string]interface{}{"id": evento.ID, "error": err.Error()})
+            if err := r.outbox.RegistrarFallo(ctx, evento.ID, err); err != nil {
+                r.log.Error("Error al registrar fallo en outbox", map[string]interface{}{"id": evento.ID, "error": err.Error()})
+            }
+            break
+        }
+        if err := r.outbox.MarcarPublicado(ctx, evento.ID); err != nil {
+            r.log.Error("Error al marcar evento publicado", map[string]interface{}{"id": evento.ID, "error": err.Error()})
+            break
+        }
+        publicados++
+    }
+
+    if publicados > 0 {
+        r.log.Info("Eventos del outbox publicados", map[string]interface{}{"publicados": publicados})
+    }
+    return publicados
+}
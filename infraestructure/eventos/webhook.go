@@ -0,0 +1,34 @@
+package eventos
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "github.com/CamiloScript/REGAPIGO/domain/eventos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/webhook"
+)
+
+// PublicadorWebhook envía cada evento como POST firmado a un receptor HTTP.
+// Usa el mismo esquema de firma y reintentos que los avisos de vencimiento.
+type PublicadorWebhook struct {
+    cliente *webhook.ClienteWebhook // Cliente HTTP con firma y reintentos
+    url     string                  // URL del receptor
+    secreto string                  // Clave HMAC
+}
+
+// NuevoPublicadorWebhook crea un publicador hacia la URL indicada.
+func NuevoPublicadorWebhook(cliente *webhook.ClienteWebhook, url, secreto string) *PublicadorWebhook {
+    return &PublicadorWebhook{cliente: cliente, url: url, secreto: secreto}
+}
+
+// Publicar serializa el evento y lo entrega al receptor; el ID del evento identifica la entrega.
+func (p *PublicadorWebhook) Publicar(ctx context.Context, evento eventos.Evento) error {
+    cuerpo, err := json.Marshal(evento)
+    if err != nil {
+        return fmt.Errorf("error al serializar evento: %v", err)
+    }
+    if resultado := p.cliente.Enviar(ctx, p.url, p.secreto, evento.ID, cuerpo); resultado.Error != nil {
+        return fmt.Errorf("error al publicar evento %s: %v", evento.ID, resultado.Error)
+    }
+    return nil
+}
@@ -0,0 +1,110 @@
+package memoria
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+)
+
+// IndiceMemoria implementa documentos.RepositorioIndice en memoria.
+// Pensado para pruebas y para ejecutar la API sin MongoDB; conserva el orden de inserción.
+type IndiceMemoria struct {
+    mu        sync.RWMutex
+    registros []documentos.RegistroIndice // Registros en orden de inserción
+}
+
+// NuevoIndiceMemoria crea un índice vacío.
+func NuevoIndiceMemoria() *IndiceMemoria {
+    return &IndiceMemoria{}
+}
+
+// Guardar agrega un registro; falla si ya existe uno con el mismo ID.
+func (i *IndiceMemoria) Guardar(ctx context.Context, registro documentos.RegistroIndice) error {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+    if i.posicion(registro.ID) >= 0 {
+        return fmt.Errorf("documento %s ya indexado", registro.ID)
+    }
+    i.registros = append(i.registros, registro)
+    return nil
+}
+
+// Buscar retorna el ID del primer registro no eliminado que cumple el filtro.
+func (i *IndiceMemoria) Buscar(ctx context.Context, filtro documentos.FiltroIndice) (string, error) {
+    i.mu.RLock()
+    defer i.mu.RUnlock()
+    for _, registro := range i.registros {
+        if !registro.Eliminado && coincide(registro, filtro) {
+            return registro.ID, nil
+        }
+    }
+    return "", documentos.ErrRegistroNoEncontrado
+}
+
+// Obtener retorna una copia del registro de un documento.
+func (i *IndiceMemoria) Obtener(ctx context.Context, idFile string) (*documentos.RegistroIndice, error) {
+    i.mu.RLock()
+    defer i.mu.RUnlock()
+    posicion := i.posicion(idFile)
+    if posicion < 0 {
+        return nil, documentos.ErrRegistroNoEncontrado
+    }
+    registro := i.registros[posicion]
+    return &registro, nil
+}
+
+// MarcarEliminado registra la eliminación lógica de un documento.
+func (i *IndiceMemoria) MarcarEliminado(ctx context.Context, idFile string, estadoVigencia string) error {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+    posicion := i.posicion(idFile)
+    if posicion < 0 {
+        return documentos.ErrRegistroNoEncontrado
+    }
+    i.registros[posicion].Eliminado = true
+    i.registros[posicion].FechaEliminacion = time.Now().UTC().Format(time.RFC3339)
+    i.registros[posicion].EstadoVigencia = estadoVigencia
+    return nil
+}
+
+// Eliminar borra el registro de un documento.
+func (i *IndiceMemoria) Eliminar(ctx context.Context, idFile string) error {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+    posicion := i.posicion(idFile)
+    if posicion < 0 {
+        return documentos.ErrRegistroNoEncontrado
+    }
+    i.registros = append(i.registros[:posicion], i.registros[posicion+1:]...)
+    return nil
+}
+
+// posicion retorna el índice del registro con el ID indicado, o -1 si no existe.
+func (i *IndiceMemoria) posicion(idFile string) int {
+    for posicion, registro := range i.registros {
+        if registro.ID == idFile {
+            return posicion
+        }
+    }
+    return -1
+}
+
+// coincide indica si el registro cumple todos los criterios no vacíos del filtro.
+func coincide(registro documentos.RegistroIndice, filtro documentos.FiltroIndice) bool {
+    criterios := [][2]string{
+        {filtro.RUTCliente, registro.RUTCliente},
+        {filtro.TipoDocumento, registro.TipoDocumento},
+        {filtro.NombreDocumento, registro.NombreDocumento},
+        {filtro.FechaCarga, registro.FechaCarga},
+        {filtro.RazonSocialCliente, registro.RazonSocialCliente},
+        {filtro.EstadoVigencia, registro.EstadoVigencia},
+    }
+    for _, criterio := range criterios {
+        if criterio[0] != "" && criterio[0] != criterio[1] {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,87 @@
+package memoria
+
+import (
+    "context"
+    "sync"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/limites"
+)
+
+// intervaloLimpieza es cada cuánto se descartan los buckets llenos y los consumos de días anteriores.
+const intervaloLimpieza = time.Minute
+
+// estadoBucket son los tokens de un bucket en su última actualización.
+type estadoBucket struct {
+    tokens      float64
+    actualizado time.Time
+    lleno       time.Time // Momento en que el bucket vuelve a estar lleno y puede descartarse
+}
+
+// LimitesMemoria implementa limites.Almacen en memoria. Los contadores son propios de la réplica:
+// con varias réplicas cada una aplica el límite completo.
+type LimitesMemoria struct {
+    mu       sync.Mutex
+    buckets  map[string]*estadoBucket // Buckets por clave
+    consumos map[string]int64         // Bytes por clave y día (clave|dia)
+    limpieza time.Time                // Última limpieza
+}
+
+// NuevoLimitesMemoria crea un almacén vacío.
+func NuevoLimitesMemoria() *LimitesMemoria {
+    return &LimitesMemoria{buckets: make(map[string]*estadoBucket), consumos: make(map[string]int64)}
+}
+
+// Tomar consume un token del bucket de la clave, creándolo lleno si no existe.
+func (l *LimitesMemoria) Tomar(ctx context.Context, clave string, bucket limites.Bucket, ahora time.Time) (limites.Decision, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.limpiar(ahora)
+
+    estado, existe := l.buckets[clave]
+    if !existe {
+        estado = &estadoBucket{tokens: float64(bucket.Capacidad), actualizado: ahora}
+        l.buckets[clave] = estado
+    }
+    disponibles := limites.Reponer(estado.tokens, ahora.Sub(estado.actualizado), bucket)
+    decision := limites.Decidir(disponibles, bucket)
+    if decision.Permitida {
+        disponibles--
+    }
+    estado.tokens, estado.actualizado, estado.lleno = disponibles, ahora, ahora.Add(decision.Reinicio)
+    return decision, nil
+}
+
+// ConsumoDiario retorna los bytes acumulados de la clave en el día indicado.
+func (l *LimitesMemoria) ConsumoDiario(ctx context.Context, clave, dia string) (int64, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.consumos[clave+"|"+dia], nil
+}
+
+// SumarConsumo acumula bytes en el día indicado.
+func (l *LimitesMemoria) SumarConsumo(ctx context.Context, clave, dia string, bytes int64) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.consumos[clave+"|"+dia] += bytes
+    return nil
+}
+
+// limpiar descarta los buckets que ya se llenaron (equivalen a uno nuevo) y los consumos de días anteriores,
+// para que la memoria no crezca con cada cliente o IP que alguna vez llamó a la API.
+func (l *LimitesMemoria) limpiar(ahora time.Time) {
+    if ahora.Sub(l.limpieza) < intervaloLimpieza {
+        return
+    }
+    l.limpieza = ahora
+    for clave, estado := range l.buckets {
+        if !ahora.Before(estado.lleno) {
+            delete(l.buckets, clave)
+        }
+    }
+    hoy := "|" + ahora.UTC().Format("2006-01-02")
+    for clave := range l.consumos {
+        if len(clave) < len(hoy) || clave[len(clave)-len(hoy):] != hoy {
+            delete(l.consumos, clave)
+        }
+    }
+}
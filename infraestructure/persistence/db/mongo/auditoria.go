@@ -0,0 +1,186 @@
+package mongo
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/application/auditoria"
+)
+
+// RepositorioAuditoriaMongo implementa auditoria.Repositorio sobre una colección de solo inserción.
+// No expone operaciones de actualización ni borrado; solo Recifrar reemplaza el RUT cifrado, que no altera el hash.
+// Con un cifrador, el RUT se guarda cifrado y se filtra por su índice ciego.
+type RepositorioAuditoriaMongo struct {
+    coleccion *mongo.Collection // Colección de auditoría
+    cifrador  *cifrado.Cifrador // Cifrado del RUT; nil lo guarda en claro
+}
+
+// registroAuditoriaMongo es la representación en MongoDB de un registro de auditoría.
+// El hash se calcula sobre el registro en claro, de modo que cifrar o recifrar el RUT no rompe la cadena.
+type registroAuditoriaMongo struct {
+    auditoria.Registro `bson:",inline"`
+    IndicesCiegos      map[string]string `bson:"indices_ciegos,omitempty"` // HMAC del RUT, para filtrarlo por igualdad
+}
+
+// NuevoRepositorioAuditoria crea el repositorio del registro de auditoría.
+func NuevoRepositorioAuditoria(client *mongo.Client, cfg *config.Config) *RepositorioAuditoriaMongo {
+    return &RepositorioAuditoriaMongo{coleccion: client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollectionAuditoria)}
+}
+
+// EstablecerCifrador activa el cifrado del RUT en los registros nuevos y el filtro por su índice ciego.
+func (r *RepositorioAuditoriaMongo) EstablecerCifrador(cifrador *cifrado.Cifrador) {
+    r.cifrador = cifrador
+}
+
+// CrearIndices crea el índice único de secuencia, que garantiza una sola cadena entre réplicas,
+// y los índices de consulta por RUT (en claro o por índice ciego), documento y actor.
+func (r *RepositorioAuditoriaMongo) CrearIndices(ctx context.Context) error {
+    indices := []mongo.IndexModel{
+        {Keys: bson.D{{Key: "secuencia", Value: 1}}, Options: options.Index().SetName("idx_secuencia").SetUnique(true)},
+        {Keys: bson.D{{Key: "rut_cliente", Value: 1}, {Key: "secuencia", Value: -1}}, Options: options.Index().SetName("idx_rut_cliente")},
+        {Keys: bson.D{{Key: "indices_ciegos." + campoRUT, Value: 1}, {Key: "secuencia", Value: -1}}, Options: options.Index().SetName("idx_indice_ciego_rut_cliente").SetSparse(true)},
+        {Keys: bson.D{{Key: "documento_id", Value: 1}, {Key: "secuencia", Value: -1}}, Options: options.Index().SetName("idx_documento_id")},
+        {Keys: bson.D{{Key: "actor", Value: 1}, {Key: "secuencia", Value: -1}}, Options: options.Index().SetName("idx_actor")},
+    }
+    if _, err := r.coleccion.Indexes().CreateMany(ctx, indices); err != nil {
+        return fmt.Errorf("error al crear índices de auditoría: %v", err)
+    }
+    return nil
+}
+
+// Ultimo retorna el registro con mayor secuencia, o nil si no hay registros.
+func (r *RepositorioAuditoriaMongo) Ultimo(ctx context.Context) (*auditoria.Registro, error) {
+    var almacenado registroAuditoriaMongo
+    opciones := options.FindOne().SetSort(bson.D{{Key: "secuencia", Value: -1}})
+    if err := r.coleccion.FindOne(ctx, bson.M{}, opciones).Decode(&almacenado); err != nil {
+        if err == mongo.ErrNoDocuments {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("error al leer último registro de auditoría: %v", err)
+    }
+    registro, err := r.revelar(almacenado)
+    if err != nil {
+        return nil, err
+    }
+    return &registro, nil
+}
+
+// InsertarLote agrega los registros en una sola operación ordenada, que se detiene en el primer error.
+// Un choque en el índice único de secuencia retorna auditoria.ErrSecuenciaOcupada.
+func (r *RepositorioAuditoriaMongo) InsertarLote(ctx context.Context, registros []auditoria.Registro) (int, error) {
+    documentos := make([]interface{}, len(registros))
+    for i, registro := range registros {
+        almacenado, err := r.proteger(registro)
+        if err != nil {
+            return 0, err
+        }
+        documentos[i] = almacenado
+    }
+    _, err := r.coleccion.InsertMany(ctx, documentos, options.InsertMany().SetOrdered(true))
+    if err == nil {
+        return len(registros), nil
+    }
+
+    // En una inserción ordenada el índice del primer error es la cantidad de registros insertados
+    insertados := 0
+    var excepcion mongo.BulkWriteException
+    if errors.As(err, &excepcion) && len(excepcion.WriteErrors) > 0 {
+        insertados = excepcion.WriteErrors[0].Index
+    }
+    if mongo.IsDuplicateKeyError(err) {
+        return insertados, auditoria.ErrSecuenciaOcupada
+    }
+    return insertados, fmt.Errorf("error al insertar registros de auditoría: %v", err)
+}
+
+// Buscar retorna los registros más recientes que cumplen el filtro.
+func (r *RepositorioAuditoriaMongo) Buscar(ctx context.Context, filtro auditoria.Filtro) ([]auditoria.Registro, error) {
+
+    // 1. Con cifrador el RUT se compara por su índice ciego o en claro en los registros sin recifrar
+    consulta := bson.M{}
+    if filtro.RUTCliente != "" && r.cifrador != nil {
+        consulta = condicionCifrada(r.cifrador, campoRUT, "rut_cliente", "indices_ciegos."+campoRUT, filtro.RUTCliente)
+    } else if filtro.RUTCliente != "" {
+        consulta["rut_cliente"] = filtro.RUTCliente
+    }
+    if filtro.DocumentoID != "" {
+        consulta["documento_id"] = filtro.DocumentoID
+    }
+    if filtro.Actor != "" {
+        consulta["actor"] = filtro.Actor
+    }
+
+    // 2. Consultar los más recientes y descifrar el RUT
+    opciones := options.Find().SetSort(bson.D{{Key: "secuencia", Value: -1}}).SetLimit(int64(filtro.Limite))
+    cursor, err := r.coleccion.Find(ctx, consulta, opciones)
+    if err != nil {
+        return nil, fmt.Errorf("error al consultar auditoría: %v", err)
+    }
+    var almacenados []registroAuditoriaMongo
+    if err := cursor.All(ctx, &almacenados); err != nil {
+        return nil, fmt.Errorf("error al decodificar auditoría: %v", err)
+    }
+    registros := make([]auditoria.Registro, 0, len(almacenados))
+    for _, almacenado := range almacenados {
+        registro, err := r.revelar(almacenado)
+        if err != nil {
+            return nil, err
+        }
+        registros = append(registros, registro)
+    }
+    return registros, nil
+}
+
+// Recorrer entrega todos los registros en orden de secuencia sin cargarlos en memoria.
+func (r *RepositorioAuditoriaMongo) Recorrer(ctx context.Context, visitar func(auditoria.Registro) error) error {
+    cursor, err := r.coleccion.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "secuencia", Value: 1}}))
+    if err != nil {
+        return fmt.Errorf("error al recorrer auditoría: %v", err)
+    }
+    defer cursor.Close(ctx)
+
+    for cursor.Next(ctx) {
+        var almacenado registroAuditoriaMongo
+        if err := cursor.Decode(&almacenado); err != nil {
+            return fmt.Errorf("error al decodificar auditoría: %v", err)
+        }
+        registro, err := r.revelar(almacenado)
+        if err != nil {
+            return err
+        }
+        if err := visitar(registro); err != nil {
+            return err
+        }
+    }
+    return cursor.Err()
+}
+
+// proteger cifra el RUT del registro y agrega su índice ciego. Sin cifrador el registro se guarda en claro.
+func (r *RepositorioAuditoriaMongo) proteger(registro auditoria.Registro) (registroAuditoriaMongo, error) {
+    valor, indice, err := cifrarCampo(r.cifrador, campoRUT, registro.RUTCliente)
+    if err != nil {
+        return registroAuditoriaMongo{}, fmt.Errorf("error al cifrar registro de auditoría: %v", err)
+    }
+    almacenado := registroAuditoriaMongo{Registro: registro}
+    almacenado.RUTCliente = valor
+    if indice != "" {
+        almacenado.IndicesCiegos = map[string]string{campoRUT: indice}
+    }
+    return almacenado, nil
+}
+
+// revelar retorna el registro con el RUT en claro, tal como se calculó su hash.
+func (r *RepositorioAuditoriaMongo) revelar(almacenado registroAuditoriaMongo) (auditoria.Registro, error) {
+    registro := almacenado.Registro
+    texto, err := descifrarCampo(r.cifrador, campoRUT, registro.RUTCliente)
+    if err != nil {
+        return auditoria.Registro{}, fmt.Errorf("error al descifrar registro de auditoría %d: %v", registro.Secuencia, err)
+    }
+    registro.RUTCliente = texto
+    return registro, nil
+}
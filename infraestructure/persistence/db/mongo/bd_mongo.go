@@ -2,41 +2,33 @@ package mongo
 
 import (
     "context"
+    "errors"
     "fmt"
     "time"
+    "go.mongodb.org/mongo-driver/bson"
     "go.mongodb.org/mongo-driver/mongo"
     "go.mongodb.org/mongo-driver/mongo/options"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
     "github.com/CamiloScript/REGAPIGO/shared/config"
-    "encoding/json"
-    "github.com/CamiloScript/REGAPIGO/shared/logger"
-    "github.com/CamiloScript/REGAPIGO/application/documento"
 )
 
-// Variables globales para reutilizar la conexión y configuración
-var (
-    client *mongo.Client
-    cfg    = config.CargarConfiguracion() // Cargar configuración desde archivo
-)
+// timeoutOperacion acota las operaciones del índice cuando el contexto recibido no tiene plazo.
+const timeoutOperacion = 5 * time.Second
 
-// ConexionDB establece o reutiliza una conexión a MongoDB.
+// Conectar establece una conexión a MongoDB y verifica que esté activa.
+// Parámetros:
+//   - ctx: Contexto que acota el tiempo de conexión.
+//   - cfg: Configuración con la URI de MongoDB.
 // Retorna un cliente funcional o un error en caso de fallo.
-func ConexionDB() (*mongo.Client, error) {
-    // Reutilizar conexión existente si ya está establecida
-    if client != nil {
-        return client, nil
-    }
-
+func Conectar(ctx context.Context, cfg *config.Config) (*mongo.Client, error) {
     // Configurar opciones del cliente usando la URI de MongoDB desde la configuración
     opts := options.Client().ApplyURI(cfg.MongoURI)
     opts.SetServerAPIOptions(options.ServerAPI(options.ServerAPIVersion1))
-
-    // Crear un contexto con timeout para evitar bloqueos
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
+    opts.SetMonitor(monitorComandos())
 
     // Establecer conexión con MongoDB
-    var err error
-    client, err = mongo.Connect(ctx, opts)
+    client, err := mongo.Connect(ctx, opts)
     if err != nil {
         return nil, fmt.Errorf("fallo al conectar: %v", err)
     }
@@ -45,137 +37,176 @@ func ConexionDB() (*mongo.Client, error) {
     if err := client.Ping(ctx, nil); err != nil {
         return nil, fmt.Errorf("fallo al verificar conexión: %v", err)
     }
-
-    fmt.Println("✅ Conexión a MongoDB establecida")
     return client, nil
 }
 
-// InsertarDocumento inserta un documento en la colección especificada.
+// RepositorioIndiceMongo implementa documentos.RepositorioIndice sobre la colección de documentos.
+// Con un cifrador, el RUT y la razón social se guardan cifrados y se buscan por su índice ciego.
+type RepositorioIndiceMongo struct {
+    coleccion *mongo.Collection // Colección de documentos indexados
+    cifrador  *cifrado.Cifrador // Cifrado de los datos sensibles; nil los guarda en claro
+}
+
+// NuevoRepositorioIndice crea el repositorio del índice a partir de un cliente ya conectado.
 // Parámetros:
-//   - document: Datos a insertar (debe ser serializable a BSON).
-// Retorna un error si falla la operación.
-func InsertarDocumento(document interface{}) error {
-    // Obtener conexión a MongoDB
-    client, err := ConexionDB()
-    if err != nil {
-        return fmt.Errorf("error obteniendo cliente: %v", err)
+//   - client: Cliente de MongoDB compartido por la aplicación.
+//   - cfg: Configuración con la base de datos y la colección.
+func NuevoRepositorioIndice(client *mongo.Client, cfg *config.Config) *RepositorioIndiceMongo {
+    return &RepositorioIndiceMongo{
+        coleccion: client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollection),
     }
+}
 
-    // Crear un contexto con timeout para la operación de inserción
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-    defer cancel()
-
-    // Seleccionar base de datos y colección desde la configuración
-    collection := client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollection)
-
-    // Ejecutar la inserción del documento
-    _, err = collection.InsertOne(ctx, document)
-    return err
+// EstablecerCifrador activa el cifrado de los datos sensibles en los registros nuevos y en las búsquedas.
+func (r *RepositorioIndiceMongo) EstablecerCifrador(cifrador *cifrado.Cifrador) {
+    r.cifrador = cifrador
 }
 
-// GuardarEnMongoDB persiste un documento en MongoDB.
-// Parámetros:
-//   - respuesta: Mapa con los datos del documento a guardar.
-//   - log: Logger para registrar eventos y errores.
-// Retorna un error si falla la operación.
-func GuardarEnMongoDB(respuesta map[string]interface{}, log *logger.Registrador) error {
-    // 1. Mapear respuesta al DTO de Alfresco usando JSON
-    var alfrescoDoc documento.AlfrescoDocumentDTO
-
-    // Convertir la respuesta del servicio a JSON
-    respuestaJSON, err := json.Marshal(respuesta)
-    if err != nil {
-        log.Error("Error serializando respuesta", map[string]interface{}{
-            "error": err.Error(),
-            "stack": "paso1",
+// CrearIndices crea los índices sobre los índices ciegos usados por Buscar.
+func (r *RepositorioIndiceMongo) CrearIndices(ctx context.Context) error {
+    modelos := make([]mongo.IndexModel, 0, len(camposCifrados))
+    for _, campo := range camposCifrados {
+        modelos = append(modelos, mongo.IndexModel{
+            Keys:    bson.D{{Key: "indices_ciegos." + campo, Value: 1}},
+            Options: options.Index().SetName("idx_indice_ciego_" + campo).SetSparse(true),
         })
-        return err
     }
+    if _, err := r.coleccion.Indexes().CreateMany(ctx, modelos); err != nil {
+        return fmt.Errorf("error al crear índices ciegos: %v", err)
+    }
+    return nil
+}
 
-    // Deserializar el JSON a la estructura AlfrescoDocumentDTO
-    if err := json.Unmarshal(respuestaJSON, &alfrescoDoc); err != nil {
-        log.Error("Error mapeando a DTO", map[string]interface{}{
-            "error": err.Error(),
-            "stack": "paso2",
-        })
-        return err
-    }
-
-    // 2. Validar campos críticos antes de guardar
-    if alfrescoDoc.Entry.ID == "" {
-        log.Warn("Documento sin ID - No se guardará en MongoDB",
-            map[string]interface{}{"origen": "Alfresco", "stack": "validación"})
-        return fmt.Errorf("documento sin ID válido")
-    }
-
-    // 3. Convertir alfrescoDoc.Entry al DTO de MongoDB
-    mongoDoc := DocumentoMongoDTO{
-        ID:            alfrescoDoc.Entry.ID,
-        NombreArchivo: alfrescoDoc.Entry.Name,
-        TipoArchivo:   alfrescoDoc.Entry.Content.MimeType,
-        FechaCarga:    alfrescoDoc.Entry.Properties["tanner:fecha-carga"].(string),
-        Metadatos: map[string]interface{}{
-            "nombre_documento":      alfrescoDoc.Entry.Properties["tanner:nombre-doc"],
-            "tipo_documento":        alfrescoDoc.Entry.Properties["tanner:tipo-documento"],
-            "razon_social_cliente":  alfrescoDoc.Entry.Properties["tanner:razon-social-cliente"],
-            "rut_cliente":           alfrescoDoc.Entry.Properties["tanner:rut-cliente"],
-            "estado_vigencia":       alfrescoDoc.Entry.Properties["tanner:estado-vigencia"],
-            "fecha_carga":           alfrescoDoc.Entry.Properties["tanner:fecha-carga"],
-            // Otros campos según necesidad
-        },
-    }
-
-    // 4. Insertar el documento en MongoDB
-    if err := InsertarDocumento(mongoDoc); err != nil {
-        log.Error("Fallo al guardar en MongoDB",
-            map[string]interface{}{
-                "error":     err.Error(),
-                "doc_id":    alfrescoDoc.Entry.ID,
-            })
-        return err
-    }
-
-    // 5. Registrar éxito de la operación
-    log.Info("Documento persistido exitosamente",
-        map[string]interface{}{
-            "id":        mongoDoc.ID,
-            "tamaño_mb": alfrescoDoc.Entry.Content.SizeInBytes / (1024 * 1024),
-        })
+// Guardar inserta el registro indexado de un documento.
+func (r *RepositorioIndiceMongo) Guardar(ctx context.Context, registro documentos.RegistroIndice) error {
+    ctx, cancel := contextoOperacion(ctx)
+    defer cancel()
 
+    dto := dtoDesdeRegistro(registro)
+    if err := protegerDTO(&dto, r.cifrador); err != nil {
+        return fmt.Errorf("error al cifrar documento: %v", err)
+    }
+    if _, err := r.coleccion.InsertOne(ctx, dto); err != nil {
+        return fmt.Errorf("error al guardar documento: %v", err)
+    }
     return nil
 }
 
-// BuscarDocumento busca un documento en MongoDB usando un filtro.
-// Parámetros:
-//   - filtro: Mapa con criterios de búsqueda (ej: {"metadatos.rut_cliente": "12345678-9"}).
-// Retorna:
-//   - idFile: ID del documento encontrado en Alfresco.
-//   - error: Mensaje en caso de fallo.
-func BuscarDocumento(filtro map[string]interface{}) (string, error) {
-    // 1. Obtener conexión a MongoDB
-    client, err := ConexionDB()
+// Buscar retorna el ID del primer documento no eliminado que cumple el filtro.
+func (r *RepositorioIndiceMongo) Buscar(ctx context.Context, filtro documentos.FiltroIndice) (string, error) {
+    ctx, cancel := contextoOperacion(ctx)
+    defer cancel()
+
+    // 1. Ejecutar la consulta excluyendo documentos eliminados
+    var resultado DocumentoMongoDTO
+    err := r.coleccion.FindOne(ctx, consultaDesdeFiltro(filtro, r.cifrador)).Decode(&resultado)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return "", documentos.ErrRegistroNoEncontrado
+    }
     if err != nil {
-        return "", fmt.Errorf("error de conexión: %v", err)
+        return "", fmt.Errorf("error al buscar documento: %v", err)
     }
 
-    // 2. Seleccionar la colección desde la configuración
-    collection := client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollection)
+    // 2. Validar que el ID del documento sea válido
+    if resultado.ID == "" {
+        return "", fmt.Errorf("ID de archivo inválido en MongoDB")
+    }
+    return resultado.ID, nil
+}
 
-    // 3. Configurar contexto con timeout para la operación de búsqueda
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// Obtener recupera el registro indexado de un documento por su ID de Alfresco.
+func (r *RepositorioIndiceMongo) Obtener(ctx context.Context, idFile string) (*documentos.RegistroIndice, error) {
+    ctx, cancel := contextoOperacion(ctx)
     defer cancel()
 
-    // 4. Ejecutar la consulta usando el filtro proporcionado
     var resultado DocumentoMongoDTO
-    err = collection.FindOne(ctx, filtro).Decode(&resultado)
+    err := r.coleccion.FindOne(ctx, bson.M{"id_archivo": idFile}).Decode(&resultado)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, documentos.ErrRegistroNoEncontrado
+    }
     if err != nil {
-        return "", fmt.Errorf("documento no encontrado: %v", err)
+        return nil, fmt.Errorf("error al buscar documento: %v", err)
+    }
+    if err := revelarDTO(&resultado, r.cifrador); err != nil {
+        return nil, fmt.Errorf("error al descifrar documento %s: %v", idFile, err)
     }
+    registro := resultado.Registro()
+    return &registro, nil
+}
 
-    // 5. Validar que el ID del documento sea válido
-    if resultado.ID == "" {
-        return "", fmt.Errorf("ID de archivo inválido en MongoDB")
+// MarcarEliminado registra la eliminación lógica de un documento.
+func (r *RepositorioIndiceMongo) MarcarEliminado(ctx context.Context, idFile string, estadoVigencia string) error {
+    ctx, cancel := contextoOperacion(ctx)
+    defer cancel()
+
+    actualizacion := bson.M{"$set": bson.M{
+        "eliminado":                 true,
+        "fecha_eliminacion":         time.Now().UTC().Format(time.RFC3339),
+        "metadatos.estado_vigencia": estadoVigencia,
+    }}
+    resultado, err := r.coleccion.UpdateOne(ctx, bson.M{"id_archivo": idFile}, actualizacion)
+    if err != nil {
+        return fmt.Errorf("error al marcar documento eliminado: %v", err)
     }
+    if resultado.MatchedCount == 0 {
+        return documentos.ErrRegistroNoEncontrado
+    }
+    return nil
+}
 
-    return resultado.ID, nil
-}
\ No newline at end of file
+// Eliminar borra definitivamente el registro indexado de un documento.
+func (r *RepositorioIndiceMongo) Eliminar(ctx context.Context, idFile string) error {
+    ctx, cancel := contextoOperacion(ctx)
+    defer cancel()
+
+    resultado, err := r.coleccion.DeleteOne(ctx, bson.M{"id_archivo": idFile})
+    if err != nil {
+        return fmt.Errorf("error al eliminar registro: %v", err)
+    }
+    if resultado.DeletedCount == 0 {
+        return documentos.ErrRegistroNoEncontrado
+    }
+    return nil
+}
+
+// consultaDesdeFiltro traduce el filtro del dominio a los campos de metadatos almacenados.
+// Con cifrador, los campos cifrados se comparan por su índice ciego o por su valor en claro, para seguir
+// encontrando los documentos cargados antes de habilitar el cifrado que aún no pasan por --recifrar.
+func consultaDesdeFiltro(filtro documentos.FiltroIndice, cifrador *cifrado.Cifrador) bson.M {
+    consulta := bson.M{"eliminado": bson.M{"$ne": true}}
+    campos := map[string]string{
+        "metadatos.rut_cliente":          filtro.RUTCliente,
+        "metadatos.tipo_documento":       filtro.TipoDocumento,
+        "metadatos.nombre_documento":     filtro.NombreDocumento,
+        "metadatos.fecha_carga":          filtro.FechaCarga,
+        "metadatos.razon_social_cliente": filtro.RazonSocialCliente,
+        "metadatos.estado_vigencia":      filtro.EstadoVigencia,
+    }
+    for campo, valor := range campos {
+        if valor != "" {
+            consulta[campo] = valor
+        }
+    }
+    if cifrador == nil {
+        return consulta
+    }
+    condiciones := bson.A{}
+    for _, campo := range camposCifrados {
+        if valor, existe := consulta["metadatos."+campo]; existe {
+            delete(consulta, "metadatos."+campo)
+            condiciones = append(condiciones, condicionCifrada(cifrador, campo, "metadatos."+campo, "indices_ciegos."+campo, valor.(string)))
+        }
+    }
+    if len(condiciones) > 0 {
+        consulta["$and"] = condiciones
+    }
+    return consulta
+}
+
+// contextoOperacion agrega el timeout por defecto si el contexto no tiene plazo.
+func contextoOperacion(ctx context.Context) (context.Context, context.CancelFunc) {
+    if _, ok := ctx.Deadline(); ok {
+        return ctx, func() {}
+    }
+    return context.WithTimeout(ctx, timeoutOperacion)
+}
@@ -0,0 +1,64 @@
+package mongo
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+)
+
+// BloqueoMongo implementa un bloqueo con expiración para elegir una única réplica líder.
+// Cada bloqueo es un documento identificado por su nombre en la colección de bloqueos;
+// la réplica que lo posee debe renovarlo antes de que expire.
+type BloqueoMongo struct {
+    coleccion   *mongo.Collection // Colección de bloqueos
+    nombre      string            // Nombre del bloqueo (ej. "programador-vigencia")
+    propietario string            // Identificador único de esta réplica
+}
+
+// NuevoBloqueo crea un bloqueo con el nombre y propietario indicados.
+func NuevoBloqueo(client *mongo.Client, cfg *config.Config, nombre, propietario string) *BloqueoMongo {
+    return &BloqueoMongo{
+        coleccion:   client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollectionBloqueos),
+        nombre:      nombre,
+        propietario: propietario,
+    }
+}
+
+// Adquirir intenta tomar o renovar el bloqueo durante ttl.
+// Retorna true si esta réplica es la propietaria al finalizar la operación.
+func (b *BloqueoMongo) Adquirir(ctx context.Context, ttl time.Duration) (bool, error) {
+
+    // 1. Solo se puede tomar un bloqueo expirado o propio
+    ahora := time.Now().UTC()
+    filtro := bson.M{
+        "_id": b.nombre,
+        "$or": []bson.M{
+            {"expira": bson.M{"$lt": ahora}},
+            {"propietario": b.propietario},
+        },
+    }
+    actualizacion := bson.M{"$set": bson.M{
+        "propietario": b.propietario,
+        "expira":      ahora.Add(ttl),
+    }}
+
+    // 2. El upsert falla por clave duplicada si otra réplica posee un bloqueo vigente
+    _, err := b.coleccion.UpdateOne(ctx, filtro, actualizacion, options.Update().SetUpsert(true))
+    if mongo.IsDuplicateKeyError(err) {
+        return false, nil
+    }
+    if err != nil {
+        return false, fmt.Errorf("error al adquirir bloqueo %s: %v", b.nombre, err)
+    }
+    return true, nil
+}
+
+// Liberar suelta el bloqueo si pertenece a esta réplica.
+func (b *BloqueoMongo) Liberar(ctx context.Context) error {
+    _, err := b.coleccion.DeleteOne(ctx, bson.M{"_id": b.nombre, "propietario": b.propietario})
+    return err
+}
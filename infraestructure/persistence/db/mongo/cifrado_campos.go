@@ -0,0 +1,90 @@
+package mongo
+
+import (
+    "errors"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "go.mongodb.org/mongo-driver/bson"
+)
+
+// camposCifrados son los metadatos con datos personales que se cifran en el índice cuando hay claves configuradas.
+var camposCifrados = []string{campoRUT, "razon_social_cliente"}
+
+// campoRUT es el campo cifrado que comparten el índice, la auditoría y el outbox; usar el mismo nombre en
+// todos permite filtrar la auditoría con el mismo índice ciego que el índice de documentos.
+const campoRUT = "rut_cliente"
+
+// errSinClaves indica que MongoDB tiene valores cifrados pero la aplicación no tiene claves configuradas.
+var errSinClaves = errors.New("MongoDB contiene datos cifrados y CIFRADO_CLAVES no está configurada")
+
+// protegerDTO cifra los campos sensibles del DTO y registra sus índices ciegos. Sin cifrador el DTO no cambia.
+func protegerDTO(dto *DocumentoMongoDTO, cifrador *cifrado.Cifrador) error {
+    if cifrador == nil {
+        return nil
+    }
+    if dto.Metadatos == nil {
+        dto.Metadatos = make(map[string]interface{}, len(camposCifrados))
+    }
+    dto.IndicesCiegos = make(map[string]string, len(camposCifrados))
+    for _, campo := range camposCifrados {
+        texto := dto.MetadatoTexto(campo)
+        valor, err := cifrador.Cifrar(campo, texto)
+        if err != nil {
+            return err
+        }
+        dto.Metadatos[campo] = valor
+        if indice := cifrador.IndiceCiego(campo, texto); indice != "" {
+            dto.IndicesCiegos[campo] = indice
+        }
+    }
+    return nil
+}
+
+// revelarDTO descifra los campos sensibles del DTO. Los valores en claro (registros anteriores a habilitar
+// el cifrado o aún no recifrados) se conservan tal cual.
+func revelarDTO(dto *DocumentoMongoDTO, cifrador *cifrado.Cifrador) error {
+    for _, campo := range camposCifrados {
+        valor := dto.MetadatoTexto(campo)
+        if !cifrado.EsCifrado(valor) {
+            continue
+        }
+        texto, err := descifrarCampo(cifrador, campo, valor)
+        if err != nil {
+            return err
+        }
+        dto.Metadatos[campo] = texto
+    }
+    return nil
+}
+
+// cifrarCampo cifra un valor guardado fuera del índice de documentos (auditoría, outbox) y calcula su índice ciego.
+// Sin cifrador el valor se retorna en claro y sin índice.
+func cifrarCampo(cifrador *cifrado.Cifrador, campo, texto string) (string, string, error) {
+    if cifrador == nil {
+        return texto, "", nil
+    }
+    valor, err := cifrador.Cifrar(campo, texto)
+    if err != nil {
+        return "", "", err
+    }
+    return valor, cifrador.IndiceCiego(campo, texto), nil
+}
+
+// descifrarCampo retorna el texto de un valor cifrado, o el valor tal cual si está en claro.
+func descifrarCampo(cifrador *cifrado.Cifrador, campo, valor string) (string, error) {
+    if !cifrado.EsCifrado(valor) {
+        return valor, nil
+    }
+    if cifrador == nil {
+        return "", errSinClaves
+    }
+    return cifrador.Descifrar(campo, valor)
+}
+
+// condicionCifrada compara un campo cifrado por su índice ciego y, mientras queden registros sin recifrar,
+// también por su valor en claro. Un valor cifrado nunca coincide con el texto, así que no hay falsos positivos.
+func condicionCifrada(cifrador *cifrado.Cifrador, campo, ruta, rutaIndice, texto string) bson.M {
+    return bson.M{"$or": bson.A{
+        bson.M{rutaIndice: cifrador.IndiceCiego(campo, texto)},
+        bson.M{ruta: texto},
+    }}
+}
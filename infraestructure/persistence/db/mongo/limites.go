@@ -0,0 +1,128 @@
+package mongo
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/application/limites"
+)
+
+// retencionConsumos es cuánto se conservan los contadores diarios de cuota antes de que el índice TTL los elimine.
+const retencionConsumos = 48 * time.Hour
+
+// LimitesMongo implementa limites.Almacen en MongoDB, compartiendo buckets y cuotas entre réplicas.
+// Cada bucket y cada contador diario es un documento identificado por su clave; el campo expira
+// permite que un índice TTL descarte los que ya no se usan.
+type LimitesMongo struct {
+    coleccion *mongo.Collection
+}
+
+// bucketMongo es el resultado de consumir un token en un bucket.
+type bucketMongo struct {
+    Disponibles float64 `bson:"disponibles"` // Tokens antes de consumir
+}
+
+// consumoMongo es un contador diario de bytes.
+type consumoMongo struct {
+    Bytes int64 `bson:"bytes"`
+}
+
+// NuevoLimitesMongo crea el almacén sobre la colección de límites.
+func NuevoLimitesMongo(client *mongo.Client, cfg *config.Config) *LimitesMongo {
+    return &LimitesMongo{coleccion: client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollectionLimites)}
+}
+
+// CrearIndices crea el índice TTL que elimina los buckets llenos y los contadores de días anteriores.
+func (l *LimitesMongo) CrearIndices(ctx context.Context) error {
+    _, err := l.coleccion.Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys:    bson.D{{Key: "expira", Value: 1}},
+        Options: options.Index().SetExpireAfterSeconds(0),
+    })
+    if err != nil {
+        return fmt.Errorf("error al crear índice de límites: %v", err)
+    }
+    return nil
+}
+
+// Tomar repone y consume un token en una sola operación atómica, para que dos réplicas no gasten el mismo token.
+func (l *LimitesMongo) Tomar(ctx context.Context, clave string, bucket limites.Bucket, ahora time.Time) (limites.Decision, error) {
+    ctx, cancel := contextoOperacion(ctx)
+    defer cancel()
+
+    // 1. Reposición: segundos desde la última actualización por la tasa, sin superar la capacidad.
+    //    Un bucket nuevo parte lleno; un reloj atrasado de otra réplica no resta tokens.
+    ahora = ahora.UTC()
+    capacidad := float64(bucket.Capacidad)
+    transcurrido := bson.M{"$max": bson.A{0, bson.M{"$divide": bson.A{
+        bson.M{"$subtract": bson.A{ahora, bson.M{"$ifNull": bson.A{"$actualizado", ahora}}}}, 1000,
+    }}}}
+    reponer := bson.M{"disponibles": bson.M{"$min": bson.A{capacidad, bson.M{"$add": bson.A{
+        bson.M{"$ifNull": bson.A{"$tokens", capacidad}},
+        bson.M{"$multiply": bson.A{transcurrido, bucket.Tasa}},
+    }}}}}
+
+    // 2. Consumo: se descuenta un token solo si hay uno completo
+    consumir := bson.M{
+        "tokens": bson.M{"$cond": bson.A{
+            bson.M{"$gte": bson.A{"$disponibles", 1}},
+            bson.M{"$subtract": bson.A{"$disponibles", 1}},
+            "$disponibles",
+        }},
+        "actualizado": ahora,
+        "expira":      ahora.Add(tiempoLlenado(bucket)),
+    }
+
+    // 3. Upsert con pipeline: el documento retornado trae los tokens disponibles antes de consumir
+    pipeline := mongo.Pipeline{{{Key: "$set", Value: reponer}}, {{Key: "$set", Value: consumir}}}
+    opciones := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+    var resultado bucketMongo
+    err := l.coleccion.FindOneAndUpdate(ctx, bson.M{"_id": clave}, pipeline, opciones).Decode(&resultado)
+    if err != nil {
+        return limites.Decision{}, fmt.Errorf("error al consumir token de %s: %v", clave, err)
+    }
+    return limites.Decidir(resultado.Disponibles, bucket), nil
+}
+
+// ConsumoDiario retorna los bytes acumulados de la clave en el día indicado.
+func (l *LimitesMongo) ConsumoDiario(ctx context.Context, clave, dia string) (int64, error) {
+    ctx, cancel := contextoOperacion(ctx)
+    defer cancel()
+
+    var consumo consumoMongo
+    err := l.coleccion.FindOne(ctx, bson.M{"_id": clave + "|" + dia}).Decode(&consumo)
+    if err == mongo.ErrNoDocuments {
+        return 0, nil
+    }
+    if err != nil {
+        return 0, fmt.Errorf("error al leer consumo de %s: %v", clave, err)
+    }
+    return consumo.Bytes, nil
+}
+
+// SumarConsumo acumula bytes en el día indicado.
+func (l *LimitesMongo) SumarConsumo(ctx context.Context, clave, dia string, bytes int64) error {
+    ctx, cancel := contextoOperacion(ctx)
+    defer cancel()
+
+    actualizacion := bson.M{
+        "$inc":         bson.M{"bytes": bytes},
+        "$setOnInsert": bson.M{"expira": time.Now().UTC().Add(retencionConsumos)},
+    }
+    _, err := l.coleccion.UpdateOne(ctx, bson.M{"_id": clave + "|" + dia}, actualizacion, options.Update().SetUpsert(true))
+    if err != nil {
+        return fmt.Errorf("error al registrar consumo de %s: %v", clave, err)
+    }
+    return nil
+}
+
+// tiempoLlenado retorna cuánto tarda un bucket vacío en llenarse; desde entonces su documento equivale a uno nuevo.
+func tiempoLlenado(bucket limites.Bucket) time.Duration {
+    if bucket.Tasa <= 0 {
+        return 24 * time.Hour
+    }
+    return time.Duration(float64(bucket.Capacidad)/bucket.Tasa*float64(time.Second)) + time.Minute
+}
@@ -0,0 +1,20 @@
+package mongo
+
+import (
+    "context"
+    "github.com/CamiloScript/REGAPIGO/shared/metricas"
+    "go.mongodb.org/mongo-driver/event"
+)
+
+// monitorMetricas registra la duración de cada comando enviado a MongoDB (find, insert, update, aggregate, ...).
+// Al observar los comandos del driver se miden todos los repositorios sin instrumentarlos uno por uno.
+func monitorMetricas() *event.CommandMonitor {
+    return &event.CommandMonitor{
+        Succeeded: func(_ context.Context, evento *event.CommandSucceededEvent) {
+            metricas.ObservarMongo(evento.CommandName, evento.Duration, true)
+        },
+        Failed: func(_ context.Context, evento *event.CommandFailedEvent) {
+            metricas.ObservarMongo(evento.CommandName, evento.Duration, false)
+        },
+    }
+}
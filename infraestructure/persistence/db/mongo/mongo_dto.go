@@ -1,5 +1,10 @@
 package mongo
 
+import (
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+)
+
 // DocumentoMongoDTO define la estructura personalizada para almacenar documentos en MongoDB.
 // Esta estructura mapea los datos del documento a los campos correspondientes en la base de datos.
 type DocumentoMongoDTO struct {
@@ -8,4 +13,60 @@ type DocumentoMongoDTO struct {
     TipoArchivo   string                 `bson:"tipo_archivo"`    // Tipo de archivo, por ejemplo: "application/pdf"
     FechaCarga    string                 `bson:"fecha_carga"`     // Fecha en que se cargó el archivo
     Metadatos     map[string]interface{} `bson:"metadatos"`       // Metadatos adicionales del documento
-}
\ No newline at end of file
+    Eliminado     bool                   `bson:"eliminado,omitempty"`         // Indica si el documento fue eliminado lógicamente
+    FechaEliminacion string              `bson:"fecha_eliminacion,omitempty"` // Fecha de la eliminación lógica
+    IndicesCiegos map[string]string      `bson:"indices_ciegos,omitempty"`    // HMAC de los metadatos cifrados, para buscarlos por igualdad
+    FalloVigencia *FalloVigenciaDTO      `bson:"fallo_vigencia,omitempty"`    // Último fallo del programador de vigencia, si lo hubo
+}
+
+// FalloVigenciaDTO es el fallo del programador de vigencia guardado en el documento indexado.
+type FalloVigenciaDTO struct {
+    Intentos       int       `bson:"intentos"`        // Ejecuciones que fallaron
+    ProximoIntento time.Time `bson:"proximo_intento"` // Instante desde el que vuelve a ser candidato
+    Error          string    `bson:"error"`           // Causa del último fallo
+}
+
+// MetadatoTexto retorna el metadato indicado como string, o vacío si no existe o no es texto.
+func (d DocumentoMongoDTO) MetadatoTexto(clave string) string {
+    valor, _ := d.Metadatos[clave].(string)
+    return valor
+}
+
+// Registro convierte el DTO almacenado al registro del dominio.
+func (d DocumentoMongoDTO) Registro() documentos.RegistroIndice {
+    return documentos.RegistroIndice{
+        ID:                   d.ID,
+        NombreArchivo:        d.NombreArchivo,
+        TipoArchivo:          d.TipoArchivo,
+        FechaCarga:           d.FechaCarga,
+        NombreDocumento:      d.MetadatoTexto("nombre_documento"),
+        TipoDocumento:        d.MetadatoTexto("tipo_documento"),
+        RazonSocialCliente:   d.MetadatoTexto("razon_social_cliente"),
+        RUTCliente:           d.MetadatoTexto("rut_cliente"),
+        EstadoVigencia:       d.MetadatoTexto("estado_vigencia"),
+        FechaTerminoVigencia: d.MetadatoTexto("fecha_termino_vigencia"),
+        Eliminado:            d.Eliminado,
+        FechaEliminacion:     d.FechaEliminacion,
+    }
+}
+
+// dtoDesdeRegistro convierte un registro del dominio al formato almacenado en MongoDB.
+func dtoDesdeRegistro(registro documentos.RegistroIndice) DocumentoMongoDTO {
+    return DocumentoMongoDTO{
+        ID:            registro.ID,
+        NombreArchivo: registro.NombreArchivo,
+        TipoArchivo:   registro.TipoArchivo,
+        FechaCarga:    registro.FechaCarga,
+        Metadatos: map[string]interface{}{
+            "nombre_documento":       registro.NombreDocumento,
+            "tipo_documento":         registro.TipoDocumento,
+            "razon_social_cliente":   registro.RazonSocialCliente,
+            "rut_cliente":            registro.RUTCliente,
+            "estado_vigencia":        registro.EstadoVigencia,
+            "fecha_carga":            registro.FechaCarga,
+            "fecha_termino_vigencia": registro.FechaTerminoVigencia,
+        },
+        Eliminado:        registro.Eliminado,
+        FechaEliminacion: registro.FechaEliminacion,
+    }
+}
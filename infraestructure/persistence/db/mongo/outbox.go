@@ -0,0 +1,110 @@
+package mongo
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/domain/eventos"
+)
+
+// Estados de un evento en el outbox.
+const (
+    estadoOutboxPendiente = "PENDIENTE"
+    estadoOutboxPublicado = "PUBLICADO"
+)
+
+// registroOutbox es la representación en MongoDB de un evento del outbox.
+type registroOutbox struct {
+    ID          string         `bson:"_id"`                    // ID del evento
+    Evento      eventos.Evento `bson:"evento"`                 // Evento completo
+    Estado      string         `bson:"estado"`                 // PENDIENTE o PUBLICADO
+    Intentos    int            `bson:"intentos"`               // Intentos fallidos de publicación
+    UltimoError string         `bson:"ultimo_error,omitempty"` // Último error de publicación
+    CreadoEn    time.Time      `bson:"creado_en"`              // Fecha de inserción
+    PublicadoEn *time.Time     `bson:"publicado_en,omitempty"` // Fecha de publicación
+}
+
+// OutboxMongo implementa eventos.EventPublisher escribiendo en una colección outbox,
+// y la interfaz de lectura que usa el relay para drenarla.
+// Con un cifrador, el RUT del evento se guarda cifrado y se descifra al entregarlo al relay.
+type OutboxMongo struct {
+    coleccion *mongo.Collection // Colección del outbox
+    cifrador  *cifrado.Cifrador // Cifrado del RUT; nil lo guarda en claro
+}
+
+// NuevoOutbox crea el outbox de eventos.
+func NuevoOutbox(client *mongo.Client, cfg *config.Config) *OutboxMongo {
+    return &OutboxMongo{coleccion: client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollectionOutbox)}
+}
+
+// EstablecerCifrador activa el cifrado del RUT en los eventos nuevos.
+func (o *OutboxMongo) EstablecerCifrador(cifrador *cifrado.Cifrador) {
+    o.cifrador = cifrador
+}
+
+// Publicar inserta el evento como pendiente.
+func (o *OutboxMongo) Publicar(ctx context.Context, evento eventos.Evento) error {
+    rut, _, err := cifrarCampo(o.cifrador, campoRUT, evento.Data.RUTCliente)
+    if err != nil {
+        return fmt.Errorf("error al cifrar evento %s: %v", evento.ID, err)
+    }
+    evento.Data.RUTCliente = rut
+
+    _, err = o.coleccion.InsertOne(ctx, registroOutbox{
+        ID:       evento.ID,
+        Evento:   evento,
+        Estado:   estadoOutboxPendiente,
+        CreadoEn: time.Now().UTC(),
+    })
+    if err != nil {
+        return fmt.Errorf("error al insertar evento en outbox: %v", err)
+    }
+    return nil
+}
+
+// Pendientes retorna los eventos no publicados en orden de creación.
+func (o *OutboxMongo) Pendientes(ctx context.Context, limite int) ([]eventos.Evento, error) {
+    opciones := options.Find().SetSort(bson.D{{Key: "creado_en", Value: 1}}).SetLimit(int64(limite))
+    cursor, err := o.coleccion.Find(ctx, bson.M{"estado": estadoOutboxPendiente}, opciones)
+    if err != nil {
+        return nil, fmt.Errorf("error al leer outbox: %v", err)
+    }
+    var registros []registroOutbox
+    if err := cursor.All(ctx, &registros); err != nil {
+        return nil, fmt.Errorf("error al decodificar outbox: %v", err)
+    }
+
+    pendientes := make([]eventos.Evento, 0, len(registros))
+    for _, registro := range registros {
+        rut, err := descifrarCampo(o.cifrador, campoRUT, registro.Evento.Data.RUTCliente)
+        if err != nil {
+            return nil, fmt.Errorf("error al descifrar evento %s: %v", registro.ID, err)
+        }
+        registro.Evento.Data.RUTCliente = rut
+        pendientes = append(pendientes, registro.Evento)
+    }
+    return pendientes, nil
+}
+
+// MarcarPublicado marca un evento como entregado al destino.
+func (o *OutboxMongo) MarcarPublicado(ctx context.Context, id string) error {
+    _, err := o.coleccion.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+        "estado":       estadoOutboxPublicado,
+        "publicado_en": time.Now().UTC(),
+    }})
+    return err
+}
+
+// RegistrarFallo incrementa los intentos de un evento y guarda el error.
+func (o *OutboxMongo) RegistrarFallo(ctx context.Context, id string, causa error) error {
+    _, err := o.coleccion.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+        "$inc": bson.M{"intentos": 1},
+        "$set": bson.M{"ultimo_error": causa.Error()},
+    })
+    return err
+}
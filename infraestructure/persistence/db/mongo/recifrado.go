@@ -0,0 +1,208 @@
+package mongo
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+)
+
+// errSinCifrador indica que se pidió recifrar sin claves configuradas.
+var errSinCifrador = errors.New("el cifrado no está configurado (CIFRADO_CLAVES)")
+
+// ResultadoRecifrado resume una ejecución de Recifrar.
+type ResultadoRecifrado struct {
+    Revisados  int      // Registros leídos
+    Recifrados int      // Registros actualizados con la clave activa
+    Fallidos   []string // ID de los registros que no pudieron descifrarse o actualizarse
+}
+
+// documentoRecifrado es un registro del índice con su _id, para actualizarlo sin depender de id_archivo.
+type documentoRecifrado struct {
+    IDMongo           interface{} `bson:"_id"`
+    DocumentoMongoDTO `bson:",inline"`
+}
+
+// Recifrar vuelve a cifrar con la clave activa los datos sensibles de todos los registros del índice:
+// los cifrados con claves anteriores, los guardados en claro antes de habilitar el cifrado y los cuyo índice
+// ciego no corresponde a la clave de índice vigente. Los registros ya vigentes no se modifican, por lo que
+// puede ejecutarse de nuevo tras una interrupción. Un registro que no puede descifrarse (ej. su clave ya no
+// está configurada) se informa en Fallidos y no detiene el resto.
+// Parámetros:
+//   - ctx: Contexto que acota el recorrido completo.
+// Retorna el resumen, o un error si no hay cifrador o la colección no puede recorrerse.
+func (r *RepositorioIndiceMongo) Recifrar(ctx context.Context) (ResultadoRecifrado, error) {
+    var resultado ResultadoRecifrado
+    if r.cifrador == nil {
+        return resultado, errSinCifrador
+    }
+
+    cursor, err := r.coleccion.Find(ctx, bson.M{})
+    if err != nil {
+        return resultado, fmt.Errorf("error al recorrer el índice: %v", err)
+    }
+    defer cursor.Close(ctx)
+
+    for cursor.Next(ctx) {
+        resultado.Revisados++
+        var documento documentoRecifrado
+        if err := cursor.Decode(&documento); err != nil {
+            resultado.Fallidos = append(resultado.Fallidos, fmt.Sprint(documento.IDMongo))
+            continue
+        }
+
+        // 1. Descifrar con la clave con que se guardó y cifrar con la activa, solo si algo no está vigente
+        anterior := documento.DocumentoMongoDTO
+        anterior.Metadatos = copiarMetadatos(documento.Metadatos)
+        if err := revelarDTO(&documento.DocumentoMongoDTO, r.cifrador); err != nil {
+            resultado.Fallidos = append(resultado.Fallidos, documento.ID)
+            continue
+        }
+        if r.vigente(anterior, documento.DocumentoMongoDTO) {
+            continue
+        }
+        if err := protegerDTO(&documento.DocumentoMongoDTO, r.cifrador); err != nil {
+            resultado.Fallidos = append(resultado.Fallidos, documento.ID)
+            continue
+        }
+
+        // 2. Actualizar solo si los campos no cambiaron desde la lectura
+        filtro := bson.M{"_id": documento.IDMongo}
+        cambios := bson.M{"indices_ciegos": documento.IndicesCiegos}
+        for _, campo := range camposCifrados {
+            filtro["metadatos."+campo] = anterior.Metadatos[campo]
+            cambios["metadatos."+campo] = documento.Metadatos[campo]
+        }
+        ctxOperacion, cancel := contextoOperacion(ctx)
+        actualizacion, err := r.coleccion.UpdateOne(ctxOperacion, filtro, bson.M{"$set": cambios})
+        cancel()
+        if err != nil || actualizacion.MatchedCount == 0 {
+            resultado.Fallidos = append(resultado.Fallidos, documento.ID)
+            continue
+        }
+        resultado.Recifrados++
+    }
+    if err := cursor.Err(); err != nil {
+        return resultado, fmt.Errorf("error al recorrer el índice: %v", err)
+    }
+    return resultado, nil
+}
+
+// Recifrar vuelve a cifrar con la clave activa el RUT de los registros de auditoría y recalcula su índice ciego,
+// con las mismas garantías que RepositorioIndiceMongo.Recifrar. El hash de cada registro se calculó sobre el RUT
+// en claro, por lo que la cadena sigue verificando.
+func (r *RepositorioAuditoriaMongo) Recifrar(ctx context.Context) (ResultadoRecifrado, error) {
+    return recifrarCampo(ctx, r.coleccion, r.cifrador, campoRUT, "rut_cliente", "indices_ciegos."+campoRUT)
+}
+
+// Recifrar vuelve a cifrar con la clave activa el RUT de los eventos del outbox, publicados o no,
+// con las mismas garantías que RepositorioIndiceMongo.Recifrar.
+func (o *OutboxMongo) Recifrar(ctx context.Context) (ResultadoRecifrado, error) {
+    return recifrarCampo(ctx, o.coleccion, o.cifrador, campoRUT, "evento.data.rut_cliente", "")
+}
+
+// Recifrar vuelve a cifrar con la clave activa el secreto de las suscripciones, incluidas las guardadas en claro
+// antes de habilitar el cifrado, con las mismas garantías que RepositorioIndiceMongo.Recifrar.
+func (r *RepositorioSuscripcionesMongo) Recifrar(ctx context.Context) (ResultadoRecifrado, error) {
+    return recifrarCampo(ctx, r.coleccion, r.cifrador, campoSecreto, "secreto", "")
+}
+
+// recifrarCampo vuelve a cifrar con la clave activa el valor guardado en ruta en cada documento de la colección.
+// Parámetros:
+//   - ctx: Contexto que acota el recorrido completo.
+//   - coleccion: Colección a recorrer.
+//   - cifrador: Cifrador con la clave activa y las anteriores.
+//   - campo: Campo lógico con que se cifra el valor (ej. rut_cliente).
+//   - ruta: Ruta del valor en el documento, en notación de puntos.
+//   - rutaIndice: Ruta de su índice ciego, o vacío si la colección no se filtra por RUT.
+// Retorna el resumen, o un error si no hay cifrador o la colección no puede recorrerse.
+func recifrarCampo(ctx context.Context, coleccion *mongo.Collection, cifrador *cifrado.Cifrador, campo, ruta, rutaIndice string) (ResultadoRecifrado, error) {
+    var resultado ResultadoRecifrado
+    if cifrador == nil {
+        return resultado, errSinCifrador
+    }
+
+    cursor, err := coleccion.Find(ctx, bson.M{})
+    if err != nil {
+        return resultado, fmt.Errorf("error al recorrer %s: %v", coleccion.Name(), err)
+    }
+    defer cursor.Close(ctx)
+
+    for cursor.Next(ctx) {
+        resultado.Revisados++
+        id := cursor.Current.Lookup("_id")
+        almacenado, _ := cursor.Current.Lookup(strings.Split(ruta, ".")...).StringValueOK()
+        indiceAlmacenado := ""
+        if rutaIndice != "" {
+            indiceAlmacenado, _ = cursor.Current.Lookup(strings.Split(rutaIndice, ".")...).StringValueOK()
+        }
+
+        // 1. Descifrar con la clave con que se guardó y cifrar con la activa, solo si el valor o su índice no están vigentes
+        texto, err := descifrarCampo(cifrador, campo, almacenado)
+        if err != nil {
+            resultado.Fallidos = append(resultado.Fallidos, textoID(id))
+            continue
+        }
+        indice := cifrador.IndiceCiego(campo, texto)
+        if cifrador.Vigente(almacenado) && (rutaIndice == "" || indiceAlmacenado == indice) {
+            continue
+        }
+        valor, err := cifrador.Cifrar(campo, texto)
+        if err != nil {
+            resultado.Fallidos = append(resultado.Fallidos, textoID(id))
+            continue
+        }
+
+        // 2. Actualizar solo si el valor no cambió desde la lectura
+        cambios := bson.M{ruta: valor}
+        if rutaIndice != "" {
+            cambios[rutaIndice] = indice
+        }
+        ctxOperacion, cancel := contextoOperacion(ctx)
+        actualizacion, err := coleccion.UpdateOne(ctxOperacion, bson.M{"_id": id, ruta: almacenado}, bson.M{"$set": cambios})
+        cancel()
+        if err != nil || actualizacion.MatchedCount == 0 {
+            resultado.Fallidos = append(resultado.Fallidos, textoID(id))
+            continue
+        }
+        resultado.Recifrados++
+    }
+    if err := cursor.Err(); err != nil {
+        return resultado, fmt.Errorf("error al recorrer %s: %v", coleccion.Name(), err)
+    }
+    return resultado, nil
+}
+
+// textoID retorna el _id de un documento como texto, para informarlo en Fallidos.
+func textoID(id bson.RawValue) string {
+    if texto, ok := id.StringValueOK(); ok {
+        return texto
+    }
+    return id.String()
+}
+
+// vigente indica si el registro almacenado ya está cifrado con la clave activa y con índices ciegos vigentes.
+// Parámetros:
+//   - almacenado: DTO tal como está en MongoDB.
+//   - revelado: El mismo DTO con los campos descifrados.
+func (r *RepositorioIndiceMongo) vigente(almacenado, revelado DocumentoMongoDTO) bool {
+    for _, campo := range camposCifrados {
+        texto := revelado.MetadatoTexto(campo)
+        if !r.cifrador.Vigente(almacenado.MetadatoTexto(campo)) || almacenado.IndicesCiegos[campo] != r.cifrador.IndiceCiego(campo, texto) {
+            return false
+        }
+    }
+    return true
+}
+
+// copiarMetadatos retorna una copia superficial de los metadatos, para conservar los valores leídos.
+func copiarMetadatos(metadatos map[string]interface{}) map[string]interface{} {
+    copia := make(map[string]interface{}, len(metadatos))
+    for clave, valor := range metadatos {
+        copia[clave] = valor
+    }
+    return copia
+}
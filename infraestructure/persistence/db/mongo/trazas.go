@@ -0,0 +1,40 @@
+package mongo
+
+import (
+    "context"
+    "go.mongodb.org/mongo-driver/event"
+    "go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+// monitorComandos combina las trazas y las métricas de cada comando. El driver admite un único monitor, por lo que
+// cada evento se entrega a ambos: primero a las trazas, para que el span quede abierto durante el comando.
+func monitorComandos() *event.CommandMonitor {
+    return combinarMonitores(otelmongo.NewMonitor(), monitorMetricas())
+}
+
+// combinarMonitores entrega cada evento a todos los monitores, en orden.
+func combinarMonitores(monitores ...*event.CommandMonitor) *event.CommandMonitor {
+    return &event.CommandMonitor{
+        Started: func(ctx context.Context, evento *event.CommandStartedEvent) {
+            for _, monitor := range monitores {
+                if monitor.Started != nil {
+                    monitor.Started(ctx, evento)
+                }
+            }
+        },
+        Succeeded: func(ctx context.Context, evento *event.CommandSucceededEvent) {
+            for _, monitor := range monitores {
+                if monitor.Succeeded != nil {
+                    monitor.Succeeded(ctx, evento)
+                }
+            }
+        },
+        Failed: func(ctx context.Context, evento *event.CommandFailedEvent) {
+            for _, monitor := range monitores {
+                if monitor.Failed != nil {
+                    monitor.Failed(ctx, evento)
+                }
+            }
+        },
+    }
+}
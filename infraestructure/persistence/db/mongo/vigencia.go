@@ -0,0 +1,174 @@
+package mongo
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+)
+
+// IndiceVigenciaMongo implementa documento.IndiceVigencia sobre la colección de documentos.
+type IndiceVigenciaMongo struct {
+    coleccion *mongo.Collection // Colección de documentos indexados
+    cifrador  *cifrado.Cifrador // Descifra el RUT y la razón social; nil si el índice no está cifrado
+}
+
+// NuevoIndiceVigencia crea el adaptador de vigencia para MongoDB.
+func NuevoIndiceVigencia(client *mongo.Client, cfg *config.Config) *IndiceVigenciaMongo {
+    return &IndiceVigenciaMongo{coleccion: client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollection)}
+}
+
+// EstablecerCifrador permite leer los datos sensibles cifrados del índice.
+func (i *IndiceVigenciaMongo) EstablecerCifrador(cifrador *cifrado.Cifrador) {
+    i.cifrador = cifrador
+}
+
+// CrearIndices crea el índice sobre la fecha de término de vigencia usado por las consultas de vencimiento.
+func (i *IndiceVigenciaMongo) CrearIndices(ctx context.Context) error {
+    _, err := i.coleccion.Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys:    bson.D{{Key: "metadatos.fecha_termino_vigencia", Value: 1}},
+        Options: options.Index().SetName("idx_fecha_termino_vigencia"),
+    })
+    return err
+}
+
+// BuscarVencidos retorna documentos activos cuya fecha de término de vigencia es anterior al instante indicado.
+// Excluye los que registraron un fallo y esperan un reintento posterior a ese instante.
+// La comparación en MongoDB es lexicográfica sobre fechas ISO 8601; el llamador debe confirmar el vencimiento.
+// Parámetros:
+//   - ctx: Contexto para controlar la operación.
+//   - ahora: Instante de referencia.
+//   - limite: Cantidad máxima de documentos a retornar.
+func (i *IndiceVigenciaMongo) BuscarVencidos(ctx context.Context, ahora time.Time, limite int) ([]documento.DocumentoVencido, error) {
+
+    // 1. Documentos no eliminados, aún no marcados como no vigentes, con fecha de término pasada y sin reintento pendiente
+    filtro := bson.M{
+        "eliminado": bson.M{"$ne": true},
+        "metadatos.estado_vigencia": bson.M{"$nin": []string{documentos.EstadoNoVigente, documentos.EstadoEliminado}},
+        "metadatos.fecha_termino_vigencia": bson.M{"$gt": "", "$lte": ahora.UTC().Format(time.RFC3339)},
+        "fallo_vigencia.proximo_intento": bson.M{"$not": bson.M{"$gt": ahora.UTC()}},
+    }
+    opciones := options.Find().
+        SetSort(bson.D{{Key: "metadatos.fecha_termino_vigencia", Value: 1}}).
+        SetLimit(int64(limite))
+
+    // 2. Ejecutar la consulta
+    cursor, err := i.coleccion.Find(ctx, filtro, opciones)
+    if err != nil {
+        return nil, fmt.Errorf("error al buscar documentos vencidos: %v", err)
+    }
+    defer cursor.Close(ctx)
+
+    // 3. Mapear resultados
+    var vencidos []documento.DocumentoVencido
+    for cursor.Next(ctx) {
+        var registro DocumentoMongoDTO
+        if err := cursor.Decode(&registro); err != nil {
+            return nil, fmt.Errorf("error al decodificar documento: %v", err)
+        }
+        if err := revelarDTO(&registro, i.cifrador); err != nil {
+            return nil, fmt.Errorf("error al descifrar documento %s: %v", registro.ID, err)
+        }
+        vencido := documento.DocumentoVencido{
+            ID:                   registro.ID,
+            RUTCliente:           registro.MetadatoTexto("rut_cliente"),
+            TipoDocumento:        registro.MetadatoTexto("tipo_documento"),
+            FechaTerminoVigencia: registro.MetadatoTexto("fecha_termino_vigencia"),
+        }
+        if registro.FalloVigencia != nil {
+            vencido.Intentos = registro.FalloVigencia.Intentos
+        }
+        vencidos = append(vencidos, vencido)
+    }
+    return vencidos, cursor.Err()
+}
+
+// BuscarPorVencer retorna documentos activos cuya fecha de término de vigencia está entre desde y hasta.
+// La comparación en MongoDB es lexicográfica sobre fechas ISO 8601; el llamador debe confirmar los días restantes.
+func (i *IndiceVigenciaMongo) BuscarPorVencer(ctx context.Context, desde, hasta time.Time) ([]notificacion.DocumentoPorVencer, error) {
+
+    // 1. Las fechas sin hora del mismo día que "desde" siguen vigentes, por eso se compara solo la fecha
+    filtro := bson.M{
+        "eliminado": bson.M{"$ne": true},
+        "metadatos.estado_vigencia": bson.M{"$nin": []string{documentos.EstadoNoVigente, documentos.EstadoEliminado}},
+        "metadatos.fecha_termino_vigencia": bson.M{
+            "$gte": desde.UTC().Format("2006-01-02"),
+            "$lte": hasta.UTC().Format(time.RFC3339),
+        },
+    }
+
+    // 2. Ejecutar la consulta
+    cursor, err := i.coleccion.Find(ctx, filtro)
+    if err != nil {
+        return nil, fmt.Errorf("error al buscar documentos por vencer: %v", err)
+    }
+    defer cursor.Close(ctx)
+
+    // 3. Mapear resultados
+    var porVencer []notificacion.DocumentoPorVencer
+    for cursor.Next(ctx) {
+        var registro DocumentoMongoDTO
+        if err := cursor.Decode(&registro); err != nil {
+            return nil, fmt.Errorf("error al decodificar documento: %v", err)
+        }
+        if err := revelarDTO(&registro, i.cifrador); err != nil {
+            return nil, fmt.Errorf("error al descifrar documento %s: %v", registro.ID, err)
+        }
+        porVencer = append(porVencer, notificacion.DocumentoPorVencer{
+            ID:                   registro.ID,
+            RUTCliente:           registro.MetadatoTexto("rut_cliente"),
+            RazonSocialCliente:   registro.MetadatoTexto("razon_social_cliente"),
+            TipoDocumento:        registro.MetadatoTexto("tipo_documento"),
+            NombreDocumento:      registro.MetadatoTexto("nombre_documento"),
+            FechaTerminoVigencia: registro.MetadatoTexto("fecha_termino_vigencia"),
+        })
+    }
+    return porVencer, cursor.Err()
+}
+
+// ActualizarEstadoVigencia cambia el estado de vigencia indexado de un documento y descarta su último fallo.
+func (i *IndiceVigenciaMongo) ActualizarEstadoVigencia(ctx context.Context, idFile string, estado string) error {
+
+    resultado, err := i.coleccion.UpdateOne(ctx,
+        bson.M{"id_archivo": idFile},
+        bson.M{
+            "$set":   bson.M{"metadatos.estado_vigencia": estado},
+            "$unset": bson.M{"fallo_vigencia": ""},
+        },
+    )
+    if err != nil {
+        return fmt.Errorf("error al actualizar estado de vigencia: %v", err)
+    }
+    if resultado.MatchedCount == 0 {
+        return documentos.ErrRegistroNoEncontrado
+    }
+    return nil
+}
+
+// RegistrarFalloVigencia guarda el fallo del programador en el documento, que queda fuera de BuscarVencidos
+// hasta fallo.ProximoIntento.
+func (i *IndiceVigenciaMongo) RegistrarFalloVigencia(ctx context.Context, idFile string, fallo documento.FalloVigencia) error {
+
+    resultado, err := i.coleccion.UpdateOne(ctx,
+        bson.M{"id_archivo": idFile},
+        bson.M{"$set": bson.M{"fallo_vigencia": FalloVigenciaDTO{
+            Intentos:       fallo.Intentos,
+            ProximoIntento: fallo.ProximoIntento.UTC(),
+            Error:          fallo.Error,
+        }}},
+    )
+    if err != nil {
+        return fmt.Errorf("error al registrar fallo de vigencia: %v", err)
+    }
+    if resultado.MatchedCount == 0 {
+        return documentos.ErrRegistroNoEncontrado
+    }
+    return nil
+}
@@ -0,0 +1,150 @@
+package mongo
+
+import (
+    "context"
+    "fmt"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+)
+
+// campoSecreto identifica el secreto HMAC de las suscripciones ante el cifrador.
+const campoSecreto = "secreto_webhook"
+
+// RepositorioSuscripcionesMongo implementa notificacion.RepositorioSuscripciones.
+// Con un cifrador, el secreto de cada suscripción se guarda cifrado y se descifra al leerla.
+type RepositorioSuscripcionesMongo struct {
+    coleccion *mongo.Collection // Colección de suscripciones
+    cifrador  *cifrado.Cifrador // Cifrado del secreto; nil lo guarda en claro
+}
+
+// NuevoRepositorioSuscripciones crea el repositorio de suscripciones de webhooks.
+func NuevoRepositorioSuscripciones(client *mongo.Client, cfg *config.Config) *RepositorioSuscripcionesMongo {
+    return &RepositorioSuscripcionesMongo{coleccion: client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollectionWebhooks)}
+}
+
+// EstablecerCifrador activa el cifrado del secreto en las suscripciones nuevas y permite leer las cifradas.
+func (r *RepositorioSuscripcionesMongo) EstablecerCifrador(cifrador *cifrado.Cifrador) {
+    r.cifrador = cifrador
+}
+
+// Crear inserta una suscripción.
+func (r *RepositorioSuscripcionesMongo) Crear(ctx context.Context, suscripcion notificacion.Suscripcion) error {
+    secreto, _, err := cifrarCampo(r.cifrador, campoSecreto, suscripcion.Secreto)
+    if err != nil {
+        return fmt.Errorf("error al cifrar el secreto de la suscripción: %v", err)
+    }
+    suscripcion.Secreto = secreto
+    if _, err := r.coleccion.InsertOne(ctx, suscripcion); err != nil {
+        return fmt.Errorf("error al crear suscripción: %v", err)
+    }
+    return nil
+}
+
+// Listar retorna todas las suscripciones.
+func (r *RepositorioSuscripcionesMongo) Listar(ctx context.Context) ([]notificacion.Suscripcion, error) {
+    return r.buscar(ctx, bson.M{})
+}
+
+// ListarActivas retorna las suscripciones que reciben notificaciones.
+func (r *RepositorioSuscripcionesMongo) ListarActivas(ctx context.Context) ([]notificacion.Suscripcion, error) {
+    return r.buscar(ctx, bson.M{"activa": true})
+}
+
+// Eliminar borra una suscripción por su ID.
+func (r *RepositorioSuscripcionesMongo) Eliminar(ctx context.Context, id string) error {
+    resultado, err := r.coleccion.DeleteOne(ctx, bson.M{"_id": id})
+    if err != nil {
+        return fmt.Errorf("error al eliminar suscripción: %v", err)
+    }
+    if resultado.DeletedCount == 0 {
+        return notificacion.ErrSuscripcionNoEncontrada
+    }
+    return nil
+}
+
+// buscar ejecuta una consulta sobre la colección de suscripciones.
+func (r *RepositorioSuscripcionesMongo) buscar(ctx context.Context, filtro bson.M) ([]notificacion.Suscripcion, error) {
+    cursor, err := r.coleccion.Find(ctx, filtro, options.Find().SetSort(bson.D{{Key: "creada_en", Value: 1}}))
+    if err != nil {
+        return nil, fmt.Errorf("error al listar suscripciones: %v", err)
+    }
+    suscripciones := []notificacion.Suscripcion{}
+    if err := cursor.All(ctx, &suscripciones); err != nil {
+        return nil, fmt.Errorf("error al decodificar suscripciones: %v", err)
+    }
+    for i := range suscripciones {
+        secreto, err := descifrarCampo(r.cifrador, campoSecreto, suscripciones[i].Secreto)
+        if err != nil {
+            return nil, fmt.Errorf("error al descifrar el secreto de la suscripción %s: %v", suscripciones[i].ID, err)
+        }
+        suscripciones[i].Secreto = secreto
+    }
+    return suscripciones, nil
+}
+
+// RepositorioEntregasMongo implementa notificacion.RepositorioEntregas.
+type RepositorioEntregasMongo struct {
+    coleccion *mongo.Collection // Colección del log de entregas
+}
+
+// NuevoRepositorioEntregas crea el repositorio del log de entregas.
+func NuevoRepositorioEntregas(client *mongo.Client, cfg *config.Config) *RepositorioEntregasMongo {
+    return &RepositorioEntregasMongo{coleccion: client.Database(cfg.MongoDatabase).Collection(cfg.MongoCollectionEntregas)}
+}
+
+// Registrar agrega una entrega al log.
+func (r *RepositorioEntregasMongo) Registrar(ctx context.Context, entrega notificacion.Entrega) error {
+    if _, err := r.coleccion.InsertOne(ctx, entrega); err != nil {
+        return fmt.Errorf("error al registrar entrega: %v", err)
+    }
+    return nil
+}
+
+// CrearIndices crea el índice por suscripción, documento y umbral usado para encontrar el último envío de un aviso.
+func (r *RepositorioEntregasMongo) CrearIndices(ctx context.Context) error {
+    _, err := r.coleccion.Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{
+            {Key: "suscripcion_id", Value: 1},
+            {Key: "documento_id", Value: 1},
+            {Key: "umbral_dias", Value: 1},
+            {Key: "fecha", Value: -1},
+        },
+        Options: options.Index().SetName("idx_aviso_fecha"),
+    })
+    return err
+}
+
+// Ultima retorna la entrega más reciente de la suscripción, documento y umbral, o nil si el aviso nunca se envió.
+func (r *RepositorioEntregasMongo) Ultima(ctx context.Context, suscripcionID, documentoID string, umbralDias int) (*notificacion.Entrega, error) {
+    var entrega notificacion.Entrega
+    err := r.coleccion.FindOne(ctx, bson.M{
+        "suscripcion_id": suscripcionID,
+        "documento_id":   documentoID,
+        "umbral_dias":    umbralDias,
+    }, options.FindOne().SetSort(bson.D{{Key: "fecha", Value: -1}})).Decode(&entrega)
+    if err == mongo.ErrNoDocuments {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("error al consultar entregas: %v", err)
+    }
+    return &entrega, nil
+}
+
+// Listar retorna las entregas más recientes de una suscripción.
+func (r *RepositorioEntregasMongo) Listar(ctx context.Context, suscripcionID string, limite int) ([]notificacion.Entrega, error) {
+    opciones := options.Find().SetSort(bson.D{{Key: "fecha", Value: -1}}).SetLimit(int64(limite))
+    cursor, err := r.coleccion.Find(ctx, bson.M{"suscripcion_id": suscripcionID}, opciones)
+    if err != nil {
+        return nil, fmt.Errorf("error al listar entregas: %v", err)
+    }
+    entregas := []notificacion.Entrega{}
+    if err := cursor.All(ctx, &entregas); err != nil {
+        return nil, fmt.Errorf("error al decodificar entregas: %v", err)
+    }
+    return entregas, nil
+}
@@ -5,16 +5,12 @@ import (
     "encoding/json"
     "fmt"
     "net/http"
-    "time"
-    "github.com/CamiloScript/REGAPIGO/shared/config"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
 )
 
 // AuthClientImpl implementa la autenticación con Alfresco.
 type AuthClientImpl struct {
-    baseURL  string              // URL base del servidor Alfresco
-    apiKey   string              // API Key para autenticación
-    client   *http.Client        // Cliente HTTP
+    conexion *ConexionAlfresco   // Conexión compartida (transporte, reintentos y circuito)
     log      *logger.Registrador // Logger
 }
 
@@ -32,17 +28,13 @@ type LoginResponse struct {
 
 // NewAuthClient crea un cliente de autenticación configurado.
 // Parámetros:
-//   - cfg: Configuración de la aplicación.
+//   - conexion: Conexión compartida con Alfresco.
 //   - log: Logger para registrar eventos y errores.
 // Retorna un cliente de autenticación configurado.
-func NewAuthClient(cfg *config.Config, log *logger.Registrador) AuthClient {
+func NewAuthClient(conexion *ConexionAlfresco, log *logger.Registrador) AuthClient {
     return &AuthClientImpl{
-        baseURL: cfg.AlfrescoBaseURL,
-        apiKey:  cfg.AlfrescoAPIKey,
-        client: &http.Client{
-            Timeout: 20 * time.Second, // Timeout para evitar bloqueos
-        },
-        log: log,
+        conexion: conexion,
+        log:      log,
     }
 }
 
@@ -53,7 +45,7 @@ func NewAuthClient(cfg *config.Config, log *logger.Registrador) AuthClient {
 // Retorna el ticket de autenticación o un error en caso de fallo.
 func (c *AuthClientImpl) Login(usuario, password string) (string, error) {
     endpoint := "/session/log-in"
-    url := c.baseURL + endpoint
+    url := c.conexion.URLBase() + endpoint
 
     // 1. Registrar inicio de autenticación
     c.log.Info("Iniciando autenticación", map[string]interface{}{
@@ -81,13 +73,13 @@ func (c *AuthClientImpl) Login(usuario, password string) (string, error) {
 
     // 4. Configurar headers requeridos
     req.Header.Set("Content-Type", "application/json")
-    req.Header.Set("ADFTannerServices", c.apiKey) // API Key desde configuración
+    req.Header.Set("ADFTannerServices", c.conexion.ClaveAPI()) // API Key vigente de la conexión
 
-    // 5. Enviar solicitud
-    resp, err := c.client.Do(req)
+    // 5. Enviar solicitud (un login repetido solo emite otro ticket: puede reintentarse)
+    resp, err := c.conexion.Hacer(req, true)
     if err != nil {
         c.log.Error("Error de red", map[string]interface{}{"error": err.Error()})
-        return "", fmt.Errorf("error de comunicación: %v", err)
+        return "", errorDeTransporte(err)
     }
     defer resp.Body.Close()
 
@@ -97,7 +89,7 @@ func (c *AuthClientImpl) Login(usuario, password string) (string, error) {
             "status_code": resp.StatusCode,
             "status":      resp.Status,
         })
-        return "", fmt.Errorf("error de autenticación: %s", resp.Status)
+        return "", errorDesdeRespuesta(resp)
     }
 
     // 7. Decodificar respuesta
@@ -107,8 +99,8 @@ func (c *AuthClientImpl) Login(usuario, password string) (string, error) {
         return "", fmt.Errorf("error en formato de respuesta: %v", err)
     }
 
-    // 8. Registrar éxito
-    c.log.Info("Autenticación exitosa", map[string]interface{}{"ticket": loginResp.ID})
+    // 8. Registrar éxito (el ticket es una credencial: nunca se registra)
+    c.log.Info("Autenticación exitosa", map[string]interface{}{"usuario": usuario})
     return loginResp.ID, nil
 }
 
@@ -8,51 +8,59 @@ import (
     "io"
     "mime/multipart"
     "net/http"
+    "net/url"
     "regexp"
-    "time"
+    "strings"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
     "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
 )
 
 // ClienteAlfresco maneja operaciones con Alfresco sin estado.
 type ClienteAlfresco struct {
-    urlBase     string              // URL base del servidor Alfresco
-    clienteHTTP *http.Client        // Cliente HTTP con timeout
-    log         *logger.Registrador // Logger para registro de eventos
-    apiKey      string              // API Key para autenticación
+    conexion *ConexionAlfresco   // Transporte, reintentos y circuito compartidos
+    log      *logger.Registrador // Logger para registro de eventos
+    apiKey   string              // API Key fija; vacía usa la vigente de la conexión
 }
 
 // NuevoClienteAlfresco crea una instancia del cliente.
 // Parámetros:
-//   - urlBase: URL base del servidor Alfresco.
-//   - apiKey: API Key para autenticación.
+//   - conexion: Conexión compartida con Alfresco.
+//   - apiKey: API Key para autenticación; vacía usa la vigente de la conexión, que sigue las rotaciones.
 //   - log: Logger para registrar eventos y errores.
 // Retorna una instancia configurada de ClienteAlfresco.
-func NuevoClienteAlfresco(urlBase, apiKey string, log *logger.Registrador) *ClienteAlfresco {
+func NuevoClienteAlfresco(conexion *ConexionAlfresco, apiKey string, log *logger.Registrador) *ClienteAlfresco {
     return &ClienteAlfresco{
-        urlBase:     urlBase,
-        apiKey:      apiKey,
-        clienteHTTP: &http.Client{Timeout: 30 * time.Second},
-        log:         log,
+        apiKey:   apiKey,
+        conexion: conexion,
+        log:      log,
     }
 }
 
+// claveAPI retorna la API Key que se envía en el header ADFTannerServices.
+func (c *ClienteAlfresco) claveAPI() string {
+    if c.apiKey != "" {
+        return c.apiKey
+    }
+    return c.conexion.ClaveAPI()
+}
+
 // SubirDocumento envía un archivo y metadatos a Alfresco.
 // Parámetros:
 //   - ctx: Contexto para controlar la solicitud.
 //   - fileBytes: Bytes del archivo a subir.
 //   - metadatos: Metadatos del archivo en formato JSON.
 //   - ticket: Ticket de autenticación.
-// Retorna un mapa con la respuesta de Alfresco o un error en caso de fallo.
+// Retorna el documento creado en Alfresco o un error en caso de fallo.
 func (c *ClienteAlfresco) SubirDocumento(
     ctx context.Context,
     fileBytes []byte,
     metadatos string,
     ticket string,
-) (map[string]interface{}, error) {
+) (*documento.AlfrescoDocumentDTO, error) {
 
     endpoint := "/tanner-alfresco/file-upload"
-    url := c.urlBase + endpoint
+    url := c.conexion.URLBase() + endpoint
 
     // Preparar formulario multipart
     cuerpo := &bytes.Buffer{}
@@ -61,19 +69,19 @@ func (c *ClienteAlfresco) SubirDocumento(
     // Crear parte del archivo
     parte, err := escritor.CreateFormFile("documento", "documento.pdf")
     if err != nil {
-        c.log.Error("Error al crear formulario", map[string]interface{}{"error": err.Error()})
+        c.log.ConContexto(ctx).Error("Error al crear formulario", map[string]interface{}{"error": err.Error()})
         return nil, fmt.Errorf("error al crear formulario: %v", err)
     }
 
     // Copiar contenido del archivo
     if _, err := io.Copy(parte, bytes.NewReader(fileBytes)); err != nil {
-        c.log.Error("Error al copiar archivo", map[string]interface{}{"error": err.Error()})
+        c.log.ConContexto(ctx).Error("Error al copiar archivo", map[string]interface{}{"error": err.Error()})
         return nil, fmt.Errorf("error al copiar archivo: %v", err)
     }
 
     // Agregar metadatos
     if err := escritor.WriteField("propiedades", metadatos); err != nil {
-        c.log.Error("Error al escribir metadatos", map[string]interface{}{"error": err.Error()})
+        c.log.ConContexto(ctx).Error("Error al escribir metadatos", map[string]interface{}{"error": err.Error()})
         return nil, fmt.Errorf("error al escribir metadatos: %v", err)
     }
     escritor.Close()
@@ -81,23 +89,29 @@ func (c *ClienteAlfresco) SubirDocumento(
     // Crear solicitud HTTP
     req, err := http.NewRequestWithContext(ctx, "POST", url, cuerpo)
     if err != nil {
-        c.log.Error("Error al crear solicitud", map[string]interface{}{"error": err.Error()})
+        c.log.ConContexto(ctx).Error("Error al crear solicitud", map[string]interface{}{"error": err.Error()})
         return nil, fmt.Errorf("error al crear solicitud: %v", err)
     }
 
     // Configurar headers
     req.Header.Set("Content-Type", escritor.FormDataContentType())
     req.Header.Set("Authorization", "Basic "+ticket) // Ticket dinámico
-    req.Header.Set("ADFTannerServices", c.apiKey)    // API Key fija
+    req.Header.Set("ADFTannerServices", c.claveAPI())    // API Key del cliente o de la conexión
+
+    // Enviar solicitud (una subida repetida crearía otra versión: no es idempotente)
+    var resultado documento.AlfrescoDocumentDTO
+    if err := c.ejecutarSolicitud(req, false, &resultado); err != nil {
+        c.log.ConContexto(ctx).Error("Error al subir archivo", map[string]interface{}{"error": err.Error()})
+        return nil, fmt.Errorf("error al subir archivo: %w", err)
+    }
 
-    // Enviar solicitud
-    var resultado map[string]interface{}
-    if err := c.ejecutarSolicitud(req, &resultado); err != nil {
-        c.log.Error("Error al subir archivo", map[string]interface{}{"error": err.Error()})
-        return nil, fmt.Errorf("error al subir archivo: %v", err)
+    // Sin ID el documento no puede indexarse ni descargarse
+    if resultado.Entry.ID == "" {
+        c.log.ConContexto(ctx).Error("Respuesta de subida sin ID", nil)
+        return nil, fmt.Errorf("%w: entry.id ausente en la subida", documento.ErrRespuestaAlfrescoInvalida)
     }
 
-    return resultado, nil
+    return &resultado, nil
 }
 
 // ListarDocumentos recupera documentos filtrados desde Alfresco.
@@ -112,32 +126,40 @@ func (c *ClienteAlfresco) ListarDocumentos(
     ticket string,
 ) ([]documento.AlfrescoDocumentDTO, error) {
     endpoint := "/tanner-alfresco/files"
-    url := c.urlBase + endpoint
+    url := c.conexion.URLBase() + endpoint
 
     // Convertir filtros a JSON
     cuerpo, err := json.Marshal(filtros)
     if err != nil {
-        c.log.Error("Error al serializar filtros", map[string]interface{}{"error": err.Error()})
+        c.log.ConContexto(ctx).Error("Error al serializar filtros", map[string]interface{}{"error": err.Error()})
         return nil, fmt.Errorf("error al serializar filtros: %v", err)
     }
 
     // Crear solicitud HTTP
     req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(cuerpo))
     if err != nil {
-        c.log.Error("Error al crear solicitud", map[string]interface{}{"error": err.Error()})
+        c.log.ConContexto(ctx).Error("Error al crear solicitud", map[string]interface{}{"error": err.Error()})
         return nil, fmt.Errorf("error al crear solicitud: %v", err)
     }
 
     // Configurar headers
     req.Header.Set("Content-Type", "application/json")
     req.Header.Set("Authorization", "Basic "+ticket) // Ticket dinámico
-    req.Header.Set("ADFTannerServices", c.apiKey)    // API Key fija
+    req.Header.Set("ADFTannerServices", c.claveAPI())    // API Key del cliente o de la conexión
 
     // Ejecutar solicitud
     var respuesta []documento.AlfrescoDocumentDTO
-    if err := c.ejecutarSolicitud(req, &respuesta); err != nil {
+    if err := c.ejecutarSolicitud(req, true, &respuesta); err != nil {
         return nil, err
     }
+
+    // Cada entrada del listado debe identificar un documento
+    for i, dto := range respuesta {
+        if dto.Entry.ID == "" {
+            c.log.ConContexto(ctx).Error("Entrada de listado sin ID", map[string]interface{}{"posicion": i})
+            return nil, fmt.Errorf("%w: entry.id ausente en la posición %d del listado", documento.ErrRespuestaAlfrescoInvalida, i)
+        }
+    }
     return respuesta, nil
 }
 
@@ -153,41 +175,41 @@ func (c *ClienteAlfresco) DescargarDocumento(
     ticket string,
 ) ([]byte, string, error) {
     endpoint := "/tanner-alfresco/file-download?idFile=" + idFile
-    url := c.urlBase + endpoint
+    url := c.conexion.URLBase() + endpoint
 
     // Crear solicitud HTTP
     req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
     if err != nil {
-        c.log.Error("Error al crear solicitud", map[string]interface{}{"error": err.Error()})
+        c.log.ConContexto(ctx).Error("Error al crear solicitud", map[string]interface{}{"error": err.Error()})
         return nil, "", fmt.Errorf("error al crear solicitud: %v", err)
     }
 
     // Configurar headers
     req.Header.Set("Authorization", "Basic "+ticket)
-    req.Header.Set("ADFTannerServices", c.apiKey)
+    req.Header.Set("ADFTannerServices", c.claveAPI())
 
     // Ejecutar solicitud
-    resp, err := c.clienteHTTP.Do(req)
+    resp, err := c.conexion.Hacer(req, true)
     if err != nil {
-        c.log.Error("Error al descargar archivo", map[string]interface{}{"error": err.Error()})
-        return nil, "", fmt.Errorf("error al descargar archivo: %v", err)
+        c.log.ConContexto(ctx).Error("Error al descargar archivo", map[string]interface{}{"error": err.Error()})
+        return nil, "", errorDeTransporte(err)
     }
     defer resp.Body.Close()
 
     // Manejar errores HTTP
     if resp.StatusCode >= 400 {
-        cuerpoError, _ := io.ReadAll(resp.Body)
-        c.log.Error("Error de Alfresco", map[string]interface{}{
+        errRepositorio := errorDesdeRespuesta(resp)
+        c.log.ConContexto(ctx).Error("Error de Alfresco", map[string]interface{}{
             "status_code": resp.StatusCode,
-            "respuesta":   string(cuerpoError),
+            "respuesta":   errRepositorio.Detalle,
         })
-        return nil, "", fmt.Errorf("error de Alfresco: %s. Detalle: %s", resp.Status, cuerpoError)
+        return nil, "", errRepositorio
     }
 
     // Leer contenido del archivo
     contenido, err := io.ReadAll(resp.Body)
     if err != nil {
-        c.log.Error("Error al leer contenido", map[string]interface{}{"error": err.Error()})
+        c.log.ConContexto(ctx).Error("Error al leer contenido", map[string]interface{}{"error": err.Error()})
         return nil, "", fmt.Errorf("error al leer contenido: %v", err)
     }
 
@@ -204,47 +226,157 @@ func (c *ClienteAlfresco) DescargarDocumento(
     return contenido, nombreArchivo, nil
 }
 
+// ActualizarPropiedades modifica las propiedades de un archivo existente.
+// Parámetros:
+//   - ctx: Contexto para controlar la solicitud.
+//   - idFile: ID del archivo a actualizar.
+//   - propiedades: Propiedades a modificar.
+//   - ticket: Ticket de autenticación.
+// Retorna un error en caso de fallo.
+func (c *ClienteAlfresco) ActualizarPropiedades(
+    ctx context.Context,
+    idFile string,
+    propiedades map[string]interface{},
+    ticket string,
+) error {
+    endpoint := "/tanner-alfresco/file-properties?idFile=" + url.QueryEscape(idFile)
+    urlSolicitud := c.conexion.URLBase() + endpoint
+
+    // Convertir propiedades a JSON
+    cuerpo, err := json.Marshal(map[string]interface{}{"properties": propiedades})
+    if err != nil {
+        c.log.ConContexto(ctx).Error("Error al serializar propiedades", map[string]interface{}{"error": err.Error()})
+        return fmt.Errorf("error al serializar propiedades: %v", err)
+    }
+
+    // Crear solicitud HTTP
+    req, err := http.NewRequestWithContext(ctx, "PUT", urlSolicitud, bytes.NewBuffer(cuerpo))
+    if err != nil {
+        c.log.ConContexto(ctx).Error("Error al crear solicitud", map[string]interface{}{"error": err.Error()})
+        return fmt.Errorf("error al crear solicitud: %v", err)
+    }
+
+    // Configurar headers
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("Authorization", "Basic "+ticket)
+    req.Header.Set("ADFTannerServices", c.claveAPI())
+
+    // Ejecutar solicitud (la respuesta no se utiliza)
+    if err := c.ejecutarSolicitud(req, true, nil); err != nil {
+        c.log.ConContexto(ctx).Error("Error al actualizar propiedades", map[string]interface{}{"idFile": idFile, "error": err.Error()})
+        return fmt.Errorf("error al actualizar propiedades: %w", err)
+    }
+    return nil
+}
+
+// EliminarDocumento elimina definitivamente un archivo por su ID.
+// Parámetros:
+//   - ctx: Contexto para controlar la solicitud.
+//   - idFile: ID del archivo a eliminar.
+//   - ticket: Ticket de autenticación.
+// Retorna un error en caso de fallo.
+func (c *ClienteAlfresco) EliminarDocumento(
+    ctx context.Context,
+    idFile string,
+    ticket string,
+) error {
+    endpoint := "/tanner-alfresco/file-delete?idFile=" + url.QueryEscape(idFile)
+    urlSolicitud := c.conexion.URLBase() + endpoint
+
+    // Crear solicitud HTTP
+    req, err := http.NewRequestWithContext(ctx, "DELETE", urlSolicitud, nil)
+    if err != nil {
+        c.log.ConContexto(ctx).Error("Error al crear solicitud", map[string]interface{}{"error": err.Error()})
+        return fmt.Errorf("error al crear solicitud: %v", err)
+    }
+
+    // Configurar headers
+    req.Header.Set("Authorization", "Basic "+ticket)
+    req.Header.Set("ADFTannerServices", c.claveAPI())
+
+    // Ejecutar solicitud (la respuesta no se utiliza)
+    if err := c.ejecutarSolicitud(req, true, nil); err != nil {
+        c.log.ConContexto(ctx).Error("Error al eliminar archivo", map[string]interface{}{"idFile": idFile, "error": err.Error()})
+        return fmt.Errorf("error al eliminar archivo: %w", err)
+    }
+    return nil
+}
+
 // ejecutarSolicitud maneja la lógica común para enviar solicitudes HTTP y procesar respuestas.
 // Parámetros:
 //   - req: Solicitud HTTP a ejecutar.
-//   - destino: Estructura donde se decodificará la respuesta JSON.
+//   - idempotente: Indica si la solicitud puede reintentarse ante fallos transitorios.
+//   - destino: Estructura donde se decodificará la respuesta JSON (nil para descartar el cuerpo).
 // Retorna un error en caso de fallo.
-func (c *ClienteAlfresco) ejecutarSolicitud(req *http.Request, destino interface{}) error {
-    // Ejecutar la solicitud HTTP
-    resp, err := c.clienteHTTP.Do(req)
+func (c *ClienteAlfresco) ejecutarSolicitud(req *http.Request, idempotente bool, destino interface{}) error {
+    // Ejecutar la solicitud HTTP con reintentos y circuito
+    resp, err := c.conexion.Hacer(req, idempotente)
     if err != nil {
         // Registrar error en el log
-        c.log.Error("Error en la solicitud HTTP", map[string]interface{}{"error": err.Error()})
-        return fmt.Errorf("error en la solicitud HTTP: %v", err)
+        c.log.ConContexto(req.Context()).Error("Error en la solicitud HTTP", map[string]interface{}{"error": err.Error()})
+        return errorDeTransporte(err)
     }
     defer resp.Body.Close() // Asegurar que el cuerpo de la respuesta se cierre
 
     // Verificar si la respuesta es un error (código 4xx o 5xx)
     if resp.StatusCode >= 400 {
-        // Leer el cuerpo del error para obtener detalles
-        cuerpoError, err := io.ReadAll(resp.Body)
-        if err != nil {
-            c.log.Error("Error al leer cuerpo de error", map[string]interface{}{"error": err.Error()})
-            return fmt.Errorf("error de Alfresco: %s", resp.Status)
-        }
+        errRepositorio := errorDesdeRespuesta(resp)
 
         // Registrar el error en el log
-        c.log.Error("Error de Alfresco", map[string]interface{}{
+        c.log.ConContexto(req.Context()).Error("Error de Alfresco", map[string]interface{}{
             "status_code": resp.StatusCode,
-            "respuesta":   string(cuerpoError),
+            "respuesta":   errRepositorio.Detalle,
         })
+        return errRepositorio
+    }
 
-        // Devolver un error con detalles
-        return fmt.Errorf("error de Alfresco: %s. Detalle: %s", resp.Status, cuerpoError)
+    // Sin destino no hay nada que decodificar
+    if destino == nil {
+        return nil
     }
 
     // Decodificar la respuesta JSON en la estructura destino
     if err := json.NewDecoder(resp.Body).Decode(destino); err != nil {
         // Registrar error en el log
-        c.log.Error("Error al decodificar respuesta", map[string]interface{}{"error": err.Error()})
-        return fmt.Errorf("error al decodificar respuesta: %v", err)
+        c.log.ConContexto(req.Context()).Error("Error al decodificar respuesta", map[string]interface{}{"error": err.Error()})
+        return fmt.Errorf("%w: %v", documento.ErrRespuestaAlfrescoInvalida, err)
     }
 
     // Si todo está bien, retornar nil (sin errores)
     return nil
-}
\ No newline at end of file
+}
+// limiteDetalleError acota el cuerpo de error de Alfresco que se conserva en el error y el log.
+const limiteDetalleError = 4096
+
+// errorDesdeRespuesta clasifica una respuesta de error de Alfresco según su código HTTP.
+func errorDesdeRespuesta(resp *http.Response) *documentos.ErrorRepositorio {
+    cuerpo, _ := io.ReadAll(io.LimitReader(resp.Body, limiteDetalleError))
+    return &documentos.ErrorRepositorio{
+        Categoria: categoriaDesdeEstado(resp.StatusCode),
+        Estado:    resp.StatusCode,
+        Detalle:   strings.TrimSpace(string(cuerpo)),
+    }
+}
+
+// categoriaDesdeEstado asigna la categoría de error que corresponde a un código HTTP de Alfresco.
+func categoriaDesdeEstado(estado int) error {
+    switch {
+    case estado == http.StatusNotFound || estado == http.StatusGone:
+        return documentos.ErrDocumentoNoEncontrado
+    case estado == http.StatusConflict || estado == http.StatusPreconditionFailed || estado == http.StatusLocked:
+        return documentos.ErrConflicto
+    case estado == http.StatusUnauthorized || estado == http.StatusForbidden:
+        return documentos.ErrTicketInvalido
+    case estado == http.StatusTooManyRequests || estado == http.StatusInsufficientStorage:
+        return documentos.ErrCuotaExcedida
+    case estado >= 500:
+        return documentos.ErrRepositorioNoDisponible
+    default:
+        return documentos.ErrValidacion
+    }
+}
+
+// errorDeTransporte envuelve un fallo sin respuesta de Alfresco (red, timeout o circuito abierto).
+func errorDeTransporte(err error) *documentos.ErrorRepositorio {
+    return &documentos.ErrorRepositorio{Categoria: documentos.ErrRepositorioNoDisponible, Causa: err}
+}
@@ -1,9 +1,9 @@
 package servicio
 
 import (
+    "context"
     "fmt"
-    "mime/multipart"
-    "github.com/gin-gonic/gin"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
     "github.com/CamiloScript/REGAPIGO/application/documento"
 )
@@ -52,91 +52,128 @@ func NuevoMockClienteAlfresco(log *logger.Registrador) *MockClienteAlfresco {
 }
 
 // SubirDocumento simula la subida de un documento a Alfresco.
-// Si ForzarError es true, devuelve un error simulado. De lo contrario, devuelve una respuesta mock.
+// Si ForzarError es true, devuelve un error simulado. De lo contrario, devuelve un documento mock.
 func (m *MockClienteAlfresco) SubirDocumento(
-    ctx *gin.Context,                  // Contexto de Gin
-    archivo *multipart.FileHeader,     // Archivo a subir
-    metadatos string,                  // Metadatos del documento
-    ticket string,                     // Ticket de autenticación
-    nombreArchivo string,              // Nombre del archivo
-) (map[string]interface{}, error) {
+    ctx context.Context,                  // Contexto de la operación
+    solicitud documentos.SolicitudSubida, // Archivo y propiedades a subir
+) (*documentos.Documento, error) {
     if m.ForzarError {
         m.Log.Error("MockClienteAlfresco: Error forzado en SubirDocumento", nil)
         return nil, fmt.Errorf("error simulado")
     }
 
+    // Recuperar el nombre del documento desde los metadatos
+    nombre := solicitud.Propiedades.NombreDoc
+
     m.Log.Info("MockClienteAlfresco: Documento subido", map[string]interface{}{
-        "filename": archivo.Filename,
-        "ticket":   ticket,
+        "filename": nombre,
+        "bytes":    len(solicitud.Contenido),
     })
 
-    // Retornar una respuesta mock con el ID y nombre del archivo subido
-    return map[string]interface{}{
-        "entry": map[string]interface{}{
-            "id":   "mock-456",
-            "name": archivo.Filename,
-        },
+    // Retornar un documento mock con el ID y nombre del archivo subido
+    return &documentos.Documento{
+        ID:          "mock-456",
+        Nombre:      nombre,
+        Propiedades: propiedadesAlfresco(solicitud.Propiedades),
     }, nil
 }
 
 // ListarDocumentos simula el listado de documentos en Alfresco.
 // Si ForzarError es true, devuelve un error simulado. De lo contrario, filtra los documentos según los filtros proporcionados.
 func (m *MockClienteAlfresco) ListarDocumentos(
-    ctx *gin.Context,
-    filtros map[string]interface{},
-    ticket string,
-    apiKey string,
-) (map[string]interface{}, error) { 
+    ctx context.Context,                   // Contexto de la operación
+    solicitud documentos.SolicitudListado, // Filtros del listado
+) ([]documentos.Documento, error) {
     if m.ForzarError {
         m.Log.Error("MockClienteAlfresco: Error forzado en ListarDocumentos", nil)
         return nil, fmt.Errorf("error simulado")
     }
 
     // Filtrar documentos según el RUT del cliente
-    resultados := []map[string]interface{}{}
+    resultados := []documentos.Documento{}
     for _, doc := range m.DocumentosDB {
-        if rut, ok := doc["properties"].(map[string]interface{})["tanner:rut-cliente"]; ok {
-            if rut == filtros["tanner:rut-cliente"] {
-                resultados = append(resultados, doc)
-            }
+        propiedades, _ := doc["properties"].(map[string]interface{})
+        if rut, ok := propiedades["tanner:rut-cliente"]; ok && rut == solicitud.Filtros.RUTCliente {
+            id, _ := doc["id"].(string)
+            nombre, _ := doc["name"].(string)
+            resultados = append(resultados, documentos.Documento{ID: id, Nombre: nombre, Propiedades: propiedades})
         }
     }
 
     m.Log.Info("MockClienteAlfresco: Documentos listados", map[string]interface{}{
         "total": len(resultados),
     })
-
-    // Retornar en el formato esperado por la interfaz
-    return map[string]interface{}{
-        "entries": resultados,
-        "total": len(resultados),
-    }, nil
+    return resultados, nil
 }
 
 // DescargarDocumento simula la descarga de un documento desde Alfresco.
 // Si ForzarError es true, devuelve un error simulado. De lo contrario, devuelve el contenido mock del documento.
 func (m *MockClienteAlfresco) DescargarDocumento(
-    c *gin.Context,                    // Contexto de Gin
-    idFile string,                     // ID del documento a descargar
-    ticket string,                     // Ticket de autenticación
-    otroParametro string,              // Parámetro adicional (no utilizado en este mock)
-) ([]byte, string, error) {
+    ctx context.Context,                     // Contexto de la operación
+    solicitud documentos.SolicitudDocumento, // Documento a descargar
+) (*documentos.ArchivoDocumento, error) {
     if m.ForzarError {
         m.Log.Error("MockClienteAlfresco: Error forzado en DescargarDocumento", nil)
-        return nil, "", fmt.Errorf("error simulado")
+        return nil, fmt.Errorf("error simulado")
     }
 
     // Buscar el documento en la base de datos simulada
-    doc, existe := m.DocumentosDB[idFile]
+    doc, existe := m.DocumentosDB[solicitud.IDArchivo]
     if !existe {
-        m.Log.Warn("MockClienteAlfresco: Documento no encontrado", map[string]interface{}{"idFile": idFile})
-        return nil, "", documento.ErrDocumentoNoEncontrado
+        m.Log.Warn("MockClienteAlfresco: Documento no encontrado", map[string]interface{}{"idFile": solicitud.IDArchivo})
+        return nil, documento.ErrDocumentoNoEncontrado
     }
 
     m.Log.Info("MockClienteAlfresco: Documento descargado", map[string]interface{}{
-        "idFile": idFile,
+        "idFile": solicitud.IDArchivo,
     })
 
     // Retornar contenido mock y el nombre del documento
-    return []byte("contenido-mock"), doc["name"].(string), nil
-}
\ No newline at end of file
+    nombre, _ := doc["name"].(string)
+    return &documentos.ArchivoDocumento{Nombre: nombre, Contenido: []byte("contenido-mock")}, nil
+}
+
+// ActualizarPropiedades simula la actualización de propiedades de un documento en Alfresco.
+// Si ForzarError es true, devuelve un error simulado. De lo contrario, mezcla las propiedades en la base simulada.
+func (m *MockClienteAlfresco) ActualizarPropiedades(
+    ctx context.Context,                         // Contexto de la operación
+    solicitud documentos.SolicitudActualizacion, // Documento y propiedades a modificar
+) error {
+    if m.ForzarError {
+        m.Log.Error("MockClienteAlfresco: Error forzado en ActualizarPropiedades", nil)
+        return fmt.Errorf("error simulado")
+    }
+
+    doc, existe := m.DocumentosDB[solicitud.IDArchivo]
+    if !existe {
+        return documento.ErrDocumentoNoEncontrado
+    }
+
+    actuales, _ := doc["properties"].(map[string]interface{})
+    if actuales == nil {
+        actuales = map[string]interface{}{}
+        doc["properties"] = actuales
+    }
+    for clave, valor := range propiedadesAlfresco(solicitud.Propiedades) {
+        actuales[clave] = valor
+    }
+    return nil
+}
+
+// EliminarDocumento simula la eliminación definitiva de un documento en Alfresco.
+// Si ForzarError es true, devuelve un error simulado. De lo contrario, elimina el documento de la base simulada.
+func (m *MockClienteAlfresco) EliminarDocumento(
+    ctx context.Context,                     // Contexto de la operación
+    solicitud documentos.SolicitudDocumento, // Documento a eliminar
+) error {
+    if m.ForzarError {
+        m.Log.Error("MockClienteAlfresco: Error forzado en EliminarDocumento", nil)
+        return fmt.Errorf("error simulado")
+    }
+
+    if _, existe := m.DocumentosDB[solicitud.IDArchivo]; !existe {
+        return documento.ErrDocumentoNoEncontrado
+    }
+    delete(m.DocumentosDB, solicitud.IDArchivo)
+    return nil
+}
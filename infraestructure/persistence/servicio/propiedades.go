@@ -0,0 +1,57 @@
+package servicio
+
+import (
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+)
+
+// propiedadesAlfresco traduce los metadatos del dominio a las propiedades de Alfresco.
+// Los campos vacíos no se incluyen, de modo que una actualización parcial no borra los demás valores.
+func propiedadesAlfresco(metadatos documentos.DocumentMetadata) map[string]interface{} {
+    return sinVacios(map[string]string{
+        "tanner:tipo-documento":         metadatos.TipoDocumento,
+        "tanner:razon-social-cliente":   metadatos.RazonSocialCliente,
+        "tanner:rut-cliente":            metadatos.RUTCliente,
+        "tanner:estado-visado":          metadatos.EstadoVisado,
+        "tanner:estado-vigencia":        metadatos.EstadoVigencia,
+        "tanner:fecha-carga":            metadatos.FechaCarga,
+        "tanner:nombre-doc":             metadatos.NombreDoc,
+        "tanner:categorias":             metadatos.Categorias,
+        "tanner:sub-categorias":         metadatos.SubCategorias,
+        "tanner:origen":                 metadatos.Origen,
+        "tanner:relacion":               metadatos.Relacion,
+        "tanner:fecha-termino-vigencia": metadatos.FechaTerminoVigencia,
+        "tanner:observaciones":          metadatos.Observaciones,
+        "cm:title":                      metadatos.CmTitle,
+        "cm:versionType":                metadatos.CmVersionType,
+        "cm:versionLabel":               metadatos.CmVersionLabel,
+        "cm:description":                metadatos.CmDescription,
+        "file_type":                     metadatos.FileType,
+    })
+}
+
+// filtrosAlfresco traduce el filtro de listado a las propiedades de Alfresco por las que se filtra.
+func filtrosAlfresco(filtro documentos.FiltroListado) map[string]interface{} {
+    return sinVacios(map[string]string{
+        "tanner:tipo-documento":       filtro.TipoDocumento,
+        "tanner:razon-social-cliente": filtro.RazonSocialCliente,
+        "tanner:rut-cliente":          filtro.RUTCliente,
+        "tanner:estado-visado":        filtro.EstadoVisado,
+        "tanner:estado-vigencia":      filtro.EstadoVigencia,
+        "tanner:nombre-doc":           filtro.NombreDoc,
+        "tanner:fecha-carga":          filtro.FechaCarga,
+        "tanner:origen":               filtro.Origen,
+        "tanner:relacion":             filtro.Relacion,
+        "cm:title":                    filtro.CmTitle,
+    })
+}
+
+// sinVacios retorna las propiedades con valor, en el formato que serializa el cliente de Alfresco.
+func sinVacios(campos map[string]string) map[string]interface{} {
+    propiedades := make(map[string]interface{}, len(campos))
+    for clave, valor := range campos {
+        if valor != "" {
+            propiedades[clave] = valor
+        }
+    }
+    return propiedades
+}
@@ -1,114 +1,97 @@
 package servicio
 
 import (
-    "github.com/gin-gonic/gin"
+    "context"
+    "encoding/json"
+    "fmt"
     "github.com/CamiloScript/REGAPIGO/domain/documentos"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
-    "github.com/CamiloScript/REGAPIGO/shared/config"
 )
 
 // ServicioAlfresco implementa AlmacenamientoDocumentos para Alfresco.
 type ServicioAlfresco struct {
-    urlBase string                 // URL base del servidor Alfresco
-    apiKey  string                 // API Key para autenticación
-    cliente *ClienteAlfresco       // Cliente HTTP inyectado
-    log     *logger.Registrador    // Logger para registrar eventos y errores
+    conexion *ConexionAlfresco      // Conexión compartida (transporte, reintentos y circuito)
+    cliente  *ClienteAlfresco       // Cliente con la API Key configurada
+    log      *logger.Registrador    // Logger para registrar eventos y errores
 }
 
 // NuevoServicioDocumentos inicializa el servicio.
-// Ahora incluye configuración completa para integración con Alfresco.
 // Parámetros:
-//   - cfg: Configuración de la aplicación.
+//   - conexion: Conexión compartida con Alfresco.
 //   - log: Logger para registrar eventos y errores.
 // Retorna una instancia configurada de ServicioAlfresco.
 func NuevoServicioDocumentos(
-    cfg *config.Config, 
+    conexion *ConexionAlfresco,
     log *logger.Registrador,
 ) documentos.AlmacenamientoDocumentos {
-    
-    // Crear cliente HTTP configurado para Alfresco
-    clienteAlfresco := NuevoClienteAlfresco(
-        cfg.AlfrescoBaseURL,
-        cfg.AlfrescoAPIKey, // Inyectar API Key desde configuración
-        log,
-    )
-
     return &ServicioAlfresco{
-        urlBase: cfg.AlfrescoBaseURL,
-        apiKey:  cfg.AlfrescoAPIKey,  // Nueva propiedad requerida
-        cliente: clienteAlfresco,     // Cliente HTTP inyectado
-        log:     log,
+        conexion: conexion,
+        cliente:  NuevoClienteAlfresco(conexion, "", log),
+        log:      log,
     }
 }
 
+// clientePara retorna el cliente con la API Key vigente de la conexión, o uno sobre la misma conexión
+// si la solicitud trae otra API Key.
+func (s *ServicioAlfresco) clientePara(credenciales documentos.Credenciales) *ClienteAlfresco {
+    if credenciales.APIKey == "" || credenciales.APIKey == s.conexion.ClaveAPI() {
+        return s.cliente
+    }
+    return NuevoClienteAlfresco(s.conexion, credenciales.APIKey, s.log)
+}
 
 // SubirDocumento implementa la subida stateless.
-// Parámetros:
-//   - c: Contexto de Gin.
-//   - fileBytes: Bytes del archivo a subir.
-//   - metadatos: Metadatos del documento en formato JSON.
-//   - ticket: Ticket de autenticación.
-//   - apiKey: API Key para autenticación.
-// Retorna un mapa con la respuesta de Alfresco o un error en caso de fallo.
-func (s *ServicioAlfresco) SubirDocumento(
-    c *gin.Context,
-    fileBytes []byte,
-    metadatos string,
-    ticket string,
-    apiKey string,
-) (map[string]interface{}, error) {
+// Retorna el documento creado en Alfresco o un error en caso de fallo.
+func (s *ServicioAlfresco) SubirDocumento(ctx context.Context, solicitud documentos.SolicitudSubida) (*documentos.Documento, error) {
+    // 1. Serializar las propiedades en el formato que espera Alfresco
+    metadatos, err := json.Marshal(propiedadesAlfresco(solicitud.Propiedades))
+    if err != nil {
+        return nil, fmt.Errorf("error al convertir metadatos a JSON: %v", err)
+    }
+
+    // 2. Subir el archivo
+    dto, err := s.clientePara(solicitud.Credenciales).SubirDocumento(ctx, solicitud.Contenido, string(metadatos), solicitud.Ticket)
+    if err != nil {
+        return nil, err
+    }
 
-    // Crear cliente con API Key y ticket actual
-    cliente := NuevoClienteAlfresco(s.urlBase, apiKey, s.log)
-    return cliente.SubirDocumento(c.Request.Context(), fileBytes, metadatos, ticket)
+    // 3. Mapear la respuesta al dominio
+    doc := dto.Documento()
+    return &doc, nil
 }
 
 // ListarDocumentos implementa el listado stateless.
-// Parámetros:
-//   - c: Contexto de Gin.
-//   - filtros: Filtros de búsqueda en formato JSON.
-//   - ticket: Ticket de autenticación.
-//   - apiKey: API Key para autenticación.
-// Retorna un mapa con los documentos listados o un error en caso de fallo.
-func (s *ServicioAlfresco) ListarDocumentos(
-    c *gin.Context,
-    filtros map[string]interface{},
-    ticket string,
-    apiKey string,
-) (map[string]interface{}, error) {
-    
-    cliente := NuevoClienteAlfresco(s.urlBase, apiKey, s.log)
-    rawResponse, err := cliente.ListarDocumentos(c.Request.Context(), filtros, ticket)
+// Retorna los documentos listados o un error en caso de fallo.
+func (s *ServicioAlfresco) ListarDocumentos(ctx context.Context, solicitud documentos.SolicitudListado) ([]documentos.Documento, error) {
+    rawResponse, err := s.clientePara(solicitud.Credenciales).ListarDocumentos(ctx, filtrosAlfresco(solicitud.Filtros), solicitud.Ticket)
     if err != nil {
         return nil, err
     }
-    
+
     // Mapear a dominio
-    documentosMap := make(map[string]interface{})
+    resultado := make([]documentos.Documento, 0, len(rawResponse))
     for _, dto := range rawResponse {
-        documentosMap[dto.Entry.ID] = documentos.Documento{
-            ID: dto.Entry.ID,
-            Nombre: dto.Entry.Name,
-            Propiedades: dto.Entry.Properties,
-        }
+        resultado = append(resultado, dto.Documento())
     }
-    return documentosMap, nil
+    return resultado, nil
 }
 
 // DescargarDocumento implementa la descarga stateless.
-// Parámetros:
-//   - c: Contexto de Gin.
-//   - idFile: ID del archivo a descargar.
-//   - ticket: Ticket de autenticación.
-//   - apiKey: API Key para autenticación.
-// Retorna el contenido del archivo en bytes, el nombre del archivo y un error en caso de fallo.
-func (s *ServicioAlfresco) DescargarDocumento(
-    c *gin.Context,
-    idFile string,
-    ticket string,
-    apiKey string,
-) ([]byte, string, error) {
+// Retorna el contenido y nombre del archivo o un error en caso de fallo.
+func (s *ServicioAlfresco) DescargarDocumento(ctx context.Context, solicitud documentos.SolicitudDocumento) (*documentos.ArchivoDocumento, error) {
+    contenido, nombre, err := s.clientePara(solicitud.Credenciales).DescargarDocumento(ctx, solicitud.IDArchivo, solicitud.Ticket)
+    if err != nil {
+        return nil, err
+    }
+    return &documentos.ArchivoDocumento{Nombre: nombre, Contenido: contenido}, nil
+}
 
-    cliente := NuevoClienteAlfresco(s.urlBase, apiKey, s.log)
-    return cliente.DescargarDocumento(c.Request.Context(), idFile, ticket)
-}
\ No newline at end of file
+// ActualizarPropiedades implementa la actualización de propiedades stateless.
+func (s *ServicioAlfresco) ActualizarPropiedades(ctx context.Context, solicitud documentos.SolicitudActualizacion) error {
+    return s.clientePara(solicitud.Credenciales).ActualizarPropiedades(ctx, solicitud.IDArchivo, propiedadesAlfresco(solicitud.Propiedades), solicitud.Ticket)
+}
+
+// EliminarDocumento implementa la eliminación definitiva stateless.
+func (s *ServicioAlfresco) EliminarDocumento(ctx context.Context, solicitud documentos.SolicitudDocumento) error {
+    return s.clientePara(solicitud.Credenciales).EliminarDocumento(ctx, solicitud.IDArchivo, solicitud.Ticket)
+}
@@ -0,0 +1,441 @@
+package servicio
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "math/rand"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/metricas"
+    "github.com/CamiloScript/REGAPIGO/shared/solicitud"
+    "github.com/CamiloScript/REGAPIGO/shared/trazas"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/propagation"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// ErrCircuitoAbierto se produce cuando el circuito hacia Alfresco está abierto y la solicitud se rechaza sin enviarse.
+var ErrCircuitoAbierto = errors.New("circuito de Alfresco abierto")
+
+// Estados del circuito.
+const (
+    CircuitoCerrado     = "cerrado"     // Las solicitudes fluyen normalmente
+    CircuitoAbierto     = "abierto"     // Las solicitudes fallan de inmediato
+    CircuitoSemiabierto = "semiabierto" // Una solicitud de prueba decide si el circuito se cierra
+)
+
+// EstadoCircuito resume el circuito para health checks y métricas.
+type EstadoCircuito struct {
+    Estado             string     `json:"estado"`                    // cerrado, abierto o semiabierto
+    FallosConsecutivos int        `json:"fallos_consecutivos"`       // Fallos desde el último éxito
+    Aperturas          int64      `json:"aperturas"`                 // Veces que el circuito se ha abierto
+    Rechazos           int64      `json:"rechazos"`                  // Solicitudes rechazadas con el circuito abierto
+    UltimaApertura     *time.Time `json:"ultima_apertura,omitempty"` // Momento de la última apertura
+}
+
+// CircuitoAlfresco corta las solicitudes a Alfresco tras una racha de fallos y las reanuda con una solicitud de prueba.
+type CircuitoAlfresco struct {
+    mu            sync.Mutex
+    umbral        int           // Fallos consecutivos que abren el circuito (0 lo desactiva)
+    enfriamiento  time.Duration // Tiempo abierto antes de permitir una prueba
+    estado        string
+    fallos        int
+    abiertoDesde  time.Time
+    pruebaEnCurso bool
+    aperturas     int64
+    rechazos      int64
+}
+
+// NuevoCircuitoAlfresco crea un circuito cerrado.
+func NuevoCircuitoAlfresco(umbral int, enfriamiento time.Duration) *CircuitoAlfresco {
+    return &CircuitoAlfresco{umbral: umbral, enfriamiento: enfriamiento, estado: CircuitoCerrado}
+}
+
+// Permitir indica si una solicitud puede enviarse. Con el circuito abierto retorna ErrCircuitoAbierto;
+// pasado el enfriamiento deja pasar una única solicitud de prueba.
+func (c *CircuitoAlfresco) Permitir() error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    switch c.estado {
+    case CircuitoAbierto:
+        if time.Since(c.abiertoDesde) < c.enfriamiento {
+            c.rechazos++
+            return ErrCircuitoAbierto
+        }
+        c.estado = CircuitoSemiabierto
+        c.pruebaEnCurso = true
+        return nil
+    case CircuitoSemiabierto:
+        if c.pruebaEnCurso {
+            c.rechazos++
+            return ErrCircuitoAbierto
+        }
+        c.pruebaEnCurso = true
+        return nil
+    default:
+        return nil
+    }
+}
+
+// RegistrarExito cierra el circuito y reinicia el conteo de fallos.
+func (c *CircuitoAlfresco) RegistrarExito() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.estado = CircuitoCerrado
+    c.fallos = 0
+    c.pruebaEnCurso = false
+}
+
+// RegistrarFallo cuenta un fallo de Alfresco; una prueba fallida o alcanzar el umbral abre el circuito.
+func (c *CircuitoAlfresco) RegistrarFallo() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.fallos++
+    c.pruebaEnCurso = false
+    if c.estado == CircuitoSemiabierto || (c.umbral > 0 && c.fallos >= c.umbral) {
+        c.estado = CircuitoAbierto
+        c.abiertoDesde = time.Now()
+        c.aperturas++
+    }
+}
+
+// Descartar libera una solicitud de prueba cuyo resultado no dice nada de Alfresco (por ejemplo, cancelada por el cliente).
+func (c *CircuitoAlfresco) Descartar() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.pruebaEnCurso = false
+}
+
+// Estado retorna una instantánea del circuito.
+func (c *CircuitoAlfresco) Estado() EstadoCircuito {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    estado := EstadoCircuito{
+        Estado:             c.estado,
+        FallosConsecutivos: c.fallos,
+        Aperturas:          c.aperturas,
+        Rechazos:           c.rechazos,
+    }
+    if c.aperturas > 0 {
+        ultima := c.abiertoDesde
+        estado.UltimaApertura = &ultima
+    }
+    return estado
+}
+
+// ConexionAlfresco agrupa lo que comparten todos los clientes de Alfresco: URL, transporte HTTP,
+// política de reintentos y circuito. Se crea una sola vez y se inyecta en los clientes.
+type ConexionAlfresco struct {
+    mu           sync.RWMutex        // Protege la URL base y la API Key, que pueden cambiar sin reiniciar
+    urlBase      string              // URL base del servidor Alfresco
+    apiKey       string              // API Key configurada
+    clienteHTTP  *http.Client        // Cliente HTTP con transporte compartido
+    reintentos   int                 // Reintentos después del primer intento
+    esperaBase   time.Duration       // Espera antes del primer reintento; se duplica en cada intento
+    esperaMaxima time.Duration       // Tope de cada espera
+    circuito     *CircuitoAlfresco   // Circuito compartido
+    log          *logger.Registrador // Logger para registrar eventos y errores
+}
+
+// NuevaConexionAlfresco construye la conexión compartida a partir de la configuración.
+// Retorna un error si la configuración TLS hacia Alfresco no puede cargarse.
+func NuevaConexionAlfresco(cfg *config.Config, log *logger.Registrador) (*ConexionAlfresco, error) {
+    transporte, err := NuevoTransporte(cfg)
+    if err != nil {
+        return nil, err
+    }
+    return &ConexionAlfresco{
+        urlBase:      cfg.AlfrescoBaseURL,
+        apiKey:       cfg.AlfrescoAPIKey,
+        clienteHTTP:  &http.Client{Transport: transporte, Timeout: cfg.AlfrescoTimeout},
+        reintentos:   cfg.AlfrescoReintentos,
+        esperaBase:   cfg.AlfrescoEsperaBase,
+        esperaMaxima: cfg.AlfrescoEsperaMaxima,
+        circuito:     NuevoCircuitoAlfresco(cfg.AlfrescoCircuitoUmbral, cfg.AlfrescoCircuitoEnfriamiento),
+        log:          log,
+    }, nil
+}
+
+// URLBase retorna la URL base vigente de Alfresco.
+func (c *ConexionAlfresco) URLBase() string {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.urlBase
+}
+
+// EstablecerURLBase reemplaza la URL base al recargar la configuración; las solicitudes siguientes usan la nueva.
+func (c *ConexionAlfresco) EstablecerURLBase(urlBase string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.urlBase = urlBase
+}
+
+// ClaveAPI retorna la API Key vigente de Alfresco.
+func (c *ConexionAlfresco) ClaveAPI() string {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.apiKey
+}
+
+// EstablecerClaveAPI reemplaza la API Key tras una rotación; las solicitudes siguientes usan la nueva.
+func (c *ConexionAlfresco) EstablecerClaveAPI(apiKey string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.apiKey = apiKey
+}
+
+// Sondear envía un GET a la URL base para verificar que Alfresco responde. No pasa por el circuito ni reintenta,
+// para que los health checks no alteren su estado. Retorna el código HTTP recibido; un 5xx se informa como error.
+func (c *ConexionAlfresco) Sondear(ctx context.Context) (int, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URLBase(), nil)
+    if err != nil {
+        return 0, err
+    }
+    req.Header.Set("ADFTannerServices", c.ClaveAPI())
+    resp, err := c.clienteHTTP.Do(req)
+    if err != nil {
+        return 0, errorDeTransporte(err)
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+    if resp.StatusCode >= http.StatusInternalServerError {
+        return resp.StatusCode, fmt.Errorf("Alfresco respondió %s", resp.Status)
+    }
+    return resp.StatusCode, nil
+}
+
+// Circuito retorna el circuito compartido, para exponer su estado.
+func (c *ConexionAlfresco) Circuito() *CircuitoAlfresco {
+    return c.circuito
+}
+
+// PublicarMetricas expone el estado y las aperturas del circuito de esta conexión en /metrics.
+func (c *ConexionAlfresco) PublicarMetricas() {
+    estados := []string{CircuitoCerrado, CircuitoAbierto, CircuitoSemiabierto}
+    metricas.EstablecerCircuitoAlfresco(estados, func() (string, int64) {
+        estado := c.circuito.Estado()
+        return estado.Estado, estado.Aperturas
+    })
+}
+
+// Hacer envía la solicitud aplicando circuito y reintentos, y registra su duración y resultado en las métricas
+// y en un span de traza hijo del que viaja en el contexto de la solicitud.
+// Se reintenta ante errores de red y respuestas 5xx solo si la operación es idempotente; las respuestas 429
+// se reintentan siempre porque Alfresco no procesó la solicitud. Retry-After se respeta hasta la espera máxima.
+// Retorna la última respuesta obtenida (que puede ser un error HTTP) o el error de transporte.
+func (c *ConexionAlfresco) Hacer(req *http.Request, idempotente bool) (*http.Response, error) {
+    operacion := operacionAlfresco(req.URL.Path)
+
+    // 1. Abrir un span de cliente (incluye los reintentos) y propagarlo a Alfresco con traceparent y X-Request-ID
+    ctx, span := trazas.Iniciar(req.Context(), "alfresco."+operacion,
+        trace.WithSpanKind(trace.SpanKindClient),
+        trace.WithAttributes(
+            attribute.String("http.request.method", req.Method),
+            attribute.String("url.path", req.URL.Path),
+        ),
+    )
+    defer span.End()
+    req = req.WithContext(ctx)
+    otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+    // El ID de la solicitud correlaciona los logs de Alfresco con los de la API
+    if idSolicitud := solicitud.DesdeContexto(ctx).ID; idSolicitud != "" {
+        req.Header.Set(solicitud.EncabezadoID, idSolicitud)
+    }
+
+    // 2. Enviar con reintentos y circuito
+    inicio := time.Now()
+    resp, err := c.hacer(req, idempotente)
+    categoria := categoriaMetrica(resp, err)
+    metricas.ObservarAlfresco(operacion, time.Since(inicio), categoria)
+
+    // 3. Registrar el resultado en el span
+    if resp != nil {
+        span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+    }
+    if categoria != "" {
+        span.SetAttributes(attribute.String("error.type", categoria))
+        descripcion := categoria
+        if err != nil {
+            descripcion = err.Error()
+        }
+        span.SetStatus(codes.Error, descripcion)
+    }
+    return resp, err
+}
+
+// operacionesAlfresco asigna un nombre de operación a cada endpoint, para etiquetar las métricas.
+var operacionesAlfresco = map[string]string{
+    "/session/log-in":                  "login",
+    "/tanner-alfresco/file-upload":     "subir",
+    "/tanner-alfresco/files":           "listar",
+    "/tanner-alfresco/file-download":   "descargar",
+    "/tanner-alfresco/file-properties": "actualizar_propiedades",
+    "/tanner-alfresco/file-delete":     "eliminar",
+}
+
+// operacionAlfresco retorna la operación del endpoint; los desconocidos se agrupan en "otra".
+func operacionAlfresco(ruta string) string {
+    for endpoint, operacion := range operacionesAlfresco {
+        if strings.HasSuffix(ruta, endpoint) {
+            return operacion
+        }
+    }
+    return "otra"
+}
+
+// categoriaMetrica resume el resultado de una llamada para la métrica de errores; vacío si fue exitosa.
+func categoriaMetrica(resp *http.Response, err error) string {
+    switch {
+    case errors.Is(err, ErrCircuitoAbierto):
+        return "circuito_abierto"
+    case err != nil:
+        return "transporte"
+    case resp.StatusCode < 400:
+        return ""
+    }
+    switch categoriaDesdeEstado(resp.StatusCode) {
+    case documentos.ErrDocumentoNoEncontrado:
+        return "no_encontrado"
+    case documentos.ErrConflicto:
+        return "conflicto"
+    case documentos.ErrTicketInvalido:
+        return "ticket_invalido"
+    case documentos.ErrCuotaExcedida:
+        return "cuota_excedida"
+    case documentos.ErrRepositorioNoDisponible:
+        return "no_disponible"
+    default:
+        return "validacion"
+    }
+}
+
+// hacer implementa Hacer sin métricas.
+func (c *ConexionAlfresco) hacer(req *http.Request, idempotente bool) (*http.Response, error) {
+    ctx := req.Context()
+
+    for intento := 0; ; intento++ {
+        // 1. Fallar rápido si Alfresco está caído
+        if err := c.circuito.Permitir(); err != nil {
+            return nil, err
+        }
+
+        // 2. Enviar un intento con un cuerpo nuevo
+        resp, err := c.clienteHTTP.Do(clonarSolicitud(req))
+
+        // 3. Clasificar el resultado
+        reintentable := false
+        switch {
+        case err != nil && ctx.Err() != nil:
+            c.circuito.Descartar()
+            return nil, err
+        case err != nil:
+            c.circuito.RegistrarFallo()
+            reintentable = idempotente
+        case resp.StatusCode >= 500:
+            c.circuito.RegistrarFallo()
+            reintentable = idempotente
+        case resp.StatusCode == http.StatusTooManyRequests:
+            c.circuito.RegistrarExito()
+            reintentable = true
+        default:
+            c.circuito.RegistrarExito()
+        }
+
+        if !reintentable || intento >= c.reintentos || (req.Body != nil && req.GetBody == nil) {
+            return resp, err
+        }
+
+        // 4. Esperar antes del siguiente intento
+        espera := c.espera(intento + 1)
+        if retryAfter, ok := esperaRetryAfter(resp); ok {
+            espera = retryAfter
+        }
+        if c.esperaMaxima > 0 && espera > c.esperaMaxima {
+            espera = c.esperaMaxima
+        }
+
+        detalle := map[string]interface{}{
+            "metodo":  req.Method,
+            "ruta":    req.URL.Path,
+            "intento": intento + 1,
+            "espera":  espera.String(),
+        }
+        if err != nil {
+            detalle["error"] = err.Error()
+        } else {
+            detalle["status_code"] = resp.StatusCode
+            io.Copy(io.Discard, resp.Body)
+            resp.Body.Close()
+        }
+        c.log.ConContexto(ctx).Warn("Reintentando solicitud a Alfresco", detalle)
+
+        if err := esperar(ctx, espera); err != nil {
+            return nil, err
+        }
+    }
+}
+
+// espera calcula la espera exponencial con jitter para el reintento indicado.
+func (c *ConexionAlfresco) espera(reintento int) time.Duration {
+    base := c.esperaBase << (reintento - 1)
+    if base <= 0 {
+        return 0
+    }
+    return base/2 + time.Duration(rand.Int63n(int64(base/2)+1))
+}
+
+// esperaRetryAfter interpreta el header Retry-After en segundos o como fecha HTTP.
+func esperaRetryAfter(resp *http.Response) (time.Duration, bool) {
+    if resp == nil {
+        return 0, false
+    }
+    valor := resp.Header.Get("Retry-After")
+    if valor == "" {
+        return 0, false
+    }
+    if segundos, err := strconv.Atoi(valor); err == nil && segundos >= 0 {
+        return time.Duration(segundos) * time.Second, true
+    }
+    if fecha, err := http.ParseTime(valor); err == nil {
+        if espera := time.Until(fecha); espera > 0 {
+            return espera, true
+        }
+        return 0, true
+    }
+    return 0, false
+}
+
+// clonarSolicitud prepara un intento con un cuerpo sin consumir.
+func clonarSolicitud(req *http.Request) *http.Request {
+    intento := req.Clone(req.Context())
+    if req.GetBody != nil {
+        if cuerpo, err := req.GetBody(); err == nil {
+            intento.Body = cuerpo
+        }
+    }
+    return intento
+}
+
+// esperar bloquea durante la duración indicada o hasta que el contexto se cancele.
+func esperar(ctx context.Context, duracion time.Duration) error {
+    temporizador := time.NewTimer(duracion)
+    defer temporizador.Stop()
+    select {
+    case <-ctx.Done():
+        return fmt.Errorf("espera de reintento cancelada: %w", ctx.Err())
+    case <-temporizador.C:
+        return nil
+    }
+}
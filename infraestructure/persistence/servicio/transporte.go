@@ -0,0 +1,90 @@
+package servicio
+
+import (
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "net/url"
+    "os"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+)
+
+// versionesTLS traduce ALFRESCO_TLS_VERSION_MINIMA; sin valor se usa TLS 1.2.
+var versionesTLS = map[string]uint16{"1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13}
+
+// NuevoTransporte crea el transporte HTTP hacia Alfresco: conexiones reutilizables, proxy y TLS según la
+// configuración. Es el único punto donde se construye, para que el cliente de documentos y el de autenticación
+// verifiquen a Alfresco de la misma forma.
+// Parámetros:
+//   - cfg: Configuración (CA, certificado de cliente, versión mínima de TLS y proxy).
+// Retorna un error si algún archivo de certificados no existe o no es válido.
+func NuevoTransporte(cfg *config.Config) (*http.Transport, error) {
+    configuracionTLS, err := nuevaConfiguracionTLS(cfg)
+    if err != nil {
+        return nil, err
+    }
+
+    // El proxy configurado tiene prioridad; sin él se respetan HTTPS_PROXY, HTTP_PROXY y NO_PROXY
+    proxy := http.ProxyFromEnvironment
+    if cfg.AlfrescoProxy != "" {
+        direccion, err := url.Parse(cfg.AlfrescoProxy)
+        if err != nil {
+            return nil, errors.New("URL del proxy de Alfresco inválida")
+        }
+        proxy = http.ProxyURL(direccion)
+    }
+
+    return &http.Transport{
+        Proxy: proxy,
+        DialContext: (&net.Dialer{
+            Timeout:   5 * time.Second,
+            KeepAlive: 30 * time.Second,
+        }).DialContext,
+        TLSClientConfig:       configuracionTLS,
+        ForceAttemptHTTP2:     true,
+        MaxIdleConns:          100,
+        MaxIdleConnsPerHost:   20,
+        IdleConnTimeout:       90 * time.Second,
+        TLSHandshakeTimeout:   10 * time.Second,
+        ExpectContinueTimeout: 1 * time.Second,
+    }, nil
+}
+
+// nuevaConfiguracionTLS arma la verificación de Alfresco: las CA del sistema más ALFRESCO_TLS_CA, y el
+// certificado de cliente si Alfresco exige mTLS.
+func nuevaConfiguracionTLS(cfg *config.Config) (*tls.Config, error) {
+    configuracion := &tls.Config{MinVersion: tls.VersionTLS12}
+    if version, existe := versionesTLS[cfg.AlfrescoTLSVersionMinima]; existe {
+        configuracion.MinVersion = version
+    }
+
+    // 1. CA adicionales (ej. la CA interna que firma el certificado de Alfresco)
+    if cfg.AlfrescoTLSCA != "" {
+        pem, err := os.ReadFile(cfg.AlfrescoTLSCA)
+        if err != nil {
+            return nil, fmt.Errorf("error al leer la CA de Alfresco: %v", err)
+        }
+        raices, err := x509.SystemCertPool()
+        if err != nil {
+            raices = x509.NewCertPool()
+        }
+        if !raices.AppendCertsFromPEM(pem) {
+            return nil, errors.New("la CA de Alfresco no contiene certificados PEM válidos")
+        }
+        configuracion.RootCAs = raices
+    }
+
+    // 2. Certificado de cliente
+    if cfg.AlfrescoTLSCertificado != "" {
+        certificado, err := tls.LoadX509KeyPair(cfg.AlfrescoTLSCertificado, cfg.AlfrescoTLSClave)
+        if err != nil {
+            return nil, fmt.Errorf("error al cargar el certificado de cliente para Alfresco: %v", err)
+        }
+        configuracion.Certificates = []tls.Certificate{certificado}
+    }
+    return configuracion, nil
+}
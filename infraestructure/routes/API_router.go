@@ -4,56 +4,139 @@ import (
     "github.com/CamiloScript/REGAPIGO/domain/auth"
     "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
     "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/salud"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
     "github.com/CamiloScript/REGAPIGO/shared/config"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/application/auditoria"
+    "github.com/CamiloScript/REGAPIGO/application/limites"
     "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
     "github.com/gin-gonic/gin"
+    mongodriver "go.mongodb.org/mongo-driver/mongo"
 )
 
+// Dependencias agrupa los servicios compartidos entre la API y las tareas en segundo plano.
+type Dependencias struct {
+    ServicioDocumentos *documento.ImplementacionServicioDocumentos // Servicio de documentos
+    ServicioAuditoria  *auditoria.ServicioAuditoria                // Registro de auditoría compartido por documentos y consultas
+    ServicioAuth       auth.AuthService                            // Servicio de autenticación con Alfresco
+    AuthInterna        *handlers.InternalAuth                      // Ticket interno de Alfresco compartido con las tareas en segundo plano
+    ConexionAlfresco   *servicio.ConexionAlfresco                  // Conexión compartida con Alfresco (estado del circuito)
+    ClienteMongo       *mongodriver.Client                         // Cliente de MongoDB compartido por los repositorios
+    MonitorSalud       *salud.Monitor                              // Verificación de dependencias para /health/ready
+    Limitador          *limites.Limitador                          // Rate limiting y cuotas por cliente; nil si están deshabilitados
+    Cifrador           *cifrado.Cifrador                           // Cifrado de los datos sensibles del índice; nil si está deshabilitado
+}
+
+// middlewaresLimites retorna el rate limiting y, si se indica una dirección, la cuota diaria, o nada si los
+// límites están deshabilitados.
+func middlewaresLimites(manejador *handlers.ManejadorLimites, direccion string) []gin.HandlerFunc {
+    if manejador == nil {
+        return nil
+    }
+    if direccion == "" {
+        return []gin.HandlerFunc{manejador.Limitar}
+    }
+    return []gin.HandlerFunc{manejador.Cuota(direccion)}
+}
+
 // RegistrarRutas configura todas las rutas de la API.
 // Parámetros:
 //   - router: Instancia del enrutador de Gin.
 //   - log: Logger para registrar eventos y errores.
 //   - cfg: Configuración de la aplicación.
-func RegistrarRutas(router *gin.Engine, log *logger.Registrador, cfg *config.Config) {
+//   - deps: Servicios construidos en main.
+func RegistrarRutas(router *gin.Engine, log *logger.Registrador, cfg *config.Config, deps Dependencias) {
+    servicioNegocio := deps.ServicioDocumentos
+    servicioAuditoria := deps.ServicioAuditoria
+    servicioAuth := deps.ServicioAuth
+    autenticacionInterna := deps.AuthInterna
+    clienteMongo := deps.ClienteMongo
+
+    // Límites por cliente: el rate limiting se aplica por grupo y las cuotas en las rutas que transfieren documentos
+    var manejadorLimites *handlers.ManejadorLimites
+    if deps.Limitador != nil {
+        manejadorLimites = handlers.NuevoManejadorLimites(deps.Limitador, log)
+    }
+    limitar := middlewaresLimites(manejadorLimites, "")
+    cuotaSubida := middlewaresLimites(manejadorLimites, limites.DireccionSubida)
+    cuotaDescarga := middlewaresLimites(manejadorLimites, limites.DireccionDescarga)
 
     // Autenticación
-    clienteAuth := servicio.NewAuthClient(cfg, log)                 // Cliente de autenticación
-    servicioAuth := auth.NewAuthService(clienteAuth, log)           // Servicio de autenticación
     manejadorAuth := handlers.NewAuthHandler(servicioAuth, log)     // Manejador de autenticación
 
     // Ruta de login
-    router.POST("/auth/login", manejadorAuth.Login)
+    router.POST("/auth/login", append(limitar, manejadorAuth.Login)...)
+
+    // Índice de documentos compartido por los manejadores
+    indice := mongo.NuevoRepositorioIndice(clienteMongo, cfg)
+    indice.EstablecerCifrador(deps.Cifrador)
 
     // Grupo de rutas protegidas (documentos)
-    grupoDocumentos := router.Group("/documentos")
+    grupoDocumentos := router.Group("/documentos", limitar...)
     {
-        // Inicializar servicios
-        servicioDocs := servicio.NuevoServicioDocumentos(cfg, log)                      // Servicio de Alfresco
-        servicioNegocio := documento.NuevoServicioDocumentos(servicioDocs, log, cfg.AlfrescoAPIKey) // Servicio de negocio
-
         // Inicializar manejadores con autenticación interna
-        manejadorDocs := handlers.NuevoManejadorDocumentos(servicioNegocio, log, cfg, servicioAuth) // Manejador de documentos
-        manejadorBusqueda := handlers.NuevoManejadorBusquedaDescarga(servicioNegocio, log, cfg, servicioAuth) // Manejador de búsqueda y descarga
+        manejadorDocs := handlers.NuevoManejadorDocumentos(servicioNegocio, indice, log, cfg, autenticacionInterna) // Manejador de documentos
+        manejadorBusqueda := handlers.NuevoManejadorBusquedaDescarga(servicioNegocio, indice, log, cfg, autenticacionInterna) // Manejador de búsqueda y descarga
 
         // Ruta para subir documentos: recibe una solicitud POST en "/documentos/subir".
-        grupoDocumentos.POST("/subir", manejadorDocs.ManejadorSubirDocumento)
+        grupoDocumentos.POST("/subir", append(cuotaSubida, manejadorDocs.ManejadorSubirDocumento)...)
 
         // Ruta para listar documentos: recibe una solicitud POST en "/documentos/listar".
         grupoDocumentos.POST("/listar", manejadorDocs.ManejadorListarDocumentos)
 
         // Ruta para descargar documentos: recibe una solicitud POST en "/documentos/descargar".
-        grupoDocumentos.POST("/descargar", manejadorDocs.ManejadorDescargarDocumento)
+        grupoDocumentos.POST("/descargar", append(cuotaDescarga, manejadorDocs.ManejadorDescargarDocumento)...)
         
         // Ruta para subir lotes de documentos: recibe una solicitud POST en "/documentos/subir-lote".
-        grupoDocumentos.POST("/subir-lote", manejadorDocs.ManejadorLoteDocumentos)
+        grupoDocumentos.POST("/subir-lote", append(cuotaSubida, manejadorDocs.ManejadorLoteDocumentos)...)
 
         // Ruta para buscar y descargar documentos: recibe una solicitud POST en "/documentos/buscar-descargar".
-        grupoDocumentos.POST("/buscar-descargar", manejadorBusqueda.BuscarYDescargarDocumento)
+        grupoDocumentos.POST("/buscar-descargar", append(cuotaDescarga, manejadorBusqueda.BuscarYDescargarDocumento)...)
+
+        // Ruta para eliminar documentos: recibe una solicitud DELETE en "/documentos/{id}".
+        grupoDocumentos.DELETE("/:id", manejadorDocs.ManejadorEliminarDocumento)
     }
 
-    // Ruta de health check
+    // Grupo de rutas de suscripciones a avisos de vencimiento
+    grupoWebhooks := router.Group("/webhooks/suscripciones", limitar...)
+    {
+        suscripciones := mongo.NuevoRepositorioSuscripciones(clienteMongo, cfg)
+        suscripciones.EstablecerCifrador(deps.Cifrador)
+        servicioSuscripciones := notificacion.NuevoServicioSuscripciones(
+            suscripciones,
+            mongo.NuevoRepositorioEntregas(clienteMongo, cfg),
+            cfg.WebhookDiasDefecto,
+        )
+        manejadorWebhooks := handlers.NuevoManejadorWebhooks(servicioSuscripciones, log)
+
+        grupoWebhooks.POST("", manejadorWebhooks.ManejadorCrearSuscripcion)
+        grupoWebhooks.GET("", manejadorWebhooks.ManejadorListarSuscripciones)
+        grupoWebhooks.DELETE("/:id", manejadorWebhooks.ManejadorEliminarSuscripcion)
+        grupoWebhooks.GET("/:id/entregas", manejadorWebhooks.ManejadorListarEntregas)
+    }
+
+    // Grupo de rutas de auditoría
+    grupoAuditoria := router.Group("/auditoria", limitar...)
+    {
+        manejadorAuditoria := handlers.NuevoManejadorAuditoria(servicioAuditoria, log)
+
+        grupoAuditoria.GET("", manejadorAuditoria.ManejadorConsultarAuditoria)
+        grupoAuditoria.GET("/verificacion", manejadorAuditoria.ManejadorVerificarAuditoria)
+    }
+
+    // Ruta de health check (incluye el estado del circuito hacia Alfresco)
     router.GET("/health", func(c *gin.Context) {
-        c.JSON(200, gin.H{"estado": "activo"})
+        c.JSON(200, gin.H{
+            "estado":   "activo",
+            "alfresco": gin.H{"circuito": deps.ConexionAlfresco.Circuito().Estado()},
+        })
     })
+
+    // Sondas de liveness y readiness
+    manejadorSalud := handlers.NuevoManejadorSalud(deps.MonitorSalud)
+    router.GET("/health/live", manejadorSalud.ManejadorVivo)
+    router.GET("/health/ready", manejadorSalud.ManejadorListo)
 }
\ No newline at end of file
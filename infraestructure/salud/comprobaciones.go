@@ -0,0 +1,55 @@
+package salud
+
+import (
+    "context"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    mongodriver "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Autenticador obtiene el ticket de la autenticación interna (handlers.InternalAuth).
+type Autenticador interface {
+    AutenticarInternamente() (string, error)
+}
+
+// ComprobacionMongo verifica MongoDB con un ping al primario. Es crítica: el índice y la auditoría dependen de ella.
+func ComprobacionMongo(cliente *mongodriver.Client) Comprobacion {
+    return Comprobacion{
+        Nombre:  "mongodb",
+        Critica: true,
+        Verificar: func(ctx context.Context) (map[string]interface{}, error) {
+            return nil, cliente.Ping(ctx, readpref.Primary())
+        },
+    }
+}
+
+// ComprobacionAlfresco verifica que Alfresco responda, sin reintentos ni circuito, e informa el estado del circuito.
+// Es crítica: sin Alfresco no hay cargas ni descargas.
+func ComprobacionAlfresco(conexion *servicio.ConexionAlfresco) Comprobacion {
+    return Comprobacion{
+        Nombre:  "alfresco",
+        Critica: true,
+        Verificar: func(ctx context.Context) (map[string]interface{}, error) {
+            estado, err := conexion.Sondear(ctx)
+            detalle := map[string]interface{}{"circuito": conexion.Circuito().Estado()}
+            if estado != 0 {
+                detalle["codigo_http"] = estado
+            }
+            return detalle, err
+        },
+    }
+}
+
+// ComprobacionTicket verifica que la autenticación interna obtenga un ticket vigente. Reutiliza el ticket en caché,
+// por lo que solo inicia sesión cuando expiró o cambió la contraseña. No es crítica: las solicitudes con ticket
+// propio siguen funcionando.
+func ComprobacionTicket(autenticador Autenticador) Comprobacion {
+    return Comprobacion{
+        Nombre:  "ticket",
+        Critica: false,
+        Verificar: func(ctx context.Context) (map[string]interface{}, error) {
+            _, err := autenticador.AutenticarInternamente()
+            return nil, err
+        },
+    }
+}
@@ -0,0 +1,133 @@
+package salud
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// Estados de una dependencia y del servicio.
+const (
+    EstadoOK        = "ok"        // La dependencia responde
+    EstadoError     = "error"     // La dependencia falló o no respondió a tiempo
+    EstadoListo     = "listo"     // Todas las dependencias responden
+    EstadoDegradado = "degradado" // Falla al menos una dependencia no crítica
+    EstadoNoListo   = "no_listo"  // Falla al menos una dependencia crítica
+)
+
+// Comprobacion verifica una dependencia. Verificar retorna información adicional para el reporte
+// (ej. el estado del circuito) y un error si la dependencia no está disponible.
+type Comprobacion struct {
+    Nombre    string                                                 // Nombre en el reporte (mongodb, alfresco, ...)
+    Critica   bool                                                   // Si falla, el servicio no está listo (503)
+    Verificar func(ctx context.Context) (map[string]interface{}, error) // Verificación; debe respetar ctx cuando pueda
+}
+
+// ResultadoDependencia es el estado de una dependencia en el reporte.
+type ResultadoDependencia struct {
+    Estado     string                 `json:"estado"`            // ok o error
+    Critica    bool                   `json:"critica"`           // Si su falla deja al servicio no listo
+    LatenciaMs int64                  `json:"latencia_ms"`       // Duración de la verificación
+    Error      string                 `json:"error,omitempty"`   // Motivo de la falla
+    Detalle    map[string]interface{} `json:"detalle,omitempty"` // Información adicional de la dependencia
+}
+
+// Reporte es el resultado de verificar todas las dependencias.
+type Reporte struct {
+    Estado       string                          `json:"estado"`        // listo, degradado o no_listo
+    VerificadoEn time.Time                       `json:"verificado_en"` // Momento de la verificación (puede venir de caché)
+    Dependencias map[string]ResultadoDependencia `json:"dependencias"`  // Resultado por dependencia
+}
+
+// Listo indica si el servicio puede recibir tráfico: ninguna dependencia crítica falla.
+func (r Reporte) Listo() bool {
+    return r.Estado != EstadoNoListo
+}
+
+// Monitor verifica las dependencias con un tiempo máximo por verificación y guarda el último reporte,
+// para que sondas frecuentes (balanceador, Kubernetes) no sobrecarguen MongoDB ni Alfresco.
+type Monitor struct {
+    mu             sync.Mutex     // Serializa las verificaciones; las solicitudes concurrentes comparten el resultado
+    comprobaciones []Comprobacion // Dependencias a verificar
+    timeout        time.Duration  // Tiempo máximo por verificación
+    cache          time.Duration  // Tiempo que se reutiliza un reporte
+    ultimo         *Reporte       // Último reporte
+}
+
+// NuevoMonitor crea un monitor con las comprobaciones indicadas.
+// Parámetros:
+//   - timeout: Tiempo máximo por verificación; al vencer la dependencia se informa con error.
+//   - cache: Tiempo que se reutiliza un reporte antes de verificar de nuevo.
+func NuevoMonitor(timeout, cache time.Duration, comprobaciones ...Comprobacion) *Monitor {
+    return &Monitor{comprobaciones: comprobaciones, timeout: timeout, cache: cache}
+}
+
+// Verificar retorna el reporte vigente, verificando de nuevo todas las dependencias si el de la caché expiró.
+func (m *Monitor) Verificar(ctx context.Context) Reporte {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if m.ultimo != nil && time.Since(m.ultimo.VerificadoEn) < m.cache {
+        return *m.ultimo
+    }
+
+    // 1. Verificar todas las dependencias en paralelo
+    resultados := make([]ResultadoDependencia, len(m.comprobaciones))
+    var grupo sync.WaitGroup
+    for i, comprobacion := range m.comprobaciones {
+        grupo.Add(1)
+        go func(i int, comprobacion Comprobacion) {
+            defer grupo.Done()
+            resultados[i] = m.verificarUna(ctx, comprobacion)
+        }(i, comprobacion)
+    }
+    grupo.Wait()
+
+    // 2. Combinar: una crítica caída deja al servicio no listo; una no crítica, degradado
+    reporte := Reporte{Estado: EstadoListo, VerificadoEn: time.Now(), Dependencias: make(map[string]ResultadoDependencia, len(resultados))}
+    for i, resultado := range resultados {
+        reporte.Dependencias[m.comprobaciones[i].Nombre] = resultado
+        if resultado.Estado == EstadoOK {
+            continue
+        }
+        if resultado.Critica {
+            reporte.Estado = EstadoNoListo
+        } else if reporte.Estado == EstadoListo {
+            reporte.Estado = EstadoDegradado
+        }
+    }
+    m.ultimo = &reporte
+    return reporte
+}
+
+// verificarUna ejecuta una comprobación con tiempo máximo. Si no termina a tiempo se informa el vencimiento;
+// la comprobación sigue en segundo plano y su resultado se descarta.
+func (m *Monitor) verificarUna(ctx context.Context, comprobacion Comprobacion) ResultadoDependencia {
+    ctx, cancelar := context.WithTimeout(ctx, m.timeout)
+    defer cancelar()
+
+    type salida struct {
+        detalle map[string]interface{}
+        err     error
+    }
+    inicio := time.Now()
+    terminada := make(chan salida, 1)
+    go func() {
+        detalle, err := comprobacion.Verificar(ctx)
+        terminada <- salida{detalle: detalle, err: err}
+    }()
+
+    resultado := ResultadoDependencia{Estado: EstadoOK, Critica: comprobacion.Critica}
+    select {
+    case s := <-terminada:
+        resultado.Detalle = s.detalle
+        if s.err != nil {
+            resultado.Estado = EstadoError
+            resultado.Error = s.err.Error()
+        }
+    case <-ctx.Done():
+        resultado.Estado = EstadoError
+        resultado.Error = "sin respuesta en " + m.timeout.String()
+    }
+    resultado.LatenciaMs = time.Since(inicio).Milliseconds()
+    return resultado
+}
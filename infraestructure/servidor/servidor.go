@@ -0,0 +1,156 @@
+package servidor
+
+import (
+    "context"
+    "errors"
+    "net"
+    "net/http"
+    "sync"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+)
+
+// Servidor envuelve el http.Server de la API junto con las tareas en segundo plano y los recursos que
+// deben cerrarse al apagar, para detener todo en orden.
+type Servidor struct {
+    http   *http.Server        // Servidor HTTP con timeouts
+    plazo  time.Duration       // Tiempo máximo para el apagado ordenado
+    log    *logger.Registrador // Logger para registrar eventos y errores
+
+    ctxTareas      context.Context    // Contexto de las tareas en segundo plano; se cancela al apagar
+    cancelarTareas context.CancelFunc // Detiene las tareas en segundo plano
+    tareas         sync.WaitGroup     // Tareas en ejecución
+
+    cierres []cierre // Recursos a cerrar después de las solicitudes y tareas, en orden de registro
+}
+
+// cierre es un recurso que se libera al apagar (ej. el cliente de MongoDB).
+type cierre struct {
+    nombre string
+    cerrar func(ctx context.Context) error
+}
+
+// Nuevo crea el servidor con los timeouts de la configuración.
+// Parámetros:
+//   - cfg: Configuración (puerto, timeouts y plazo de apagado).
+//   - manejador: Router con las rutas de la API.
+//   - log: Logger para registrar eventos y errores.
+func Nuevo(cfg *config.Config, manejador http.Handler, log *logger.Registrador) *Servidor {
+    ctxTareas, cancelarTareas := context.WithCancel(context.Background())
+    return &Servidor{
+        http: &http.Server{
+            Addr:              ":" + cfg.Puerto,
+            Handler:           manejador,
+            ReadHeaderTimeout: cfg.ServidorTimeoutEncabezados,
+            ReadTimeout:       cfg.ServidorTimeoutLectura,
+            WriteTimeout:      cfg.ServidorTimeoutEscritura,
+            IdleTimeout:       cfg.ServidorTimeoutInactividad,
+        },
+        plazo:          cfg.ServidorPlazoApagado,
+        log:            log,
+        ctxTareas:      ctxTareas,
+        cancelarTareas: cancelarTareas,
+    }
+}
+
+// IniciarTarea ejecuta una tarea en segundo plano. La tarea debe terminar cuando se cancele su contexto;
+// el apagado la espera hasta el plazo configurado.
+func (s *Servidor) IniciarTarea(tarea func(ctx context.Context)) {
+    s.tareas.Add(1)
+    go func() {
+        defer s.tareas.Done()
+        tarea(s.ctxTareas)
+    }()
+}
+
+// AlCerrar registra un recurso que se cierra al apagar, después de las solicitudes y las tareas.
+func (s *Servidor) AlCerrar(nombre string, cerrar func(ctx context.Context) error) {
+    s.cierres = append(s.cierres, cierre{nombre: nombre, cerrar: cerrar})
+}
+
+// Ejecutar escucha en el puerto configurado y atiende solicitudes hasta que se cancele ctx (ej. SIGTERM).
+func (s *Servidor) Ejecutar(ctx context.Context) error {
+    escucha, err := net.Listen("tcp", s.http.Addr)
+    if err != nil {
+        return err
+    }
+    return s.Servir(ctx, escucha)
+}
+
+// Servir atiende solicitudes en el listener indicado hasta que se cancele ctx y luego apaga en orden.
+// Retorna el error del servidor o del apagado; nil si todo terminó dentro del plazo.
+func (s *Servidor) Servir(ctx context.Context, escucha net.Listener) error {
+    // 1. Atender solicitudes en segundo plano
+    //    (con TLS si se llamó a ConfigurarTLS; el certificado lo entrega TLSConfig)
+    conTLS := s.http.TLSConfig != nil // Se lee antes de servir: Serve modifica TLSConfig al configurar HTTP/2
+    errServidor := make(chan error, 1)
+    go func() {
+        if conTLS {
+            errServidor <- s.http.ServeTLS(escucha, "", "")
+            return
+        }
+        errServidor <- s.http.Serve(escucha)
+    }()
+    s.log.Info("Servidor escuchando", map[string]interface{}{"direccion": escucha.Addr().String(), "tls": conTLS})
+
+    // 2. Esperar la señal de apagado o una falla del servidor
+    select {
+    case err := <-errServidor:
+        s.cancelarTareas()
+        return err
+    case <-ctx.Done():
+    }
+
+    // 3. Apagar dentro del plazo
+    ctxApagado, cancelar := context.WithTimeout(context.Background(), s.plazo)
+    defer cancelar()
+    return s.Apagar(ctxApagado)
+}
+
+// Apagar deja de aceptar solicitudes, espera las que están en curso y las tareas en segundo plano,
+// cierra los recursos registrados y vacía los logs. Lo que no termine antes de ctx se abandona.
+func (s *Servidor) Apagar(ctx context.Context) error {
+    inicio := time.Now()
+    s.log.Info("Apagando servidor", map[string]interface{}{"plazo": s.plazo.String()})
+    var errores []error
+
+    // 1. Cerrar el listener y esperar las solicitudes en curso
+    if err := s.http.Shutdown(ctx); err != nil {
+        s.log.Warn("Solicitudes en curso interrumpidas por el plazo de apagado", map[string]interface{}{"error": err.Error()})
+        errores = append(errores, err)
+    }
+
+    // 2. Detener las tareas en segundo plano y esperarlas
+    s.cancelarTareas()
+    terminadas := make(chan struct{})
+    go func() {
+        s.tareas.Wait()
+        close(terminadas)
+    }()
+    select {
+    case <-terminadas:
+    case <-ctx.Done():
+        s.log.Warn("Tareas en segundo plano sin terminar al vencer el plazo de apagado", nil)
+        errores = append(errores, ctx.Err())
+    }
+
+    // 3. Cerrar recursos; cada uno recibe el tiempo restante, o uno breve si el plazo ya venció
+    ctxCierre := ctx
+    if ctx.Err() != nil {
+        var cancelar context.CancelFunc
+        ctxCierre, cancelar = context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancelar()
+    }
+    for _, recurso := range s.cierres {
+        if err := recurso.cerrar(ctxCierre); err != nil {
+            s.log.Warn("Error al cerrar recurso", map[string]interface{}{"recurso": recurso.nombre, "error": err.Error()})
+            errores = append(errores, err)
+        }
+    }
+
+    // 4. Vaciar los logs pendientes
+    s.log.Info("Servidor detenido", map[string]interface{}{"duracion": time.Since(inicio).String()})
+    s.log.Vaciar()
+    return errors.Join(errores...)
+}
@@ -0,0 +1,207 @@
+package servidor
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "os"
+    "sync"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+)
+
+// Modos de verificación del certificado de cliente (TLS_CLIENTE_MODO).
+const (
+    ModoClienteRequerido = "requerido" // Sin certificado válido se rechaza la conexión
+    ModoClienteOpcional  = "opcional"  // Se verifica si se presenta; sin él el cliente se identifica por API Key
+)
+
+// versionesTLS traduce TLS_VERSION_MINIMA.
+var versionesTLS = map[string]uint16{"1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13}
+
+// CertificadosTLS mantiene el certificado del servidor y la CA de clientes vigentes. Los archivos se vuelven
+// a leer cuando cambia su fecha de modificación, de modo que una renovación (ej. cert-manager) se aplica a las
+// conexiones nuevas sin reiniciar. Si los archivos nuevos son inválidos se conservan los anteriores.
+type CertificadosTLS struct {
+    rutaCertificado string              // Archivo PEM del certificado del servidor
+    rutaClave       string              // Archivo PEM de la clave privada
+    rutaCA          string              // Archivo PEM de las CA de clientes; vacío sin mTLS
+    log             *logger.Registrador // Logger para registrar recargas y errores
+
+    mu          sync.RWMutex
+    certificado *tls.Certificate // Certificado vigente
+    clientes    *x509.CertPool   // CA de clientes vigente
+    modificados []time.Time      // Fecha de modificación de cada archivo en la última carga
+}
+
+// NuevoCertificadosTLS carga los certificados indicados.
+// Parámetros:
+//   - certificado: Archivo PEM del certificado del servidor, con la cadena intermedia.
+//   - clave: Archivo PEM de la clave privada.
+//   - ca: Archivo PEM con las CA que firman los certificados de cliente; vacío si no se usa mTLS.
+//   - log: Logger para registrar recargas y errores.
+// Retorna un error si algún archivo no existe o no es válido.
+func NuevoCertificadosTLS(certificado, clave, ca string, log *logger.Registrador) (*CertificadosTLS, error) {
+    c := &CertificadosTLS{rutaCertificado: certificado, rutaClave: clave, rutaCA: ca, log: log}
+    if err := c.cargar(); err != nil {
+        return nil, err
+    }
+    return c, nil
+}
+
+// archivos retorna las rutas vigiladas.
+func (c *CertificadosTLS) archivos() []string {
+    if c.rutaCA == "" {
+        return []string{c.rutaCertificado, c.rutaClave}
+    }
+    return []string{c.rutaCertificado, c.rutaClave, c.rutaCA}
+}
+
+// cargar lee los archivos y reemplaza los certificados vigentes solo si todos son válidos.
+func (c *CertificadosTLS) cargar() error {
+    // 1. Registrar las fechas antes de leer, para que un cambio durante la lectura se detecte en la próxima revisión
+    modificados := make([]time.Time, 0, 3)
+    for _, ruta := range c.archivos() {
+        modificados = append(modificados, fechaModificacion(ruta))
+    }
+
+    // 2. Certificado y clave del servidor
+    certificado, err := tls.LoadX509KeyPair(c.rutaCertificado, c.rutaClave)
+    if err != nil {
+        return fmt.Errorf("error al cargar el certificado TLS: %v", err)
+    }
+
+    // 3. CA de clientes
+    var clientes *x509.CertPool
+    if c.rutaCA != "" {
+        pem, err := os.ReadFile(c.rutaCA)
+        if err != nil {
+            return fmt.Errorf("error al leer la CA de clientes: %v", err)
+        }
+        clientes = x509.NewCertPool()
+        if !clientes.AppendCertsFromPEM(pem) {
+            return errors.New("la CA de clientes no contiene certificados PEM válidos")
+        }
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.certificado, c.clientes, c.modificados = &certificado, clientes, modificados
+    return nil
+}
+
+// Recargar vuelve a leer los archivos si alguno cambió desde la última carga.
+// Retorna true si se aplicaron certificados nuevos; ante un error se conservan los vigentes.
+func (c *CertificadosTLS) Recargar() (bool, error) {
+    c.mu.RLock()
+    cambio := false
+    for i, ruta := range c.archivos() {
+        if !fechaModificacion(ruta).Equal(c.modificados[i]) {
+            cambio = true
+        }
+    }
+    c.mu.RUnlock()
+    if !cambio {
+        return false, nil
+    }
+    if err := c.cargar(); err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// Vigilar revisa los archivos cada intervalo hasta que se cancele ctx.
+func (c *CertificadosTLS) Vigilar(ctx context.Context, intervalo time.Duration) {
+    ticker := time.NewTicker(intervalo)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+        recargado, err := c.Recargar()
+        if err != nil {
+            c.log.Warn("Certificados TLS inválidos; se mantienen los vigentes", map[string]interface{}{"error": err.Error()})
+            continue
+        }
+        if recargado {
+            c.log.Info("Certificados TLS recargados", map[string]interface{}{"vence": c.Vencimiento().Format(time.RFC3339)})
+        }
+    }
+}
+
+// Vencimiento retorna la fecha de expiración del certificado vigente.
+func (c *CertificadosTLS) Vencimiento() time.Time {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    if c.certificado.Leaf == nil {
+        return time.Time{}
+    }
+    return c.certificado.Leaf.NotAfter
+}
+
+// Configuracion retorna la configuración TLS del servidor. Cada conexión nueva toma el certificado y la CA
+// vigentes en ese momento; las conexiones abiertas conservan los del handshake.
+// Parámetros:
+//   - versionMinima: 1.2 o 1.3.
+//   - modoCliente: ModoClienteRequerido u ModoClienteOpcional; solo aplica si hay CA de clientes.
+func (c *CertificadosTLS) Configuracion(versionMinima, modoCliente string) *tls.Config {
+    base := &tls.Config{
+        MinVersion: versionesTLS[versionMinima],
+        NextProtos: []string{"h2", "http/1.1"},
+        GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+            c.mu.RLock()
+            defer c.mu.RUnlock()
+            return c.certificado, nil
+        },
+    }
+    if c.rutaCA == "" {
+        return base
+    }
+
+    autenticacion := tls.RequireAndVerifyClientCert
+    if modoCliente == ModoClienteOpcional {
+        autenticacion = tls.VerifyClientCertIfGiven
+    }
+    base.ClientAuth = autenticacion
+    base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+        configuracion := base.Clone()
+        configuracion.GetConfigForClient = nil
+        c.mu.RLock()
+        configuracion.ClientCAs = c.clientes
+        c.mu.RUnlock()
+        return configuracion, nil
+    }
+    return base
+}
+
+// ConfigurarTLS hace que el servidor atienda con TLS (y mTLS si hay CA de clientes) y vigile los certificados.
+// Debe llamarse antes de Ejecutar o Servir.
+// Retorna un error si los certificados no pueden cargarse.
+func (s *Servidor) ConfigurarTLS(cfg *config.Config) error {
+    certificados, err := NuevoCertificadosTLS(cfg.TLSCertificado, cfg.TLSClave, cfg.TLSClienteCA, s.log)
+    if err != nil {
+        return err
+    }
+    s.http.TLSConfig = certificados.Configuracion(cfg.TLSVersionMinima, cfg.TLSClienteModo)
+    s.IniciarTarea(func(ctx context.Context) { certificados.Vigilar(ctx, cfg.TLSRecargaIntervalo) })
+    s.log.Info("TLS habilitado", map[string]interface{}{
+        "version_minima": cfg.TLSVersionMinima,
+        "mtls":           cfg.TLSClienteCA != "",
+        "vence":          certificados.Vencimiento().Format(time.RFC3339),
+    })
+    return nil
+}
+
+// fechaModificacion retorna la fecha de modificación del archivo, o cero si no existe.
+func fechaModificacion(ruta string) time.Time {
+    informacion, err := os.Stat(ruta)
+    if err != nil {
+        return time.Time{}
+    }
+    return informacion.ModTime()
+}
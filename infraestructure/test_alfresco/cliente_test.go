@@ -0,0 +1,110 @@
+package test_alfresco
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// servidorAlfresco levanta un Alfresco simulado que responde siempre con el cuerpo indicado.
+func servidorAlfresco(t *testing.T, cuerpo string) documentos.AlmacenamientoDocumentos {
+    servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        w.Write([]byte(cuerpo))
+    }))
+    t.Cleanup(servidor.Close)
+
+    log := logger.NuevoRegistrador("TEST", "|")
+    conexion, err := servicio.NuevaConexionAlfresco(&config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key"}, log)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return servicio.NuevoServicioDocumentos(conexion, log)
+}
+
+// TestSubirDocumentoMapeaDTO verifica que la respuesta de subida se convierte en un Documento del dominio.
+func TestSubirDocumentoMapeaDTO(t *testing.T) {
+    almacenamiento := servidorAlfresco(t, `{"entry":{"id":"abc-1","name":"contrato.pdf","modifiedAt":"2024-05-01T10:00:00Z",
+        "modifiedByUser":{"displayName":"Carga API"},"content":{"mimeType":"application/pdf","sizeInBytes":2048},
+        "properties":{"tanner:rut-cliente":"11111111-1"}}}`)
+
+    doc, err := almacenamiento.SubirDocumento(context.Background(), documentos.SolicitudSubida{Contenido: []byte("%PDF")})
+    assert.NoError(t, err)
+    assert.Equal(t, "abc-1", doc.ID)
+    assert.Equal(t, "contrato.pdf", doc.Nombre)
+    assert.Equal(t, "application/pdf", doc.Tipo)
+    assert.Equal(t, int64(2048), doc.Tamano)
+    assert.Equal(t, "Carga API", doc.Autor)
+    assert.Equal(t, "11111111-1", doc.Propiedades["tanner:rut-cliente"])
+}
+
+// TestRespuestasMalformadas verifica que los cuerpos inesperados de Alfresco producen ErrRespuestaAlfrescoInvalida.
+func TestRespuestasMalformadas(t *testing.T) {
+    casos := []struct {
+        nombre    string
+        cuerpo    string
+        operacion func(documentos.AlmacenamientoDocumentos) error
+    }{
+        {"subida JSON inválido", `{"entry":`, subir},
+        {"subida sin entry", `{"status":"ok"}`, subir},
+        {"subida con id no textual", `{"entry":{"id":42}}`, subir},
+        {"subida con properties no objeto", `{"entry":{"id":"abc-1","properties":"x"}}`, subir},
+        {"listado con objeto en vez de arreglo", `{"entries":[]}`, listar},
+        {"listado con entrada sin id", `[{"entry":{"id":"abc-1"}},{"entry":{"name":"huerfano.pdf"}}]`, listar},
+    }
+
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            err := caso.operacion(servidorAlfresco(t, caso.cuerpo))
+            assert.True(t, errors.Is(err, documento.ErrRespuestaAlfrescoInvalida), "error inesperado: %v", err)
+        })
+    }
+}
+
+func subir(almacenamiento documentos.AlmacenamientoDocumentos) error {
+    _, err := almacenamiento.SubirDocumento(context.Background(), documentos.SolicitudSubida{Contenido: []byte("%PDF")})
+    return err
+}
+
+func listar(almacenamiento documentos.AlmacenamientoDocumentos) error {
+    _, err := almacenamiento.ListarDocumentos(context.Background(), documentos.SolicitudListado{})
+    return err
+}
+
+// TestCambioURLBase verifica que un cambio de URL base se aplica a las solicitudes siguientes sin recrear los clientes.
+func TestCambioURLBase(t *testing.T) {
+    solicitudes := map[string]int{}
+    nuevoServidor := func(nombre string) *httptest.Server {
+        servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            solicitudes[nombre]++
+            w.Header().Set("Content-Type", "application/json")
+            w.Write([]byte(`{"entry":{"id":"abc-1","name":"contrato.pdf"}}`))
+        }))
+        t.Cleanup(servidor.Close)
+        return servidor
+    }
+    principal, respaldo := nuevoServidor("principal"), nuevoServidor("respaldo")
+
+    log := logger.NuevoRegistrador("TEST", "|")
+    conexion, err := servicio.NuevaConexionAlfresco(&config.Config{AlfrescoBaseURL: principal.URL, AlfrescoAPIKey: "api-key"}, log)
+    if err != nil {
+        t.Fatal(err)
+    }
+    almacenamiento := servicio.NuevoServicioDocumentos(conexion, log)
+
+    _, err = almacenamiento.SubirDocumento(context.Background(), documentos.SolicitudSubida{Contenido: []byte("%PDF")})
+    assert.NoError(t, err)
+    conexion.EstablecerURLBase(respaldo.URL)
+    _, err = almacenamiento.SubirDocumento(context.Background(), documentos.SolicitudSubida{Contenido: []byte("%PDF")})
+    assert.NoError(t, err)
+
+    assert.Equal(t, map[string]int{"principal": 1, "respaldo": 1}, solicitudes)
+}
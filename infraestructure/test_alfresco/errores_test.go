@@ -0,0 +1,105 @@
+package test_alfresco
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/memoria"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// alfrescoConEstado levanta un Alfresco simulado que responde siempre con el estado indicado.
+func alfrescoConEstado(t *testing.T, estado int) *servicio.ConexionAlfresco {
+    servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(estado)
+        w.Write([]byte(`{"error":{"briefSummary":"detalle interno de Alfresco"}}`))
+    }))
+    t.Cleanup(servidor.Close)
+
+    conexion, err := servicio.NuevaConexionAlfresco(&config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key"}, logger.NuevoRegistrador("TEST", "|"))
+
+    if err != nil {
+
+        t.Fatal(err)
+
+    }
+
+    return conexion
+}
+
+// TestClasificacionErroresAlfresco verifica que cada estado HTTP de Alfresco produce su categoría de error.
+func TestClasificacionErroresAlfresco(t *testing.T) {
+    casos := []struct {
+        estado    int
+        categoria error
+    }{
+        {http.StatusNotFound, documentos.ErrDocumentoNoEncontrado},
+        {http.StatusConflict, documentos.ErrConflicto},
+        {http.StatusUnauthorized, documentos.ErrTicketInvalido},
+        {http.StatusTooManyRequests, documentos.ErrCuotaExcedida},
+        {http.StatusBadRequest, documentos.ErrValidacion},
+        {http.StatusServiceUnavailable, documentos.ErrRepositorioNoDisponible},
+    }
+
+    for _, caso := range casos {
+        t.Run(http.StatusText(caso.estado), func(t *testing.T) {
+            almacenamiento := servicio.NuevoServicioDocumentos(alfrescoConEstado(t, caso.estado), logger.NuevoRegistrador("TEST", "|"))
+            _, err := almacenamiento.DescargarDocumento(context.Background(), documentos.SolicitudDocumento{IDArchivo: "abc-1"})
+
+            assert.True(t, errors.Is(err, caso.categoria), "error inesperado: %v", err)
+            var errRepositorio *documentos.ErrorRepositorio
+            if assert.True(t, errors.As(err, &errRepositorio)) {
+                assert.Equal(t, caso.estado, errRepositorio.Estado)
+            }
+        })
+    }
+}
+
+// TestRespuestaErrorDocumento verifica que el manejador traduce el error tipado a estado HTTP y cuerpo con código e ID de solicitud.
+func TestRespuestaErrorDocumento(t *testing.T) {
+    casos := []struct {
+        estadoAlfresco int
+        estadoAPI      int
+        codigo         string
+    }{
+        {http.StatusNotFound, http.StatusNotFound, handlers.CodigoDocumentoNoEncontrado},
+        {http.StatusConflict, http.StatusConflict, handlers.CodigoConflicto},
+        {http.StatusServiceUnavailable, http.StatusServiceUnavailable, handlers.CodigoRepositorioNoDisponible},
+    }
+
+    for _, caso := range casos {
+        t.Run(http.StatusText(caso.estadoAlfresco), func(t *testing.T) {
+            log := logger.NuevoRegistrador("TEST", "|")
+            almacenamiento := servicio.NuevoServicioDocumentos(alfrescoConEstado(t, caso.estadoAlfresco), log)
+            servicioDocs := documento.NuevoServicioDocumentos(almacenamiento, log, "api-key")
+            manejador := handlers.NuevoManejadorDocumentos(servicioDocs, memoria.NuevoIndiceMemoria(), log, &config.Config{}, handlers.NewInternalAuth(&servicio.MockAuthClient{Log: log}, log, &config.Config{}))
+
+            router := gin.New()
+            router.Use(func(c *gin.Context) { c.Set("idSolicitud", "sol-123") })
+            router.POST("/descargar", manejador.ManejadorDescargarDocumento)
+
+            resp := httptest.NewRecorder()
+            router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/descargar?idFile=abc-1", nil))
+
+            var cuerpo handlers.Problema
+            assert.Equal(t, caso.estadoAPI, resp.Code)
+            assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &cuerpo))
+            assert.Equal(t, caso.codigo, cuerpo.Codigo)
+            assert.Equal(t, "sol-123", cuerpo.Instancia)
+            assert.Equal(t, caso.estadoAPI, cuerpo.Estado)
+            assert.Equal(t, handlers.TipoContenidoProblema, resp.Header().Get("Content-Type"))
+            assert.NotContains(t, resp.Body.String(), "detalle interno de Alfresco")
+        })
+    }
+}
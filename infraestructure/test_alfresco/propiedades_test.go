@@ -0,0 +1,74 @@
+package test_alfresco
+
+import (
+    "context"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// TestPropiedadesAlfresco verifica que el adaptador traduce los metadatos y filtros tipados a los nombres de
+// propiedad de Alfresco y omite los campos vacíos.
+func TestPropiedadesAlfresco(t *testing.T) {
+    var recibido map[string]interface{}
+    servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        recibido = nil
+        if r.Method == http.MethodPost && r.URL.Path == "/tanner-alfresco/file-upload" {
+            json.Unmarshal([]byte(r.FormValue("propiedades")), &recibido)
+            w.Write([]byte(`{"entry":{"id":"abc-1"}}`))
+            return
+        }
+        cuerpo, _ := io.ReadAll(r.Body)
+        json.Unmarshal(cuerpo, &recibido)
+        if r.URL.Path == "/tanner-alfresco/files" {
+            w.Write([]byte(`[]`))
+        }
+    }))
+    t.Cleanup(servidor.Close)
+
+    log := logger.NuevoRegistrador("TEST", "|")
+    conexion, err := servicio.NuevaConexionAlfresco(&config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key"}, log)
+    if err != nil {
+        t.Fatal(err)
+    }
+    almacenamiento := servicio.NuevoServicioDocumentos(conexion, log)
+
+    // 1. Subida: solo los metadatos informados
+    _, err = almacenamiento.SubirDocumento(context.Background(), documentos.SolicitudSubida{
+        Contenido:   []byte("%PDF"),
+        Propiedades: documentos.DocumentMetadata{RUTCliente: "11111111-1", TipoDocumento: "Contrato", CmVersionLabel: "1.0"},
+    })
+    assert.NoError(t, err)
+    assert.Equal(t, map[string]interface{}{
+        "tanner:rut-cliente":    "11111111-1",
+        "tanner:tipo-documento": "Contrato",
+        "cm:versionLabel":       "1.0",
+    }, recibido)
+
+    // 2. Listado: el filtro tipado se envía con los nombres de Alfresco
+    _, err = almacenamiento.ListarDocumentos(context.Background(), documentos.SolicitudListado{
+        Filtros: documentos.FiltroListado{RUTCliente: "11111111-1", EstadoVigencia: documentos.EstadoNoVigente},
+    })
+    assert.NoError(t, err)
+    assert.Equal(t, map[string]interface{}{
+        "tanner:rut-cliente":     "11111111-1",
+        "tanner:estado-vigencia": documentos.EstadoNoVigente,
+    }, recibido)
+
+    // 3. Actualización parcial: no se envían campos vacíos que borrarían los valores actuales
+    err = almacenamiento.ActualizarPropiedades(context.Background(), documentos.SolicitudActualizacion{
+        IDArchivo:   "abc-1",
+        Propiedades: documentos.DocumentMetadata{EstadoVigencia: documentos.EstadoNoVigente},
+    })
+    assert.NoError(t, err)
+    assert.Equal(t, map[string]interface{}{
+        "properties": map[string]interface{}{"tanner:estado-vigencia": documentos.EstadoNoVigente},
+    }, recibido)
+}
@@ -0,0 +1,138 @@
+package test_alfresco
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// respuestaAlfresco define cómo responde el Alfresco simulado en un intento.
+type respuestaAlfresco struct {
+    codigo     int
+    retryAfter string
+    cuerpo     string
+}
+
+// alfrescoSecuencial responde con la secuencia indicada (repitiendo la última) y cuenta los intentos recibidos.
+func alfrescoSecuencial(t *testing.T, cfg config.Config, respuestas ...respuestaAlfresco) (*servicio.ConexionAlfresco, documentos.AlmacenamientoDocumentos, *int32) {
+    var intentos int32
+    servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        n := int(atomic.AddInt32(&intentos, 1)) - 1
+        if n >= len(respuestas) {
+            n = len(respuestas) - 1
+        }
+        respuesta := respuestas[n]
+        if respuesta.retryAfter != "" {
+            w.Header().Set("Retry-After", respuesta.retryAfter)
+        }
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(respuesta.codigo)
+        w.Write([]byte(respuesta.cuerpo))
+    }))
+    t.Cleanup(servidor.Close)
+
+    log := logger.NuevoRegistrador("TEST", "|")
+    cfg.AlfrescoBaseURL = servidor.URL
+    conexion, err := servicio.NuevaConexionAlfresco(&cfg, log)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return conexion, servicio.NuevoServicioDocumentos(conexion, log), &intentos
+}
+
+// configuracionRapida usa esperas cortas para que las pruebas no dependan de tiempos reales.
+func configuracionRapida() config.Config {
+    return config.Config{
+        AlfrescoTimeout:              2 * time.Second,
+        AlfrescoReintentos:           3,
+        AlfrescoEsperaBase:           time.Millisecond,
+        AlfrescoEsperaMaxima:         2 * time.Second,
+        AlfrescoCircuitoUmbral:       10,
+        AlfrescoCircuitoEnfriamiento: time.Minute,
+    }
+}
+
+// TestReintentaOperacionIdempotente verifica que el listado se reintenta ante 5xx hasta obtener respuesta.
+func TestReintentaOperacionIdempotente(t *testing.T) {
+    _, almacenamiento, intentos := alfrescoSecuencial(t, configuracionRapida(),
+        respuestaAlfresco{codigo: http.StatusBadGateway},
+        respuestaAlfresco{codigo: http.StatusServiceUnavailable},
+        respuestaAlfresco{codigo: http.StatusOK, cuerpo: `[{"entry":{"id":"abc-1"}}]`},
+    )
+
+    docs, err := almacenamiento.ListarDocumentos(context.Background(), documentos.SolicitudListado{})
+    assert.NoError(t, err)
+    assert.Len(t, docs, 1)
+    assert.Equal(t, int32(3), atomic.LoadInt32(intentos))
+}
+
+// TestNoReintentaSubidaAnte5xx verifica que una subida fallida no se repite, para no duplicar versiones.
+func TestNoReintentaSubidaAnte5xx(t *testing.T) {
+    _, almacenamiento, intentos := alfrescoSecuencial(t, configuracionRapida(),
+        respuestaAlfresco{codigo: http.StatusInternalServerError},
+        respuestaAlfresco{codigo: http.StatusOK, cuerpo: `{"entry":{"id":"abc-1"}}`},
+    )
+
+    _, err := almacenamiento.SubirDocumento(context.Background(), documentos.SolicitudSubida{Contenido: []byte("%PDF")})
+    assert.Error(t, err)
+    assert.Equal(t, int32(1), atomic.LoadInt32(intentos))
+}
+
+// TestRespetaRetryAfter verifica que un 429 se reintenta (incluso en subidas) tras la espera indicada por Alfresco.
+func TestRespetaRetryAfter(t *testing.T) {
+    _, almacenamiento, intentos := alfrescoSecuencial(t, configuracionRapida(),
+        respuestaAlfresco{codigo: http.StatusTooManyRequests, retryAfter: "1"},
+        respuestaAlfresco{codigo: http.StatusOK, cuerpo: `{"entry":{"id":"abc-1"}}`},
+    )
+
+    inicio := time.Now()
+    doc, err := almacenamiento.SubirDocumento(context.Background(), documentos.SolicitudSubida{Contenido: []byte("%PDF")})
+    assert.NoError(t, err)
+    assert.Equal(t, "abc-1", doc.ID)
+    assert.Equal(t, int32(2), atomic.LoadInt32(intentos))
+    assert.GreaterOrEqual(t, time.Since(inicio), time.Second)
+}
+
+// TestCircuitoFallaRapido verifica que el circuito se abre tras el umbral, rechaza sin contactar a Alfresco
+// y se cierra cuando la solicitud de prueba posterior al enfriamiento tiene éxito.
+func TestCircuitoFallaRapido(t *testing.T) {
+    cfg := configuracionRapida()
+    cfg.AlfrescoReintentos = 0
+    cfg.AlfrescoCircuitoUmbral = 2
+    cfg.AlfrescoCircuitoEnfriamiento = 100 * time.Millisecond
+    conexion, almacenamiento, intentos := alfrescoSecuencial(t, cfg,
+        respuestaAlfresco{codigo: http.StatusServiceUnavailable},
+        respuestaAlfresco{codigo: http.StatusServiceUnavailable},
+        respuestaAlfresco{codigo: http.StatusOK, cuerpo: `[]`},
+    )
+    listar := func() error {
+        _, err := almacenamiento.ListarDocumentos(context.Background(), documentos.SolicitudListado{})
+        return err
+    }
+
+    // 1. Dos fallos abren el circuito
+    assert.Error(t, listar())
+    assert.Error(t, listar())
+    assert.Equal(t, servicio.CircuitoAbierto, conexion.Circuito().Estado().Estado)
+
+    // 2. Con el circuito abierto no se contacta a Alfresco
+    err := listar()
+    assert.True(t, errors.Is(err, servicio.ErrCircuitoAbierto), "error inesperado: %v", err)
+    assert.Equal(t, int32(2), atomic.LoadInt32(intentos))
+    assert.Equal(t, int64(1), conexion.Circuito().Estado().Rechazos)
+
+    // 3. Tras el enfriamiento, la prueba exitosa cierra el circuito
+    time.Sleep(150 * time.Millisecond)
+    assert.NoError(t, listar())
+    assert.Equal(t, servicio.CircuitoCerrado, conexion.Circuito().Estado().Estado)
+    assert.Equal(t, int64(1), conexion.Circuito().Estado().Aperturas)
+}
@@ -0,0 +1,238 @@
+package test_alfresco
+
+import (
+    "context"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "io"
+    "log"
+    "math/big"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// autoridad es una CA generada para la prueba.
+type autoridad struct {
+    certificado *x509.Certificate
+    clave       *ecdsa.PrivateKey
+}
+
+// nuevaAutoridad genera una CA autofirmada y guarda su certificado PEM en el directorio indicado.
+// Retorna la CA y la ruta del archivo.
+func nuevaAutoridad(t *testing.T, directorio, nombre string) (autoridad, string) {
+    clave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatalf("Error al generar la clave de la CA: %v", err)
+    }
+    plantilla := &x509.Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: nombre},
+        NotBefore:             time.Now().Add(-time.Hour),
+        NotAfter:              time.Now().Add(24 * time.Hour),
+        KeyUsage:              x509.KeyUsageCertSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+    der, err := x509.CreateCertificate(rand.Reader, plantilla, plantilla, &clave.PublicKey, clave)
+    if err != nil {
+        t.Fatalf("Error al generar la CA: %v", err)
+    }
+    certificado, _ := x509.ParseCertificate(der)
+    ruta := filepath.Join(directorio, nombre+".crt")
+    escribirPEM(t, ruta, "CERTIFICATE", der)
+    return autoridad{certificado: certificado, clave: clave}, ruta
+}
+
+// emitir firma un certificado de servidor (para 127.0.0.1) o de cliente con el CN indicado.
+func (a autoridad) emitir(t *testing.T, nombre string, esServidor bool) tls.Certificate {
+    clave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatalf("Error al generar la clave: %v", err)
+    }
+    serie, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+    plantilla := &x509.Certificate{
+        SerialNumber: serie,
+        Subject:      pkix.Name{CommonName: nombre},
+        NotBefore:    time.Now().Add(-time.Hour),
+        NotAfter:     time.Now().Add(24 * time.Hour),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+    }
+    if esServidor {
+        plantilla.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+        plantilla.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+    }
+    der, err := x509.CreateCertificate(rand.Reader, plantilla, a.certificado, &clave.PublicKey, a.clave)
+    if err != nil {
+        t.Fatalf("Error al emitir el certificado: %v", err)
+    }
+    return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: clave}
+}
+
+// guardar escribe el certificado y la clave en archivos PEM. Retorna sus rutas.
+func guardar(t *testing.T, directorio, nombre string, certificado tls.Certificate) (string, string) {
+    claveDER, _ := x509.MarshalECPrivateKey(certificado.PrivateKey.(*ecdsa.PrivateKey))
+    rutaCertificado, rutaClave := filepath.Join(directorio, nombre+".crt"), filepath.Join(directorio, nombre+".key")
+    escribirPEM(t, rutaCertificado, "CERTIFICATE", certificado.Certificate[0])
+    escribirPEM(t, rutaClave, "EC PRIVATE KEY", claveDER)
+    return rutaCertificado, rutaClave
+}
+
+func escribirPEM(t *testing.T, ruta, tipo string, der []byte) {
+    if err := os.WriteFile(ruta, pem.EncodeToMemory(&pem.Block{Type: tipo, Bytes: der}), 0o600); err != nil {
+        t.Fatalf("Error al escribir %s: %v", ruta, err)
+    }
+}
+
+// alfrescoTLS levanta un Alfresco simulado con HTTPS que responde al login y a los listados.
+// La configuración TLS del servidor recibe el certificado emitido por la CA; clientes registra el CN
+// del certificado de cliente de cada solicitud.
+func alfrescoTLS(t *testing.T, ca autoridad, configurar func(*tls.Config)) (*httptest.Server, *[]string) {
+    clientes := &[]string{}
+    servidor := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if len(r.TLS.PeerCertificates) > 0 {
+            *clientes = append(*clientes, r.TLS.PeerCertificates[0].Subject.CommonName)
+        }
+        w.Header().Set("Content-Type", "application/json")
+        if strings.HasSuffix(r.URL.Path, "/session/log-in") {
+            w.Write([]byte(`{"id":"TICKET_1"}`))
+            return
+        }
+        w.Write([]byte(`[]`))
+    }))
+    servidor.TLS = &tls.Config{Certificates: []tls.Certificate{ca.emitir(t, "alfresco", true)}}
+    if configurar != nil {
+        configurar(servidor.TLS)
+    }
+    servidor.Config.ErrorLog = log.New(io.Discard, "", 0) // Los handshakes rechazados son parte de la prueba
+    servidor.StartTLS()
+    t.Cleanup(servidor.Close)
+    return servidor, clientes
+}
+
+// consultar ejecuta un login y un listado con los clientes construidos sobre la configuración.
+// Retorna el error de cada uno.
+func consultar(t *testing.T, cfg *config.Config) (error, error) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    conexion, err := servicio.NuevaConexionAlfresco(cfg, log)
+    if err != nil {
+        t.Fatal(err)
+    }
+    _, errLogin := servicio.NewAuthClient(conexion, log).Login("usuario", "clave")
+    _, errListado := servicio.NuevoServicioDocumentos(conexion, log).ListarDocumentos(context.Background(), documentos.SolicitudListado{})
+    return errLogin, errListado
+}
+
+// TestTLSConCAPropia verifica que ambos clientes confían en un Alfresco firmado por la CA configurada,
+// y solo en ese caso.
+func TestTLSConCAPropia(t *testing.T) {
+    directorio := t.TempDir()
+    ca, rutaCA := nuevaAutoridad(t, directorio, "ca-alfresco")
+    servidor, _ := alfrescoTLS(t, ca, nil)
+
+    // Sin la CA el certificado de Alfresco no es de confianza
+    errLogin, errListado := consultar(t, &config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key"})
+    assert.Error(t, errLogin)
+    assert.Error(t, errListado)
+
+    // Con la CA ambos clientes se conectan
+    errLogin, errListado = consultar(t, &config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key", AlfrescoTLSCA: rutaCA})
+    assert.NoError(t, errLogin)
+    assert.NoError(t, errListado)
+}
+
+// TestTLSCertificadoCliente verifica que el certificado de cliente se presenta a un Alfresco que exige mTLS.
+func TestTLSCertificadoCliente(t *testing.T) {
+    directorio := t.TempDir()
+    ca, rutaCA := nuevaAutoridad(t, directorio, "ca-alfresco")
+    caClientes, _ := nuevaAutoridad(t, directorio, "ca-clientes")
+    raices := x509.NewCertPool()
+    raices.AddCert(caClientes.certificado)
+    servidor, clientes := alfrescoTLS(t, ca, func(c *tls.Config) {
+        c.ClientAuth = tls.RequireAndVerifyClientCert
+        c.ClientCAs = raices
+    })
+
+    cfg := &config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key", AlfrescoTLSCA: rutaCA}
+    errLogin, _ := consultar(t, cfg)
+    assert.Error(t, errLogin)
+
+    cfg.AlfrescoTLSCertificado, cfg.AlfrescoTLSClave = guardar(t, directorio, "regapi", caClientes.emitir(t, "regapi", false))
+    errLogin, errListado := consultar(t, cfg)
+    assert.NoError(t, errLogin)
+    assert.NoError(t, errListado)
+    assert.Equal(t, []string{"regapi", "regapi"}, *clientes)
+}
+
+// TestTLSVersionMinima verifica que no se negocia una versión inferior a ALFRESCO_TLS_VERSION_MINIMA.
+func TestTLSVersionMinima(t *testing.T) {
+    directorio := t.TempDir()
+    ca, rutaCA := nuevaAutoridad(t, directorio, "ca-alfresco")
+    servidor, _ := alfrescoTLS(t, ca, func(c *tls.Config) { c.MaxVersion = tls.VersionTLS12 })
+
+    cfg := &config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key", AlfrescoTLSCA: rutaCA, AlfrescoTLSVersionMinima: "1.2"}
+    errLogin, _ := consultar(t, cfg)
+    assert.NoError(t, errLogin)
+
+    cfg.AlfrescoTLSVersionMinima = "1.3"
+    errLogin, _ = consultar(t, cfg)
+    assert.Error(t, errLogin)
+}
+
+// TestProxyAlfresco verifica que las solicitudes de ambos clientes pasan por el proxy configurado.
+func TestProxyAlfresco(t *testing.T) {
+    var destinos []string
+    proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        destinos = append(destinos, r.URL.Host+r.URL.Path)
+        w.Header().Set("Content-Type", "application/json")
+        if strings.HasSuffix(r.URL.Path, "/session/log-in") {
+            w.Write([]byte(`{"id":"TICKET_1"}`))
+            return
+        }
+        w.Write([]byte(`[]`))
+    }))
+    t.Cleanup(proxy.Close)
+
+    // El host de Alfresco no existe: solo el proxy puede resolverlo
+    errLogin, errListado := consultar(t, &config.Config{AlfrescoBaseURL: "http://alfresco.interno/api", AlfrescoAPIKey: "api-key", AlfrescoProxy: proxy.URL})
+    assert.NoError(t, errLogin)
+    assert.NoError(t, errListado)
+    if assert.Len(t, destinos, 2) {
+        assert.Equal(t, "alfresco.interno/api/session/log-in", destinos[0])
+        assert.True(t, strings.HasPrefix(destinos[1], "alfresco.interno/api/"))
+    }
+}
+
+// TestTransporteInvalido verifica que una CA o un certificado de cliente ilegibles se reportan al construir la conexión.
+func TestTransporteInvalido(t *testing.T) {
+    directorio := t.TempDir()
+    invalido := filepath.Join(directorio, "invalido.pem")
+    os.WriteFile(invalido, []byte("no es PEM"), 0o600)
+    log := logger.NuevoRegistrador("TEST", "|")
+
+    casos := []*config.Config{
+        {AlfrescoTLSCA: filepath.Join(directorio, "no-existe.crt")},
+        {AlfrescoTLSCA: invalido},
+        {AlfrescoTLSCertificado: invalido, AlfrescoTLSClave: invalido},
+    }
+    for _, cfg := range casos {
+        _, err := servicio.NuevaConexionAlfresco(cfg, log)
+        assert.Error(t, err)
+    }
+}
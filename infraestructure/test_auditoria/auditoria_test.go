@@ -0,0 +1,222 @@
+package test_auditoria
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/auditoria"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/solicitud"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// repositorioMemoria simula la colección de auditoría con su índice único de secuencia.
+type repositorioMemoria struct {
+    mu        sync.Mutex
+    registros []auditoria.Registro
+    // robarSecuencia simula otra réplica que inserta justo antes que este servicio
+    robarSecuencia int
+    lecturas       int           // Llamadas a Ultimo
+    lotes          int           // Llamadas a InsertarLote
+    demora         time.Duration // Latencia simulada de cada inserción
+}
+
+func (r *repositorioMemoria) Ultimo(ctx context.Context) (*auditoria.Registro, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.lecturas++
+    if len(r.registros) == 0 {
+        return nil, nil
+    }
+    ultimo := r.registros[len(r.registros)-1]
+    return &ultimo, nil
+}
+
+func (r *repositorioMemoria) InsertarLote(ctx context.Context, registros []auditoria.Registro) (int, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.lotes++
+    for i, registro := range registros {
+        if r.robarSecuencia > 0 {
+            r.robarSecuencia--
+            ajeno := auditoria.Registro{ID: "otra-replica", Secuencia: registro.Secuencia, HashAnterior: registro.HashAnterior, Accion: auditoria.AccionListar}
+            ajeno.Hash = auditoria.CalcularHash(ajeno)
+            r.registros = append(r.registros, ajeno)
+            return i, auditoria.ErrSecuenciaOcupada
+        }
+        for _, existente := range r.registros {
+            if existente.Secuencia == registro.Secuencia {
+                return i, auditoria.ErrSecuenciaOcupada
+            }
+        }
+        if r.demora > 0 {
+            time.Sleep(r.demora)
+        }
+        r.registros = append(r.registros, registro)
+    }
+    return len(registros), nil
+}
+
+func (r *repositorioMemoria) Buscar(ctx context.Context, filtro auditoria.Filtro) ([]auditoria.Registro, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    resultado := []auditoria.Registro{}
+    for i := len(r.registros) - 1; i >= 0 && len(resultado) < filtro.Limite; i-- {
+        registro := r.registros[i]
+        if filtro.RUTCliente != "" && registro.RUTCliente != filtro.RUTCliente {
+            continue
+        }
+        if filtro.Actor != "" && registro.Actor != filtro.Actor {
+            continue
+        }
+        resultado = append(resultado, registro)
+    }
+    return resultado, nil
+}
+
+func (r *repositorioMemoria) Recorrer(ctx context.Context, visitar func(auditoria.Registro) error) error {
+    r.mu.Lock()
+    copia := append([]auditoria.Registro{}, r.registros...)
+    r.mu.Unlock()
+    for _, registro := range copia {
+        if err := visitar(registro); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// registrarAccesos agrega tres descargas de prueba.
+func registrarAccesos(t *testing.T, servicio *auditoria.ServicioAuditoria) {
+    for _, actor := range []string{"ana", "luis", "ana"} {
+        err := servicio.Registrar(context.Background(), auditoria.Registro{
+            Actor:       actor,
+            Accion:      auditoria.AccionDescargar,
+            DocumentoID: "doc-1",
+            RUTCliente:  "11111111-1",
+            Resultado:   auditoria.ResultadoExito,
+        })
+        assert.NoError(t, err)
+    }
+}
+
+// TestAuditoriaEncadenaRegistros - Cada registro apunta al hash del anterior y la cadena verifica.
+func TestAuditoriaEncadenaRegistros(t *testing.T) {
+    repositorio := &repositorioMemoria{}
+    servicio := auditoria.NuevoServicioAuditoria(repositorio, logger.NuevoRegistrador("TEST", "|"))
+    registrarAccesos(t, servicio)
+
+    assert.Len(t, repositorio.registros, 3)
+    assert.Equal(t, "", repositorio.registros[0].HashAnterior)
+    for i := 1; i < 3; i++ {
+        assert.Equal(t, int64(i+1), repositorio.registros[i].Secuencia)
+        assert.Equal(t, repositorio.registros[i-1].Hash, repositorio.registros[i].HashAnterior)
+    }
+
+    verificacion, err := servicio.Verificar(context.Background())
+    assert.NoError(t, err)
+    assert.True(t, verificacion.Integra)
+    assert.Equal(t, 3, verificacion.Registros)
+
+    registros, err := servicio.Buscar(context.Background(), auditoria.Filtro{Actor: "ana"})
+    assert.NoError(t, err)
+    assert.Len(t, registros, 2)
+}
+
+// TestAuditoriaDetectaAlteracion - Modificar o borrar un registro rompe la cadena.
+func TestAuditoriaDetectaAlteracion(t *testing.T) {
+    repositorio := &repositorioMemoria{}
+    servicio := auditoria.NuevoServicioAuditoria(repositorio, logger.NuevoRegistrador("TEST", "|"))
+    registrarAccesos(t, servicio)
+
+    // Cambiar el actor de un registro intermedio
+    repositorio.registros[1].Actor = "otro"
+    verificacion, err := servicio.Verificar(context.Background())
+    assert.NoError(t, err)
+    assert.False(t, verificacion.Integra)
+    assert.Equal(t, int64(2), verificacion.SecuenciaRota)
+
+    // Borrar el registro intermedio
+    repositorio.registros[1].Actor = "luis"
+    repositorio.registros = append(repositorio.registros[:1], repositorio.registros[2:]...)
+    verificacion, err = servicio.Verificar(context.Background())
+    assert.NoError(t, err)
+    assert.False(t, verificacion.Integra)
+    assert.Equal(t, int64(3), verificacion.SecuenciaRota)
+}
+
+// TestAuditoriaReintentaSecuenciaOcupada - Si otra réplica toma la secuencia, el registro se enlaza tras ella.
+func TestAuditoriaReintentaSecuenciaOcupada(t *testing.T) {
+    repositorio := &repositorioMemoria{robarSecuencia: 1}
+    servicio := auditoria.NuevoServicioAuditoria(repositorio, logger.NuevoRegistrador("TEST", "|"))
+
+    err := servicio.Registrar(context.Background(), auditoria.Registro{Actor: "ana", Accion: auditoria.AccionBuscar})
+    assert.NoError(t, err)
+    assert.Len(t, repositorio.registros, 2)
+    assert.Equal(t, "ana", repositorio.registros[1].Actor)
+    assert.Equal(t, int64(2), repositorio.registros[1].Secuencia)
+
+    verificacion, err := servicio.Verificar(context.Background())
+    assert.NoError(t, err)
+    assert.True(t, verificacion.Integra)
+}
+
+// TestAuditoriaAgrupaSolicitudesConcurrentes - Las solicitudes concurrentes se insertan en lotes sobre el último
+// registro conocido, sin leerlo del repositorio en cada inserción, y la cadena sigue íntegra.
+func TestAuditoriaAgrupaSolicitudesConcurrentes(t *testing.T) {
+    repositorio := &repositorioMemoria{demora: time.Millisecond}
+    servicio := auditoria.NuevoServicioAuditoria(repositorio, logger.NuevoRegistrador("TEST", "|"))
+
+    var grupo sync.WaitGroup
+    for i := 0; i < 50; i++ {
+        grupo.Add(1)
+        go func(i int) {
+            defer grupo.Done()
+            err := servicio.Registrar(context.Background(), auditoria.Registro{Actor: fmt.Sprintf("actor-%d", i), Accion: auditoria.AccionBuscar})
+            assert.NoError(t, err)
+        }(i)
+    }
+    grupo.Wait()
+
+    assert.Len(t, repositorio.registros, 50)
+    assert.Equal(t, 1, repositorio.lecturas)
+    assert.Less(t, repositorio.lotes, 50)
+
+    verificacion, err := servicio.Verificar(context.Background())
+    assert.NoError(t, err)
+    assert.True(t, verificacion.Integra)
+    assert.Equal(t, 50, verificacion.Registros)
+}
+
+// TestServicioDocumentosAuditaDesdeContexto verifica que el servicio de documentos funciona sin Gin
+// y toma la identidad del solicitante desde context.Context.
+func TestServicioDocumentosAuditaDesdeContexto(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    repositorio := &repositorioMemoria{}
+    servicioDocs := documento.NuevoServicioDocumentos(servicio.NuevoMockClienteAlfresco(log), log, "mock-key")
+    servicioDocs.EstablecerAuditor(auditoria.NuevoServicioAuditoria(repositorio, log))
+
+    ctx := solicitud.ConDatos(context.Background(), solicitud.Datos{
+        ID:    "sol-1",
+        Actor: "lote:nocturno",
+        Rol:   "operador",
+        IP:    "10.0.0.1",
+    })
+    archivo, err := servicioDocs.DescargarDocumento(ctx, "doc-123", "11111111-1", "TICKET_mock_123")
+    assert.NoError(t, err)
+    assert.Equal(t, "contrato.pdf", archivo.Nombre)
+
+    assert.Len(t, repositorio.registros, 1)
+    registro := repositorio.registros[0]
+    assert.Equal(t, auditoria.AccionDescargar, registro.Accion)
+    assert.Equal(t, "doc-123", registro.DocumentoID)
+    assert.Equal(t, "11111111-1", registro.RUTCliente)
+    assert.Equal(t, "lote:nocturno", registro.Actor)
+    assert.Equal(t, "operador", registro.Rol)
+    assert.Equal(t, "10.0.0.1", registro.IP)
+    assert.Equal(t, "sol-1", registro.IDSolicitud)
+}
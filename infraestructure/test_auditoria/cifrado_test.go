@@ -0,0 +1,184 @@
+package test_auditoria
+
+import (
+    "bytes"
+    "context"
+    "strings"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/auditoria"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// cfgMongo es la configuración de colecciones usada con el cliente simulado.
+var cfgMongo = &config.Config{MongoDatabase: "regapi", MongoCollectionAuditoria: "auditoria"}
+
+// nuevoCifrador crea un cifrador con las claves k1 y k2 y la activa indicada.
+func nuevoCifrador(t *testing.T, activa string) *cifrado.Cifrador {
+    claves := map[string][]byte{"k1": bytes.Repeat([]byte{1}, cifrado.TamanoClave), "k2": bytes.Repeat([]byte{2}, cifrado.TamanoClave)}
+    cifrador, err := cifrado.NuevoCifrador(claves, activa, bytes.Repeat([]byte{9}, cifrado.TamanoClave))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return cifrador
+}
+
+// cadena retorna dos registros encadenados con el RUT indicado, con sus hashes calculados en claro.
+func cadena(rut string) []auditoria.Registro {
+    registros := make([]auditoria.Registro, 2)
+    anterior := ""
+    for i := range registros {
+        registros[i] = auditoria.Registro{
+            ID:           "reg-" + string(rune('1'+i)),
+            Secuencia:    int64(i + 1),
+            Fecha:        time.Date(2026, 5, 10, 12, i, 0, 0, time.UTC),
+            Actor:        "ana",
+            Accion:       auditoria.AccionDescargar,
+            RUTCliente:   rut,
+            Resultado:    auditoria.ResultadoExito,
+            HashAnterior: anterior,
+        }
+        registros[i].Hash = auditoria.CalcularHash(registros[i])
+        anterior = registros[i].Hash
+    }
+    return registros
+}
+
+// almacenar retorna el documento que el repositorio guarda para el registro, con el RUT ya cifrado.
+func almacenar(t *testing.T, cifrador *cifrado.Cifrador, registro auditoria.Registro) bson.D {
+    rut, err := cifrador.Cifrar("rut_cliente", registro.RUTCliente)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return bson.D{
+        {Key: "_id", Value: registro.ID},
+        {Key: "secuencia", Value: registro.Secuencia},
+        {Key: "fecha", Value: registro.Fecha},
+        {Key: "actor", Value: registro.Actor},
+        {Key: "accion", Value: registro.Accion},
+        {Key: "rut_cliente", Value: rut},
+        {Key: "resultado", Value: registro.Resultado},
+        {Key: "hash_anterior", Value: registro.HashAnterior},
+        {Key: "hash", Value: registro.Hash},
+        {Key: "indices_ciegos", Value: bson.D{{Key: "rut_cliente", Value: cifrador.IndiceCiego("rut_cliente", registro.RUTCliente)}}},
+    }
+}
+
+// comando retorna el último comando enviado, decodificado.
+func comando(mt *mtest.T) bson.M {
+    var enviado bson.M
+    if err := bson.Unmarshal(mt.GetStartedEvent().Command, &enviado); err != nil {
+        mt.Fatalf("Comando inválido: %v", err)
+    }
+    return enviado
+}
+
+// TestAuditoriaMongoCifraRUT verifica que el RUT se guarda cifrado con su índice ciego, que las consultas por RUT
+// usan el índice ciego y que la cadena de hashes, calculada en claro, sigue verificando.
+func TestAuditoriaMongoCifraRUT(t *testing.T) {
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+    cifrador := nuevoCifrador(t, "k2")
+    registros := cadena("11111111-1")
+
+    mt.Run("insertar", func(mt *mtest.T) {
+        repositorio := mongo.NuevoRepositorioAuditoria(mt.Client, cfgMongo)
+        repositorio.EstablecerCifrador(cifrador)
+        mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}})
+
+        insertados, err := repositorio.InsertarLote(context.Background(), registros)
+        assert.NoError(t, err)
+        assert.Equal(t, 2, insertados)
+        documento := comando(mt)["documents"].(bson.A)[0].(bson.M)
+        assert.True(t, strings.HasPrefix(documento["rut_cliente"].(string), "enc:v1:k2:"))
+        assert.NotContains(t, documento["rut_cliente"], "11111111-1")
+        assert.Equal(t, cifrador.IndiceCiego("rut_cliente", "11111111-1"), documento["indices_ciegos"].(bson.M)["rut_cliente"])
+        assert.Equal(t, registros[0].Hash, documento["hash"])
+    })
+
+    mt.Run("buscar por RUT", func(mt *mtest.T) {
+        repositorio := mongo.NuevoRepositorioAuditoria(mt.Client, cfgMongo)
+        repositorio.EstablecerCifrador(cifrador)
+        mt.AddMockResponses(mtest.CreateCursorResponse(0, "regapi.auditoria", mtest.FirstBatch, almacenar(t, cifrador, registros[1])))
+
+        encontrados, err := repositorio.Buscar(context.Background(), auditoria.Filtro{RUTCliente: "11111111-1", Limite: 10})
+        assert.NoError(t, err)
+        if assert.Len(t, encontrados, 1) {
+            assert.Equal(t, "11111111-1", encontrados[0].RUTCliente)
+            assert.Equal(t, encontrados[0].Hash, auditoria.CalcularHash(encontrados[0]))
+        }
+        filtro := comando(mt)["filter"].(bson.M)
+        assert.Equal(t, bson.M{"$or": bson.A{
+            bson.M{"indices_ciegos.rut_cliente": cifrador.IndiceCiego("rut_cliente", "11111111-1")},
+            bson.M{"rut_cliente": "11111111-1"},
+        }}, filtro)
+    })
+
+    mt.Run("verificar cadena cifrada", func(mt *mtest.T) {
+        repositorio := mongo.NuevoRepositorioAuditoria(mt.Client, cfgMongo)
+        repositorio.EstablecerCifrador(cifrador)
+        mt.AddMockResponses(mtest.CreateCursorResponse(0, "regapi.auditoria", mtest.FirstBatch,
+            almacenar(t, cifrador, registros[0]), almacenar(t, cifrador, registros[1])))
+
+        verificacion, err := auditoria.NuevoServicioAuditoria(repositorio, logger.NuevoRegistrador("TEST", "|")).Verificar(context.Background())
+        assert.NoError(t, err)
+        assert.True(t, verificacion.Integra)
+        assert.Equal(t, 2, verificacion.Registros)
+    })
+
+    mt.Run("sin claves configuradas", func(mt *mtest.T) {
+        repositorio := mongo.NuevoRepositorioAuditoria(mt.Client, cfgMongo)
+        mt.AddMockResponses(mtest.CreateCursorResponse(0, "regapi.auditoria", mtest.FirstBatch, almacenar(t, cifrador, registros[0])))
+
+        _, err := repositorio.Buscar(context.Background(), auditoria.Filtro{Limite: 10})
+        assert.Error(t, err)
+    })
+}
+
+// TestAuditoriaMongoRecifrar verifica que el recifrado actualiza los RUT en claro, los cifrados con otra clave y
+// los índices ciegos desactualizados, y omite los registros ya vigentes.
+func TestAuditoriaMongoRecifrar(t *testing.T) {
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+    cifrador := nuevoCifrador(t, "k2")
+    anterior := nuevoCifrador(t, "k1")
+    rutAnterior, _ := anterior.Cifrar("rut_cliente", "22222222-2")
+    rutVigente, _ := cifrador.Cifrar("rut_cliente", "33333333-3")
+
+    mt.Run("recifrar", func(mt *mtest.T) {
+        repositorio := mongo.NuevoRepositorioAuditoria(mt.Client, cfgMongo)
+        repositorio.EstablecerCifrador(cifrador)
+        mt.AddMockResponses(
+            mtest.CreateCursorResponse(0, "regapi.auditoria", mtest.FirstBatch,
+                bson.D{{Key: "_id", Value: "en-claro"}, {Key: "rut_cliente", Value: "11111111-1"}},
+                bson.D{{Key: "_id", Value: "clave-anterior"}, {Key: "rut_cliente", Value: rutAnterior},
+                    {Key: "indices_ciegos", Value: bson.D{{Key: "rut_cliente", Value: "indice-antiguo"}}}},
+                bson.D{{Key: "_id", Value: "vigente"}, {Key: "rut_cliente", Value: rutVigente},
+                    {Key: "indices_ciegos", Value: bson.D{{Key: "rut_cliente", Value: cifrador.IndiceCiego("rut_cliente", "33333333-3")}}}},
+                bson.D{{Key: "_id", Value: "sin-rut"}},
+            ),
+            bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+            bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}},
+        )
+
+        resultado, err := repositorio.Recifrar(context.Background())
+        assert.NoError(t, err)
+        assert.Equal(t, 4, resultado.Revisados)
+        assert.Equal(t, 1, resultado.Recifrados)
+        assert.Equal(t, []string{"clave-anterior"}, resultado.Fallidos) // Cambió entre la lectura y la actualización
+
+        comando(mt) // find
+        actualizacion := comando(mt)["updates"].(bson.A)[0].(bson.M)
+        assert.Equal(t, bson.M{"_id": "en-claro", "rut_cliente": "11111111-1"}, actualizacion["q"])
+        cambios := actualizacion["u"].(bson.M)["$set"].(bson.M)
+        assert.True(t, strings.HasPrefix(cambios["rut_cliente"].(string), "enc:v1:k2:"))
+        assert.Equal(t, cifrador.IndiceCiego("rut_cliente", "11111111-1"), cambios["indices_ciegos.rut_cliente"])
+        actualizacion = comando(mt)["updates"].(bson.A)[0].(bson.M)
+        assert.Equal(t, bson.M{"_id": "clave-anterior", "rut_cliente": rutAnterior}, actualizacion["q"])
+        assert.Nil(t, mt.GetStartedEvent())
+    })
+}
@@ -0,0 +1,61 @@
+package test_auditoria
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/application/auditoria"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// TestConsultarAuditoriaLimite verifica el sobre {data, meta} y el rango aceptado para limite.
+func TestConsultarAuditoriaLimite(t *testing.T) {
+    servicio := auditoria.NuevoServicioAuditoria(&repositorioMemoria{}, logger.NuevoRegistrador("TEST", "|"))
+    registrarAccesos(t, servicio)
+
+    cfg := &config.Config{AdminApiKey: "clave-admin"}
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareIdentidad(cfg))
+    router.GET("/auditoria", handlers.NuevoManejadorAuditoria(servicio, logger.NuevoRegistrador("TEST", "|")).ManejadorConsultarAuditoria)
+
+    casos := []struct {
+        consulta string
+        estado   int
+        total    int
+    }{
+        {"", http.StatusOK, 3},
+        {"?limite=2", http.StatusOK, 2},
+        {"?limite=500", http.StatusOK, 3},
+        {"?limite=501", http.StatusBadRequest, 0},
+        {"?limite=0", http.StatusBadRequest, 0},
+        {"?limite=muchos", http.StatusBadRequest, 0},
+    }
+    for _, caso := range casos {
+        t.Run(caso.consulta, func(t *testing.T) {
+            req := httptest.NewRequest(http.MethodGet, "/auditoria"+caso.consulta, nil)
+            req.Header.Set("ADFTannerServices", "clave-admin")
+            w := httptest.NewRecorder()
+            router.ServeHTTP(w, req)
+
+            assert.Equal(t, caso.estado, w.Code)
+            if caso.estado != http.StatusOK {
+                var problema handlers.Problema
+                assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problema))
+                assert.Equal(t, handlers.CodigoSolicitudInvalida, problema.Codigo)
+                assert.Equal(t, "limite", problema.Errores[0].Campo)
+                return
+            }
+            var respuesta handlers.RespuestaAuditoria
+            assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &respuesta))
+            assert.Len(t, respuesta.Data, caso.total)
+            assert.Equal(t, caso.total, respuesta.Meta.Total)
+        })
+    }
+}
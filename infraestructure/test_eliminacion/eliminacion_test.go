@@ -0,0 +1,123 @@
+package test_eliminacion
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/memoria"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// nuevoServicio crea el servicio de documentos sobre el mock de Alfresco con retención de 5 años para contratos.
+func nuevoServicio(log *logger.Registrador) (*documento.ImplementacionServicioDocumentos, *servicio.MockClienteAlfresco) {
+    mockCliente := servicio.NuevoMockClienteAlfresco(log)
+    servicioDocs := documento.NuevoServicioDocumentos(mockCliente, log, "mock-key")
+    servicioDocs.EstablecerReglasRetencion(documentos.ReglasRetencion{AniosPorTipo: map[string]int{"contrato": 5}})
+    return servicioDocs, mockCliente
+}
+
+// TestEliminarDocumentoLogica verifica que la eliminación lógica marca el documento como eliminado en Alfresco.
+func TestEliminarDocumentoLogica(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs, mockCliente := nuevoServicio(log)
+
+    err := servicioDocs.EliminarDocumento(context.Background(), documento.SolicitudEliminacion{IDArchivo: "doc-123", Ticket: "TICKET_mock_123"})
+    assert.NoError(t, err)
+
+    // El documento sigue existiendo, marcado como eliminado
+    doc, existe := mockCliente.DocumentosDB["doc-123"]
+    assert.True(t, existe)
+    propiedades := doc["properties"].(map[string]interface{})
+    assert.Equal(t, documentos.EstadoEliminado, propiedades["tanner:estado-vigencia"])
+}
+
+// TestEliminarDocumentoDefinitiva verifica que la eliminación definitiva borra el documento de Alfresco.
+func TestEliminarDocumentoDefinitiva(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs, mockCliente := nuevoServicio(log)
+
+    err := servicioDocs.EliminarDocumento(context.Background(), documento.SolicitudEliminacion{IDArchivo: "doc-123", Definitivo: true, Ticket: "TICKET_mock_123"})
+    assert.NoError(t, err)
+
+    _, existe := mockCliente.DocumentosDB["doc-123"]
+    assert.False(t, existe)
+}
+
+// TestEliminarDocumentoEnRetencion verifica que un documento retenido no se modifica en Alfresco.
+func TestEliminarDocumentoEnRetencion(t *testing.T) {
+    casos := []struct {
+        nombre    string
+        metadatos documentos.DocumentMetadata
+    }{
+        {"vigencia futura", documentos.DocumentMetadata{FechaTerminoVigencia: "2999-01-01"}},
+        {"periodo por tipo", documentos.DocumentMetadata{TipoDocumento: "contrato", FechaCarga: "2999-01-01"}},
+    }
+    for _, caso := range casos {
+        for _, definitivo := range []bool{false, true} {
+            t.Run(caso.nombre, func(t *testing.T) {
+                log := logger.NuevoRegistrador("TEST", "|")
+                servicioDocs, mockCliente := nuevoServicio(log)
+
+                err := servicioDocs.EliminarDocumento(context.Background(), documento.SolicitudEliminacion{
+                    IDArchivo:  "doc-123",
+                    Metadatos:  caso.metadatos,
+                    Definitivo: definitivo,
+                    Ticket:     "TICKET_mock_123",
+                })
+                assert.ErrorIs(t, err, documentos.ErrDocumentoEnRetencion)
+
+                // El documento permanece intacto
+                doc, existe := mockCliente.DocumentosDB["doc-123"]
+                assert.True(t, existe)
+                propiedades := doc["properties"].(map[string]interface{})
+                assert.NotContains(t, propiedades, "tanner:estado-vigencia")
+            })
+        }
+    }
+}
+
+// TestEliminarDocumentoNoExistente verifica que se propaga el error del almacenamiento cuando el documento no existe.
+func TestEliminarDocumentoNoExistente(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs, _ := nuevoServicio(log)
+
+    for _, definitivo := range []bool{false, true} {
+        err := servicioDocs.EliminarDocumento(context.Background(), documento.SolicitudEliminacion{IDArchivo: "doc-999", Definitivo: definitivo, Ticket: "TICKET_mock_123"})
+        assert.ErrorIs(t, err, documento.ErrDocumentoNoEncontrado)
+    }
+}
+
+// TestEliminarDefinitivaRequiereAdministrador verifica que la eliminación definitiva se rechaza sin el rol administrador.
+func TestEliminarDefinitivaRequiereAdministrador(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs, mockCliente := nuevoServicio(log)
+    cfg := &config.Config{AdminApiKey: "clave-admin"}
+    manejador := handlers.NuevoManejadorDocumentos(servicioDocs, memoria.NuevoIndiceMemoria(), log, cfg, handlers.NewInternalAuth(&servicio.MockAuthClient{Log: log}, log, cfg))
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareIdentidad(cfg))
+    router.DELETE("/documentos/:id", manejador.ManejadorEliminarDocumento)
+
+    for _, clave := range []string{"", "clave-operador"} {
+        req := httptest.NewRequest(http.MethodDelete, "/documentos/doc-123?definitivo=true", nil)
+        req.Header.Set("ADFTannerServices", clave)
+        w := httptest.NewRecorder()
+        router.ServeHTTP(w, req)
+
+        assert.Equal(t, http.StatusForbidden, w.Code)
+    }
+
+    // El documento no se tocó
+    _, existe := mockCliente.DocumentosDB["doc-123"]
+    assert.True(t, existe)
+}
@@ -0,0 +1,113 @@
+package test_eventos
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/domain/eventos"
+    infraeventos "github.com/CamiloScript/REGAPIGO/infraestructure/eventos"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// outboxMemoria es un outbox en memoria que conserva el orden de inserción.
+type outboxMemoria struct {
+    mu         sync.Mutex
+    pendientes []eventos.Evento
+    fallos     map[string]int
+}
+
+func (o *outboxMemoria) Pendientes(ctx context.Context, limite int) ([]eventos.Evento, error) {
+    o.mu.Lock()
+    defer o.mu.Unlock()
+    if len(o.pendientes) < limite {
+        limite = len(o.pendientes)
+    }
+    return append([]eventos.Evento{}, o.pendientes[:limite]...), nil
+}
+
+func (o *outboxMemoria) MarcarPublicado(ctx context.Context, id string) error {
+    o.mu.Lock()
+    defer o.mu.Unlock()
+    for i, evento := range o.pendientes {
+        if evento.ID == id {
+            o.pendientes = append(o.pendientes[:i], o.pendientes[i+1:]...)
+            return nil
+        }
+    }
+    return errors.New("evento no encontrado")
+}
+
+func (o *outboxMemoria) RegistrarFallo(ctx context.Context, id string, causa error) error {
+    o.mu.Lock()
+    defer o.mu.Unlock()
+    o.fallos[id]++
+    return nil
+}
+
+// destinoIntermitente falla en la primera publicación y luego delega al publicador en memoria.
+type destinoIntermitente struct {
+    fallar  bool
+    memoria *infraeventos.PublicadorMemoria
+}
+
+func (d *destinoIntermitente) Publicar(ctx context.Context, evento eventos.Evento) error {
+    if d.fallar {
+        d.fallar = false
+        return errors.New("receptor no disponible")
+    }
+    return d.memoria.Publicar(ctx, evento)
+}
+
+// bloqueoSiempreLider simula una réplica única.
+type bloqueoSiempreLider struct{}
+
+func (bloqueoSiempreLider) Adquirir(ctx context.Context, ttl time.Duration) (bool, error) { return true, nil }
+func (bloqueoSiempreLider) Liberar(ctx context.Context) error                            { return nil }
+
+// TestNuevoEventoFormatoCloudEvents - Verifica los atributos CloudEvents serializados.
+func TestNuevoEventoFormatoCloudEvents(t *testing.T) {
+    evento := eventos.NuevoEvento(eventos.TipoDocumentoSubido, "sol-1", eventos.DatosDocumento{
+        DocumentoID: "doc-1",
+        RUTCliente:  "11111111-1",
+    })
+
+    cuerpo, err := json.Marshal(evento)
+    assert.NoError(t, err)
+
+    var atributos map[string]interface{}
+    assert.NoError(t, json.Unmarshal(cuerpo, &atributos))
+    assert.Equal(t, "1.0", atributos["specversion"])
+    assert.Equal(t, eventos.TipoDocumentoSubido, atributos["type"])
+    assert.Equal(t, eventos.OrigenEventos, atributos["source"])
+    assert.Equal(t, "sol-1", atributos["idsolicitud"])
+    assert.NotEmpty(t, atributos["id"])
+    assert.NotEmpty(t, atributos["time"])
+}
+
+// TestRelayOutboxConservaOrdenYReintenta - Un fallo detiene el lote y el siguiente drenaje lo reanuda en orden.
+func TestRelayOutboxConservaOrdenYReintenta(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    outbox := &outboxMemoria{fallos: map[string]int{}}
+    for _, id := range []string{"doc-1", "doc-2", "doc-3"} {
+        outbox.pendientes = append(outbox.pendientes, eventos.NuevoEvento(eventos.TipoDocumentoActualizado, "", eventos.DatosDocumento{DocumentoID: id}))
+    }
+    memoria := infraeventos.NuevoPublicadorMemoria()
+    destino := &destinoIntermitente{fallar: true, memoria: memoria}
+
+    relay := infraeventos.NuevoRelayOutbox(outbox, destino, bloqueoSiempreLider{}, time.Second, log)
+
+    assert.Equal(t, 0, relay.Drenar(context.Background()))
+    assert.Len(t, outbox.fallos, 1)
+
+    assert.Equal(t, 3, relay.Drenar(context.Background()))
+    publicados := memoria.Eventos()
+    assert.Len(t, publicados, 3)
+    for i, id := range []string{"doc-1", "doc-2", "doc-3"} {
+        assert.Equal(t, id, publicados[i].Data.DocumentoID)
+    }
+    assert.Empty(t, outbox.pendientes)
+}
@@ -0,0 +1,80 @@
+package test_eventos
+
+import (
+    "bytes"
+    "context"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/domain/eventos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/stretchr/testify/assert"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// TestOutboxMongoCifraRUT verifica que el RUT del evento se guarda cifrado en el outbox, que el relay lo recibe
+// en claro y que el recifrado lo migra a la clave activa.
+func TestOutboxMongoCifraRUT(t *testing.T) {
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+    cfg := &config.Config{MongoDatabase: "regapi", MongoCollectionOutbox: "outbox"}
+    claves := map[string][]byte{"k1": bytes.Repeat([]byte{1}, cifrado.TamanoClave), "k2": bytes.Repeat([]byte{2}, cifrado.TamanoClave)}
+    cifrador, _ := cifrado.NuevoCifrador(claves, "k2", bytes.Repeat([]byte{9}, cifrado.TamanoClave))
+    anterior, _ := cifrado.NuevoCifrador(claves, "k1", bytes.Repeat([]byte{9}, cifrado.TamanoClave))
+    evento := eventos.NuevoEvento(eventos.TipoDocumentoActualizado, "sol-1", eventos.DatosDocumento{DocumentoID: "doc-1", RUTCliente: "11111111-1"})
+
+    mt.Run("publicar", func(mt *mtest.T) {
+        outbox := mongo.NuevoOutbox(mt.Client, cfg)
+        outbox.EstablecerCifrador(cifrador)
+        mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}})
+
+        assert.NoError(t, outbox.Publicar(context.Background(), evento))
+        var enviado bson.M
+        bson.Unmarshal(mt.GetStartedEvent().Command, &enviado)
+        datos := enviado["documents"].(bson.A)[0].(bson.M)["evento"].(bson.M)["data"].(bson.M)
+        assert.True(t, strings.HasPrefix(datos["rut_cliente"].(string), "enc:v1:k2:"))
+        assert.Equal(t, "doc-1", datos["documento_id"])
+    })
+
+    mt.Run("pendientes", func(mt *mtest.T) {
+        outbox := mongo.NuevoOutbox(mt.Client, cfg)
+        outbox.EstablecerCifrador(cifrador)
+        rut, _ := anterior.Cifrar("rut_cliente", "11111111-1")
+        mt.AddMockResponses(mtest.CreateCursorResponse(0, "regapi.outbox", mtest.FirstBatch, bson.D{
+            {Key: "_id", Value: evento.ID},
+            {Key: "evento", Value: bson.D{{Key: "id", Value: evento.ID}, {Key: "data", Value: bson.D{{Key: "documento_id", Value: "doc-1"}, {Key: "rut_cliente", Value: rut}}}}},
+            {Key: "estado", Value: "PENDIENTE"},
+        }))
+
+        pendientes, err := outbox.Pendientes(context.Background(), 10)
+        assert.NoError(t, err)
+        if assert.Len(t, pendientes, 1) {
+            assert.Equal(t, "11111111-1", pendientes[0].Data.RUTCliente)
+        }
+    })
+
+    mt.Run("recifrar", func(mt *mtest.T) {
+        outbox := mongo.NuevoOutbox(mt.Client, cfg)
+        outbox.EstablecerCifrador(cifrador)
+        mt.AddMockResponses(
+            mtest.CreateCursorResponse(0, "regapi.outbox", mtest.FirstBatch, bson.D{
+                {Key: "_id", Value: evento.ID},
+                {Key: "evento", Value: bson.D{{Key: "data", Value: bson.D{{Key: "rut_cliente", Value: "11111111-1"}}}}},
+            }),
+            bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+        )
+
+        resultado, err := outbox.Recifrar(context.Background())
+        assert.NoError(t, err)
+        assert.Equal(t, 1, resultado.Recifrados)
+        mt.GetStartedEvent() // find
+        var enviado bson.M
+        bson.Unmarshal(mt.GetStartedEvent().Command, &enviado)
+        actualizacion := enviado["updates"].(bson.A)[0].(bson.M)
+        assert.Equal(t, bson.M{"_id": evento.ID, "evento.data.rut_cliente": "11111111-1"}, actualizacion["q"])
+        cambios := actualizacion["u"].(bson.M)["$set"].(bson.M)
+        assert.True(t, strings.HasPrefix(cambios["evento.data.rut_cliente"].(string), "enc:v1:k2:"))
+        assert.Len(t, cambios, 1)
+    })
+}
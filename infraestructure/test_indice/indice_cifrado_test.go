@@ -0,0 +1,55 @@
+package test_indice
+
+import (
+    "bytes"
+    "context"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/stretchr/testify/assert"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// TestBuscarIndiceCifradoIncluyeClaro verifica que, con cifrado activo, la búsqueda por RUT y razón social
+// compara el índice ciego y también el valor en claro de los documentos aún no recifrados.
+func TestBuscarIndiceCifradoIncluyeClaro(t *testing.T) {
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+    cfg := &config.Config{MongoDatabase: "regapi", MongoCollection: "documentos"}
+    claves := map[string][]byte{"k1": bytes.Repeat([]byte{1}, cifrado.TamanoClave)}
+    cifrador, err := cifrado.NuevoCifrador(claves, "k1", bytes.Repeat([]byte{9}, cifrado.TamanoClave))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    mt.Run("documento en claro", func(mt *mtest.T) {
+        repositorio := mongo.NuevoRepositorioIndice(mt.Client, cfg)
+        repositorio.EstablecerCifrador(cifrador)
+        mt.AddMockResponses(mtest.CreateCursorResponse(0, "regapi.documentos", mtest.FirstBatch, bson.D{
+            {Key: "id_archivo", Value: "doc-antiguo"},
+            {Key: "metadatos", Value: bson.D{{Key: "rut_cliente", Value: "11111111-1"}, {Key: "razon_social_cliente", Value: "ACME"}}},
+        }))
+
+        id, err := repositorio.Buscar(context.Background(), documentos.FiltroIndice{RUTCliente: "11111111-1", RazonSocialCliente: "ACME", TipoDocumento: "Poder"})
+        assert.NoError(t, err)
+        assert.Equal(t, "doc-antiguo", id)
+
+        var enviado bson.M
+        assert.NoError(t, bson.Unmarshal(mt.GetStartedEvent().Command, &enviado))
+        filtro := enviado["filter"].(bson.M)
+        assert.Equal(t, "Poder", filtro["metadatos.tipo_documento"])
+        assert.NotContains(t, filtro, "metadatos.rut_cliente")
+        assert.ElementsMatch(t, bson.A{
+            bson.M{"$or": bson.A{
+                bson.M{"indices_ciegos.rut_cliente": cifrador.IndiceCiego("rut_cliente", "11111111-1")},
+                bson.M{"metadatos.rut_cliente": "11111111-1"},
+            }},
+            bson.M{"$or": bson.A{
+                bson.M{"indices_ciegos.razon_social_cliente": cifrador.IndiceCiego("razon_social_cliente", "ACME")},
+                bson.M{"metadatos.razon_social_cliente": "ACME"},
+            }},
+        }, filtro["$and"])
+    })
+}
@@ -0,0 +1,231 @@
+package test_limites
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/limites"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/memoria"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// reloj es una fuente de tiempo controlada por la prueba.
+type reloj struct {
+    ahora time.Time
+}
+
+func (r *reloj) Ahora() time.Time { return r.ahora }
+
+// clavesConocidas son las API Keys que la prueba configura en LIMITES_CLIENTES; las demás se identifican por IP.
+var clavesConocidas = []string{"clave-a", "clave-b", "clave-ilimitada"}
+
+// routerConLimites arma una API con una ruta limitada, una de subida y una de descarga que responde 50 bytes.
+func routerConLimites(t *testing.T, almacen limites.Almacen, reglas limites.Reglas) (*gin.Engine, *reloj) {
+    r := &reloj{ahora: time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)}
+    manejador := handlers.NuevoManejadorLimites(limites.NuevoLimitador(almacen, reglas), logger.NuevoRegistrador("TEST", "|"))
+    manejador.EstablecerReloj(r.Ahora)
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    cfg := &config.Config{LimitesClientes: make(map[string]config.ReglaLimite)}
+    for _, clave := range clavesConocidas {
+        cfg.LimitesClientes[huella(clave)] = config.ReglaLimite{}
+    }
+    router.Use(middleware.MiddlewareIdentidad(cfg))
+    grupo := router.Group("/documentos", manejador.Limitar)
+    grupo.POST("/listar", func(c *gin.Context) { c.Status(http.StatusOK) })
+    grupo.POST("/subir", manejador.Cuota(limites.DireccionSubida), func(c *gin.Context) {
+        var cuerpo map[string]string
+        if err := c.ShouldBindJSON(&cuerpo); err != nil {
+            c.Status(http.StatusBadRequest)
+            return
+        }
+        c.Status(http.StatusCreated)
+    })
+    grupo.POST("/descargar", manejador.Cuota(limites.DireccionDescarga), func(c *gin.Context) {
+        c.String(http.StatusOK, strings.Repeat("x", 50))
+    })
+    return router, r
+}
+
+// enviar hace una solicitud POST con la API Key indicada (vacía: el cliente se identifica por IP).
+func enviar(router *gin.Engine, ruta, clave, cuerpo string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest("POST", ruta, strings.NewReader(cuerpo))
+    if clave != "" {
+        req.Header.Set("ADFTannerServices", clave)
+    }
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+    return w
+}
+
+// huella retorna el identificador de cliente de una API Key, como se escribe en LIMITES_CLIENTES.
+func huella(clave string) string {
+    suma := sha256.Sum256([]byte(clave))
+    return "clave:" + hex.EncodeToString(suma[:])[:12]
+}
+
+// TestTokenBucket verifica la ráfaga, los encabezados RateLimit-* y el 429 con Retry-After hasta el próximo token.
+func TestTokenBucket(t *testing.T) {
+    router, r := routerConLimites(t, memoria.NuevoLimitesMemoria(), limites.Reglas{
+        Global: limites.Politica{SolicitudesMinuto: 30, Rafaga: 2},
+    })
+
+    primera := enviar(router, "/documentos/listar", "clave-a", "")
+    assert.Equal(t, http.StatusOK, primera.Code)
+    assert.Equal(t, "2", primera.Header().Get("RateLimit-Limit"))
+    assert.Equal(t, "1", primera.Header().Get("RateLimit-Remaining"))
+    assert.Equal(t, "2", primera.Header().Get("RateLimit-Reset"))
+
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/listar", "clave-a", "").Code)
+
+    rechazada := enviar(router, "/documentos/listar", "clave-a", "")
+    assert.Equal(t, http.StatusTooManyRequests, rechazada.Code)
+    assert.Equal(t, "0", rechazada.Header().Get("RateLimit-Remaining"))
+    assert.Equal(t, "2", rechazada.Header().Get("Retry-After"))
+    assert.Equal(t, handlers.TipoContenidoProblema, rechazada.Header().Get("Content-Type"))
+    var problema handlers.Problema
+    assert.NoError(t, json.Unmarshal(rechazada.Body.Bytes(), &problema))
+    assert.Equal(t, handlers.CodigoLimiteExcedido, problema.Codigo)
+
+    // Otro cliente y otra ruta tienen su propio bucket
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/listar", "clave-b", "").Code)
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/descargar", "clave-a", "").Code)
+
+    // A 30 por minuto se repone un token cada 2 segundos
+    r.ahora = r.ahora.Add(2 * time.Second)
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/listar", "clave-a", "").Code)
+    assert.Equal(t, http.StatusTooManyRequests, enviar(router, "/documentos/listar", "clave-a", "").Code)
+}
+
+// TestReglasPorClienteYRuta verifica la precedencia cliente@ruta, ruta, cliente y global.
+func TestReglasPorClienteYRuta(t *testing.T) {
+    router, _ := routerConLimites(t, memoria.NuevoLimitesMemoria(), limites.Reglas{
+        Global:  limites.Politica{SolicitudesMinuto: 60, Rafaga: 1},
+        PorRuta: map[string]limites.Politica{"/documentos/subir": {SolicitudesMinuto: 60, Rafaga: 2}},
+        PorCliente: map[string]limites.Politica{
+            huella("clave-a"):                       {SolicitudesMinuto: 60, Rafaga: 5, CuotaSubida: limites.Heredar, CuotaDescarga: limites.Heredar},
+            huella("clave-a") + "@/documentos/subir": {SolicitudesMinuto: 60, Rafaga: 3},
+        },
+    })
+
+    casos := []struct {
+        nombre     string
+        ruta       string
+        clave      string
+        permitidas int
+    }{
+        {"global", "/documentos/listar", "clave-b", 1},
+        {"cliente", "/documentos/listar", "clave-a", 5},
+        {"ruta", "/documentos/subir", "clave-b", 2},
+        {"cliente en ruta", "/documentos/subir", "clave-a", 3},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            for i := 0; i < caso.permitidas; i++ {
+                w := enviar(router, caso.ruta, caso.clave, `{}`)
+                assert.NotEqual(t, http.StatusTooManyRequests, w.Code, "solicitud %d", i+1)
+                assert.Equal(t, strconv.Itoa(caso.permitidas), w.Header().Get("RateLimit-Limit"))
+            }
+            assert.Equal(t, http.StatusTooManyRequests, enviar(router, caso.ruta, caso.clave, `{}`).Code)
+        })
+    }
+}
+
+// TestCuotaDiaria verifica que las cuotas de subida y descarga se acumulan por cliente, responden 429 con
+// Retry-After hasta la medianoche UTC y se reinician al día siguiente.
+func TestCuotaDiaria(t *testing.T) {
+    router, r := routerConLimites(t, memoria.NuevoLimitesMemoria(), limites.Reglas{
+        Global:     limites.Politica{SolicitudesMinuto: 600, Rafaga: 100, CuotaSubida: 100, CuotaDescarga: 60},
+        PorCliente: map[string]limites.Politica{huella("clave-ilimitada"): {SolicitudesMinuto: 600, Rafaga: 100, CuotaSubida: 0, CuotaDescarga: 0}},
+    })
+    cuerpo := `{"base64":"` + strings.Repeat("A", 60) + `"}` // 73 bytes
+
+    // Subida: la segunda supera los 100 bytes por Content-Length
+    assert.Equal(t, http.StatusCreated, enviar(router, "/documentos/subir", "clave-a", cuerpo).Code)
+    rechazada := enviar(router, "/documentos/subir", "clave-a", cuerpo)
+    assert.Equal(t, http.StatusTooManyRequests, rechazada.Code)
+    assert.Equal(t, "3600", rechazada.Header().Get("Retry-After"))
+    assert.Contains(t, rechazada.Body.String(), handlers.CodigoCuotaDiariaExcedida)
+
+    // Descarga: la primera (50 bytes) cabe, la segunda se completa aunque exceda y la tercera se rechaza
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/descargar", "clave-a", "").Code)
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/descargar", "clave-a", "").Code)
+    assert.Equal(t, http.StatusTooManyRequests, enviar(router, "/documentos/descargar", "clave-a", "").Code)
+
+    // Un cliente sin cuota no se limita; las solicitudes rechazadas por validación no consumen cuota
+    for i := 0; i < 3; i++ {
+        assert.Equal(t, http.StatusCreated, enviar(router, "/documentos/subir", "clave-ilimitada", cuerpo).Code)
+    }
+    assert.Equal(t, http.StatusBadRequest, enviar(router, "/documentos/subir", "clave-b", strings.Repeat("x", 90)).Code)
+    assert.Equal(t, http.StatusCreated, enviar(router, "/documentos/subir", "clave-b", cuerpo).Code)
+
+    // Al día siguiente (UTC) la cuota se reinicia
+    r.ahora = r.ahora.Add(time.Hour)
+    assert.Equal(t, http.StatusCreated, enviar(router, "/documentos/subir", "clave-a", cuerpo).Code)
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/descargar", "clave-a", "").Code)
+}
+
+// TestClaveNoConfigurada verifica que una API Key que no está configurada no identifica al cliente: rotar el
+// header no da un bucket ni una cuota nuevos, porque el cliente sigue identificado por su IP.
+func TestClaveNoConfigurada(t *testing.T) {
+    router, _ := routerConLimites(t, memoria.NuevoLimitesMemoria(), limites.Reglas{
+        Global: limites.Politica{SolicitudesMinuto: 30, Rafaga: 2, CuotaSubida: 100},
+    })
+    cuerpo := `{"base64":"` + strings.Repeat("A", 60) + `"}` // 73 bytes
+
+    // Tasa: cada solicitud con una clave distinta consume del mismo bucket
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/listar", "rotada-1", "").Code)
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/listar", "rotada-2", "").Code)
+    assert.Equal(t, http.StatusTooManyRequests, enviar(router, "/documentos/listar", "rotada-3", "").Code)
+    assert.Equal(t, http.StatusTooManyRequests, enviar(router, "/documentos/listar", "", "").Code)
+
+    // Cuota: la segunda subida excede la cuota de la IP aunque cambie la clave
+    assert.Equal(t, http.StatusCreated, enviar(router, "/documentos/subir", "rotada-4", cuerpo).Code)
+    rechazada := enviar(router, "/documentos/subir", "rotada-5", cuerpo)
+    assert.Equal(t, http.StatusTooManyRequests, rechazada.Code)
+    assert.Contains(t, rechazada.Body.String(), handlers.CodigoCuotaDiariaExcedida)
+
+    // Una clave configurada tiene su propio bucket
+    assert.Equal(t, http.StatusOK, enviar(router, "/documentos/listar", "clave-a", "").Code)
+}
+
+// almacenCaido simula un almacén de contadores no disponible.
+type almacenCaido struct{}
+
+func (almacenCaido) Tomar(context.Context, string, limites.Bucket, time.Time) (limites.Decision, error) {
+    return limites.Decision{}, errors.New("mongo no disponible")
+}
+
+func (almacenCaido) ConsumoDiario(context.Context, string, string) (int64, error) {
+    return 0, errors.New("mongo no disponible")
+}
+
+func (almacenCaido) SumarConsumo(context.Context, string, string, int64) error {
+    return errors.New("mongo no disponible")
+}
+
+// TestAlmacenNoDisponible verifica que una falla del almacén no bloquea la API.
+func TestAlmacenNoDisponible(t *testing.T) {
+    router, _ := routerConLimites(t, almacenCaido{}, limites.Reglas{
+        Global: limites.Politica{SolicitudesMinuto: 1, Rafaga: 1, CuotaSubida: 1, CuotaDescarga: 1},
+    })
+    for i := 0; i < 3; i++ {
+        w := enviar(router, "/documentos/descargar", "", "")
+        assert.Equal(t, http.StatusOK, w.Code)
+        assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+    }
+}
@@ -0,0 +1,157 @@
+package test_metricas
+
+import (
+    "bufio"
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/metricas"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// exponer lee /metrics y retorna el valor de cada serie, indexado por nombre y etiquetas tal como se exponen.
+func exponer(t *testing.T) map[string]float64 {
+    w := httptest.NewRecorder()
+    metricas.Manejador().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+    if w.Code != http.StatusOK {
+        t.Fatalf("/metrics respondió %d", w.Code)
+    }
+    series := make(map[string]float64)
+    lector := bufio.NewScanner(w.Body)
+    for lector.Scan() {
+        linea := lector.Text()
+        if strings.HasPrefix(linea, "#") || linea == "" {
+            continue
+        }
+        separador := strings.LastIndex(linea, " ")
+        valor, err := strconv.ParseFloat(linea[separador+1:], 64)
+        if err != nil {
+            t.Fatalf("Línea de exposición inválida: %q", linea)
+        }
+        series[linea[:separador]] = valor
+    }
+    return series
+}
+
+// diferencia retorna cuánto cambió una serie entre dos lecturas de /metrics.
+func diferencia(antes, despues map[string]float64, serie string) float64 {
+    return despues[serie] - antes[serie]
+}
+
+// TestMetricasHTTP verifica el histograma de solicitudes etiquetado con la plantilla de la ruta y el estado.
+func TestMetricasHTTP(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareRegistro(logger.NuevoRegistrador("TEST", "|")))
+    router.GET("/documentos/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+    antes := exponer(t)
+    for _, id := range []string{"a", "b", "c"} {
+        router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/documentos/"+id, nil))
+    }
+    router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/no-existe", nil))
+    despues := exponer(t)
+
+    assert.Equal(t, 3.0, diferencia(antes, despues, `regapi_http_solicitud_duracion_segundos_count{estado="204",metodo="GET",ruta="/documentos/:id"}`))
+    assert.Equal(t, 1.0, diferencia(antes, despues, `regapi_http_solicitud_duracion_segundos_count{estado="404",metodo="GET",ruta="sin_ruta"}`))
+    assert.Contains(t, despues, `regapi_http_solicitud_duracion_segundos_bucket{estado="204",metodo="GET",ruta="/documentos/:id",le="+Inf"}`)
+}
+
+// TestMetricasAlfresco verifica la latencia por operación y los errores por operación y categoría.
+func TestMetricasAlfresco(t *testing.T) {
+    servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if strings.HasSuffix(r.URL.Path, "/file-delete") {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        w.Write([]byte(`[]`))
+    }))
+    defer servidor.Close()
+    conexion, err := servicio.NuevaConexionAlfresco(&config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key", AlfrescoTimeout: time.Second}, logger.NuevoRegistrador("TEST", "|"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    almacenamiento := servicio.NuevoServicioDocumentos(conexion, logger.NuevoRegistrador("TEST", "|"))
+
+    antes := exponer(t)
+    _, err = almacenamiento.ListarDocumentos(context.Background(), documentos.SolicitudListado{})
+    assert.NoError(t, err)
+    err = almacenamiento.EliminarDocumento(context.Background(), documentos.SolicitudDocumento{IDArchivo: "abc-1"})
+    assert.ErrorIs(t, err, documentos.ErrDocumentoNoEncontrado)
+    despues := exponer(t)
+
+    assert.Equal(t, 1.0, diferencia(antes, despues, `regapi_alfresco_llamada_duracion_segundos_count{operacion="listar"}`))
+    assert.Equal(t, 1.0, diferencia(antes, despues, `regapi_alfresco_llamada_duracion_segundos_count{operacion="eliminar"}`))
+    assert.Equal(t, 1.0, diferencia(antes, despues, `regapi_alfresco_errores_total{categoria="no_encontrado",operacion="eliminar"}`))
+    assert.Equal(t, 0.0, diferencia(antes, despues, `regapi_alfresco_errores_total{categoria="no_encontrado",operacion="listar"}`))
+}
+
+// TestMetricasCircuito verifica que /metrics refleja el estado y las aperturas del circuito de Alfresco.
+func TestMetricasCircuito(t *testing.T) {
+    cfg := &config.Config{AlfrescoBaseURL: "http://alfresco.invalid", AlfrescoCircuitoUmbral: 2, AlfrescoCircuitoEnfriamiento: time.Hour}
+    conexion, err := servicio.NuevaConexionAlfresco(cfg, logger.NuevoRegistrador("TEST", "|"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    conexion.PublicarMetricas()
+
+    // 1. Circuito recién creado: cerrado y sin aperturas
+    series := exponer(t)
+    assert.Equal(t, 1.0, series[`regapi_alfresco_circuito_estado{estado="cerrado"}`])
+    assert.Equal(t, 0.0, series[`regapi_alfresco_circuito_estado{estado="abierto"}`])
+    assert.Contains(t, series, `regapi_alfresco_circuito_estado{estado="semiabierto"}`)
+    assert.Equal(t, 0.0, series["regapi_alfresco_circuito_aperturas_total"])
+
+    // 2. Alcanzar el umbral de fallos abre el circuito
+    conexion.Circuito().RegistrarFallo()
+    conexion.Circuito().RegistrarFallo()
+    series = exponer(t)
+    assert.Equal(t, 0.0, series[`regapi_alfresco_circuito_estado{estado="cerrado"}`])
+    assert.Equal(t, 1.0, series[`regapi_alfresco_circuito_estado{estado="abierto"}`])
+    assert.Equal(t, 1.0, series["regapi_alfresco_circuito_aperturas_total"])
+
+    // 3. Un éxito lo cierra; las aperturas se conservan
+    conexion.Circuito().RegistrarExito()
+    series = exponer(t)
+    assert.Equal(t, 1.0, series[`regapi_alfresco_circuito_estado{estado="cerrado"}`])
+    assert.Equal(t, 1.0, series["regapi_alfresco_circuito_aperturas_total"])
+}
+
+// TestMetricasDocumentos verifica bytes subidos y descargados, tamaño de lotes y renovaciones de ticket.
+func TestMetricasDocumentos(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    servicioDocs := documento.NuevoServicioDocumentos(servicio.NuevoMockClienteAlfresco(log), log, "mock-key")
+    interna := handlers.NewInternalAuth(&servicio.MockAuthClient{Log: log}, log, &config.Config{})
+    contenido := []byte("%PDF-1.4 contenido de prueba")
+
+    antes := exponer(t)
+    _, err := servicioDocs.SubirDocumento(context.Background(), documento.SolicitudCarga{Contenido: contenido, Ticket: "TICKET_mock_123"})
+    assert.NoError(t, err)
+    archivo, err := servicioDocs.DescargarDocumento(context.Background(), "doc-123", "", "TICKET_mock_123")
+    assert.NoError(t, err)
+    metricas.ObservarLote(12)
+    _, err = interna.AutenticarInternamente()
+    assert.NoError(t, err)
+    despues := exponer(t)
+
+    assert.Equal(t, float64(len(contenido)), diferencia(antes, despues, `regapi_documentos_bytes_total{direccion="subida"}`))
+    if assert.NotNil(t, archivo) {
+        assert.Equal(t, float64(len(archivo.Contenido)), diferencia(antes, despues, `regapi_documentos_bytes_total{direccion="descarga"}`))
+    }
+    assert.Equal(t, 1.0, diferencia(antes, despues, `regapi_lote_documentos_count`))
+    assert.Equal(t, 12.0, diferencia(antes, despues, `regapi_lote_documentos_sum`))
+    assert.Equal(t, 1.0, diferencia(antes, despues, `regapi_ticket_renovaciones_total{resultado="exito"}`))
+}
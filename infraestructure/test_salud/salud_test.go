@@ -0,0 +1,119 @@
+package test_salud
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/salud"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// comprobacion crea una comprobación que retorna el error indicado y cuenta sus ejecuciones.
+func comprobacion(nombre string, critica bool, err error, ejecuciones *int32) salud.Comprobacion {
+    return salud.Comprobacion{
+        Nombre:  nombre,
+        Critica: critica,
+        Verificar: func(ctx context.Context) (map[string]interface{}, error) {
+            atomic.AddInt32(ejecuciones, 1)
+            return nil, err
+        },
+    }
+}
+
+// consultarListo ejecuta GET /health/ready y retorna el código y el reporte.
+func consultarListo(t *testing.T, monitor *salud.Monitor) (int, salud.Reporte) {
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.GET("/health/ready", handlers.NuevoManejadorSalud(monitor).ManejadorListo)
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest("GET", "/health/ready", nil)
+    router.ServeHTTP(w, req)
+
+    var reporte salud.Reporte
+    if err := json.Unmarshal(w.Body.Bytes(), &reporte); err != nil {
+        t.Fatalf("Respuesta no es JSON: %v", err)
+    }
+    return w.Code, reporte
+}
+
+// TestReadiness verifica el estado combinado y el código HTTP según la criticidad de la dependencia caída.
+func TestReadiness(t *testing.T) {
+    var n int32
+    casos := []struct {
+        nombre         string
+        comprobaciones []salud.Comprobacion
+        codigo         int
+        estado         string
+    }{
+        {"todo disponible", []salud.Comprobacion{comprobacion("mongodb", true, nil, &n), comprobacion("ticket", false, nil, &n)}, http.StatusOK, salud.EstadoListo},
+        {"cae una no crítica", []salud.Comprobacion{comprobacion("mongodb", true, nil, &n), comprobacion("ticket", false, errors.New("401"), &n)}, http.StatusOK, salud.EstadoDegradado},
+        {"cae una crítica", []salud.Comprobacion{comprobacion("mongodb", true, errors.New("sin conexión"), &n), comprobacion("ticket", false, nil, &n)}, http.StatusServiceUnavailable, salud.EstadoNoListo},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            codigo, reporte := consultarListo(t, salud.NuevoMonitor(time.Second, time.Minute, caso.comprobaciones...))
+            assert.Equal(t, caso.codigo, codigo)
+            assert.Equal(t, caso.estado, reporte.Estado)
+            assert.Len(t, reporte.Dependencias, 2)
+        })
+    }
+}
+
+// TestReadinessTimeoutYCache verifica que una dependencia lenta se informa como error y que el reporte se reutiliza.
+func TestReadinessTimeoutYCache(t *testing.T) {
+    var ejecuciones int32
+    lenta := salud.Comprobacion{
+        Nombre:  "alfresco",
+        Critica: true,
+        Verificar: func(ctx context.Context) (map[string]interface{}, error) {
+            atomic.AddInt32(&ejecuciones, 1)
+            <-ctx.Done()
+            return nil, ctx.Err()
+        },
+    }
+    monitor := salud.NuevoMonitor(50*time.Millisecond, time.Minute, lenta)
+
+    codigo, reporte := consultarListo(t, monitor)
+    assert.Equal(t, http.StatusServiceUnavailable, codigo)
+    assert.Equal(t, salud.EstadoError, reporte.Dependencias["alfresco"].Estado)
+    assert.Contains(t, reporte.Dependencias["alfresco"].Error, "sin respuesta")
+    assert.GreaterOrEqual(t, reporte.Dependencias["alfresco"].LatenciaMs, int64(50))
+
+    // Dentro del tiempo de caché no se vuelve a consultar la dependencia
+    consultarListo(t, monitor)
+    assert.Equal(t, int32(1), atomic.LoadInt32(&ejecuciones))
+}
+
+// TestComprobacionAlfresco verifica la sonda a Alfresco y que el reporte incluye el estado del circuito.
+func TestComprobacionAlfresco(t *testing.T) {
+    estado := int32(http.StatusOK)
+    servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(int(atomic.LoadInt32(&estado)))
+    }))
+    defer servidor.Close()
+    conexion, err := servicio.NuevaConexionAlfresco(&config.Config{AlfrescoBaseURL: servidor.URL, AlfrescoAPIKey: "api-key", AlfrescoTimeout: time.Second}, logger.NuevoRegistrador("TEST", "|"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    verificacion := salud.ComprobacionAlfresco(conexion)
+
+    detalle, err := verificacion.Verificar(context.Background())
+    assert.NoError(t, err)
+    assert.Equal(t, http.StatusOK, detalle["codigo_http"])
+    assert.Equal(t, servicio.CircuitoCerrado, detalle["circuito"].(servicio.EstadoCircuito).Estado)
+
+    atomic.StoreInt32(&estado, http.StatusBadGateway)
+    detalle, err = verificacion.Verificar(context.Background())
+    assert.Error(t, err)
+    assert.Equal(t, http.StatusBadGateway, detalle["codigo_http"])
+}
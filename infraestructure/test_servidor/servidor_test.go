@@ -0,0 +1,208 @@
+package test_servidor
+
+import (
+    "bytes"
+    "context"
+    "encoding/base64"
+    "encoding/json"
+    "net"
+    "net/http"
+    "sync"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/memoria"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/servidor"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// almacenamientoLento simula una subida a Alfresco que no termina hasta que la prueba la libera.
+type almacenamientoLento struct {
+    *servicio.MockClienteAlfresco
+    iniciada chan struct{} // Se cierra cuando la subida llega a Alfresco
+    liberar  chan struct{} // La prueba lo cierra para que la subida termine
+}
+
+// SubirDocumento espera a que la prueba libere la subida.
+func (a *almacenamientoLento) SubirDocumento(ctx context.Context, solicitud documentos.SolicitudSubida) (*documentos.Documento, error) {
+    close(a.iniciada)
+    <-a.liberar
+    return a.MockClienteAlfresco.SubirDocumento(ctx, solicitud)
+}
+
+// bitacora registra el orden de los pasos del apagado.
+type bitacora struct {
+    mu    sync.Mutex
+    pasos []string
+}
+
+func (b *bitacora) anotar(paso string) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.pasos = append(b.pasos, paso)
+}
+
+func (b *bitacora) leer() []string {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return append([]string(nil), b.pasos...)
+}
+
+// servidorDePrueba levanta el servidor con la ruta de subida real sobre un almacenamiento lento.
+// Retorna el servidor, el almacenamiento, la bitácora del apagado y el listener donde atenderá.
+func servidorDePrueba(t *testing.T, plazo time.Duration) (*servidor.Servidor, *almacenamientoLento, *bitacora, net.Listener) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    almacenamiento := &almacenamientoLento{
+        MockClienteAlfresco: servicio.NuevoMockClienteAlfresco(log),
+        iniciada:            make(chan struct{}),
+        liberar:             make(chan struct{}),
+    }
+    cfg := &config.Config{
+        ServidorTimeoutEncabezados: 5 * time.Second,
+        ServidorTimeoutLectura:     5 * time.Second,
+        ServidorTimeoutEscritura:   10 * time.Second,
+        ServidorTimeoutInactividad: 5 * time.Second,
+        ServidorPlazoApagado:       plazo,
+    }
+    servicioDocs := documento.NuevoServicioDocumentos(almacenamiento, log, "mock-key")
+    manejador := handlers.NuevoManejadorDocumentos(servicioDocs, memoria.NuevoIndiceMemoria(), log, cfg, handlers.NewInternalAuth(&servicio.MockAuthClient{Log: log}, log, cfg))
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.POST("/documentos/subir", manejador.ManejadorSubirDocumento)
+
+    // Una tarea en segundo plano y un recurso, para verificar el orden del apagado
+    pasos := &bitacora{}
+    srv := servidor.Nuevo(cfg, router, log)
+    srv.IniciarTarea(func(ctx context.Context) {
+        <-ctx.Done()
+        pasos.anotar("tarea detenida")
+    })
+    srv.AlCerrar("mongodb", func(ctx context.Context) error {
+        pasos.anotar("mongodb desconectado")
+        return nil
+    })
+
+    escucha, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Error al abrir el puerto de prueba: %v", err)
+    }
+    return srv, almacenamiento, pasos, escucha
+}
+
+// subir envía la subida en segundo plano y entrega el código de estado (o 0 si la conexión falló).
+func subir(t *testing.T, direccion string, pasos *bitacora) <-chan int {
+    estados := make(chan int, 1)
+    cuerpo := solicitudSubida(t)
+    go func() {
+        req, _ := http.NewRequest("POST", "http://"+direccion+"/documentos/subir", bytes.NewReader(cuerpo))
+        req.Header.Set("Content-Type", "application/json")
+        req.Header.Set("Authorization", "Basic TICKET_mock_123")
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            estados <- 0
+            return
+        }
+        resp.Body.Close()
+        pasos.anotar("subida respondida")
+        estados <- resp.StatusCode
+    }()
+    return estados
+}
+
+// esperarRechazo espera a que el servidor deje de aceptar conexiones nuevas.
+func esperarRechazo(direccion string) bool {
+    limite := time.Now().Add(2 * time.Second)
+    for time.Now().Before(limite) {
+        conexion, err := net.DialTimeout("tcp", direccion, 100*time.Millisecond)
+        if err != nil {
+            return true
+        }
+        conexion.Close()
+        time.Sleep(10 * time.Millisecond)
+    }
+    return false
+}
+
+// TestApagadoCompletaSubidaEnCurso verifica que al apagar no se aceptan solicitudes nuevas, la subida en curso
+// termina con éxito y recién después se detienen las tareas y se desconecta MongoDB.
+func TestApagadoCompletaSubidaEnCurso(t *testing.T) {
+    srv, almacenamiento, pasos, escucha := servidorDePrueba(t, 5*time.Second)
+    ctx, apagar := context.WithCancel(context.Background())
+    resultado := make(chan error, 1)
+    go func() { resultado <- srv.Servir(ctx, escucha) }()
+    direccion := escucha.Addr().String()
+
+    // 1. Iniciar una subida y esperar a que llegue a Alfresco
+    estados := subir(t, direccion, pasos)
+    select {
+    case <-almacenamiento.iniciada:
+    case <-time.After(5 * time.Second):
+        t.Fatal("La subida no llegó al almacenamiento")
+    }
+
+    // 2. Apagar (equivale a SIGTERM): el servidor deja de aceptar conexiones nuevas
+    apagar()
+    assert.True(t, esperarRechazo(direccion), "el servidor siguió aceptando conexiones durante el apagado")
+    assert.Empty(t, pasos.leer(), "el apagado no debe avanzar mientras la subida está en curso")
+
+    // 3. Liberar la subida: responde con éxito y el apagado termina en orden
+    close(almacenamiento.liberar)
+    assert.Equal(t, http.StatusOK, <-estados)
+    select {
+    case err := <-resultado:
+        assert.NoError(t, err)
+    case <-time.After(5 * time.Second):
+        t.Fatal("El servidor no terminó de apagarse")
+    }
+    assert.Equal(t, []string{"subida respondida", "tarea detenida", "mongodb desconectado"}, pasos.leer())
+}
+
+// TestApagadoRespetaPlazo verifica que una solicitud que no termina no bloquea el apagado más allá del plazo.
+func TestApagadoRespetaPlazo(t *testing.T) {
+    srv, almacenamiento, pasos, escucha := servidorDePrueba(t, 200*time.Millisecond)
+    defer close(almacenamiento.liberar)
+    ctx, apagar := context.WithCancel(context.Background())
+    resultado := make(chan error, 1)
+    go func() { resultado <- srv.Servir(ctx, escucha) }()
+
+    subir(t, escucha.Addr().String(), pasos)
+    <-almacenamiento.iniciada
+    apagar()
+
+    select {
+    case err := <-resultado:
+        assert.ErrorIs(t, err, context.DeadlineExceeded)
+    case <-time.After(5 * time.Second):
+        t.Fatal("El apagado no respetó el plazo")
+    }
+    assert.Contains(t, pasos.leer(), "mongodb desconectado")
+}
+
+// solicitudSubida arma el cuerpo de una subida válida.
+func solicitudSubida(t *testing.T) []byte {
+    cuerpo, err := json.Marshal(map[string]interface{}{
+        "base64": base64.StdEncoding.EncodeToString([]byte("%PDF-1.4 documento de prueba")),
+        "metadatos": map[string]interface{}{
+            "cm:title":               "Contrato",
+            "tanner:rut-cliente":     "20218874-5",
+            "tanner:tipo-documento":  "Factura",
+            "tanner:estado-vigencia": "Vigente",
+            "tanner:nombre-doc":      "contrato.pdf",
+            "cm:versionType":         "MAJOR",
+            "cm:versionLabel":        "1.0",
+            "cm:description":         "Documento de prueba",
+            "tanner:sub-categorias":  "Riesgo",
+        },
+    })
+    if err != nil {
+        t.Fatalf("Error al serializar la solicitud: %v", err)
+    }
+    return cuerpo
+}
@@ -0,0 +1,305 @@
+package test_servidor
+
+import (
+    "context"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/json"
+    "encoding/pem"
+    "math/big"
+    "net"
+    "net/http"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/servidor"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// autoridad es una CA generada para la prueba.
+type autoridad struct {
+    certificado *x509.Certificate
+    clave       *ecdsa.PrivateKey
+    pem         []byte
+}
+
+// nuevaAutoridad genera una CA autofirmada.
+func nuevaAutoridad(t *testing.T, nombre string) autoridad {
+    clave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatalf("Error al generar la clave de la CA: %v", err)
+    }
+    plantilla := &x509.Certificate{
+        SerialNumber:          big.NewInt(1),
+        Subject:               pkix.Name{CommonName: nombre},
+        NotBefore:             time.Now().Add(-time.Hour),
+        NotAfter:              time.Now().Add(24 * time.Hour),
+        KeyUsage:              x509.KeyUsageCertSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+    der, err := x509.CreateCertificate(rand.Reader, plantilla, plantilla, &clave.PublicKey, clave)
+    if err != nil {
+        t.Fatalf("Error al generar la CA: %v", err)
+    }
+    certificado, _ := x509.ParseCertificate(der)
+    return autoridad{certificado: certificado, clave: clave, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// emitir firma un certificado de servidor (para 127.0.0.1) o de cliente con el CN indicado.
+// Retorna el certificado y la clave en PEM.
+func (a autoridad) emitir(t *testing.T, nombre string, esServidor bool) ([]byte, []byte) {
+    clave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatalf("Error al generar la clave: %v", err)
+    }
+    serie, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+    plantilla := &x509.Certificate{
+        SerialNumber: serie,
+        Subject:      pkix.Name{CommonName: nombre, Organization: []string{"REGAPI pruebas"}},
+        NotBefore:    time.Now().Add(-time.Hour),
+        NotAfter:     time.Now().Add(24 * time.Hour),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+    }
+    if esServidor {
+        plantilla.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+        plantilla.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+    }
+    der, err := x509.CreateCertificate(rand.Reader, plantilla, a.certificado, &clave.PublicKey, a.clave)
+    if err != nil {
+        t.Fatalf("Error al emitir el certificado: %v", err)
+    }
+    claveDER, _ := x509.MarshalECPrivateKey(clave)
+    return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: claveDER})
+}
+
+// escribir guarda el contenido en el archivo y adelanta su fecha de modificación, para que la recarga
+// lo detecte aunque el sistema de archivos tenga resolución de un segundo.
+func escribir(t *testing.T, ruta string, contenido []byte, modificado time.Time) {
+    if err := os.WriteFile(ruta, contenido, 0o600); err != nil {
+        t.Fatalf("Error al escribir %s: %v", ruta, err)
+    }
+    if err := os.Chtimes(ruta, modificado, modificado); err != nil {
+        t.Fatalf("Error al cambiar la fecha de %s: %v", ruta, err)
+    }
+}
+
+// entornoTLS son los archivos de certificados de una prueba.
+type entornoTLS struct {
+    ca          autoridad
+    certificado string
+    clave       string
+}
+
+// nuevoEntornoTLS genera una CA y un certificado de servidor con el CN indicado.
+func nuevoEntornoTLS(t *testing.T, nombreServidor string) entornoTLS {
+    directorio := t.TempDir()
+    entorno := entornoTLS{
+        ca:          nuevaAutoridad(t, "CA servidor"),
+        certificado: filepath.Join(directorio, "tls.crt"),
+        clave:       filepath.Join(directorio, "tls.key"),
+    }
+    certificado, clave := entorno.ca.emitir(t, nombreServidor, true)
+    escribir(t, entorno.certificado, certificado, time.Now())
+    escribir(t, entorno.clave, clave, time.Now())
+    return entorno
+}
+
+// servidorTLS levanta el servidor con TLS y una ruta que informa la identidad resuelta.
+// Retorna la dirección base (https://127.0.0.1:puerto).
+func servidorTLS(t *testing.T, cfg *config.Config) string {
+    cfg.ServidorTimeoutEncabezados = 5 * time.Second
+    cfg.ServidorTimeoutLectura = 5 * time.Second
+    cfg.ServidorTimeoutEscritura = 5 * time.Second
+    cfg.ServidorTimeoutInactividad = 5 * time.Second
+    cfg.ServidorPlazoApagado = 2 * time.Second
+    cfg.TLSVersionMinima = "1.2"
+    cfg.TLSRecargaIntervalo = 20 * time.Millisecond
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareIdentidad(cfg))
+    router.GET("/identidad", func(c *gin.Context) {
+        c.JSON(http.StatusOK, map[string]string{
+            "rol":     middleware.RolSolicitante(c),
+            "actor":   middleware.ActorSolicitante(c),
+            "cliente": middleware.ClienteSolicitante(c),
+        })
+    })
+
+    srv := servidor.Nuevo(cfg, router, logger.NuevoRegistrador("TEST", "|"))
+    if err := srv.ConfigurarTLS(cfg); err != nil {
+        t.Fatalf("Error al configurar TLS: %v", err)
+    }
+    escucha, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Error al abrir el puerto de prueba: %v", err)
+    }
+    ctx, cancelar := context.WithCancel(context.Background())
+    terminado := make(chan error, 1)
+    go func() { terminado <- srv.Servir(ctx, escucha) }()
+    t.Cleanup(func() {
+        cancelar()
+        <-terminado
+    })
+    return "https://" + escucha.Addr().String()
+}
+
+// clienteTLS crea un cliente sin conexiones reutilizadas que confía en la CA indicada y, opcionalmente,
+// presenta un certificado de cliente.
+func clienteTLS(t *testing.T, ca autoridad, certificado, clave []byte) *http.Client {
+    confianza := x509.NewCertPool()
+    confianza.AppendCertsFromPEM(ca.pem)
+    configuracion := &tls.Config{RootCAs: confianza}
+    if certificado != nil {
+        par, err := tls.X509KeyPair(certificado, clave)
+        if err != nil {
+            t.Fatalf("Error al cargar el certificado de cliente: %v", err)
+        }
+        configuracion.Certificates = []tls.Certificate{par}
+    }
+    return &http.Client{
+        Timeout:   5 * time.Second,
+        Transport: &http.Transport{TLSClientConfig: configuracion, DisableKeepAlives: true},
+    }
+}
+
+// consultarIdentidad llama a /identidad y retorna la identidad resuelta y el CN del certificado del servidor.
+func consultarIdentidad(cliente *http.Client, base string) (map[string]string, string, error) {
+    respuesta, err := cliente.Get(base + "/identidad")
+    if err != nil {
+        return nil, "", err
+    }
+    defer respuesta.Body.Close()
+    var identidad map[string]string
+    if err := json.NewDecoder(respuesta.Body).Decode(&identidad); err != nil {
+        return nil, "", err
+    }
+    return identidad, respuesta.TLS.PeerCertificates[0].Subject.CommonName, nil
+}
+
+// TestTLSRecargaCertificados verifica que el servidor atiende con TLS, que aplica un certificado renovado a las
+// conexiones nuevas sin reiniciar y que conserva el vigente si los archivos nuevos son inválidos.
+func TestTLSRecargaCertificados(t *testing.T) {
+    entorno := nuevoEntornoTLS(t, "servidor-v1")
+    base := servidorTLS(t, &config.Config{TLSCertificado: entorno.certificado, TLSClave: entorno.clave})
+    cliente := clienteTLS(t, entorno.ca, nil, nil)
+
+    // 1. Atiende con el certificado inicial; sin mTLS el cliente se identifica por IP
+    identidad, emisor, err := consultarIdentidad(cliente, base)
+    if !assert.NoError(t, err) {
+        return
+    }
+    assert.Equal(t, "servidor-v1", emisor)
+    assert.Equal(t, "api:operador", identidad["actor"])
+    assert.Equal(t, "ip:127.0.0.1", identidad["cliente"])
+
+    // 2. HTTP sin TLS no se atiende
+    respuesta, err := http.Get("http" + base[len("https"):] + "/identidad")
+    if err == nil {
+        assert.Equal(t, http.StatusBadRequest, respuesta.StatusCode)
+        respuesta.Body.Close()
+    }
+
+    // 3. Renovación: las conexiones nuevas reciben el certificado nuevo
+    certificado, clave := entorno.ca.emitir(t, "servidor-v2", true)
+    renovado := time.Now().Add(time.Minute)
+    escribir(t, entorno.clave, clave, renovado)
+    escribir(t, entorno.certificado, certificado, renovado)
+    assert.Eventually(t, func() bool {
+        _, emisor, err := consultarIdentidad(cliente, base)
+        return err == nil && emisor == "servidor-v2"
+    }, 2*time.Second, 20*time.Millisecond)
+
+    // 4. Un certificado inválido no interrumpe el servicio
+    escribir(t, entorno.certificado, []byte("no es un certificado"), renovado.Add(time.Minute))
+    time.Sleep(100 * time.Millisecond)
+    _, emisor, err = consultarIdentidad(cliente, base)
+    assert.NoError(t, err)
+    assert.Equal(t, "servidor-v2", emisor)
+}
+
+// TestMTLSIdentidad verifica que el sujeto del certificado de cliente determina el actor, el rol y el cliente
+// de los límites, y que sin un certificado firmado por la CA configurada no se establece la conexión.
+func TestMTLSIdentidad(t *testing.T) {
+    entorno := nuevoEntornoTLS(t, "servidor")
+    clientes := nuevaAutoridad(t, "CA clientes")
+    rutaCA := filepath.Join(t.TempDir(), "clientes.crt")
+    escribir(t, rutaCA, clientes.pem, time.Now())
+
+    base := servidorTLS(t, &config.Config{
+        TLSCertificado:             entorno.certificado,
+        TLSClave:                   entorno.clave,
+        TLSClienteCA:               rutaCA,
+        TLSClienteModo:             servidor.ModoClienteRequerido,
+        TLSClientesAdministradores: []string{"backoffice"},
+    })
+
+    casos := []struct {
+        nombre string
+        sujeto string
+        rol    string
+    }{
+        {"administrador", "backoffice", middleware.RolAdministrador},
+        {"operador", "portal-clientes", middleware.RolOperador},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            certificado, clave := clientes.emitir(t, caso.sujeto, false)
+            identidad, _, err := consultarIdentidad(clienteTLS(t, entorno.ca, certificado, clave), base)
+            if !assert.NoError(t, err) {
+                return
+            }
+            assert.Equal(t, caso.rol, identidad["rol"])
+            assert.Equal(t, "cert:"+caso.sujeto, identidad["actor"])
+            assert.Equal(t, "cert:"+caso.sujeto, identidad["cliente"])
+        })
+    }
+
+    t.Run("sin certificado", func(t *testing.T) {
+        _, _, err := consultarIdentidad(clienteTLS(t, entorno.ca, nil, nil), base)
+        assert.Error(t, err)
+    })
+
+    t.Run("CA desconocida", func(t *testing.T) {
+        certificado, clave := nuevaAutoridad(t, "CA ajena").emitir(t, "backoffice", false)
+        _, _, err := consultarIdentidad(clienteTLS(t, entorno.ca, certificado, clave), base)
+        assert.Error(t, err)
+    })
+}
+
+// TestMTLSOpcional verifica que en modo opcional un cliente sin certificado se atiende y se identifica como antes.
+func TestMTLSOpcional(t *testing.T) {
+    entorno := nuevoEntornoTLS(t, "servidor")
+    clientes := nuevaAutoridad(t, "CA clientes")
+    rutaCA := filepath.Join(t.TempDir(), "clientes.crt")
+    escribir(t, rutaCA, clientes.pem, time.Now())
+
+    base := servidorTLS(t, &config.Config{
+        TLSCertificado: entorno.certificado,
+        TLSClave:       entorno.clave,
+        TLSClienteCA:   rutaCA,
+        TLSClienteModo: servidor.ModoClienteOpcional,
+    })
+
+    identidad, _, err := consultarIdentidad(clienteTLS(t, entorno.ca, nil, nil), base)
+    if assert.NoError(t, err) {
+        assert.Equal(t, "api:operador", identidad["actor"])
+    }
+
+    certificado, clave := clientes.emitir(t, "portal-clientes", false)
+    identidad, _, err = consultarIdentidad(clienteTLS(t, entorno.ca, certificado, clave), base)
+    if assert.NoError(t, err) {
+        assert.Equal(t, "cert:portal-clientes", identidad["actor"])
+    }
+}
@@ -0,0 +1,95 @@
+package test_trazas
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "github.com/rs/zerolog"
+    "github.com/stretchr/testify/assert"
+)
+
+// routerConIDSolicitud arma una API que registra una línea de log durante la solicitud y lista documentos
+// en un Alfresco simulado. Retorna el router, la salida del log y el X-Request-ID que recibió Alfresco.
+func routerConIDSolicitud(t *testing.T) (*gin.Engine, *bytes.Buffer, *string) {
+    var idRecibido string
+    alfresco := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        idRecibido = r.Header.Get("X-Request-ID")
+        w.Write([]byte(`[]`))
+    }))
+    t.Cleanup(alfresco.Close)
+
+    salida := &bytes.Buffer{}
+    log := logger.NuevoRegistrador("TEST", "|")
+    log.Logger = zerolog.New(salida)
+    conexion, err := servicio.NuevaConexionAlfresco(&config.Config{AlfrescoBaseURL: alfresco.URL, AlfrescoAPIKey: "api-key"}, log)
+    if err != nil {
+        t.Fatal(err)
+    }
+    almacenamiento := servicio.NuevoServicioDocumentos(conexion, log)
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareRegistro(log))
+    router.GET("/documentos", func(c *gin.Context) {
+        log.ConContexto(c.Request.Context()).Info("Listando documentos", nil)
+        _, err := almacenamiento.ListarDocumentos(c.Request.Context(), documentos.SolicitudListado{})
+        assert.NoError(t, err)
+        c.Status(http.StatusOK)
+    })
+    return router, salida, &idRecibido
+}
+
+// TestIDSolicitudDelCliente verifica que el X-Request-ID recibido se devuelve, aparece en cada línea de log
+// de la solicitud y se reenvía a Alfresco.
+func TestIDSolicitudDelCliente(t *testing.T) {
+    router, salida, idAlfresco := routerConIDSolicitud(t)
+
+    req := httptest.NewRequest("GET", "/documentos", nil)
+    req.Header.Set("X-Request-ID", "balanceador-7f3a:01")
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    assert.Equal(t, "balanceador-7f3a:01", w.Header().Get("X-Request-ID"))
+    assert.Equal(t, "balanceador-7f3a:01", *idAlfresco)
+    lineas := strings.Split(strings.TrimSpace(salida.String()), "\n")
+    assert.Len(t, lineas, 2)
+    for _, linea := range lineas {
+        assert.Contains(t, linea, `"id_solicitud":"balanceador-7f3a:01"`)
+    }
+}
+
+// TestIDSolicitudGenerado verifica que sin encabezado, o con uno inválido, se genera un UUID nuevo.
+func TestIDSolicitudGenerado(t *testing.T) {
+    casos := map[string]string{
+        "sin encabezado":   "",
+        "salto de línea":   "abc\nnivel=error",
+        "demasiado largo":  strings.Repeat("a", 129),
+        "caracteres raros": "id con espacios",
+    }
+    for nombre, encabezado := range casos {
+        t.Run(nombre, func(t *testing.T) {
+            router, salida, idAlfresco := routerConIDSolicitud(t)
+            req := httptest.NewRequest("GET", "/documentos", nil)
+            if encabezado != "" {
+                req.Header.Set("X-Request-ID", encabezado)
+            }
+            w := httptest.NewRecorder()
+            router.ServeHTTP(w, req)
+
+            id := w.Header().Get("X-Request-ID")
+            _, err := uuid.Parse(id)
+            assert.NoError(t, err, "ID generado inválido: %q", id)
+            assert.Equal(t, id, *idAlfresco)
+            assert.Contains(t, salida.String(), `"id_solicitud":"`+id+`"`)
+        })
+    }
+}
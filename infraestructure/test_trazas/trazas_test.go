@@ -0,0 +1,131 @@
+package test_trazas
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/rs/zerolog"
+    "github.com/stretchr/testify/assert"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/propagation"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Traza y span del cliente que llama a la API, en formato W3C.
+const (
+    trazaCliente       = "4bf92f3577b34da6a3ce929d0e0e4736"
+    spanCliente        = "00f067aa0ba902b7"
+    traceparentCliente = "00-" + trazaCliente + "-" + spanCliente + "-01"
+)
+
+// grabarSpans instala un proveedor que guarda los spans terminados en memoria.
+func grabarSpans(t *testing.T) *tracetest.SpanRecorder {
+    grabador := tracetest.NewSpanRecorder()
+    anterior := otel.GetTracerProvider()
+    otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(grabador)))
+    otel.SetTextMapPropagator(propagation.TraceContext{})
+    t.Cleanup(func() { otel.SetTracerProvider(anterior) })
+    return grabador
+}
+
+// spanPorNombre retorna el span terminado con el nombre indicado.
+func spanPorNombre(t *testing.T, grabador *tracetest.SpanRecorder, nombre string) sdktrace.ReadOnlySpan {
+    for _, span := range grabador.Ended() {
+        if span.Name() == nombre {
+            return span
+        }
+    }
+    t.Fatalf("No se registró el span %s", nombre)
+    return nil
+}
+
+// TestTrazaHastaAlfresco verifica que el span de la solicitud continúa la traza del cliente y que la llamada
+// a Alfresco es un span hijo cuyo traceparent se envía en la solicitud saliente.
+func TestTrazaHastaAlfresco(t *testing.T) {
+    grabador := grabarSpans(t)
+
+    // 1. Alfresco simulado que guarda el traceparent recibido
+    var traceparentRecibido string
+    alfresco := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        traceparentRecibido = r.Header.Get("traceparent")
+        w.Header().Set("Content-Type", "application/json")
+        w.Write([]byte(`[{"entry":{"id":"abc-1","name":"contrato.pdf"}}]`))
+    }))
+    defer alfresco.Close()
+    log := logger.NuevoRegistrador("TEST", "|")
+    conexion, err := servicio.NuevaConexionAlfresco(&config.Config{AlfrescoBaseURL: alfresco.URL, AlfrescoAPIKey: "api-key"}, log)
+    if err != nil {
+        t.Fatal(err)
+    }
+    almacenamiento := servicio.NuevoServicioDocumentos(conexion, log)
+
+    // 2. API con el middleware de trazas que lista documentos en Alfresco
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareTrazas("TEST"))
+    router.GET("/documentos", func(c *gin.Context) {
+        _, err := almacenamiento.ListarDocumentos(c.Request.Context(), documentos.SolicitudListado{})
+        assert.NoError(t, err)
+        c.Status(http.StatusOK)
+    })
+
+    req := httptest.NewRequest("GET", "/documentos", nil)
+    req.Header.Set("traceparent", traceparentCliente)
+    router.ServeHTTP(httptest.NewRecorder(), req)
+
+    // 3. El span de la solicitud es hijo del span del cliente
+    solicitud := spanPorNombre(t, grabador, "/documentos")
+    assert.Equal(t, trazaCliente, solicitud.SpanContext().TraceID().String())
+    assert.Equal(t, spanCliente, solicitud.Parent().SpanID().String())
+
+    // 4. La llamada a Alfresco es hija del span de la solicitud y viaja en el traceparent saliente
+    llamada := spanPorNombre(t, grabador, "alfresco.listar")
+    assert.Equal(t, solicitud.SpanContext().SpanID(), llamada.Parent().SpanID())
+    assert.Equal(t, "00-"+trazaCliente+"-"+llamada.SpanContext().SpanID().String()+"-01", traceparentRecibido)
+}
+
+// TestLogIncluyeTraceID verifica que la línea de log de la solicitud incluye el trace_id del span en curso.
+func TestLogIncluyeTraceID(t *testing.T) {
+    grabarSpans(t)
+    var salida bytes.Buffer
+    log := logger.NuevoRegistrador("TEST", "|")
+    log.Logger = zerolog.New(&salida)
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareTrazas("TEST"), middleware.MiddlewareRegistro(log))
+    router.GET("/documentos", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+    req := httptest.NewRequest("GET", "/documentos", nil)
+    req.Header.Set("traceparent", traceparentCliente)
+    router.ServeHTTP(httptest.NewRecorder(), req)
+
+    assert.Contains(t, salida.String(), `"trace_id":"`+trazaCliente+`"`)
+    assert.Contains(t, salida.String(), `"span_id":"`)
+}
+
+// TestSondasSinTraza verifica que las sondas de salud y las métricas no generan spans.
+func TestSondasSinTraza(t *testing.T) {
+    grabador := grabarSpans(t)
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareTrazas("TEST"))
+    router.GET("/health/live", func(c *gin.Context) { c.Status(http.StatusOK) })
+    router.GET("/metrics", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+    for _, ruta := range []string{"/health/live", "/metrics"} {
+        router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", ruta, nil))
+    }
+    for _, span := range grabador.Ended() {
+        assert.False(t, strings.HasPrefix(span.Name(), "/"), "span inesperado %s", span.Name())
+    }
+}
@@ -0,0 +1,140 @@
+package test_vigencia
+
+import (
+    "context"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/stretchr/testify/assert"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// cfgMongo es la configuración de colecciones usada con el cliente simulado.
+var cfgMongo = &config.Config{MongoDatabase: "regapi", MongoCollection: "documentos", MongoCollectionBloqueos: "bloqueos"}
+
+// respuestaOK es la respuesta del servidor a una escritura que afectó un documento.
+var respuestaOK = bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}}
+
+// comandoEnviado retorna el único comando de escritura enviado, decodificado.
+func comandoEnviado(mt *mtest.T, arreglo string) bson.M {
+    var comando bson.M
+    if err := bson.Unmarshal(mt.GetStartedEvent().Command, &comando); err != nil {
+        mt.Fatalf("Comando inválido: %v", err)
+    }
+    elementos := comando[arreglo].(bson.A)
+    return elementos[0].(bson.M)
+}
+
+// TestBloqueoMongo verifica el bloqueo de líder: se toma con un upsert condicionado a que esté expirado o sea
+// propio, otra réplica con el bloqueo vigente produce clave duplicada y solo el propietario lo libera.
+func TestBloqueoMongo(t *testing.T) {
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+    mt.Run("adquirir", func(mt *mtest.T) {
+        bloqueo := mongo.NuevoBloqueo(mt.Client, cfgMongo, "programador-vigencia", "replica-a")
+        mt.AddMockResponses(respuestaOK)
+
+        lider, err := bloqueo.Adquirir(context.Background(), time.Minute)
+        assert.NoError(t, err)
+        assert.True(t, lider)
+
+        actualizacion := comandoEnviado(mt, "updates")
+        assert.Equal(t, true, actualizacion["upsert"])
+        filtro := actualizacion["q"].(bson.M)
+        assert.Equal(t, "programador-vigencia", filtro["_id"])
+        condiciones := filtro["$or"].(bson.A)
+        assert.Equal(t, bson.M{"propietario": "replica-a"}, condiciones[1])
+        cambios := actualizacion["u"].(bson.M)["$set"].(bson.M)
+        assert.Equal(t, "replica-a", cambios["propietario"])
+    })
+
+    mt.Run("otra réplica es líder", func(mt *mtest.T) {
+        bloqueo := mongo.NuevoBloqueo(mt.Client, cfgMongo, "programador-vigencia", "replica-b")
+        mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"}))
+
+        lider, err := bloqueo.Adquirir(context.Background(), time.Minute)
+        assert.NoError(t, err)
+        assert.False(t, lider)
+    })
+
+    mt.Run("error de MongoDB", func(mt *mtest.T) {
+        bloqueo := mongo.NuevoBloqueo(mt.Client, cfgMongo, "programador-vigencia", "replica-a")
+        mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 91, Message: "shutdown in progress"}))
+
+        lider, err := bloqueo.Adquirir(context.Background(), time.Minute)
+        assert.Error(t, err)
+        assert.False(t, lider)
+    })
+
+    mt.Run("liberar", func(mt *mtest.T) {
+        bloqueo := mongo.NuevoBloqueo(mt.Client, cfgMongo, "programador-vigencia", "replica-a")
+        mt.AddMockResponses(respuestaOK)
+
+        assert.NoError(t, bloqueo.Liberar(context.Background()))
+        eliminacion := comandoEnviado(mt, "deletes")
+        assert.Equal(t, bson.M{"_id": "programador-vigencia", "propietario": "replica-a"}, eliminacion["q"])
+    })
+}
+
+// TestIndiceVigenciaMongoFallos verifica que la consulta de vencidos excluye los documentos en espera de
+// reintento y que los fallos se registran y se descartan en el documento.
+func TestIndiceVigenciaMongoFallos(t *testing.T) {
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+    ahora := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+    mt.Run("buscar vencidos", func(mt *mtest.T) {
+        indice := mongo.NuevoIndiceVigencia(mt.Client, cfgMongo)
+        mt.AddMockResponses(mtest.CreateCursorResponse(0, "regapi.documentos", mtest.FirstBatch, bson.D{
+            {Key: "id_archivo", Value: "doc-1"},
+            {Key: "metadatos", Value: bson.D{{Key: "fecha_termino_vigencia", Value: "2026-05-01"}}},
+            {Key: "fallo_vigencia", Value: bson.D{{Key: "intentos", Value: 3}, {Key: "proximo_intento", Value: ahora.Add(-time.Minute)}}},
+        }))
+
+        vencidos, err := indice.BuscarVencidos(context.Background(), ahora, 10)
+        assert.NoError(t, err)
+        if assert.Len(t, vencidos, 1) {
+            assert.Equal(t, "doc-1", vencidos[0].ID)
+            assert.Equal(t, 3, vencidos[0].Intentos)
+        }
+
+        var comando bson.M
+        bson.Unmarshal(mt.GetStartedEvent().Command, &comando)
+        filtro := comando["filter"].(bson.M)
+        espera := filtro["fallo_vigencia.proximo_intento"].(bson.M)["$not"].(bson.M)["$gt"]
+        assert.Equal(t, primitive.NewDateTimeFromTime(ahora), espera)
+    })
+
+    mt.Run("registrar fallo", func(mt *mtest.T) {
+        indice := mongo.NuevoIndiceVigencia(mt.Client, cfgMongo)
+        mt.AddMockResponses(respuestaOK)
+
+        err := indice.RegistrarFalloVigencia(context.Background(), "doc-1", documento.FalloVigencia{Intentos: 2, ProximoIntento: ahora, Error: "conflicto"})
+        assert.NoError(t, err)
+        actualizacion := comandoEnviado(mt, "updates")
+        fallo := actualizacion["u"].(bson.M)["$set"].(bson.M)["fallo_vigencia"].(bson.M)
+        assert.Equal(t, int32(2), fallo["intentos"])
+        assert.Equal(t, "conflicto", fallo["error"])
+    })
+
+    mt.Run("registrar fallo de documento inexistente", func(mt *mtest.T) {
+        indice := mongo.NuevoIndiceVigencia(mt.Client, cfgMongo)
+        mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+
+        err := indice.RegistrarFalloVigencia(context.Background(), "doc-x", documento.FalloVigencia{Intentos: 1, ProximoIntento: ahora})
+        assert.ErrorIs(t, err, documentos.ErrRegistroNoEncontrado)
+    })
+
+    mt.Run("marcar descarta el fallo", func(mt *mtest.T) {
+        indice := mongo.NuevoIndiceVigencia(mt.Client, cfgMongo)
+        mt.AddMockResponses(respuestaOK)
+
+        assert.NoError(t, indice.ActualizarEstadoVigencia(context.Background(), "doc-1", documentos.EstadoNoVigente))
+        actualizacion := comandoEnviado(mt, "updates")
+        assert.Equal(t, bson.M{"fallo_vigencia": ""}, actualizacion["u"].(bson.M)["$unset"])
+    })
+}
@@ -0,0 +1,304 @@
+package test_vigencia
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/domain/documentos"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// registroVigencia es un documento del índice simulado.
+type registroVigencia struct {
+    doc    documento.DocumentoVencido
+    estado string
+    fallo  *documento.FalloVigencia
+}
+
+// indiceVigenciaMemoria simula la consulta de MongoDB: orden ascendente por fecha, comparación lexicográfica
+// y exclusión de los documentos que esperan un reintento.
+type indiceVigenciaMemoria struct {
+    mu        sync.Mutex
+    registros map[string]*registroVigencia
+}
+
+func nuevoIndice(docs ...documento.DocumentoVencido) *indiceVigenciaMemoria {
+    indice := &indiceVigenciaMemoria{registros: make(map[string]*registroVigencia)}
+    for _, doc := range docs {
+        indice.registros[doc.ID] = &registroVigencia{doc: doc, estado: documentos.EstadoVigente}
+    }
+    return indice
+}
+
+func (i *indiceVigenciaMemoria) BuscarVencidos(_ context.Context, ahora time.Time, limite int) ([]documento.DocumentoVencido, error) {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+    var vencidos []documento.DocumentoVencido
+    for _, registro := range i.registros {
+        if registro.estado != documentos.EstadoVigente || registro.doc.FechaTerminoVigencia > ahora.UTC().Format(time.RFC3339) {
+            continue
+        }
+        if registro.fallo != nil && registro.fallo.ProximoIntento.After(ahora) {
+            continue
+        }
+        doc := registro.doc
+        if registro.fallo != nil {
+            doc.Intentos = registro.fallo.Intentos
+        }
+        vencidos = append(vencidos, doc)
+    }
+    sort.Slice(vencidos, func(a, b int) bool {
+        if vencidos[a].FechaTerminoVigencia == vencidos[b].FechaTerminoVigencia {
+            return vencidos[a].ID < vencidos[b].ID
+        }
+        return vencidos[a].FechaTerminoVigencia < vencidos[b].FechaTerminoVigencia
+    })
+    if len(vencidos) > limite {
+        vencidos = vencidos[:limite]
+    }
+    return vencidos, nil
+}
+
+func (i *indiceVigenciaMemoria) ActualizarEstadoVigencia(_ context.Context, idFile, estado string) error {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+    i.registros[idFile].estado = estado
+    i.registros[idFile].fallo = nil
+    return nil
+}
+
+func (i *indiceVigenciaMemoria) RegistrarFalloVigencia(_ context.Context, idFile string, fallo documento.FalloVigencia) error {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+    i.registros[idFile].fallo = &fallo
+    return nil
+}
+
+func (i *indiceVigenciaMemoria) registro(id string) registroVigencia {
+    i.mu.Lock()
+    defer i.mu.Unlock()
+    return *i.registros[id]
+}
+
+// alfrescoVigencia simula el servicio de documentos; rechaza los documentos indicados en fallar.
+type alfrescoVigencia struct {
+    fallar      map[string]bool
+    actualizados []string
+}
+
+func (a *alfrescoVigencia) ActualizarEstadoVigencia(_ context.Context, solicitud documento.SolicitudEstadoVigencia) error {
+    if a.fallar[solicitud.IDArchivo] {
+        return fmt.Errorf("%w: documento bloqueado", documentos.ErrConflicto)
+    }
+    a.actualizados = append(a.actualizados, solicitud.IDArchivo)
+    return nil
+}
+
+// bloqueoFijo simula el bloqueo de líder con un resultado fijo.
+type bloqueoFijo struct {
+    lider bool
+    err   error
+}
+
+func (b bloqueoFijo) Adquirir(context.Context, time.Duration) (bool, error) { return b.lider, b.err }
+func (b bloqueoFijo) Liberar(context.Context) error                         { return nil }
+
+type autenticadorFijo struct{}
+
+func (autenticadorFijo) AutenticarInternamente() (string, error) { return "TICKET_1", nil }
+func (autenticadorFijo) Invalidar()                              {}
+
+// reloj es una fuente de tiempo controlada por la prueba.
+type reloj struct{ ahora time.Time }
+
+func (r *reloj) Ahora() time.Time { return r.ahora }
+
+// nuevoProgramador arma un programador con intervalo de una hora y el reloj de la prueba.
+func nuevoProgramador(indice documento.IndiceVigencia, alfresco documento.ActualizadorVigencia, bloqueo documento.BloqueoLider, r *reloj) *documento.ProgramadorVigencia {
+    programador := documento.NuevoProgramadorVigencia(indice, alfresco, bloqueo, autenticadorFijo{}, time.Hour, logger.NuevoRegistrador("TEST", "|"))
+    programador.EstablecerReloj(r.Ahora)
+    return programador
+}
+
+// TestProgramadorVigenciaMarcaVencidos verifica que solo la réplica líder procesa y que solo se marcan los
+// documentos cuya fecha de término ya pasó.
+func TestProgramadorVigenciaMarcaVencidos(t *testing.T) {
+    r := &reloj{ahora: time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)}
+    nuevo := func() *indiceVigenciaMemoria {
+        return nuevoIndice(
+            documento.DocumentoVencido{ID: "vencido", FechaTerminoVigencia: "2026-05-01"},
+            documento.DocumentoVencido{ID: "vencido-hora", FechaTerminoVigencia: "2026-05-10T11:00:00Z"},
+            documento.DocumentoVencido{ID: "vence-hoy", FechaTerminoVigencia: "2026-05-10"},
+            documento.DocumentoVencido{ID: "futuro", FechaTerminoVigencia: "2026-06-01"},
+        )
+    }
+
+    casos := []struct {
+        nombre   string
+        bloqueo  bloqueoFijo
+        marcados []string
+    }{
+        {"líder", bloqueoFijo{lider: true}, []string{"vencido", "vencido-hora"}},
+        {"otra réplica es líder", bloqueoFijo{lider: false}, nil},
+        {"bloqueo no disponible", bloqueoFijo{err: errors.New("mongo no disponible")}, nil},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            indice, alfresco := nuevo(), &alfrescoVigencia{}
+            procesados := nuevoProgramador(indice, alfresco, caso.bloqueo, r).Ejecutar(context.Background())
+
+            assert.Equal(t, len(caso.marcados), procesados)
+            assert.ElementsMatch(t, caso.marcados, alfresco.actualizados)
+            for _, id := range caso.marcados {
+                assert.Equal(t, documentos.EstadoNoVigente, indice.registro(id).estado)
+            }
+            assert.Equal(t, documentos.EstadoVigente, indice.registro("vence-hoy").estado)
+            assert.Equal(t, documentos.EstadoVigente, indice.registro("futuro").estado)
+        })
+    }
+}
+
+// TestProgramadorVigenciaFallosNoBloquean verifica que un lote completo de documentos que fallan siempre no
+// impide procesar los siguientes, y que sus reintentos se espacian cada vez más.
+func TestProgramadorVigenciaFallosNoBloquean(t *testing.T) {
+    r := &reloj{ahora: time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)}
+    alfresco := &alfrescoVigencia{fallar: map[string]bool{}}
+    var docs []documento.DocumentoVencido
+    for i := 0; i < 100; i++ {
+        id := fmt.Sprintf("bloqueado-%03d", i)
+        alfresco.fallar[id] = true
+        docs = append(docs, documento.DocumentoVencido{ID: id, FechaTerminoVigencia: "2026-01-01"})
+    }
+    docs = append(docs, documento.DocumentoVencido{ID: "ilegible", FechaTerminoVigencia: "01/02/2026"})
+    docs = append(docs, documento.DocumentoVencido{ID: "normal", FechaTerminoVigencia: "2026-05-01"})
+    indice := nuevoIndice(docs...)
+    programador := nuevoProgramador(indice, alfresco, bloqueoFijo{lider: true}, r)
+
+    // 1. El primer lote son los 100 que fallan: se registran con un reintento en un intervalo
+    assert.Equal(t, 0, programador.Ejecutar(context.Background()))
+    fallo := indice.registro("bloqueado-000").fallo
+    if assert.NotNil(t, fallo) {
+        assert.Equal(t, 1, fallo.Intentos)
+        assert.Equal(t, r.ahora.Add(time.Hour), fallo.ProximoIntento)
+        assert.Contains(t, fallo.Error, "documento bloqueado")
+    }
+
+    // 2. La ejecución siguiente ya no los ve y procesa el resto; la fecha ilegible también queda en espera
+    r.ahora = r.ahora.Add(time.Minute)
+    assert.Equal(t, 1, programador.Ejecutar(context.Background()))
+    assert.Equal(t, documentos.EstadoNoVigente, indice.registro("normal").estado)
+    if fallo := indice.registro("ilegible").fallo; assert.NotNil(t, fallo) {
+        assert.Equal(t, "fecha de término de vigencia no reconocida", fallo.Error)
+    }
+
+    // 3. Cumplida la espera se reintentan, y un nuevo fallo duplica la espera
+    r.ahora = r.ahora.Add(time.Hour)
+    assert.Equal(t, 0, programador.Ejecutar(context.Background()))
+    fallo = indice.registro("bloqueado-000").fallo
+    if assert.NotNil(t, fallo) {
+        assert.Equal(t, 2, fallo.Intentos)
+        assert.Equal(t, r.ahora.Add(2*time.Hour), fallo.ProximoIntento)
+    }
+
+    // 4. La espera se acota a 24 horas
+    for i := 0; i < 8; i++ {
+        r.ahora = indice.registro("bloqueado-000").fallo.ProximoIntento
+        programador.Ejecutar(context.Background())
+    }
+    fallo = indice.registro("bloqueado-000").fallo
+    assert.Equal(t, 10, fallo.Intentos)
+    assert.Equal(t, r.ahora.Add(24*time.Hour), fallo.ProximoIntento)
+
+    // 5. Cuando Alfresco lo acepta, el documento se marca y su fallo se descarta
+    delete(alfresco.fallar, "bloqueado-000")
+    r.ahora = fallo.ProximoIntento
+    assert.Equal(t, 1, programador.Ejecutar(context.Background()))
+    assert.Equal(t, documentos.EstadoNoVigente, indice.registro("bloqueado-000").estado)
+    assert.Nil(t, indice.registro("bloqueado-000").fallo)
+}
+
+// bloqueoPerdido concede el bloqueo las primeras veces indicadas y luego lo pierde, como cuando expira y
+// otra réplica lo toma a mitad de un lote.
+type bloqueoPerdido struct {
+    concedidos int
+    ttls       []time.Duration
+}
+
+func (b *bloqueoPerdido) Adquirir(_ context.Context, ttl time.Duration) (bool, error) {
+    b.ttls = append(b.ttls, ttl)
+    return len(b.ttls) <= b.concedidos, nil
+}
+func (b *bloqueoPerdido) Liberar(context.Context) error { return nil }
+
+// TestProgramadorVigenciaRenuevaBloqueo verifica que el bloqueo se renueva antes de cada documento del lote
+// y que el lote se detiene si otra réplica lo tomó.
+func TestProgramadorVigenciaRenuevaBloqueo(t *testing.T) {
+    r := &reloj{ahora: time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)}
+    indice := nuevoIndice(
+        documento.DocumentoVencido{ID: "primero", FechaTerminoVigencia: "2026-05-01"},
+        documento.DocumentoVencido{ID: "segundo", FechaTerminoVigencia: "2026-05-02"},
+        documento.DocumentoVencido{ID: "tercero", FechaTerminoVigencia: "2026-05-03"},
+    )
+    alfresco := &alfrescoVigencia{fallar: map[string]bool{}}
+    bloqueo := &bloqueoPerdido{concedidos: 2}
+
+    // La adquisición inicial y la renovación antes del segundo documento se conceden; la del tercero no
+    assert.Equal(t, 2, nuevoProgramador(indice, alfresco, bloqueo, r).Ejecutar(context.Background()))
+    assert.Equal(t, []string{"primero", "segundo"}, alfresco.actualizados)
+    assert.Equal(t, []time.Duration{2 * time.Hour, 2 * time.Hour, 2 * time.Hour}, bloqueo.ttls)
+    assert.Nil(t, indice.registro("tercero").fallo)
+}
+
+// alfrescoTicketRechazado rechaza el primer ticket emitido y acepta los siguientes.
+type alfrescoTicketRechazado struct {
+    tickets []string
+}
+
+func (a *alfrescoTicketRechazado) ActualizarEstadoVigencia(_ context.Context, solicitud documento.SolicitudEstadoVigencia) error {
+    a.tickets = append(a.tickets, solicitud.Ticket)
+    if solicitud.Ticket == "TICKET_1" {
+        return fmt.Errorf("error al actualizar: %w", documentos.ErrTicketInvalido)
+    }
+    return nil
+}
+
+// autenticadorRenovable emite un ticket nuevo tras cada invalidación.
+type autenticadorRenovable struct {
+    emitidos       int
+    invalidado     bool
+    invalidaciones int
+}
+
+func (a *autenticadorRenovable) AutenticarInternamente() (string, error) {
+    if a.emitidos == 0 || a.invalidado {
+        a.emitidos++
+        a.invalidado = false
+    }
+    return fmt.Sprintf("TICKET_%d", a.emitidos), nil
+}
+func (a *autenticadorRenovable) Invalidar() { a.invalidado = true; a.invalidaciones++ }
+
+// TestProgramadorVigenciaRenuevaTicketRechazado verifica que un ticket rechazado se renueva una vez y el lote
+// continúa con el ticket nuevo.
+func TestProgramadorVigenciaRenuevaTicketRechazado(t *testing.T) {
+    r := &reloj{ahora: time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)}
+    indice := nuevoIndice(
+        documento.DocumentoVencido{ID: "primero", FechaTerminoVigencia: "2026-05-01"},
+        documento.DocumentoVencido{ID: "segundo", FechaTerminoVigencia: "2026-05-02"},
+    )
+    alfresco := &alfrescoTicketRechazado{}
+    autenticador := &autenticadorRenovable{}
+    programador := documento.NuevoProgramadorVigencia(indice, alfresco, bloqueoFijo{lider: true}, autenticador, time.Hour, logger.NuevoRegistrador("TEST", "|"))
+    programador.EstablecerReloj(r.Ahora)
+
+    assert.Equal(t, 2, programador.Ejecutar(context.Background()))
+    assert.Equal(t, []string{"TICKET_1", "TICKET_2", "TICKET_2"}, alfresco.tickets)
+    assert.Equal(t, 1, autenticador.invalidaciones)
+    assert.Nil(t, indice.registro("primero").fallo)
+}
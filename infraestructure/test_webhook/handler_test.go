@@ -0,0 +1,98 @@
+package test_webhook
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/api/handlers"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// claveAdmin es la ADMIN_API_KEY de las pruebas del manejador.
+const claveAdmin = "clave-admin"
+
+// routerWebhooks arma las rutas de suscripciones sobre repositorios en memoria.
+func routerWebhooks() *gin.Engine {
+    servicio := notificacion.NuevoServicioSuscripciones(&suscripcionesMemoria{}, &entregasMemoria{}, []int{30})
+    manejador := handlers.NuevoManejadorWebhooks(servicio, logger.NuevoRegistrador("TEST", "|"))
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareIdentidad(&config.Config{AdminApiKey: claveAdmin}))
+    router.POST("/webhooks/suscripciones", manejador.ManejadorCrearSuscripcion)
+    router.GET("/webhooks/suscripciones", manejador.ManejadorListarSuscripciones)
+    router.DELETE("/webhooks/suscripciones/:id", manejador.ManejadorEliminarSuscripcion)
+    router.GET("/webhooks/suscripciones/:id/entregas", manejador.ManejadorListarEntregas)
+    return router
+}
+
+// solicitar ejecuta una solicitud con la API Key indicada y retorna la respuesta.
+func solicitar(router *gin.Engine, metodo, ruta, clave, cuerpo string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(metodo, ruta, strings.NewReader(cuerpo))
+    req.Header.Set("Content-Type", "application/json")
+    if clave != "" {
+        req.Header.Set("ADFTannerServices", clave)
+    }
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+    return w
+}
+
+// TestManejadorWebhooksProblemas verifica que los errores de la gestión de suscripciones se responden como
+// application/problem+json con su código estable.
+func TestManejadorWebhooksProblemas(t *testing.T) {
+    router := routerWebhooks()
+
+    casos := []struct {
+        nombre string
+        metodo string
+        ruta   string
+        clave  string
+        cuerpo string
+        estado int
+        codigo string
+    }{
+        {"sin rol administrador", "POST", "/webhooks/suscripciones", "", `{"url":"https://receptor.cl/avisos"}`, http.StatusForbidden, handlers.CodigoRolInsuficiente},
+        {"JSON mal formado", "POST", "/webhooks/suscripciones", claveAdmin, `{"url":`, http.StatusBadRequest, handlers.CodigoSolicitudInvalida},
+        {"URL inválida", "POST", "/webhooks/suscripciones", claveAdmin, `{"url":"ftp://receptor.cl"}`, http.StatusBadRequest, handlers.CodigoSuscripcionInvalida},
+        {"umbral inválido", "POST", "/webhooks/suscripciones", claveAdmin, `{"url":"https://receptor.cl","dias_antes":[0]}`, http.StatusBadRequest, handlers.CodigoSuscripcionInvalida},
+        {"listar sin rol", "GET", "/webhooks/suscripciones", "", "", http.StatusForbidden, handlers.CodigoRolInsuficiente},
+        {"entregas sin rol", "GET", "/webhooks/suscripciones/sub-1/entregas", "", "", http.StatusForbidden, handlers.CodigoRolInsuficiente},
+        {"entregas sobre el límite", "GET", "/webhooks/suscripciones/sub-1/entregas?limite=501", claveAdmin, "", http.StatusBadRequest, handlers.CodigoSolicitudInvalida},
+        {"entregas con límite inválido", "GET", "/webhooks/suscripciones/sub-1/entregas?limite=-1", claveAdmin, "", http.StatusBadRequest, handlers.CodigoSolicitudInvalida},
+        {"eliminar sin rol", "DELETE", "/webhooks/suscripciones/sub-1", "", "", http.StatusForbidden, handlers.CodigoRolInsuficiente},
+        {"eliminar inexistente", "DELETE", "/webhooks/suscripciones/no-existe", claveAdmin, "", http.StatusNotFound, handlers.CodigoSuscripcionNoEncontrada},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            w := solicitar(router, caso.metodo, caso.ruta, caso.clave, caso.cuerpo)
+            assert.Equal(t, caso.estado, w.Code)
+            assert.Equal(t, handlers.TipoContenidoProblema, w.Header().Get("Content-Type"))
+            var problema handlers.Problema
+            assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problema))
+            assert.Equal(t, caso.codigo, problema.Codigo)
+            assert.Equal(t, caso.estado, problema.Estado)
+        })
+    }
+
+    // La suscripción válida se crea y luego puede eliminarse
+    creada := solicitar(router, "POST", "/webhooks/suscripciones", claveAdmin, `{"url":"https://receptor.cl/avisos"}`)
+    assert.Equal(t, http.StatusCreated, creada.Code)
+    var suscripcion notificacion.Suscripcion
+    assert.NoError(t, json.Unmarshal(creada.Body.Bytes(), &suscripcion))
+    listado := solicitar(router, "GET", "/webhooks/suscripciones", claveAdmin, "")
+    assert.Equal(t, http.StatusOK, listado.Code)
+    var suscripciones handlers.RespuestaSuscripciones
+    assert.NoError(t, json.Unmarshal(listado.Body.Bytes(), &suscripciones))
+    assert.Equal(t, 1, suscripciones.Meta.Total)
+    assert.Equal(t, suscripcion.ID, suscripciones.Data[0].ID)
+    assert.Equal(t, http.StatusOK, solicitar(router, "GET", "/webhooks/suscripciones/"+suscripcion.ID+"/entregas?limite=500", claveAdmin, "").Code)
+    assert.Equal(t, http.StatusNoContent, solicitar(router, "DELETE", "/webhooks/suscripciones/"+suscripcion.ID, claveAdmin, "").Code)
+}
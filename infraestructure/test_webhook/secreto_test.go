@@ -0,0 +1,78 @@
+package test_webhook
+
+import (
+    "bytes"
+    "context"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/stretchr/testify/assert"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// cfgMongo es la configuración de colecciones usada con el cliente simulado.
+var cfgMongo = &config.Config{MongoDatabase: "regapi", MongoCollectionWebhooks: "webhooks"}
+
+// nuevoCifrador crea un cifrador con las claves k1 y k2 y la activa indicada.
+func nuevoCifrador(t *testing.T, activa string) *cifrado.Cifrador {
+    claves := map[string][]byte{"k1": bytes.Repeat([]byte{1}, cifrado.TamanoClave), "k2": bytes.Repeat([]byte{2}, cifrado.TamanoClave)}
+    cifrador, err := cifrado.NuevoCifrador(claves, activa, bytes.Repeat([]byte{9}, cifrado.TamanoClave))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return cifrador
+}
+
+// TestSecretoSuscripcionCifrado verifica que el secreto HMAC se guarda cifrado y se descifra al listar.
+func TestSecretoSuscripcionCifrado(t *testing.T) {
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+    mt.Run("crear guarda el secreto cifrado", func(mt *mtest.T) {
+        repositorio := mongo.NuevoRepositorioSuscripciones(mt.Client, cfgMongo)
+        repositorio.EstablecerCifrador(nuevoCifrador(t, "k2"))
+        mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+        err := repositorio.Crear(context.Background(), notificacion.Suscripcion{ID: "sub-1", URL: "https://receptor", Secreto: "clave-hmac", Activa: true})
+        assert.NoError(t, err)
+
+        var enviado struct {
+            Documentos []notificacion.Suscripcion `bson:"documents"`
+        }
+        assert.NoError(t, bson.Unmarshal(mt.GetStartedEvent().Command, &enviado))
+        if assert.Len(t, enviado.Documentos, 1) {
+            assert.True(t, strings.HasPrefix(enviado.Documentos[0].Secreto, "enc:v1:k2:"))
+            assert.NotContains(t, enviado.Documentos[0].Secreto, "clave-hmac")
+        }
+    })
+
+    mt.Run("listar descifra el secreto", func(mt *mtest.T) {
+        cifrador := nuevoCifrador(t, "k1")
+        secreto, err := cifrador.Cifrar("secreto_webhook", "clave-hmac")
+        assert.NoError(t, err)
+        repositorio := mongo.NuevoRepositorioSuscripciones(mt.Client, cfgMongo)
+        repositorio.EstablecerCifrador(nuevoCifrador(t, "k2")) // k1 sigue disponible para leer
+        mt.AddMockResponses(mtest.CreateCursorResponse(0, "regapi.webhooks", mtest.FirstBatch,
+            bson.D{{Key: "_id", Value: "sub-1"}, {Key: "secreto", Value: secreto}, {Key: "activa", Value: true}}))
+
+        suscripciones, err := repositorio.ListarActivas(context.Background())
+        assert.NoError(t, err)
+        if assert.Len(t, suscripciones, 1) {
+            assert.Equal(t, "clave-hmac", suscripciones[0].Secreto)
+        }
+    })
+
+    mt.Run("secreto cifrado sin cifrador", func(mt *mtest.T) {
+        secreto, err := nuevoCifrador(t, "k1").Cifrar("secreto_webhook", "clave-hmac")
+        assert.NoError(t, err)
+        repositorio := mongo.NuevoRepositorioSuscripciones(mt.Client, cfgMongo)
+        mt.AddMockResponses(mtest.CreateCursorResponse(0, "regapi.webhooks", mtest.FirstBatch,
+            bson.D{{Key: "_id", Value: "sub-1"}, {Key: "secreto", Value: secreto}, {Key: "activa", Value: true}}))
+
+        _, err = repositorio.ListarActivas(context.Background())
+        assert.Error(t, err)
+    })
+}
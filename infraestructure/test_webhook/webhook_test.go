@@ -0,0 +1,249 @@
+package test_webhook
+
+import (
+    "context"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/webhook"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// receptorPrueba es un receptor HTTP local que valida firmas y responde con una secuencia de códigos.
+type receptorPrueba struct {
+    mu       sync.Mutex
+    secreto  string
+    codigos  []int    // Códigos a responder en orden; el último se repite
+    cuerpos  [][]byte // Cuerpos recibidos con firma válida
+    entregas []string // Header X-Webhook-Entrega de cada intento
+    firmasInvalidas int
+}
+
+func (r *receptorPrueba) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    cuerpo, _ := io.ReadAll(req.Body)
+    firma := webhook.FirmarPayload(r.secreto, req.Header.Get(webhook.HeaderTimestamp), cuerpo)
+    if firma != req.Header.Get(webhook.HeaderFirma) {
+        r.firmasInvalidas++
+        w.WriteHeader(http.StatusUnauthorized)
+        return
+    }
+    r.cuerpos = append(r.cuerpos, cuerpo)
+    r.entregas = append(r.entregas, req.Header.Get(webhook.HeaderEntrega))
+
+    codigo := r.codigos[0]
+    if len(r.codigos) > 1 {
+        r.codigos = r.codigos[1:]
+    }
+    w.WriteHeader(codigo)
+}
+
+// TestClienteWebhookReintentaHastaExito - Verifica la firma HMAC y el reintento ante un 503.
+func TestClienteWebhookReintentaHastaExito(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    receptor := &receptorPrueba{secreto: "s3cr3t0", codigos: []int{http.StatusServiceUnavailable, http.StatusOK}}
+    servidor := httptest.NewServer(receptor)
+    defer servidor.Close()
+
+    cliente := webhook.NuevoClienteWebhook(3, time.Millisecond, log)
+    resultado := cliente.Enviar(context.Background(), servidor.URL, "s3cr3t0", "entrega-1", []byte(`{"ok":true}`))
+
+    assert.NoError(t, resultado.Error)
+    assert.Equal(t, 2, resultado.Intentos)
+    assert.Equal(t, http.StatusOK, resultado.CodigoHTTP)
+    assert.Equal(t, 0, receptor.firmasInvalidas)
+    assert.Equal(t, []string{"entrega-1", "entrega-1"}, receptor.entregas)
+}
+
+// TestClienteWebhookNoReintentaRechazo - Un 4xx distinto de 429 no se reintenta.
+func TestClienteWebhookNoReintentaRechazo(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    receptor := &receptorPrueba{secreto: "s3cr3t0", codigos: []int{http.StatusBadRequest}}
+    servidor := httptest.NewServer(receptor)
+    defer servidor.Close()
+
+    cliente := webhook.NuevoClienteWebhook(3, time.Millisecond, log)
+    resultado := cliente.Enviar(context.Background(), servidor.URL, "s3cr3t0", "entrega-2", []byte(`{}`))
+
+    assert.Error(t, resultado.Error)
+    assert.Equal(t, 1, resultado.Intentos)
+    assert.Equal(t, http.StatusBadRequest, resultado.CodigoHTTP)
+}
+
+// TestNotificadorEnviaUnaVezPorUmbral - Un documento que vence en 10 días se notifica una sola vez con el umbral de 15 días.
+func TestNotificadorEnviaUnaVezPorUmbral(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    receptor := &receptorPrueba{secreto: "clave", codigos: []int{http.StatusOK}}
+    servidor := httptest.NewServer(receptor)
+    defer servidor.Close()
+
+    ahora := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+    suscripciones := &suscripcionesMemoria{lista: []notificacion.Suscripcion{{
+        ID: "sub-1", URL: servidor.URL, Secreto: "clave", DiasAntes: []int{30, 15, 7}, Activa: true,
+    }}}
+    entregas := &entregasMemoria{}
+    indice := indiceMemoria{documentos: []notificacion.DocumentoPorVencer{
+        {ID: "doc-poder", RUTCliente: "11111111-1", TipoDocumento: "Poder", FechaTerminoVigencia: "2025-03-10"},
+        {ID: "doc-lejano", RUTCliente: "22222222-2", TipoDocumento: "Balance", FechaTerminoVigencia: "2025-06-01"},
+    }}
+
+    notificador := notificacion.NuevoNotificadorVencimientos(
+        suscripciones, entregas, indice,
+        webhook.NuevoClienteWebhook(0, time.Millisecond, log),
+        bloqueoSiempreLider{}, time.Hour, log,
+    )
+
+    // Primera ejecución: un aviso para doc-poder
+    assert.Equal(t, 1, notificador.Ejecutar(context.Background(), ahora))
+    // Segunda ejecución: el log de entregas evita duplicados
+    assert.Equal(t, 0, notificador.Ejecutar(context.Background(), ahora.Add(time.Hour)))
+
+    if assert.Len(t, receptor.cuerpos, 1) {
+        var payload notificacion.PayloadPorVencer
+        assert.NoError(t, json.Unmarshal(receptor.cuerpos[0], &payload))
+        assert.Equal(t, notificacion.TipoEventoPorVencer, payload.Tipo)
+        assert.Equal(t, "doc-poder", payload.Documento.ID)
+        assert.Equal(t, 15, payload.UmbralDias)
+        assert.Equal(t, 10, payload.DiasRestantes)
+    }
+    if assert.Len(t, entregas.lista, 1) {
+        assert.Equal(t, notificacion.EstadoEntregado, entregas.lista[0].Estado)
+    }
+}
+
+// notificadorFallido arma un notificador con un documento que vence en 10 días y un receptor que responde los
+// códigos indicados, sin reintentos HTTP dentro de cada envío.
+func notificadorFallido(t *testing.T, codigos []int) (*notificacion.NotificadorVencimientos, *entregasMemoria, *receptorPrueba) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    receptor := &receptorPrueba{secreto: "clave", codigos: codigos}
+    servidor := httptest.NewServer(receptor)
+    t.Cleanup(servidor.Close)
+
+    suscripciones := &suscripcionesMemoria{lista: []notificacion.Suscripcion{{
+        ID: "sub-1", URL: servidor.URL, Secreto: "clave", DiasAntes: []int{15}, Activa: true,
+    }}}
+    entregas := &entregasMemoria{}
+    indice := indiceMemoria{documentos: []notificacion.DocumentoPorVencer{
+        {ID: "doc-poder", RUTCliente: "11111111-1", TipoDocumento: "Poder", FechaTerminoVigencia: "2025-03-10"},
+    }}
+    notificador := notificacion.NuevoNotificadorVencimientos(
+        suscripciones, entregas, indice,
+        webhook.NuevoClienteWebhook(0, time.Millisecond, log),
+        bloqueoSiempreLider{}, time.Hour, log,
+    )
+    return notificador, entregas, receptor
+}
+
+// TestNotificadorReenviaConEsperaCreciente - Un aviso fallido se reenvía tras esperas que se duplican y se abandona
+// al agotar los envíos.
+func TestNotificadorReenviaConEsperaCreciente(t *testing.T) {
+    notificador, entregas, receptor := notificadorFallido(t, []int{http.StatusServiceUnavailable})
+    notificador.EstablecerEnviosMaximos(3)
+    ahora := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+    // Primer envío: falla y programa el siguiente tras un intervalo
+    assert.Equal(t, 0, notificador.Ejecutar(context.Background(), ahora))
+    if assert.Len(t, entregas.lista, 1) {
+        assert.Equal(t, notificacion.EstadoFallido, entregas.lista[0].Estado)
+        assert.Equal(t, 1, entregas.lista[0].Envio)
+        assert.Equal(t, ahora.Add(time.Hour), *entregas.lista[0].ProximoEnvio)
+    }
+
+    // Antes de la espera no se reenvía
+    notificador.Ejecutar(context.Background(), ahora.Add(30*time.Minute))
+    assert.Len(t, entregas.lista, 1)
+
+    // Segundo envío: la espera siguiente se duplica
+    notificador.Ejecutar(context.Background(), ahora.Add(time.Hour))
+    if assert.Len(t, entregas.lista, 2) {
+        assert.Equal(t, 2, entregas.lista[1].Envio)
+        assert.Equal(t, ahora.Add(3*time.Hour), *entregas.lista[1].ProximoEnvio)
+    }
+
+    // Tercer envío: es el último, no programa otro
+    notificador.Ejecutar(context.Background(), ahora.Add(3*time.Hour))
+    if assert.Len(t, entregas.lista, 3) {
+        assert.Equal(t, 3, entregas.lista[2].Envio)
+        assert.Nil(t, entregas.lista[2].ProximoEnvio)
+    }
+
+    // El aviso abandonado no vuelve a enviarse
+    notificador.Ejecutar(context.Background(), ahora.Add(48*time.Hour))
+    assert.Len(t, entregas.lista, 3)
+    assert.Len(t, receptor.cuerpos, 3)
+}
+
+// TestNotificadorReenvioEntregado - Un reenvío exitoso cierra el aviso.
+func TestNotificadorReenvioEntregado(t *testing.T) {
+    notificador, entregas, receptor := notificadorFallido(t, []int{http.StatusInternalServerError, http.StatusOK})
+    ahora := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+    assert.Equal(t, 0, notificador.Ejecutar(context.Background(), ahora))
+    assert.Equal(t, 1, notificador.Ejecutar(context.Background(), ahora.Add(time.Hour)))
+    assert.Equal(t, 0, notificador.Ejecutar(context.Background(), ahora.Add(48*time.Hour)))
+
+    if assert.Len(t, entregas.lista, 2) {
+        assert.Equal(t, notificacion.EstadoEntregado, entregas.lista[1].Estado)
+        assert.Equal(t, 2, entregas.lista[1].Envio)
+    }
+    assert.Len(t, receptor.cuerpos, 2)
+}
+
+// Dobles de prueba en memoria
+
+type suscripcionesMemoria struct{ lista []notificacion.Suscripcion }
+
+func (s *suscripcionesMemoria) Crear(_ context.Context, sub notificacion.Suscripcion) error {
+    s.lista = append(s.lista, sub)
+    return nil
+}
+func (s *suscripcionesMemoria) Listar(context.Context) ([]notificacion.Suscripcion, error) { return s.lista, nil }
+func (s *suscripcionesMemoria) ListarActivas(context.Context) ([]notificacion.Suscripcion, error) {
+    return s.lista, nil
+}
+func (s *suscripcionesMemoria) Eliminar(_ context.Context, id string) error {
+    for i, sub := range s.lista {
+        if sub.ID == id {
+            s.lista = append(s.lista[:i], s.lista[i+1:]...)
+            return nil
+        }
+    }
+    return notificacion.ErrSuscripcionNoEncontrada
+}
+
+type entregasMemoria struct{ lista []notificacion.Entrega }
+
+func (e *entregasMemoria) Registrar(_ context.Context, entrega notificacion.Entrega) error {
+    e.lista = append(e.lista, entrega)
+    return nil
+}
+func (e *entregasMemoria) Ultima(_ context.Context, sub, doc string, umbral int) (*notificacion.Entrega, error) {
+    for i := len(e.lista) - 1; i >= 0; i-- {
+        if entrega := e.lista[i]; entrega.SuscripcionID == sub && entrega.DocumentoID == doc && entrega.UmbralDias == umbral {
+            return &entrega, nil
+        }
+    }
+    return nil, nil
+}
+func (e *entregasMemoria) Listar(context.Context, string, int) ([]notificacion.Entrega, error) {
+    return e.lista, nil
+}
+
+type indiceMemoria struct{ documentos []notificacion.DocumentoPorVencer }
+
+func (i indiceMemoria) BuscarPorVencer(context.Context, time.Time, time.Time) ([]notificacion.DocumentoPorVencer, error) {
+    return i.documentos, nil
+}
+
+type bloqueoSiempreLider struct{}
+
+func (bloqueoSiempreLider) Adquirir(context.Context, time.Duration) (bool, error) { return true, nil }
+func (bloqueoSiempreLider) Liberar(context.Context) error                         { return nil }
@@ -0,0 +1,154 @@
+package webhook
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "math/rand"
+    "net/http"
+    "strconv"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+)
+
+// Headers enviados en cada webhook.
+const (
+    HeaderFirma     = "X-Webhook-Firma"     // "sha256=<hex>" del HMAC de "<timestamp>.<cuerpo>"
+    HeaderTimestamp = "X-Webhook-Timestamp" // Segundos Unix usados en la firma
+    HeaderEntrega   = "X-Webhook-Entrega"   // Identificador de la entrega, estable entre reintentos
+)
+
+// maxCuerpoDescartado acota lo que se lee de la respuesta del receptor antes de cerrarla: basta para reutilizar la
+// conexión en respuestas normales sin que un receptor lento o malicioso retenga al notificador con un cuerpo enorme.
+const maxCuerpoDescartado = 64 << 10
+
+// ClienteWebhook envía payloads firmados con HMAC-SHA256 y reintenta con espera exponencial.
+type ClienteWebhook struct {
+    clienteHTTP *http.Client        // Cliente HTTP con timeout
+    reintentos  int                 // Reintentos después del primer intento
+    esperaBase  time.Duration       // Espera antes del primer reintento; se duplica en cada intento
+    log         *logger.Registrador // Logger para registrar eventos y errores
+}
+
+// NuevoClienteWebhook crea un cliente de webhooks.
+// Parámetros:
+//   - reintentos: Reintentos después del primer intento fallido.
+//   - esperaBase: Espera inicial entre intentos.
+//   - log: Logger para registrar eventos y errores.
+func NuevoClienteWebhook(reintentos int, esperaBase time.Duration, log *logger.Registrador) *ClienteWebhook {
+    return &ClienteWebhook{
+        clienteHTTP: &http.Client{Timeout: 10 * time.Second},
+        reintentos:  reintentos,
+        esperaBase:  esperaBase,
+        log:         log,
+    }
+}
+
+// FirmarPayload calcula la firma HMAC-SHA256 de un payload.
+// Los receptores deben recalcularla con su secreto y compararla con el header X-Webhook-Firma.
+func FirmarPayload(secreto, timestamp string, cuerpo []byte) string {
+    mac := hmac.New(sha256.New, []byte(secreto))
+    mac.Write([]byte(timestamp))
+    mac.Write([]byte("."))
+    mac.Write(cuerpo)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enviar entrega el payload al receptor, reintentando ante errores de red, respuestas 5xx y 429.
+// Parámetros:
+//   - ctx: Contexto para cancelar la entrega y sus esperas.
+//   - url: URL del receptor.
+//   - secreto: Clave HMAC de la suscripción.
+//   - idEntrega: Identificador de la entrega enviado en cada intento.
+//   - cuerpo: Payload JSON.
+func (c *ClienteWebhook) Enviar(ctx context.Context, url, secreto, idEntrega string, cuerpo []byte) notificacion.ResultadoEnvio {
+    var resultado notificacion.ResultadoEnvio
+
+    for intento := 0; intento <= c.reintentos; intento++ {
+        // 1. Esperar antes de cada reintento
+        if intento > 0 {
+            if err := esperar(ctx, c.espera(intento)); err != nil {
+                resultado.Error = err
+                return resultado
+            }
+        }
+        resultado.Intentos = intento + 1
+
+        // 2. Enviar un intento
+        codigo, reintentable, err := c.enviarIntento(ctx, url, secreto, idEntrega, cuerpo)
+        resultado.CodigoHTTP = codigo
+        resultado.Error = err
+        if err == nil || !reintentable {
+            return resultado
+        }
+
+        c.log.Warn("Intento de webhook fallido", map[string]interface{}{
+            "url":      url,
+            "entrega":  idEntrega,
+            "intento":  resultado.Intentos,
+            "error":    err.Error(),
+        })
+    }
+    return resultado
+}
+
+// enviarIntento realiza una única solicitud firmada.
+// Retorna el código HTTP, si el fallo admite reintento y el error, si lo hubo.
+func (c *ClienteWebhook) enviarIntento(ctx context.Context, url, secreto, idEntrega string, cuerpo []byte) (int, bool, error) {
+    // 1. Crear solicitud HTTP
+    req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(cuerpo))
+    if err != nil {
+        return 0, false, fmt.Errorf("error al crear solicitud: %v", err)
+    }
+
+    // 2. Firmar el payload
+    timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(HeaderTimestamp, timestamp)
+    req.Header.Set(HeaderEntrega, idEntrega)
+    req.Header.Set(HeaderFirma, FirmarPayload(secreto, timestamp, cuerpo))
+
+    // 3. Enviar solicitud
+    resp, err := c.clienteHTTP.Do(req)
+    if err != nil {
+        return 0, ctx.Err() == nil, fmt.Errorf("error de comunicación: %v", err)
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, io.LimitReader(resp.Body, maxCuerpoDescartado))
+
+    // 4. Clasificar la respuesta
+    switch {
+    case resp.StatusCode >= 200 && resp.StatusCode < 300:
+        return resp.StatusCode, false, nil
+    case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+        return resp.StatusCode, true, fmt.Errorf("receptor respondió %s", resp.Status)
+    default:
+        return resp.StatusCode, false, fmt.Errorf("receptor rechazó el webhook: %s", resp.Status)
+    }
+}
+
+// espera calcula la espera exponencial con jitter para el intento indicado.
+func (c *ClienteWebhook) espera(intento int) time.Duration {
+    base := c.esperaBase << (intento - 1)
+    if base <= 0 {
+        return 0
+    }
+    return base/2 + time.Duration(rand.Int63n(int64(base/2)+1))
+}
+
+// esperar bloquea durante la duración indicada o hasta que el contexto se cancele.
+func esperar(ctx context.Context, duracion time.Duration) error {
+    temporizador := time.NewTimer(duracion)
+    defer temporizador.Stop()
+    select {
+    case <-ctx.Done():
+        return ctx.Err()
+    case <-temporizador.C:
+        return nil
+    }
+}
@@ -1,71 +1,152 @@
 package main
 
 import (
-    "github.com/CamiloScript/REGAPIGO/shared/middleware"
-    "github.com/CamiloScript/REGAPIGO/infraestructure/routes"
+    "context"
+    "errors"
+    "flag"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/shared/secretos"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
     "github.com/CamiloScript/REGAPIGO/shared/config"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/trazas"
     "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
-    "github.com/gin-gonic/gin"
-    ginSwagger "github.com/swaggo/gin-swagger"
-    swaggerFiles "github.com/swaggo/files"
-    "github.com/gin-contrib/cors"
+    "github.com/google/uuid"
+    mongodriver "go.mongodb.org/mongo-driver/mongo"
 )
 
-
-
 func main() {
-    // 1. Cargar configuración desde variables de entorno
-    cfg := config.CargarConfiguracion()
+    // 1. Cargar configuración: valores por defecto, archivo (--config o REGAPI_CONFIG) y variables REGAPI_*
+    rutaConfiguracion := flag.String("config", "", "Archivo de configuración JSON (por defecto REGAPI_CONFIG o "+config.RutaPorDefecto+")")
+    recifrar := flag.Bool("recifrar", false, "Vuelve a cifrar los datos sensibles del índice, la auditoría y el outbox con CIFRADO_CLAVE_ACTIVA y termina")
+    flag.Parse()
+    proveedorSecretos := secretos.ResolutorPorDefecto()
+    cfg := cargarConfiguracion(*rutaConfiguracion, proveedorSecretos)
 
-    // 2. Validar variables críticas antes de continuar
-    if cfg.MongoURI == "" {
-        panic("Falta configurar MONGODB_URI en el archivo .env")
+    // 2. Inicializar logger con el formato, nivel, archivo y reglas de redacción configurados
+    // (los secretos de la configuración se muestran enmascarados)
+    log, err := nuevoRegistrador(cfg)
+    if err != nil {
+        logger.NuevoRegistrador("API_DOC", "|").Fatal("Configuración de logs inválida", map[string]interface{}{"error": err.Error()})
     }
-
-    // 3. Inicializar logger con formato unificado
-    log := logger.NuevoRegistrador("API_DOC", cfg.SeparadorLog)
+    log.Info("Configuración cargada", cfg.Resumen())
     log.Info("Inicializando servicios", map[string]interface{}{"puerto": cfg.Puerto})
 
-    // 4. Conectar a MongoDB (verificación temprana)
-    if _, err := mongo.ConexionDB(); err != nil {
-        log.Fatal("Error crítico en MongoDB", map[string]interface{}{
-            "modulo": "database",
-            "error":  err.Error(),
-        })
+    // 2.1 Instalar el proveedor de trazas antes de crear los clientes instrumentados (Gin, Alfresco, MongoDB)
+    apagarTrazas, err := trazas.Configurar(cfg, "API_DOC")
+    if err != nil {
+        log.Fatal("Error al configurar las trazas", map[string]interface{}{"error": err.Error()})
     }
-    log.Info("Conexión MongoDB establecida", nil)
 
-    
+    // 3. Conectar a MongoDB; el cliente y el cifrado de los datos sensibles se comparten con todos los repositorios
+    clienteMongo := conectarMongo(cfg, log)
+    cifrador := nuevoCifrador(cfg, log)
+    crearIndicesIniciales(cfg, log, clienteMongo, cifrador)
 
-    // 5. Configurar router Gin con middlewares
-    router := gin.Default()
-    router.Use(
-        middleware.MiddlewareRegistro(log), // Middleware de logging
-    )
-
-    // Configurar CORS
-    router.Use(cors.New(cors.Config{
-        AllowAllOrigins: true,
-        AllowMethods:    []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-        AllowHeaders:    []string{"Origin", "Content-Type", "Accept", "Authorization", "ADFTannerServices"},
-    }))
+    // Con --recifrar solo se migran los datos cifrados a la clave activa y se termina, sin atender solicitudes
+    if *recifrar {
+        recifrarColecciones(clienteMongo, cfg, cifrador, log)
+        clienteMongo.Disconnect(context.Background())
+        return
+    }
 
-    // 6. Registrar todas las rutas HTTP
-    routes.RegistrarRutas(router, log, cfg)
+    // 4. Construir los servicios compartidos por la API y las tareas en segundo plano
+    deps := construirDependencias(cfg, log, clienteMongo, cifrador)
 
-    // 7. Servir archivos estáticos
-    router.Static("/docs", "./docs")
+    // 5. Construir el servidor HTTP con sus rutas; las trazas se vacían al apagar
+    srv := construirServidor(cfg, log, deps)
+    srv.AlCerrar("trazas", apagarTrazas) // Exporta los spans pendientes, incluidos los del apagado
 
-    // 8. Registrar ruta de Swagger
-    router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+    // 6. Iniciar tareas en segundo plano; el apagado las detiene y espera
+    iniciarTareas(srv, cfg, log, deps, *rutaConfiguracion, proveedorSecretos)
 
-    // 9. Iniciar servidor HTTP
+    // 7. Atender solicitudes hasta SIGINT o SIGTERM y apagar en orden
+    ctxSenal, detenerSenales := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer detenerSenales()
     log.Info("Servidor listo", map[string]interface{}{
         "puerto":  cfg.Puerto,
         "version": "1.2.0",
     })
-    if err := router.Run(":" + cfg.Puerto); err != nil {
-        log.Fatal("Error al iniciar servidor", map[string]interface{}{"error": err.Error()})
+    if err := srv.Ejecutar(ctxSenal); err != nil {
+        log.Fatal("Error en el servidor", map[string]interface{}{"error": err.Error()})
+    }
+}
+
+// cargarConfiguracion carga la configuración y detiene el inicio con todos los problemas encontrados a la vista.
+func cargarConfiguracion(ruta string, proveedorSecretos secretos.Proveedor) *config.Config {
+    cfg, err := config.CargarConfiguracionConSecretos(ruta, proveedorSecretos)
+    if err == nil {
+        return cfg
+    }
+    registroInicial := logger.NuevoRegistrador("API_DOC", "|")
+    var errConfiguracion *config.ErrorConfiguracion
+    if errors.As(err, &errConfiguracion) {
+        for _, problema := range errConfiguracion.Problemas {
+            registroInicial.Error("Problema de configuración", map[string]interface{}{"detalle": problema})
+        }
+    }
+    registroInicial.Fatal("Configuración inválida", map[string]interface{}{"error": err.Error()})
+    return nil
+}
+
+// nuevoRegistrador crea el logger de la aplicación a partir de las claves LOG_*.
+func nuevoRegistrador(cfg *config.Config) (*logger.Registrador, error) {
+    reglas := cfg.LogRedaccion
+    if len(reglas) == 1 && reglas[0] == "ninguna" {
+        reglas = nil
     }
-}
\ No newline at end of file
+    redactor, err := logger.NuevoRedactor(reglas, cfg.LogCamposSensibles)
+    if err != nil {
+        return nil, err
+    }
+    return logger.NuevoRegistradorConOpciones("API_DOC", logger.Opciones{
+        Formato:          cfg.LogFormato,
+        Nivel:            cfg.LogLevel,
+        Separador:        cfg.SeparadorLog,
+        Archivo:          cfg.LogArchivo,
+        ArchivoTamanoMB:  cfg.LogArchivoTamanoMB,
+        ArchivoRespaldos: cfg.LogArchivoRespaldos,
+        ArchivoDias:      cfg.LogArchivoDias,
+        Redactor:         redactor,
+    }), nil
+}
+
+// conectarMongo conecta a MongoDB con verificación temprana y termina si no está disponible.
+func conectarMongo(cfg *config.Config, log *logger.Registrador) *mongodriver.Client {
+    ctxConexion, cancelarConexion := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancelarConexion()
+    clienteMongo, err := mongo.Conectar(ctxConexion, cfg)
+    if err != nil {
+        log.Fatal("Error crítico en MongoDB", map[string]interface{}{
+            "modulo": "database",
+            "error":  err.Error(),
+        })
+    }
+    log.Info("Conexión MongoDB establecida", nil)
+    return clienteMongo
+}
+
+// crearIndicesIniciales crea los índices que deben existir antes de atender solicitudes o recifrar.
+func crearIndicesIniciales(cfg *config.Config, log *logger.Registrador, clienteMongo *mongodriver.Client, cifrador *cifrado.Cifrador) {
+    // El índice único de secuencia impide que dos réplicas bifurquen la cadena de auditoría
+    if err := mongo.NuevoRepositorioAuditoria(clienteMongo, cfg).CrearIndices(context.Background()); err != nil {
+        log.Warn("No se pudieron crear los índices de auditoría", map[string]interface{}{"error": err.Error()})
+    }
+
+    // El RUT y la razón social se guardan cifrados en el índice (y el RUT en la auditoría y el outbox); las búsquedas usan sus índices ciegos
+    if cifrador != nil {
+        if err := mongo.NuevoRepositorioIndice(clienteMongo, cfg).CrearIndices(context.Background()); err != nil {
+            log.Warn("No se pudieron crear los índices ciegos", map[string]interface{}{"error": err.Error()})
+        }
+    }
+}
+
+// propietarioReplica identifica a esta réplica en los bloqueos de líder.
+var propietarioReplica = func() string {
+    hostname, _ := os.Hostname()
+    return hostname + "-" + uuid.New().String()
+}()
+
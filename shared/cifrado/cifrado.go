@@ -0,0 +1,150 @@
+package cifrado
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+// prefijo identifica los valores cifrados por esta versión del formato: enc:v1:<id clave>:<base64(nonce|texto cifrado)>.
+// Un valor sin prefijo es texto en claro (registros anteriores a habilitar el cifrado).
+const prefijo = "enc:v1:"
+
+// TamanoClave es el largo de las claves AES-256 y el mínimo de la clave del índice ciego, en bytes.
+const TamanoClave = 32
+
+// ErrClaveDesconocida indica que el valor fue cifrado con una clave que no está configurada.
+var ErrClaveDesconocida = errors.New("clave de cifrado desconocida")
+
+// idValido restringe los identificadores de clave para que no se confundan con el separador del formato.
+var idValido = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Cifrador cifra campos con AES-GCM y calcula índices ciegos (HMAC-SHA256) para buscarlos por igualdad.
+// Cifra siempre con la clave activa y descifra con cualquiera de las configuradas, de modo que al rotar
+// la clave los registros existentes siguen legibles hasta que se vuelven a cifrar.
+type Cifrador struct {
+    claves map[string]cipher.AEAD // AEAD por identificador de clave
+    activa string                 // Identificador de la clave usada al cifrar
+    indice []byte                 // Clave HMAC de los índices ciegos
+}
+
+// NuevoCifrador crea el cifrador.
+// Parámetros:
+//   - claves: Claves AES-256 por identificador; incluye las anteriores para leer registros aún no recifrados.
+//   - activa: Identificador de la clave con la que se cifran los valores nuevos.
+//   - claveIndice: Clave HMAC de los índices ciegos, independiente de las de cifrado.
+// Retorna un error si alguna clave no tiene el largo esperado o la activa no existe.
+func NuevoCifrador(claves map[string][]byte, activa string, claveIndice []byte) (*Cifrador, error) {
+    c := &Cifrador{claves: make(map[string]cipher.AEAD, len(claves)), activa: activa, indice: claveIndice}
+    for id, clave := range claves {
+        if !idValido.MatchString(id) {
+            return nil, fmt.Errorf("identificador de clave inválido: %s", id)
+        }
+        if len(clave) != TamanoClave {
+            return nil, fmt.Errorf("la clave %s debe tener %d bytes", id, TamanoClave)
+        }
+        bloque, err := aes.NewCipher(clave)
+        if err != nil {
+            return nil, fmt.Errorf("clave %s inválida: %v", id, err)
+        }
+        aead, err := cipher.NewGCM(bloque)
+        if err != nil {
+            return nil, fmt.Errorf("clave %s inválida: %v", id, err)
+        }
+        c.claves[id] = aead
+    }
+    if _, existe := c.claves[activa]; !existe {
+        return nil, fmt.Errorf("la clave activa %s no está entre las claves configuradas", activa)
+    }
+    if len(claveIndice) < TamanoClave {
+        return nil, fmt.Errorf("la clave del índice ciego debe tener al menos %d bytes", TamanoClave)
+    }
+    return c, nil
+}
+
+// ClaveActiva retorna el identificador de la clave con la que se cifra.
+func (c *Cifrador) ClaveActiva() string {
+    return c.activa
+}
+
+// Cifrar cifra el texto con la clave activa. El nombre del campo se autentica junto al valor, para que un
+// valor cifrado no pueda copiarse a otro campo. El texto vacío se conserva vacío.
+func (c *Cifrador) Cifrar(campo, texto string) (string, error) {
+    if texto == "" {
+        return "", nil
+    }
+    aead := c.claves[c.activa]
+    nonce := make([]byte, aead.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return "", fmt.Errorf("error al generar nonce: %v", err)
+    }
+    sellado := aead.Seal(nonce, nonce, []byte(texto), []byte(campo))
+    return prefijo + c.activa + ":" + base64.RawURLEncoding.EncodeToString(sellado), nil
+}
+
+// Descifrar retorna el texto de un valor cifrado con cualquiera de las claves configuradas.
+// Un valor sin el prefijo del formato se considera texto en claro y se retorna tal cual.
+func (c *Cifrador) Descifrar(campo, valor string) (string, error) {
+    id, cuerpo, cifrado := separar(valor)
+    if !cifrado {
+        return valor, nil
+    }
+    aead, existe := c.claves[id]
+    if !existe {
+        return "", fmt.Errorf("%w: %s", ErrClaveDesconocida, id)
+    }
+    sellado, err := base64.RawURLEncoding.DecodeString(cuerpo)
+    if err != nil || len(sellado) < aead.NonceSize() {
+        return "", fmt.Errorf("valor cifrado de %s mal formado", campo)
+    }
+    texto, err := aead.Open(nil, sellado[:aead.NonceSize()], sellado[aead.NonceSize():], []byte(campo))
+    if err != nil {
+        return "", fmt.Errorf("no se pudo descifrar %s con la clave %s", campo, id)
+    }
+    return string(texto), nil
+}
+
+// IndiceCiego retorna el HMAC del texto para buscar por igualdad sin almacenarlo en claro.
+// Es determinista y depende del campo, de modo que el mismo valor en dos campos produce índices distintos.
+// El texto vacío no tiene índice.
+func (c *Cifrador) IndiceCiego(campo, texto string) string {
+    if texto == "" {
+        return ""
+    }
+    mac := hmac.New(sha256.New, c.indice)
+    mac.Write([]byte(campo))
+    mac.Write([]byte{0})
+    mac.Write([]byte(texto))
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Vigente indica si el valor ya está cifrado con la clave activa; el texto vacío no requiere cifrado.
+func (c *Cifrador) Vigente(valor string) bool {
+    if valor == "" {
+        return true
+    }
+    id, _, cifrado := separar(valor)
+    return cifrado && id == c.activa
+}
+
+// EsCifrado indica si el valor tiene el formato de un valor cifrado.
+func EsCifrado(valor string) bool {
+    return strings.HasPrefix(valor, prefijo)
+}
+
+// separar retorna el identificador de clave y el cuerpo de un valor cifrado.
+func separar(valor string) (string, string, bool) {
+    if !EsCifrado(valor) {
+        return "", "", false
+    }
+    id, cuerpo, ok := strings.Cut(strings.TrimPrefix(valor, prefijo), ":")
+    return id, cuerpo, ok
+}
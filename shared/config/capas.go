@@ -0,0 +1,418 @@
+package config
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/fs"
+    "net/url"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// PrefijoEntorno es el prefijo de las variables de entorno que sobrescriben la configuración (REGAPI_PORT, REGAPI_MONGODB_URI, ...).
+const PrefijoEntorno = "REGAPI_"
+
+// VariableRutaConfiguracion indica el archivo de configuración cuando no se usa el flag --config.
+const VariableRutaConfiguracion = "REGAPI_CONFIG"
+
+// RutaPorDefecto es el archivo de configuración que se lee si no se indica otro.
+const RutaPorDefecto = "./settings/appsettings.json"
+
+// Capas de las que puede provenir un valor.
+const (
+    OrigenDefecto = "defecto"
+    OrigenArchivo = "archivo"
+    OrigenEntorno = "entorno"
+)
+
+// ErrorConfiguracion reúne todos los problemas encontrados al cargar la configuración.
+type ErrorConfiguracion struct {
+    Problemas []string // Un mensaje por clave inválida, faltante o desconocida
+}
+
+// Error implementa la interfaz error.
+func (e *ErrorConfiguracion) Error() string {
+    return fmt.Sprintf("configuración inválida (%d problemas): %s", len(e.Problemas), strings.Join(e.Problemas, "; "))
+}
+
+// capas acumula los valores de cada capa y los problemas encontrados.
+type capas struct {
+    valores   map[string]string // Valor efectivo por clave
+    origenes  map[string]string // Capa que aportó el valor efectivo
+    problemas []string          // Problemas encontrados hasta el momento
+}
+
+// nuevasCapas inicia la combinación con los valores por defecto.
+func nuevasCapas() *capas {
+    c := &capas{valores: make(map[string]string), origenes: make(map[string]string)}
+    for clave := range clavesConocidas() {
+        c.valores[clave] = valoresPorDefecto[clave]
+        c.origenes[clave] = OrigenDefecto
+    }
+    return c
+}
+
+// clavesConocidas retorna el conjunto de claves que acepta la configuración.
+func clavesConocidas() map[string]bool {
+    conocidas := make(map[string]bool)
+    for clave := range valoresPorDefecto {
+        conocidas[clave] = true
+    }
+    for _, clave := range clavesOpcionales {
+        conocidas[clave] = true
+    }
+    for _, clave := range clavesRequeridas {
+        conocidas[clave] = true
+    }
+    return conocidas
+}
+
+// problema registra un problema asociado a una clave.
+func (c *capas) problema(clave, detalle string) {
+    c.problemas = append(c.problemas, fmt.Sprintf("%s: %s", clave, detalle))
+}
+
+// RutaArchivo retorna el archivo de configuración que se lee para la ruta indicada (flag --config):
+// la ruta misma, REGAPI_CONFIG o RutaPorDefecto.
+func RutaArchivo(ruta string) string {
+    ruta, _ = resolverRuta(ruta)
+    return ruta
+}
+
+// resolverRuta retorna el archivo de configuración y si fue indicado explícitamente.
+func resolverRuta(ruta string) (string, bool) {
+    if ruta != "" {
+        return ruta, true
+    }
+    if ruta = os.Getenv(VariableRutaConfiguracion); ruta != "" {
+        return ruta, true
+    }
+    return RutaPorDefecto, false
+}
+
+// aplicarArchivo sobrescribe los valores con los del archivo JSON. El archivo por defecto es opcional;
+// uno indicado explícitamente debe existir.
+func (c *capas) aplicarArchivo(ruta string) {
+    // 1. Resolver la ruta
+    ruta, explicita := resolverRuta(ruta)
+
+    // 2. Leer y decodificar el archivo
+    contenido, err := os.ReadFile(ruta)
+    if err != nil {
+        if !explicita && errors.Is(err, fs.ErrNotExist) {
+            return
+        }
+        c.problema(ruta, fmt.Sprintf("no se pudo leer el archivo de configuración: %v", err))
+        return
+    }
+    var archivo map[string]interface{}
+    decodificador := json.NewDecoder(bytes.NewReader(contenido))
+    decodificador.UseNumber()
+    if err := decodificador.Decode(&archivo); err != nil {
+        c.problema(ruta, fmt.Sprintf("JSON inválido: %v", err))
+        return
+    }
+
+    // 3. Aplicar cada clave; se aceptan texto, números y booleanos
+    conocidas := clavesConocidas()
+    for _, clave := range clavesOrdenadas(archivo) {
+        if !conocidas[clave] {
+            c.problema(clave, "clave desconocida en "+ruta)
+            continue
+        }
+        switch valor := archivo[clave].(type) {
+        case nil:
+            continue
+        case string:
+            c.valores[clave] = valor
+        case json.Number:
+            c.valores[clave] = valor.String()
+        case bool:
+            c.valores[clave] = strconv.FormatBool(valor)
+        default:
+            c.problema(clave, "tipo no soportado en el archivo; se espera texto, número o booleano")
+            continue
+        }
+        c.origenes[clave] = OrigenArchivo
+    }
+}
+
+// aplicarEntorno sobrescribe los valores con las variables REGAPI_*.
+func (c *capas) aplicarEntorno() {
+    conocidas := clavesConocidas()
+    var variables []string
+    for _, variable := range os.Environ() {
+        if strings.HasPrefix(variable, PrefijoEntorno) {
+            variables = append(variables, variable)
+        }
+    }
+    sort.Strings(variables)
+
+    for _, variable := range variables {
+        nombre, valor, _ := strings.Cut(variable, "=")
+        if nombre == VariableRutaConfiguracion {
+            continue
+        }
+        clave := strings.TrimPrefix(nombre, PrefijoEntorno)
+        if !conocidas[clave] {
+            c.problema(nombre, "variable de entorno desconocida")
+            continue
+        }
+        c.valores[clave] = valor
+        c.origenes[clave] = OrigenEntorno
+    }
+}
+
+// clavesOrdenadas retorna las claves del mapa en orden, para reportar problemas de forma estable.
+func clavesOrdenadas(mapa map[string]interface{}) []string {
+    claves := make([]string, 0, len(mapa))
+    for clave := range mapa {
+        claves = append(claves, clave)
+    }
+    sort.Strings(claves)
+    return claves
+}
+
+// valorVisible retorna el valor tal como puede mostrarse en logs y errores.
+func valorVisible(clave, valor string) string {
+    if clavesSecretas[clave] && valor != "" {
+        return "***"
+    }
+    return strconv.Quote(valor)
+}
+
+// lector convierte los valores combinados a su tipo y registra un problema por cada valor inválido.
+type lector struct {
+    capas *capas
+}
+
+// invalido registra un valor con formato incorrecto, sin exponer secretos.
+func (l *lector) invalido(clave, detalle string) {
+    l.capas.problema(clave, fmt.Sprintf("%s (valor %s)", detalle, valorVisible(clave, l.capas.valores[clave])))
+}
+
+// valor retorna el texto de la clave; las claves tipadas con valor por defecto no pueden quedar vacías.
+func (l *lector) valor(clave string) (string, bool) {
+    valor := strings.TrimSpace(l.capas.valores[clave])
+    if valor == "" {
+        if _, tieneDefecto := valoresPorDefecto[clave]; tieneDefecto {
+            l.capas.problema(clave, "valor vacío")
+        }
+        return "", false
+    }
+    return valor, true
+}
+
+// texto retorna el valor sin conversión. Las claves requeridas se validan aparte; las que tienen valor
+// por defecto no pueden quedar vacías.
+func (l *lector) texto(clave string) string {
+    if _, tieneDefecto := valoresPorDefecto[clave]; tieneDefecto {
+        valor, _ := l.valor(clave)
+        return valor
+    }
+    return l.capas.valores[clave]
+}
+
+// entero convierte el valor a int, con un mínimo permitido.
+func (l *lector) entero(clave string, minimo int) int {
+    return int(l.entero64(clave, int64(minimo)))
+}
+
+// entero64 convierte el valor a int64, con un mínimo permitido.
+func (l *lector) entero64(clave string, minimo int64) int64 {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return 0
+    }
+    numero, err := strconv.ParseInt(valor, 10, 64)
+    if err != nil {
+        l.invalido(clave, "se esperaba un número entero")
+        return 0
+    }
+    if numero < minimo {
+        l.invalido(clave, fmt.Sprintf("debe ser mayor o igual que %d", minimo))
+        return 0
+    }
+    return numero
+}
+
+// puerto valida un puerto TCP.
+func (l *lector) puerto(clave string) string {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return ""
+    }
+    if numero, err := strconv.Atoi(valor); err != nil || numero < 1 || numero > 65535 {
+        l.invalido(clave, "se esperaba un puerto entre 1 y 65535")
+    }
+    return valor
+}
+
+// booleano convierte el valor a bool.
+func (l *lector) booleano(clave string) bool {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return false
+    }
+    booleano, err := strconv.ParseBool(valor)
+    if err != nil {
+        l.invalido(clave, "se esperaba true o false")
+    }
+    return booleano
+}
+
+// duracion convierte el valor (ej. "30m", "1h") a una duración positiva.
+func (l *lector) duracion(clave string) time.Duration {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return 0
+    }
+    duracion, err := time.ParseDuration(valor)
+    if err != nil {
+        l.invalido(clave, "se esperaba una duración (ej. 30s, 5m, 1h)")
+        return 0
+    }
+    if duracion <= 0 {
+        l.invalido(clave, "la duración debe ser positiva")
+        return 0
+    }
+    return duracion
+}
+
+// proporcion convierte el valor a un número entre 0 y 1 (ej. "0.25" para una de cada cuatro).
+func (l *lector) proporcion(clave string) float64 {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return 0
+    }
+    numero, err := strconv.ParseFloat(valor, 64)
+    if err != nil || numero < 0 || numero > 1 {
+        l.invalido(clave, "se esperaba un número entre 0 y 1")
+        return 0
+    }
+    return numero
+}
+
+// opcion valida que el valor sea una de las opciones (sin distinguir mayúsculas) y retorna la opción canónica.
+func (l *lector) opcion(clave string, opciones ...string) string {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return ""
+    }
+    for _, opcion := range opciones {
+        if strings.EqualFold(valor, opcion) {
+            return opcion
+        }
+    }
+    l.invalido(clave, "se esperaba uno de "+strings.Join(opciones, ", "))
+    return ""
+}
+
+// url valida una URL absoluta http o https.
+func (l *lector) url(clave string) string {
+    valor := strings.TrimSpace(l.capas.valores[clave])
+    if valor == "" {
+        return ""
+    }
+    direccion, err := url.Parse(valor)
+    if err != nil || (direccion.Scheme != "http" && direccion.Scheme != "https") || direccion.Host == "" {
+        l.invalido(clave, "se esperaba una URL http o https")
+    }
+    return valor
+}
+
+// listaEnteros convierte "30,15,7" a una lista de enteros positivos.
+func (l *lector) listaEnteros(clave string) []int {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return nil
+    }
+    var resultado []int
+    for _, parte := range strings.Split(valor, ",") {
+        numero, err := strconv.Atoi(strings.TrimSpace(parte))
+        if err != nil || numero <= 0 {
+            l.invalido(clave, "se esperaba una lista de enteros positivos separados por coma")
+            return nil
+        }
+        resultado = append(resultado, numero)
+    }
+    return resultado
+}
+
+// listaTiposMime convierte "application/pdf,image/png" a una lista de tipos MIME en minúsculas.
+func (l *lector) listaTiposMime(clave string) []string {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return nil
+    }
+    var resultado []string
+    for _, parte := range strings.Split(valor, ",") {
+        tipo := strings.ToLower(strings.TrimSpace(parte))
+        principal, subtipo, ok := strings.Cut(tipo, "/")
+        if !ok || principal == "" || subtipo == "" || strings.ContainsAny(tipo, " ;") {
+            l.invalido(clave, "se esperaba una lista de tipos MIME separados por coma (ej. application/pdf,image/png)")
+            return nil
+        }
+        resultado = append(resultado, tipo)
+    }
+    return resultado
+}
+
+// lista convierte "a, b" a una lista de textos sin espacios; vacía si la clave no tiene valor.
+func (l *lector) lista(clave string) []string {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return nil
+    }
+    var resultado []string
+    for _, parte := range strings.Split(valor, ",") {
+        if parte = strings.TrimSpace(parte); parte != "" {
+            resultado = append(resultado, parte)
+        }
+    }
+    return resultado
+}
+
+// listaOpciones convierte una lista separada por coma validando que cada elemento sea una de las opciones.
+func (l *lector) listaOpciones(clave string, opciones ...string) []string {
+    var resultado []string
+    for _, elemento := range l.lista(clave) {
+        valida := false
+        for _, opcion := range opciones {
+            if strings.EqualFold(elemento, opcion) {
+                resultado = append(resultado, opcion)
+                valida = true
+                break
+            }
+        }
+        if !valida {
+            l.invalido(clave, "se esperaba una lista con elementos de "+strings.Join(opciones, ", "))
+            return nil
+        }
+    }
+    return resultado
+}
+
+// mapaEnteros convierte "clave:valor,clave:valor" a un mapa de enteros.
+func (l *lector) mapaEnteros(clave string) map[string]int {
+    resultado := make(map[string]int)
+    valor := strings.TrimSpace(l.capas.valores[clave])
+    if valor == "" {
+        return resultado
+    }
+    for _, par := range strings.Split(valor, ",") {
+        nombre, numero, ok := strings.Cut(par, ":")
+        entero, err := strconv.Atoi(strings.TrimSpace(numero))
+        if !ok || strings.TrimSpace(nombre) == "" || err != nil {
+            l.invalido(clave, "se esperaban pares tipo:años separados por coma")
+            return make(map[string]int)
+        }
+        resultado[strings.TrimSpace(nombre)] = entero
+    }
+    return resultado
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+    "encoding/base64"
+    "fmt"
+    "strings"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+)
+
+// clavesCifrado convierte "id=base64,id=base64" a claves AES-256 por identificador (ej. generadas con
+// openssl rand -base64 32). Se conservan las claves anteriores para leer los registros aún no recifrados.
+func (l *lector) clavesCifrado(clave string) map[string][]byte {
+    formato := fmt.Sprintf("se esperaban pares id=clave separados por coma, con claves de %d bytes en base64", cifrado.TamanoClave)
+    claves := make(map[string][]byte)
+    for _, entrada := range l.lista(clave) {
+        id, valor, ok := strings.Cut(entrada, "=")
+        id = strings.TrimSpace(id)
+        bytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(valor))
+        if !ok || !idClaveValido(id) || err != nil || len(bytes) != cifrado.TamanoClave {
+            l.invalido(clave, formato+"; los id solo admiten letras, números, - y _")
+            return nil
+        }
+        if _, repetida := claves[id]; repetida {
+            l.invalido(clave, "el id "+id+" está repetido")
+            return nil
+        }
+        claves[id] = bytes
+    }
+    return claves
+}
+
+// claveBase64 decodifica una clave en base64 de al menos el largo indicado; vacía retorna nil.
+func (l *lector) claveBase64(clave string, minimo int) []byte {
+    valor, ok := l.valor(clave)
+    if !ok {
+        return nil
+    }
+    bytes, err := base64.StdEncoding.DecodeString(valor)
+    if err != nil || len(bytes) < minimo {
+        l.invalido(clave, fmt.Sprintf("se esperaba una clave de al menos %d bytes en base64", minimo))
+        return nil
+    }
+    return bytes
+}
+
+// idClaveValido indica si el identificador de clave solo usa letras, números, - y _.
+func idClaveValido(id string) bool {
+    if id == "" {
+        return false
+    }
+    for _, r := range id {
+        if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+            return false
+        }
+    }
+    return true
+}
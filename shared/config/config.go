@@ -1,10 +1,12 @@
 package config
 
 import (
-    "encoding/json"
-    "os"
-    "strconv"
-    "log"
+    "fmt"
+    "slices"
+    "strings"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/CamiloScript/REGAPIGO/shared/secretos"
 )
 
 // Config es una estructura que centraliza toda la configuración de la aplicación.
@@ -23,63 +25,385 @@ type Config struct {
     AuthUser          string  // Usuario de autenticación interna
     AuthPassword      string  // Contraseña de autenticación interna
     ApiKey            string  // API Key para solicitudes externas
+    AdminApiKey       string  // API Key que otorga el rol administrador (eliminación definitiva)
+    RetencionPorTipo  map[string]int // Años mínimos de retención por tipo de documento
+    MongoCollectionBloqueos string  // Colección de MongoDB para los bloqueos de tareas en segundo plano
+    VigenciaHabilitada bool         // Activa el programador de vencimiento de documentos
+    VigenciaIntervalo  time.Duration // Intervalo entre ejecuciones del programador de vencimiento
+    MongoCollectionWebhooks string  // Colección de MongoDB para suscripciones de webhooks
+    MongoCollectionEntregas string  // Colección de MongoDB para el log de entregas de webhooks
+    WebhookHabilitado  bool          // Activa el notificador de vencimientos por webhook
+    WebhookIntervalo   time.Duration // Intervalo entre revisiones de documentos por vencer
+    WebhookReintentos  int           // Reintentos por entrega de webhook
+    WebhookEsperaBase  time.Duration // Espera inicial entre reintentos (se duplica en cada intento)
+    WebhookEnviosMaximos int         // Envíos de un aviso fallido, uno por ejecución del notificador, antes de abandonarlo
+    WebhookDiasDefecto []int         // Umbrales de aviso por defecto para nuevas suscripciones
+    EventosPublicador  string        // Implementación del bus de eventos: memoria, webhook u outbox
+    EventosWebhookURL  string        // Receptor de eventos (publicador webhook o destino del relay)
+    EventosWebhookSecreto string     // Clave HMAC para firmar los eventos enviados por webhook
+    EventosRelayIntervalo time.Duration // Intervalo entre drenajes del outbox
+    MongoCollectionOutbox string     // Colección de MongoDB para el outbox de eventos
+    MongoCollectionAuditoria string  // Colección de MongoDB para el registro de auditoría
+    AlfrescoTimeout    time.Duration // Tiempo máximo por intento de solicitud a Alfresco
+    AlfrescoReintentos int           // Reintentos ante fallos transitorios de Alfresco (solo operaciones idempotentes)
+    AlfrescoEsperaBase time.Duration // Espera inicial entre reintentos (se duplica en cada intento, con jitter)
+    AlfrescoEsperaMaxima time.Duration // Tope de espera entre reintentos, incluso si Retry-After pide más
+    AlfrescoCircuitoUmbral int       // Fallos consecutivos que abren el circuito
+    AlfrescoCircuitoEnfriamiento time.Duration // Tiempo que el circuito permanece abierto antes de probar de nuevo
+    AlfrescoTLSCA      string        // Archivo PEM con CA adicionales para verificar a Alfresco (ej. CA interna)
+    AlfrescoTLSCertificado string    // Archivo PEM con el certificado de cliente presentado a Alfresco
+    AlfrescoTLSClave   string        // Archivo PEM con la clave privada del certificado de cliente
+    AlfrescoTLSVersionMinima string  // Versión mínima de TLS hacia Alfresco: 1.2 o 1.3
+    AlfrescoProxy      string        // URL del proxy HTTP hacia Alfresco; vacío usa HTTPS_PROXY/HTTP_PROXY/NO_PROXY
+    AuthTicketVigencia time.Duration // Tiempo que se reutiliza el ticket de la autenticación interna
+    SecretosIntervalo  time.Duration // Intervalo entre revisiones de rotación de secretos
+    TiposMimePermitidos []string     // Tipos MIME aceptados al subir documentos
+    ConfigIntervalo    time.Duration // Intervalo entre revisiones de cambios del archivo de configuración
+    ServidorTimeoutEncabezados time.Duration // Tiempo máximo para leer los encabezados de una solicitud
+    ServidorTimeoutLectura     time.Duration // Tiempo máximo para leer una solicitud completa (incluye el cuerpo)
+    ServidorTimeoutEscritura   time.Duration // Tiempo máximo para escribir la respuesta
+    ServidorTimeoutInactividad time.Duration // Tiempo que se mantiene abierta una conexión keep-alive inactiva
+    ServidorPlazoApagado       time.Duration // Tiempo máximo para terminar solicitudes y tareas al apagar
+    SaludTimeout       time.Duration // Tiempo máximo por verificación de dependencia en /health/ready
+    SaludCache         time.Duration // Tiempo que se reutiliza el resultado de /health/ready
+    LogFormato         string        // Formato de los logs en stdout: consola o json
+    LogArchivo         string        // Archivo de log adicional (JSON, con rotación); vacío para solo stdout
+    LogArchivoTamanoMB int           // Tamaño en MB a partir del cual se rota el archivo de log
+    LogArchivoRespaldos int          // Archivos de log rotados que se conservan
+    LogArchivoDias     int           // Días que se conserva un archivo de log rotado
+    LogRedaccion       []string      // Reglas de enmascaramiento de datos sensibles en los logs
+    LogCamposSensibles []string      // Campos adicionales cuyo valor nunca se registra
+    LimitesHabilitado  bool          // Activa el rate limiting y las cuotas diarias por cliente
+    LimitesAlmacen     string        // Dónde se guardan los contadores: memoria (una réplica) o mongo (varias)
+    MongoCollectionLimites string    // Colección de MongoDB para los contadores de límites
+    LimitesSolicitudesMinuto int     // Solicitudes por minuto por cliente y ruta, si no hay una regla más específica
+    LimitesRafaga      int           // Solicitudes seguidas permitidas por cliente y ruta
+    LimitesCuotaSubidaMB   int64     // MB diarios de subida por cliente (0 sin cuota)
+    LimitesCuotaDescargaMB int64     // MB diarios de descarga por cliente (0 sin cuota)
+    LimitesRutas       map[string]ReglaLimite // Tasa y ráfaga por ruta (ej. /documentos/subir-lote)
+    LimitesClientes    map[string]ReglaLimite // Tasa, ráfaga y cuotas por cliente, o por cliente@ruta
+    TrazasExportador   string        // Exportador de spans de OpenTelemetry: ninguno, stdout u otlp
+    TrazasEndpoint     string        // URL del collector OTLP/HTTP (ej. http://otel-collector:4318)
+    TrazasMuestreo     float64       // Proporción de trazas nuevas que se muestrean (0 a 1)
+    CORSOrigenes       []string      // Orígenes permitidos: exactos, patrones https://*.dominio o *; vacío desactiva CORS
+    CORSMetodos        []string      // Métodos permitidos en solicitudes de otros orígenes
+    CORSEncabezados    []string      // Encabezados que el navegador puede enviar
+    CORSEncabezadosExpuestos []string // Encabezados de la respuesta que el navegador expone al script
+    CORSCredenciales   bool          // Permite cookies y encabezados de autenticación del navegador
+    CORSMaxAge         time.Duration // Tiempo que el navegador reutiliza la respuesta a un preflight
+    SeguridadEncabezados bool        // Agrega X-Content-Type-Options, X-Frame-Options y HSTS a las respuestas
+    SeguridadHSTSMaxAge int64        // Segundos de Strict-Transport-Security; 0 no lo envía
+    SeguridadHSTSSubdominios bool    // Extiende HSTS a los subdominios (includeSubDomains)
+    TLSHabilitado      bool          // Atiende con TLS usando TLS_CERTIFICADO y TLS_CLAVE
+    TLSCertificado     string        // Archivo PEM con el certificado del servidor (y la cadena intermedia)
+    TLSClave           string        // Archivo PEM con la clave privada del servidor
+    TLSVersionMinima   string        // Versión mínima de TLS aceptada: 1.2 o 1.3
+    TLSRecargaIntervalo time.Duration // Intervalo entre revisiones de cambios de los archivos de certificados
+    TLSClienteCA       string        // Archivo PEM con las CA de los certificados de cliente; vacío desactiva mTLS
+    TLSClienteModo     string        // requerido (sin certificado se rechaza la conexión) u opcional
+    TLSClientesAdministradores []string // Sujetos (CN) de certificados de cliente con rol administrador
+    CifradoClaves      map[string][]byte // Claves AES-256 por id para cifrar los datos sensibles del índice; vacío no cifra
+    CifradoClaveActiva string        // Id de la clave con la que se cifran los valores nuevos
+    CifradoIndiceClave []byte        // Clave HMAC de los índices ciegos que permiten buscar por RUT y razón social
+
+    valores  map[string]string // Valores efectivos por clave, tras combinar las capas
+    origenes map[string]string // Capa de la que proviene cada valor (defecto, archivo o entorno)
+    secretos *almacenSecretos  // Secretos referenciados con file:// o env:, recargables
 }
 
-// CargarConfiguracion carga la configuración desde el archivo appsettings.json.
-func CargarConfiguracion() *Config {
-    // Abrir el archivo appsettings.json
-    file, err := os.Open("./settings/appsettings.json")
-    if err != nil {
-        log.Fatalf("No se pudo abrir el archivo appsettings.json: %v", err)
-    }
-    defer file.Close()
-
-    // Leer el contenido del archivo
-    var configMap map[string]string
-    decoder := json.NewDecoder(file)
-    if err := decoder.Decode(&configMap); err != nil {
-        log.Fatalf("Error al decodificar appsettings.json: %v", err)
-    }
-
-    // Retornar una nueva instancia de Config con valores cargados
-    return &Config{
-        Puerto:            configMap["PORT"],
-        AlfrescoBaseURL:   configMap["ALFRESCO_BASE_URL"],
-        AlfrescoAPIKey:    configMap["ALFRESCO_API_KEY"],
-        MaxFileSize:       getEnvAsInt64(configMap["MAX_FILE_SIZE"], 10*1024*1024),
-        LogLevel:          configMap["LOG_LEVEL"],
-        SeparadorLog:      configMap["LOG_SEPARATOR"],
-        ClaveSesion:       configMap["SESSION_KEY"],
-        TiempoSesion:      getEnvAsInt(configMap["SESSION_DURATION"], 24),
-        MongoURI:          configMap["MONGODB_URI"],
-        MongoDatabase:     configMap["MONGODB_DATABASE"],
-        MongoCollection:   configMap["MONGODB_COLLECTION"],
-        AuthUser:          configMap["AUTH_USER"],
-        AuthPassword:      configMap["AUTH_PASSWORD"],
-        ApiKey:            configMap["API_KEY"],
-    }
+// valoresPorDefecto es la primera capa de configuración; el archivo y el entorno la sobrescriben.
+var valoresPorDefecto = map[string]string{
+    "PORT":                           "5000",
+    "LOG_LEVEL":                      "INFO",
+    "LOG_SEPARATOR":                  "|",
+    "MAX_FILE_SIZE":                  "10485760",
+    "SESSION_DURATION":               "24",
+    "MONGODB_COLLECTION_LOCKS":       "locks",
+    "VIGENCIA_HABILITADA":            "false",
+    "VIGENCIA_INTERVALO":             "1h",
+    "MONGODB_COLLECTION_WEBHOOKS":    "suscripciones_webhook",
+    "MONGODB_COLLECTION_ENTREGAS":    "entregas_webhook",
+    "WEBHOOK_HABILITADO":             "false",
+    "WEBHOOK_INTERVALO":              "1h",
+    "WEBHOOK_REINTENTOS":             "3",
+    "WEBHOOK_ESPERA_BASE":            "2s",
+    "WEBHOOK_ENVIOS_MAXIMOS":         "5",
+    "WEBHOOK_DIAS_DEFECTO":           "30,15,7",
+    "EVENTOS_PUBLICADOR":             "memoria",
+    "EVENTOS_RELAY_INTERVALO":        "10s",
+    "MONGODB_COLLECTION_OUTBOX":      "outbox_eventos",
+    "MONGODB_COLLECTION_AUDITORIA":   "auditoria",
+    "ALFRESCO_TIMEOUT":               "30s",
+    "ALFRESCO_REINTENTOS":            "3",
+    "ALFRESCO_ESPERA_BASE":           "200ms",
+    "ALFRESCO_ESPERA_MAXIMA":         "5s",
+    "ALFRESCO_CIRCUITO_UMBRAL":       "5",
+    "ALFRESCO_CIRCUITO_ENFRIAMIENTO": "30s",
+    "ALFRESCO_TLS_VERSION_MINIMA":    "1.2",
+    "AUTH_TICKET_VIGENCIA":           "15m",
+    "SECRETOS_INTERVALO":             "1m",
+    "TIPOS_MIME_PERMITIDOS":          "application/pdf,image/jpeg,image/png",
+    "CONFIG_INTERVALO":               "5s",
+    "SERVIDOR_TIMEOUT_ENCABEZADOS":   "10s",
+    "SERVIDOR_TIMEOUT_LECTURA":       "60s",
+    "SERVIDOR_TIMEOUT_ESCRITURA":     "120s",
+    "SERVIDOR_TIMEOUT_INACTIVIDAD":   "120s",
+    "SERVIDOR_PLAZO_APAGADO":         "30s",
+    "SALUD_TIMEOUT":                  "2s",
+    "SALUD_CACHE":                    "5s",
+    "LOG_FORMATO":                    "consola",
+    "LOG_ARCHIVO_TAMANO_MB":          "100",
+    "LOG_ARCHIVO_RESPALDOS":          "5",
+    "LOG_ARCHIVO_DIAS":               "30",
+    "LOG_REDACCION":                  "ticket,contrasena,clave_api,base64,rut",
+    "LIMITES_HABILITADO":             "false",
+    "LIMITES_ALMACEN":                "memoria",
+    "MONGODB_COLLECTION_LIMITES":     "limites",
+    "LIMITES_SOLICITUDES_MINUTO":     "120",
+    "LIMITES_RAFAGA":                 "30",
+    "LIMITES_CUOTA_SUBIDA_MB":        "0",
+    "LIMITES_CUOTA_DESCARGA_MB":      "0",
+    "TRAZAS_EXPORTADOR":              "ninguno",
+    "TRAZAS_MUESTREO":                "1",
+    "CORS_METODOS":                   "GET,POST,PUT,DELETE,OPTIONS",
+    "CORS_ENCABEZADOS":               "Origin,Content-Type,Accept,Authorization,ADFTannerServices,X-Usuario,X-Request-ID",
+    "CORS_ENCABEZADOS_EXPUESTOS":     "X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After",
+    "CORS_CREDENCIALES":              "false",
+    "CORS_MAX_AGE":                   "12h",
+    "SEGURIDAD_ENCABEZADOS":          "true",
+    "SEGURIDAD_HSTS_MAX_AGE":         "31536000",
+    "SEGURIDAD_HSTS_SUBDOMINIOS":     "true",
+    "TLS_HABILITADO":                 "false",
+    "TLS_VERSION_MINIMA":             "1.2",
+    "TLS_RECARGA_INTERVALO":          "30s",
+    "TLS_CLIENTE_MODO":               "requerido",
+}
+
+// clavesOpcionales son las claves sin valor por defecto que pueden quedar vacías.
+var clavesOpcionales = []string{
+    "SESSION_KEY", "API_KEY", "ADMIN_API_KEY", "RETENCION_TIPOS_DOCUMENTO",
+    "EVENTOS_WEBHOOK_URL", "EVENTOS_WEBHOOK_SECRETO", "TRAZAS_OTLP_ENDPOINT",
+    "LOG_ARCHIVO", "LOG_CAMPOS_SENSIBLES", "LIMITES_RUTAS", "LIMITES_CLIENTES",
+    "CORS_ORIGENES", "TLS_CERTIFICADO", "TLS_CLAVE", "TLS_CLIENTE_CA", "TLS_CLIENTES_ADMINISTRADORES",
+    "ALFRESCO_TLS_CA", "ALFRESCO_TLS_CERTIFICADO", "ALFRESCO_TLS_CLAVE", "ALFRESCO_PROXY",
+    "CIFRADO_CLAVES", "CIFRADO_CLAVE_ACTIVA", "CIFRADO_INDICE_CLAVE",
+}
+
+// clavesRequeridas deben tener valor en alguna capa para iniciar la aplicación.
+var clavesRequeridas = []string{
+    "ALFRESCO_BASE_URL", "ALFRESCO_API_KEY", "MONGODB_URI", "MONGODB_DATABASE",
+    "MONGODB_COLLECTION", "AUTH_USER", "AUTH_PASSWORD",
+}
+
+// clavesSecretas nunca se muestran en logs ni en mensajes de error. Son las únicas que aceptan
+// referencias a un proveedor de secretos (file:///run/secrets/x o env:NOMBRE).
+var clavesSecretas = map[string]bool{
+    "ALFRESCO_API_KEY":        true,
+    "MONGODB_URI":             true, // Puede incluir usuario y contraseña
+    "SESSION_KEY":             true,
+    "AUTH_PASSWORD":           true,
+    "API_KEY":                 true,
+    "ADMIN_API_KEY":           true,
+    "EVENTOS_WEBHOOK_SECRETO": true,
+    "ALFRESCO_PROXY":          true, // Puede incluir usuario y contraseña del proxy
+    "CIFRADO_CLAVES":          true,
+    "CIFRADO_INDICE_CLAVE":    true,
 }
 
-// getEnvAsInt64 es una función auxiliar que convierte un string a int64.
-func getEnvAsInt64(value string, defaultValue int64) int64 {
-    if value == "" {
-        return defaultValue
+// CargarConfiguracion combina, en orden de prioridad creciente, los valores por defecto, el archivo JSON
+// y las variables de entorno REGAPI_*, resuelve las referencias a secretos (file://, env:) y valida el resultado.
+// Parámetros:
+//   - ruta: Archivo de configuración (flag --config). Si está vacía se usa REGAPI_CONFIG o, en su defecto,
+//     ./settings/appsettings.json, que en ese caso puede no existir.
+// Retorna la configuración o un *ErrorConfiguracion con todos los problemas encontrados.
+func CargarConfiguracion(ruta string) (*Config, error) {
+    return CargarConfiguracionConSecretos(ruta, secretos.ResolutorPorDefecto())
+}
+
+// CargarConfiguracionConSecretos es CargarConfiguracion con un proveedor de secretos explícito.
+func CargarConfiguracionConSecretos(ruta string, proveedor secretos.Proveedor) (*Config, error) {
+    // 1. Combinar las capas
+    capas := nuevasCapas()
+    capas.aplicarArchivo(ruta)
+    capas.aplicarEntorno()
+
+    // 2. Verificar las claves requeridas
+    for _, clave := range clavesRequeridas {
+        if capas.valores[clave] == "" {
+            capas.problema(clave, "valor requerido")
+        }
+    }
+
+    // 3. Resolver las referencias a secretos
+    almacen := resolverSecretos(capas, proveedor)
+
+    // 4. Convertir cada valor a su tipo
+    l := &lector{capas: capas}
+    cfg := &Config{
+        Puerto:            l.puerto("PORT"),
+        AlfrescoBaseURL:   l.url("ALFRESCO_BASE_URL"),
+        AlfrescoAPIKey:    l.texto("ALFRESCO_API_KEY"),
+        MaxFileSize:       l.entero64("MAX_FILE_SIZE", 1),
+        LogLevel:          l.opcion("LOG_LEVEL", "DEBUG", "INFO", "WARN", "ERROR", "FATAL", "PANIC"),
+        SeparadorLog:      l.texto("LOG_SEPARATOR"),
+        ClaveSesion:       l.texto("SESSION_KEY"),
+        TiempoSesion:      l.entero("SESSION_DURATION", 1),
+        MongoURI:          l.texto("MONGODB_URI"),
+        MongoDatabase:     l.texto("MONGODB_DATABASE"),
+        MongoCollection:   l.texto("MONGODB_COLLECTION"),
+        AuthUser:          l.texto("AUTH_USER"),
+        AuthPassword:      l.texto("AUTH_PASSWORD"),
+        ApiKey:            l.texto("API_KEY"),
+        AdminApiKey:       l.texto("ADMIN_API_KEY"),
+        RetencionPorTipo:  l.mapaEnteros("RETENCION_TIPOS_DOCUMENTO"),
+        MongoCollectionBloqueos: l.texto("MONGODB_COLLECTION_LOCKS"),
+        VigenciaHabilitada: l.booleano("VIGENCIA_HABILITADA"),
+        VigenciaIntervalo:  l.duracion("VIGENCIA_INTERVALO"),
+        MongoCollectionWebhooks: l.texto("MONGODB_COLLECTION_WEBHOOKS"),
+        MongoCollectionEntregas: l.texto("MONGODB_COLLECTION_ENTREGAS"),
+        WebhookHabilitado:  l.booleano("WEBHOOK_HABILITADO"),
+        WebhookIntervalo:   l.duracion("WEBHOOK_INTERVALO"),
+        WebhookReintentos:  l.entero("WEBHOOK_REINTENTOS", 0),
+        WebhookEsperaBase:  l.duracion("WEBHOOK_ESPERA_BASE"),
+        WebhookEnviosMaximos: l.entero("WEBHOOK_ENVIOS_MAXIMOS", 1),
+        WebhookDiasDefecto: l.listaEnteros("WEBHOOK_DIAS_DEFECTO"),
+        EventosPublicador:  l.opcion("EVENTOS_PUBLICADOR", "memoria", "webhook", "outbox"),
+        EventosWebhookURL:  l.texto("EVENTOS_WEBHOOK_URL"),
+        EventosWebhookSecreto: l.texto("EVENTOS_WEBHOOK_SECRETO"),
+        EventosRelayIntervalo: l.duracion("EVENTOS_RELAY_INTERVALO"),
+        MongoCollectionOutbox: l.texto("MONGODB_COLLECTION_OUTBOX"),
+        MongoCollectionAuditoria: l.texto("MONGODB_COLLECTION_AUDITORIA"),
+        AlfrescoTimeout:    l.duracion("ALFRESCO_TIMEOUT"),
+        AlfrescoReintentos: l.entero("ALFRESCO_REINTENTOS", 0),
+        AlfrescoEsperaBase: l.duracion("ALFRESCO_ESPERA_BASE"),
+        AlfrescoEsperaMaxima: l.duracion("ALFRESCO_ESPERA_MAXIMA"),
+        AlfrescoCircuitoUmbral: l.entero("ALFRESCO_CIRCUITO_UMBRAL", 0),
+        AlfrescoCircuitoEnfriamiento: l.duracion("ALFRESCO_CIRCUITO_ENFRIAMIENTO"),
+        AlfrescoTLSCA:      l.texto("ALFRESCO_TLS_CA"),
+        AlfrescoTLSCertificado: l.texto("ALFRESCO_TLS_CERTIFICADO"),
+        AlfrescoTLSClave:   l.texto("ALFRESCO_TLS_CLAVE"),
+        AlfrescoTLSVersionMinima: l.opcion("ALFRESCO_TLS_VERSION_MINIMA", "1.2", "1.3"),
+        AlfrescoProxy:      l.url("ALFRESCO_PROXY"),
+        AuthTicketVigencia: l.duracion("AUTH_TICKET_VIGENCIA"),
+        SecretosIntervalo:  l.duracion("SECRETOS_INTERVALO"),
+        TiposMimePermitidos: l.listaTiposMime("TIPOS_MIME_PERMITIDOS"),
+        ConfigIntervalo:    l.duracion("CONFIG_INTERVALO"),
+        ServidorTimeoutEncabezados: l.duracion("SERVIDOR_TIMEOUT_ENCABEZADOS"),
+        ServidorTimeoutLectura:     l.duracion("SERVIDOR_TIMEOUT_LECTURA"),
+        ServidorTimeoutEscritura:   l.duracion("SERVIDOR_TIMEOUT_ESCRITURA"),
+        ServidorTimeoutInactividad: l.duracion("SERVIDOR_TIMEOUT_INACTIVIDAD"),
+        ServidorPlazoApagado:       l.duracion("SERVIDOR_PLAZO_APAGADO"),
+        SaludTimeout:       l.duracion("SALUD_TIMEOUT"),
+        SaludCache:         l.duracion("SALUD_CACHE"),
+        LogFormato:         l.opcion("LOG_FORMATO", "consola", "json"),
+        LogArchivo:         l.texto("LOG_ARCHIVO"),
+        LogArchivoTamanoMB: l.entero("LOG_ARCHIVO_TAMANO_MB", 1),
+        LogArchivoRespaldos: l.entero("LOG_ARCHIVO_RESPALDOS", 0),
+        LogArchivoDias:     l.entero("LOG_ARCHIVO_DIAS", 0),
+        LogRedaccion:       l.listaOpciones("LOG_REDACCION", "ticket", "contrasena", "clave_api", "base64", "rut", "ninguna"),
+        LogCamposSensibles: l.lista("LOG_CAMPOS_SENSIBLES"),
+        LimitesHabilitado:  l.booleano("LIMITES_HABILITADO"),
+        LimitesAlmacen:     l.opcion("LIMITES_ALMACEN", "memoria", "mongo"),
+        MongoCollectionLimites: l.texto("MONGODB_COLLECTION_LIMITES"),
+        LimitesSolicitudesMinuto: l.entero("LIMITES_SOLICITUDES_MINUTO", 1),
+        LimitesRafaga:      l.entero("LIMITES_RAFAGA", 1),
+        LimitesCuotaSubidaMB:   l.entero64("LIMITES_CUOTA_SUBIDA_MB", 0),
+        LimitesCuotaDescargaMB: l.entero64("LIMITES_CUOTA_DESCARGA_MB", 0),
+        LimitesRutas:       l.reglasLimite("LIMITES_RUTAS", false, nombreRuta),
+        LimitesClientes:    l.reglasLimite("LIMITES_CLIENTES", true, nombreCliente),
+        TrazasExportador:   l.opcion("TRAZAS_EXPORTADOR", "ninguno", "stdout", "otlp"),
+        TrazasEndpoint:     l.url("TRAZAS_OTLP_ENDPOINT"),
+        TrazasMuestreo:     l.proporcion("TRAZAS_MUESTREO"),
+        CORSOrigenes:       l.origenesCORS("CORS_ORIGENES"),
+        CORSMetodos:        l.listaOpciones("CORS_METODOS", "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"),
+        CORSEncabezados:    l.lista("CORS_ENCABEZADOS"),
+        CORSEncabezadosExpuestos: l.lista("CORS_ENCABEZADOS_EXPUESTOS"),
+        CORSCredenciales:   l.booleano("CORS_CREDENCIALES"),
+        CORSMaxAge:         l.duracion("CORS_MAX_AGE"),
+        SeguridadEncabezados: l.booleano("SEGURIDAD_ENCABEZADOS"),
+        SeguridadHSTSMaxAge: l.entero64("SEGURIDAD_HSTS_MAX_AGE", 0),
+        SeguridadHSTSSubdominios: l.booleano("SEGURIDAD_HSTS_SUBDOMINIOS"),
+        TLSHabilitado:      l.booleano("TLS_HABILITADO"),
+        TLSCertificado:     l.texto("TLS_CERTIFICADO"),
+        TLSClave:           l.texto("TLS_CLAVE"),
+        TLSVersionMinima:   l.opcion("TLS_VERSION_MINIMA", "1.2", "1.3"),
+        TLSRecargaIntervalo: l.duracion("TLS_RECARGA_INTERVALO"),
+        TLSClienteCA:       l.texto("TLS_CLIENTE_CA"),
+        TLSClienteModo:     l.opcion("TLS_CLIENTE_MODO", "requerido", "opcional"),
+        TLSClientesAdministradores: l.lista("TLS_CLIENTES_ADMINISTRADORES"),
+        CifradoClaves:      l.clavesCifrado("CIFRADO_CLAVES"),
+        CifradoClaveActiva: l.texto("CIFRADO_CLAVE_ACTIVA"),
+        CifradoIndiceClave: l.claveBase64("CIFRADO_INDICE_CLAVE", cifrado.TamanoClave),
+        valores:  capas.valores,
+        origenes: capas.origenes,
+        secretos: almacen,
     }
-    intValue, err := strconv.ParseInt(value, 10, 64)
-    if err != nil {
-        return defaultValue
+
+    // 5. Validaciones entre claves
+    if cfg.EventosPublicador != "memoria" && cfg.EventosWebhookURL == "" {
+        capas.problema("EVENTOS_WEBHOOK_URL", "valor requerido con EVENTOS_PUBLICADOR="+cfg.EventosPublicador)
+    }
+    if cfg.ServidorTimeoutLectura < cfg.ServidorTimeoutEncabezados {
+        capas.problema("SERVIDOR_TIMEOUT_LECTURA", "debe ser mayor o igual que SERVIDOR_TIMEOUT_ENCABEZADOS")
+    }
+    if len(cfg.LogRedaccion) > 1 && slices.Contains(cfg.LogRedaccion, "ninguna") {
+        capas.problema("LOG_REDACCION", "ninguna no puede combinarse con otras reglas")
+    }
+    if cfg.CORSCredenciales && slices.Contains(cfg.CORSOrigenes, "*") {
+        capas.problema("CORS_ORIGENES", "* no puede usarse con CORS_CREDENCIALES=true; indique los orígenes")
+    }
+    if cfg.TLSHabilitado && cfg.TLSCertificado == "" {
+        capas.problema("TLS_CERTIFICADO", "valor requerido con TLS_HABILITADO=true")
+    }
+    if cfg.TLSHabilitado && cfg.TLSClave == "" {
+        capas.problema("TLS_CLAVE", "valor requerido con TLS_HABILITADO=true")
+    }
+    if !cfg.TLSHabilitado && cfg.TLSClienteCA != "" {
+        capas.problema("TLS_CLIENTE_CA", "mTLS requiere TLS_HABILITADO=true")
+    }
+    if (cfg.AlfrescoTLSCertificado == "") != (cfg.AlfrescoTLSClave == "") {
+        capas.problema("ALFRESCO_TLS_CERTIFICADO", "ALFRESCO_TLS_CERTIFICADO y ALFRESCO_TLS_CLAVE deben indicarse juntos")
+    }
+    conClaves := strings.TrimSpace(capas.valores["CIFRADO_CLAVES"]) != ""
+    conIndice := strings.TrimSpace(capas.valores["CIFRADO_INDICE_CLAVE"]) != ""
+    if conClaves && cfg.CifradoClaveActiva == "" {
+        capas.problema("CIFRADO_CLAVE_ACTIVA", "valor requerido con CIFRADO_CLAVES")
+    } else if _, existe := cfg.CifradoClaves[cfg.CifradoClaveActiva]; cfg.CifradoHabilitado() && !existe {
+        capas.problema("CIFRADO_CLAVE_ACTIVA", "debe ser uno de los id de CIFRADO_CLAVES")
+    }
+    if conClaves && !conIndice {
+        capas.problema("CIFRADO_INDICE_CLAVE", "valor requerido con CIFRADO_CLAVES")
+    }
+    if !conClaves && (cfg.CifradoClaveActiva != "" || conIndice) {
+        capas.problema("CIFRADO_CLAVES", "valor requerido con CIFRADO_CLAVE_ACTIVA o CIFRADO_INDICE_CLAVE")
+    }
+    if cfg.AlfrescoEsperaMaxima < cfg.AlfrescoEsperaBase {
+        capas.problema("ALFRESCO_ESPERA_MAXIMA", "debe ser mayor o igual que ALFRESCO_ESPERA_BASE")
     }
-    return intValue
-}
 
-// getEnvAsInt es una función auxiliar que convierte un string a int.
-func getEnvAsInt(value string, defaultValue int) int {
-    if value == "" {
-        return defaultValue
+    if len(capas.problemas) > 0 {
+        return nil, &ErrorConfiguracion{Problemas: capas.problemas}
     }
-    intValue, err := strconv.Atoi(value)
-    if err != nil {
-        return defaultValue
+    return cfg, nil
+}
+
+// CifradoHabilitado indica si los datos sensibles del índice se cifran.
+func (c *Config) CifradoHabilitado() bool {
+    return len(c.CifradoClaves) > 0
+}
+
+// Resumen retorna cada clave con su valor y la capa de la que proviene, para registrarla al iniciar.
+// Los valores secretos se reemplazan por "***", o por su referencia si provienen de un proveedor de secretos.
+func (c *Config) Resumen() map[string]interface{} {
+    resumen := make(map[string]interface{}, len(c.valores))
+    for clave, valor := range c.valores {
+        visible := valorVisible(clave, valor)
+        if c.secretos != nil {
+            if referencia, existe := c.secretos.referencias[clave]; existe {
+                visible = referencia
+            }
+        }
+        resumen[clave] = fmt.Sprintf("%s (%s)", visible, c.origenes[clave])
     }
-    return intValue
-}
\ No newline at end of file
+    return resumen
+}
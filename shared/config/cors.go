@@ -0,0 +1,34 @@
+package config
+
+import (
+    "net/url"
+    "strings"
+)
+
+// origenesCORS convierte CORS_ORIGENES a una lista de orígenes en minúsculas. Cada elemento es un origen exacto
+// (https://app.ejemplo.cl, con puerto opcional), un patrón de subdominios (https://*.ejemplo.cl) o * para todos.
+func (l *lector) origenesCORS(clave string) []string {
+    var resultado []string
+    for _, origen := range l.lista(clave) {
+        origen = strings.ToLower(strings.TrimSuffix(origen, "/"))
+        if origen != "*" && !origenValido(origen) {
+            l.invalido(clave, "se esperaban orígenes esquema://host[:puerto], patrones esquema://*.dominio o *")
+            return nil
+        }
+        resultado = append(resultado, origen)
+    }
+    return resultado
+}
+
+// origenValido indica si el texto es un origen http o https sin ruta, con * opcional solo como primer subdominio.
+func origenValido(origen string) bool {
+    patron := strings.Replace(origen, "://*.", "://comodin.", 1)
+    if strings.Contains(patron, "*") {
+        return false
+    }
+    direccion, err := url.Parse(patron)
+    if err != nil || (direccion.Scheme != "http" && direccion.Scheme != "https") || direccion.Host == "" {
+        return false
+    }
+    return direccion.Path == "" && direccion.RawQuery == "" && direccion.Fragment == "" && direccion.User == nil
+}
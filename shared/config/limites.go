@@ -0,0 +1,86 @@
+package config
+
+import (
+    "strconv"
+    "strings"
+)
+
+// SinCuota indica en ReglaLimite que la cuota se hereda de la configuración global.
+const SinCuota int64 = -1
+
+// ReglaLimite es una entrada de LIMITES_RUTAS o LIMITES_CLIENTES: solicitudes por minuto y ráfaga del token bucket
+// y, solo en clientes, cuotas diarias en MB.
+type ReglaLimite struct {
+    SolicitudesMinuto int   // Tokens que se reponen por minuto
+    Rafaga            int   // Capacidad del bucket: solicitudes seguidas permitidas
+    CuotaSubidaMB     int64 // MB diarios de subida; SinCuota hereda LIMITES_CUOTA_SUBIDA_MB
+    CuotaDescargaMB   int64 // MB diarios de descarga; SinCuota hereda LIMITES_CUOTA_DESCARGA_MB
+}
+
+// reglasLimite convierte "nombre=solicitudes/rafaga[/subida_mb/descarga_mb],..." a reglas por nombre.
+// Parámetros:
+//   - clave: Clave de configuración.
+//   - conCuotas: Si las reglas pueden incluir cuotas (LIMITES_CLIENTES) o solo tasa y ráfaga (LIMITES_RUTAS).
+//   - validarNombre: Verifica el nombre de cada regla; retorna el problema o vacío.
+func (l *lector) reglasLimite(clave string, conCuotas bool, validarNombre func(string) string) map[string]ReglaLimite {
+    formato := "se esperaban reglas nombre=solicitudes/rafaga separadas por coma"
+    if conCuotas {
+        formato = "se esperaban reglas cliente=solicitudes/rafaga[/subida_mb/descarga_mb] separadas por coma"
+    }
+
+    reglas := make(map[string]ReglaLimite)
+    for _, entrada := range l.lista(clave) {
+        nombre, valores, ok := strings.Cut(entrada, "=")
+        nombre = strings.TrimSpace(nombre)
+        partes := strings.Split(valores, "/")
+        if !ok || nombre == "" || (len(partes) != 2 && (!conCuotas || len(partes) != 4)) {
+            l.invalido(clave, formato)
+            return nil
+        }
+        if problema := validarNombre(nombre); problema != "" {
+            l.invalido(clave, problema)
+            return nil
+        }
+
+        numeros := make([]int64, len(partes))
+        for i, parte := range partes {
+            numero, err := strconv.ParseInt(strings.TrimSpace(parte), 10, 64)
+            if err != nil || numero < 0 || (i < 2 && numero == 0) {
+                l.invalido(clave, formato+"; la tasa y la ráfaga deben ser positivas y las cuotas no negativas")
+                return nil
+            }
+            numeros[i] = numero
+        }
+        if len(numeros) == 4 && strings.Contains(nombre, "@") {
+            l.invalido(clave, "las cuotas son diarias por cliente; "+nombre+" solo admite solicitudes/rafaga")
+            return nil
+        }
+        regla := ReglaLimite{SolicitudesMinuto: int(numeros[0]), Rafaga: int(numeros[1]), CuotaSubidaMB: SinCuota, CuotaDescargaMB: SinCuota}
+        if len(numeros) == 4 {
+            regla.CuotaSubidaMB, regla.CuotaDescargaMB = numeros[2], numeros[3]
+        }
+        reglas[nombre] = regla
+    }
+    return reglas
+}
+
+// nombreRuta valida que el nombre de una regla de LIMITES_RUTAS sea una ruta de la API.
+func nombreRuta(nombre string) string {
+    if !strings.HasPrefix(nombre, "/") {
+        return "la ruta " + nombre + " debe comenzar con /"
+    }
+    return ""
+}
+
+// nombreCliente valida el nombre de una regla de LIMITES_CLIENTES: un cliente (clave:<huella>, cert:<sujeto>
+// o ip:<dirección>), opcionalmente seguido de @ y una ruta.
+func nombreCliente(nombre string) string {
+    cliente, ruta, conRuta := strings.Cut(nombre, "@")
+    if !strings.HasPrefix(cliente, "clave:") && !strings.HasPrefix(cliente, "cert:") && !strings.HasPrefix(cliente, "ip:") {
+        return "el cliente " + cliente + " debe comenzar con clave:, cert: o ip:"
+    }
+    if conRuta {
+        return nombreRuta(ruta)
+    }
+    return ""
+}
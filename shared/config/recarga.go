@@ -0,0 +1,148 @@
+package config
+
+import (
+    "sort"
+    "sync"
+    "github.com/CamiloScript/REGAPIGO/shared/secretos"
+)
+
+// clavesRecargables son las claves que se aplican sin reiniciar; un cambio en cualquier otra se informa
+// pero solo tiene efecto al reiniciar la aplicación.
+var clavesRecargables = map[string]bool{
+    "LOG_LEVEL":             true,
+    "TIPOS_MIME_PERMITIDOS": true,
+    "ALFRESCO_BASE_URL":     true,
+}
+
+// EsRecargable indica si la clave se aplica sin reiniciar.
+func EsRecargable(clave string) bool {
+    return clavesRecargables[clave]
+}
+
+// Cambio describe una clave cuyo valor difiere entre dos configuraciones. Los secretos se muestran enmascarados.
+type Cambio struct {
+    Clave    string // Clave de configuración
+    Anterior string // Valor visible antes del cambio
+    Nuevo    string // Valor visible después del cambio
+}
+
+// Diferencias compara la configuración con otra más reciente.
+// Retorna los cambios de claves recargables y, aparte, las claves que cambiaron pero requieren reiniciar.
+// Los secretos referenciados se comparan por su referencia; sus rotaciones se aplican con RecargarSecretos.
+func (c *Config) Diferencias(nueva *Config) ([]Cambio, []string) {
+    var cambios []Cambio
+    var requierenReinicio []string
+    for _, clave := range clavesOrdenadasTexto(c.valores, nueva.valores) {
+        anterior, siguiente := c.configurado(clave), nueva.configurado(clave)
+        if anterior == siguiente {
+            continue
+        }
+        if !clavesRecargables[clave] {
+            requierenReinicio = append(requierenReinicio, clave)
+            continue
+        }
+        cambios = append(cambios, Cambio{
+            Clave:    clave,
+            Anterior: valorVisible(clave, anterior),
+            Nuevo:    valorVisible(clave, siguiente),
+        })
+    }
+    return cambios, requierenReinicio
+}
+
+// configurado retorna el valor tal como se configuró: la referencia si es un secreto referenciado.
+func (c *Config) configurado(clave string) string {
+    if c.secretos != nil {
+        if referencia, existe := c.secretos.referencias[clave]; existe {
+            return referencia
+        }
+    }
+    return c.valores[clave]
+}
+
+// clavesOrdenadasTexto retorna la unión de las claves de ambos mapas, en orden.
+func clavesOrdenadasTexto(mapas ...map[string]string) []string {
+    union := make(map[string]bool)
+    for _, mapa := range mapas {
+        for clave := range mapa {
+            union[clave] = true
+        }
+    }
+    claves := make([]string, 0, len(union))
+    for clave := range union {
+        claves = append(claves, clave)
+    }
+    sort.Strings(claves)
+    return claves
+}
+
+// Recargador vuelve a leer la configuración y entrega las claves recargables a quien las aplica.
+// Una configuración inválida se rechaza completa y se conserva la vigente.
+type Recargador struct {
+    mu        sync.Mutex         // Serializa las recargas (SIGHUP y cambios del archivo pueden coincidir)
+    ruta      string             // Ruta indicada con --config (vacía usa REGAPI_CONFIG o la ruta por defecto)
+    proveedor secretos.Proveedor // Proveedor de secretos para validar las referencias
+    vigente   *Config            // Última configuración aplicada
+    aplicar   func(*Config)      // Aplica las claves recargables de una configuración válida
+}
+
+// NuevoRecargador crea un recargador a partir de la configuración cargada al iniciar.
+// Parámetros:
+//   - ruta: Ruta indicada con --config, la misma usada al iniciar.
+//   - proveedor: Proveedor de secretos.
+//   - inicial: Configuración vigente.
+//   - aplicar: Función que aplica las claves recargables; solo recibe configuraciones válidas.
+func NuevoRecargador(ruta string, proveedor secretos.Proveedor, inicial *Config, aplicar func(*Config)) *Recargador {
+    return &Recargador{ruta: ruta, proveedor: proveedor, vigente: inicial, aplicar: aplicar}
+}
+
+// Vigente retorna la última configuración aplicada.
+func (r *Recargador) Vigente() *Config {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return r.vigente
+}
+
+// Recargar lee y valida la configuración completa. Si es válida y cambió alguna clave recargable, la aplica
+// y pasa a ser la vigente. Retorna los cambios aplicados y las claves que requieren reiniciar; ante un error
+// (*ErrorConfiguracion) no se aplica nada.
+func (r *Recargador) Recargar() ([]Cambio, []string, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    // 1. Cargar y validar todas las capas, igual que al iniciar
+    nueva, err := CargarConfiguracionConSecretos(r.ruta, r.proveedor)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // 2. Comparar con la vigente
+    cambios, requierenReinicio := r.vigente.Diferencias(nueva)
+    if len(cambios) == 0 {
+        return nil, requierenReinicio, nil
+    }
+
+    // 3. Aplicar; la vigente conserva los valores de las claves que requieren reiniciar, que siguen en uso
+    r.aplicar(nueva)
+    r.vigente = r.vigente.conRecargables(nueva)
+    return cambios, requierenReinicio, nil
+}
+
+// conRecargables retorna una copia de la configuración con las claves recargables tomadas de otra.
+func (c *Config) conRecargables(nueva *Config) *Config {
+    copia := *c
+    copia.LogLevel = nueva.LogLevel
+    copia.TiposMimePermitidos = nueva.TiposMimePermitidos
+    copia.AlfrescoBaseURL = nueva.AlfrescoBaseURL
+    copia.valores = make(map[string]string, len(c.valores))
+    copia.origenes = make(map[string]string, len(c.origenes))
+    for clave, valor := range c.valores {
+        copia.valores[clave] = valor
+        copia.origenes[clave] = c.origenes[clave]
+    }
+    for clave := range clavesRecargables {
+        copia.valores[clave] = nueva.valores[clave]
+        copia.origenes[clave] = nueva.origenes[clave]
+    }
+    return &copia
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "github.com/CamiloScript/REGAPIGO/shared/secretos"
+)
+
+// almacenSecretos guarda las referencias a secretos (file://, env:) y su valor vigente, que cambia al rotar.
+type almacenSecretos struct {
+    mu          sync.RWMutex
+    proveedor   secretos.Proveedor // Proveedor que resuelve las referencias
+    referencias map[string]string  // Clave → referencia configurada
+    valores     map[string]string  // Clave → valor resuelto vigente
+}
+
+// resolverSecretos reemplaza en las capas cada clave secreta que contiene una referencia por su valor resuelto.
+// Solo las claves secretas aceptan referencias; el resto se usa tal cual.
+func resolverSecretos(c *capas, proveedor secretos.Proveedor) *almacenSecretos {
+    almacen := &almacenSecretos{
+        proveedor:   proveedor,
+        referencias: make(map[string]string),
+        valores:     make(map[string]string),
+    }
+    for _, clave := range clavesSecretasOrdenadas() {
+        referencia := c.valores[clave]
+        if !secretos.EsReferencia(referencia) {
+            continue
+        }
+        valor, err := proveedor.Resolver(referencia)
+        if err != nil {
+            c.problema(clave, fmt.Sprintf("no se pudo resolver el secreto %s: %v", referencia, err))
+            continue
+        }
+        if valor == "" {
+            c.problema(clave, fmt.Sprintf("el secreto %s está vacío", referencia))
+        }
+        almacen.referencias[clave] = referencia
+        almacen.valores[clave] = valor
+        c.valores[clave] = valor
+    }
+    return almacen
+}
+
+// clavesSecretasOrdenadas retorna las claves secretas en orden, para reportar problemas de forma estable.
+func clavesSecretasOrdenadas() []string {
+    claves := make([]string, 0, len(clavesSecretas))
+    for clave := range clavesSecretas {
+        claves = append(claves, clave)
+    }
+    sort.Strings(claves)
+    return claves
+}
+
+// vigente retorna el valor actual de la clave, o el inicial si la clave no usa una referencia.
+func (a *almacenSecretos) vigente(clave, inicial string) string {
+    if a == nil {
+        return inicial
+    }
+    a.mu.RLock()
+    defer a.mu.RUnlock()
+    if valor, existe := a.valores[clave]; existe {
+        return valor
+    }
+    return inicial
+}
+
+// recargar vuelve a resolver todas las referencias. Si alguna falla se conservan los valores anteriores.
+func (a *almacenSecretos) recargar() ([]string, error) {
+    // 1. Resolver todo antes de aplicar, para no dejar una rotación a medias
+    nuevos := make(map[string]string, len(a.referencias))
+    var fallos []string
+    for clave, referencia := range a.referencias {
+        valor, err := a.proveedor.Resolver(referencia)
+        if err == nil && valor == "" {
+            err = fmt.Errorf("secreto vacío")
+        }
+        if err != nil {
+            fallos = append(fallos, fmt.Sprintf("%s: %v", clave, err))
+            continue
+        }
+        nuevos[clave] = valor
+    }
+    if len(fallos) > 0 {
+        sort.Strings(fallos)
+        return nil, fmt.Errorf("no se pudieron recargar los secretos: %s", strings.Join(fallos, "; "))
+    }
+
+    // 2. Aplicar y reportar las claves que cambiaron
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    var cambiadas []string
+    for clave, valor := range nuevos {
+        if a.valores[clave] != valor {
+            cambiadas = append(cambiadas, clave)
+        }
+    }
+    sort.Strings(cambiadas)
+    a.valores = nuevos
+    return cambiadas, nil
+}
+
+// RecargarSecretos vuelve a resolver las referencias a secretos, para aplicar rotaciones sin reiniciar.
+// Retorna las claves cuyo valor cambió; ante cualquier error conserva todos los valores anteriores.
+func (c *Config) RecargarSecretos() ([]string, error) {
+    if c.secretos == nil {
+        return nil, nil
+    }
+    return c.secretos.recargar()
+}
+
+// ContrasenaAuth retorna la contraseña vigente de la autenticación interna, incluida la última rotación.
+func (c *Config) ContrasenaAuth() string {
+    return c.secretos.vigente("AUTH_PASSWORD", c.AuthPassword)
+}
+
+// ClaveAPIAlfresco retorna la API Key vigente de Alfresco, incluida la última rotación.
+func (c *Config) ClaveAPIAlfresco() string {
+    return c.secretos.vigente("ALFRESCO_API_KEY", c.AlfrescoAPIKey)
+}
+
+// ClaveAdmin retorna la API Key vigente del rol administrador, incluida la última rotación.
+func (c *Config) ClaveAdmin() string {
+    return c.secretos.vigente("ADMIN_API_KEY", c.AdminApiKey)
+}
@@ -1,46 +1,144 @@
 package logger
 
 import (
+    "context"
+    "io"
     "os"
     "strings"
+    "github.com/CamiloScript/REGAPIGO/shared/solicitud"
     "github.com/rs/zerolog"
+    "go.opentelemetry.io/otel/trace"
+    "gopkg.in/natefinch/lumberjack.v2"
     "fmt"
 )
 
+// Formatos de salida por la consola estándar.
+const (
+    FormatoConsola = "consola" // Texto legible con colores, para desarrollo
+    FormatoJSON    = "json"    // Una línea JSON por registro, para agregadores de logs
+)
+
+// Opciones configura la salida del registrador.
+type Opciones struct {
+    Formato           string    // consola o json
+    Nivel             string    // DEBUG, INFO, WARN, ERROR, FATAL o PANIC
+    Separador         string    // Separador de los valores de campo en formato consola
+    Archivo           string    // Archivo adicional en JSON con rotación; vacío para escribir solo en stdout
+    ArchivoTamanoMB   int       // Tamaño a partir del cual se rota el archivo
+    ArchivoRespaldos  int       // Archivos rotados que se conservan
+    ArchivoDias       int       // Días que se conserva un archivo rotado
+    Redactor          *Redactor // Enmascara datos sensibles; nil no enmascara
+}
+
 // Registrador estructura principal para el registro personalizado
 type Registrador struct {
     zerolog.Logger    // Embebe el logger de zerolog para utilizar sus funcionalidades
     nombreServicio string // Nombre del servicio asociado al registrador
+    separador      string            // Separador de los valores de campo en formato consola
+    redactor       *Redactor         // Enmascara datos sensibles en mensajes y campos
+    archivo        *lumberjack.Logger // Archivo con rotación; nil si solo se escribe en stdout
 }
 
 // NuevoRegistrador crea una nueva instancia del registrador con formato personalizado
 // Parámetros:
 //   - nombreServicio: Nombre del servicio para identificar los logs.
 //   - separador: Carácter separador para los valores de los campos en los logs.
-// Retorna una instancia configurada de Registrador.
+// Retorna una instancia configurada de Registrador, en consola y con todas las reglas de redacción.
 func NuevoRegistrador(nombreServicio, separador string) *Registrador {
-    // Define la salida del logger con un formato de consola
-    salida := zerolog.ConsoleWriter{
-        Out:        os.Stdout,                      // La salida será la consola estándar
-        TimeFormat: "2006-01-02T15:04:05Z07:00",   // Formato de fecha y hora
+    redactor, _ := NuevoRedactor(ReglasPorDefecto, nil) // Las reglas por defecto siempre existen
+    return NuevoRegistradorConOpciones(nombreServicio, Opciones{
+        Formato:   FormatoConsola,
+        Separador: separador,
+        Redactor:  redactor,
+    })
+}
+
+// NuevoRegistradorConOpciones crea un registrador con el formato, nivel, archivo y redacción indicados.
+// Parámetros:
+//   - nombreServicio: Nombre del servicio para identificar los logs.
+//   - opciones: Configuración de la salida.
+// Retorna una instancia configurada de Registrador.
+func NuevoRegistradorConOpciones(nombreServicio string, opciones Opciones) *Registrador {
+    r := &Registrador{
+        nombreServicio: nombreServicio,
+        separador:      opciones.Separador,
+        redactor:       opciones.Redactor,
+    }
+    if opciones.Archivo != "" {
+        r.archivo = &lumberjack.Logger{
+            Filename:   opciones.Archivo,
+            MaxSize:    opciones.ArchivoTamanoMB,
+            MaxBackups: opciones.ArchivoRespaldos,
+            MaxAge:     opciones.ArchivoDias,
+        }
+    }
+    r.construir(opciones.Formato)
+    if opciones.Nivel != "" {
+        r.EstablecerNivel(opciones.Nivel)
     }
+    return r
+}
 
-    // Personaliza la forma en que se muestran los valores de los campos
-    salida.FormatFieldValue = func(i interface{}) string {
-        return separador + i.(string) // Agrega un separador al valor del campo
+// construir crea el logger de zerolog con el formato indicado en stdout y, si hay archivo, también en él (en JSON).
+func (r *Registrador) construir(formato string) {
+    var salida io.Writer = os.Stdout
+    if formato != FormatoJSON {
+        consola := zerolog.ConsoleWriter{
+            Out:        os.Stdout,                      // La salida será la consola estándar
+            TimeFormat: "2006-01-02T15:04:05Z07:00",   // Formato de fecha y hora
+        }
+        // Personaliza la forma en que se muestran los valores de los campos (no todos son texto: números, errores, ...)
+        separador := r.separador
+        consola.FormatFieldValue = func(i interface{}) string {
+            return separador + fmt.Sprint(i) // Agrega un separador al valor del campo
+        }
+        salida = consola
+    }
+    if r.archivo != nil {
+        salida = zerolog.MultiLevelWriter(salida, r.archivo)
     }
 
     // Inicializa el logger con el servicio asociado y la marca de tiempo
-    registrador := zerolog.New(salida).
+    r.Logger = zerolog.New(salida).
         With().
         Timestamp(). // Agrega la marca de tiempo automáticamente
-        Str("servicio", nombreServicio). // Agrega el nombre del servicio a los logs
+        Str("servicio", r.nombreServicio). // Agrega el nombre del servicio a los logs
         Logger()
+}
 
-    return &Registrador{
-        Logger:          registrador,
-        nombreServicio: nombreServicio,
+// preparar convierte los valores del contexto a texto y enmascara los datos sensibles.
+func (r *Registrador) preparar(contexto map[string]interface{}) map[string]interface{} {
+    // Convertir todos los valores a string para evitar problemas de tipo (por ejemplo, json.Number)
+    contextoString := make(map[string]interface{}, len(contexto))
+    for k, v := range contexto {
+        contextoString[k] = r.redactor.Campo(k, fmt.Sprintf("%v", v))
+    }
+    return contextoString
+}
+
+// ConContexto retorna un registrador que agrega a cada línea el ID de la solicitud (id_solicitud) y los IDs de la
+// traza en curso (trace_id y span_id), o el mismo registrador si ctx no tiene ninguno.
+// Parámetros:
+//   - ctx: Contexto de la solicitud u operación.
+func (r *Registrador) ConContexto(ctx context.Context) *Registrador {
+    idSolicitud := solicitud.DesdeContexto(ctx).ID
+    contextoTraza := trace.SpanContextFromContext(ctx)
+    if idSolicitud == "" && !contextoTraza.IsValid() {
+        return r
+    }
+
+    campos := r.Logger.With()
+    if idSolicitud != "" {
+        campos = campos.Str("id_solicitud", idSolicitud)
+    }
+    if contextoTraza.IsValid() {
+        campos = campos.
+            Str("trace_id", contextoTraza.TraceID().String()).
+            Str("span_id", contextoTraza.SpanID().String())
     }
+    copia := *r
+    copia.Logger = campos.Logger()
+    return &copia
 }
 
 // RegistrarDebug registra un mensaje de nivel DEBUG
@@ -48,12 +146,7 @@ func NuevoRegistrador(nombreServicio, separador string) *Registrador {
 //   - mensaje: Mensaje a registrar.
 //   - contexto: Contexto adicional en forma de mapa.
 func (r *Registrador) Debug(mensaje string, contexto map[string]interface{}) {
-    // Convertir todos los valores a string para evitar problemas de tipo (por ejemplo, json.Number)
-    contextoString := make(map[string]interface{})
-    for k, v := range contexto {
-        contextoString[k] = fmt.Sprintf("%v", v)
-    }
-    r.Logger.Debug().Fields(contextoString).Msg(mensaje) // Registra un mensaje con contexto adicional
+    r.Logger.Debug().Fields(r.preparar(contexto)).Msg(r.redactor.Texto(mensaje)) // Registra un mensaje con contexto adicional
 }
 
 // RegistrarInfo registra un mensaje de nivel INFO
@@ -61,12 +154,7 @@ func (r *Registrador) Debug(mensaje string, contexto map[string]interface{}) {
 //   - mensaje: Mensaje a registrar.
 //   - contexto: Contexto adicional en forma de mapa.
 func (r *Registrador) Info(mensaje string, contexto map[string]interface{}) {
-    // Convertir todos los valores a string para evitar problemas de tipo (por ejemplo, json.Number)
-    contextoString := make(map[string]interface{})
-    for k, v := range contexto {
-        contextoString[k] = fmt.Sprintf("%v", v)
-    }
-    r.Logger.Info().Fields(contextoString).Msg(mensaje) // Registra un mensaje informativo
+    r.Logger.Info().Fields(r.preparar(contexto)).Msg(r.redactor.Texto(mensaje)) // Registra un mensaje informativo
 }
 
 // RegistrarAdvertencia registra un mensaje de nivel WARN
@@ -74,12 +162,7 @@ func (r *Registrador) Info(mensaje string, contexto map[string]interface{}) {
 //   - mensaje: Mensaje a registrar.
 //   - contexto: Contexto adicional en forma de mapa.
 func (r *Registrador) Warn(mensaje string, contexto map[string]interface{}) {
-    // Convertir todos los valores a string para evitar problemas de tipo (por ejemplo, json.Number)
-    contextoString := make(map[string]interface{})
-    for k, v := range contexto {
-        contextoString[k] = fmt.Sprintf("%v", v)
-    }
-    r.Logger.Warn().Fields(contextoString).Msg(mensaje) // Registra un mensaje de advertencia
+    r.Logger.Warn().Fields(r.preparar(contexto)).Msg(r.redactor.Texto(mensaje)) // Registra un mensaje de advertencia
 }
 
 // RegistrarError registra un mensaje de nivel ERROR
@@ -87,12 +170,7 @@ func (r *Registrador) Warn(mensaje string, contexto map[string]interface{}) {
 //   - mensaje: Mensaje a registrar.
 //   - contexto: Contexto adicional en forma de mapa.
 func (r *Registrador) Error(mensaje string, contexto map[string]interface{}) {
-    // Convertir todos los valores a string para evitar problemas de tipo (por ejemplo, json.Number)
-    contextoString := make(map[string]interface{})
-    for k, v := range contexto {
-        contextoString[k] = fmt.Sprintf("%v", v)
-    }
-    r.Logger.Error().Fields(contextoString).Msg(mensaje) // Registra un mensaje de error
+    r.Logger.Error().Fields(r.preparar(contexto)).Msg(r.redactor.Texto(mensaje)) // Registra un mensaje de error
 }
 
 // RegistrarFatal registra un mensaje de nivel FATAL y termina la aplicación
@@ -100,12 +178,7 @@ func (r *Registrador) Error(mensaje string, contexto map[string]interface{}) {
 //   - mensaje: Mensaje a registrar.
 //   - contexto: Contexto adicional en forma de mapa.
 func (r *Registrador) Fatal(mensaje string, contexto map[string]interface{}) {
-    // Convertir todos los valores a string para evitar problemas de tipo (por ejemplo, json.Number)
-    contextoString := make(map[string]interface{})
-    for k, v := range contexto {
-        contextoString[k] = fmt.Sprintf("%v", v)
-    }
-    r.Logger.Fatal().Fields(contextoString).Msg(mensaje) // Registra un mensaje fatal y detiene la ejecución
+    r.Logger.Fatal().Fields(r.preparar(contexto)).Msg(r.redactor.Texto(mensaje)) // Registra un mensaje fatal y detiene la ejecución
 }
 
 // RegistrarPanico registra un mensaje de nivel PANIC y provoca un pánico en la aplicación
@@ -113,12 +186,7 @@ func (r *Registrador) Fatal(mensaje string, contexto map[string]interface{}) {
 //   - mensaje: Mensaje a registrar.
 //   - contexto: Contexto adicional en forma de mapa.
 func (r *Registrador) Panic(mensaje string, contexto map[string]interface{}) {
-    // Convertir todos los valores a string para evitar problemas de tipo (por ejemplo, json.Number)
-    contextoString := make(map[string]interface{})
-    for k, v := range contexto {
-        contextoString[k] = fmt.Sprintf("%v", v)
-    }
-    r.Logger.Panic().Fields(contextoString).Msg(mensaje) // Registra un mensaje de pánico
+    r.Logger.Panic().Fields(r.preparar(contexto)).Msg(r.redactor.Texto(mensaje)) // Registra un mensaje de pánico
 }
 
 // EstablecerNivel configura el nivel de registro global
@@ -145,9 +213,14 @@ func (r *Registrador) EstablecerNivel(nivel string) {
 
 // EstablecerFormatoJSON cambia el formato del registro a JSON
 func (r *Registrador) EstablecerFormatoJSON() {
-    r.Logger = zerolog.New(os.Stdout). // Define la salida como la consola estándar
-        With().
-        Timestamp(). // Agrega la marca de tiempo automáticamente
-        Str("servicio", r.nombreServicio). // Incluye el nombre del servicio en los logs
-        Logger()
-}
\ No newline at end of file
+    r.construir(FormatoJSON)
+}
+
+// Vaciar escribe en el destino los registros pendientes y cierra el archivo. Se llama al apagar, antes de
+// terminar el proceso.
+func (r *Registrador) Vaciar() {
+    _ = os.Stdout.Sync() // La consola puede no soportar Sync; no es un error para el apagado
+    if r.archivo != nil {
+        _ = r.archivo.Close()
+    }
+}
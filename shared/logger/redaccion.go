@@ -0,0 +1,141 @@
+package logger
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// Reglas de redacción disponibles en LOG_REDACCION.
+const (
+    ReglaTicket     = "ticket"     // Tickets de Alfresco (TICKET_...) y encabezados Authorization
+    ReglaContrasena = "contrasena" // Contraseñas y secretos
+    ReglaClaveAPI   = "clave_api"  // API Keys (ADFTannerServices, api_key, ...)
+    ReglaBase64     = "base64"     // Contenido de documentos en base64
+    ReglaRUT        = "rut"        // Dígitos del RUT, salvo los tres últimos y el verificador
+)
+
+// ReglasPorDefecto activa todas las reglas.
+var ReglasPorDefecto = []string{ReglaTicket, ReglaContrasena, ReglaClaveAPI, ReglaBase64, ReglaRUT}
+
+// Oculto reemplaza el valor completo de un campo sensible.
+const Oculto = "***"
+
+// minimoBase64 es la longitud desde la que una secuencia base64 se considera contenido de un documento
+// y no un identificador.
+const minimoBase64 = 80
+
+// regla agrupa los nombres de campo cuyo valor se oculta por completo y los patrones que se enmascaran
+// dentro de cualquier texto (mensajes y valores de otros campos).
+type regla struct {
+    campos   []string
+    patrones []patron
+}
+
+// patron reemplaza cada coincidencia de la expresión dentro de un texto.
+type patron struct {
+    expresion *regexp.Regexp
+    reemplazo func(coincidencia []string) string
+}
+
+// asignacion enmascara el valor de pares clave=valor o "clave": "valor" cuyo nombre coincide con nombres.
+func asignacion(nombres string) patron {
+    return patron{
+        expresion: regexp.MustCompile(`(?i)((?:` + nombres + `)["']?\s*[:=]\s*["']?)[^\s"',&}]+`),
+        reemplazo: func(c []string) string { return c[1] + Oculto },
+    }
+}
+
+var reglas = map[string]regla{
+    ReglaTicket: {
+        campos: []string{"ticket", "authorization", "alf_ticket"},
+        patrones: []patron{
+            {regexp.MustCompile(`TICKET_[0-9A-Za-z]+`), func([]string) string { return "TICKET_" + Oculto }},
+            {regexp.MustCompile(`(?i)\b(Basic|Bearer)\s+[A-Za-z0-9._~+/=-]+`), func(c []string) string { return c[1] + " " + Oculto }},
+            asignacion(`alf_ticket|ticket`),
+        },
+    },
+    ReglaContrasena: {
+        campos:   []string{"password", "contrasena", "contraseña", "passwd", "secreto", "secret"},
+        patrones: []patron{asignacion(`password|contrasena|contraseña|passwd|secreto|secret`)},
+    },
+    ReglaClaveAPI: {
+        campos:   []string{"apikey", "api_key", "clave_api", "x-api-key", "adftannerservices"},
+        patrones: []patron{asignacion(`api[_-]?key|clave_api|adftannerservices`)},
+    },
+    ReglaBase64: {
+        campos: []string{"base64"},
+        patrones: []patron{{
+            regexp.MustCompile(`[A-Za-z0-9+/]{` + strconv.Itoa(minimoBase64) + `,}={0,2}`),
+            func(c []string) string { return fmt.Sprintf("[base64 omitido, %d caracteres]", len(c[0])) },
+        }},
+    },
+    ReglaRUT: {
+        patrones: []patron{{
+            regexp.MustCompile(`\b(\d{1,2})(\.?)(\d{3})(\.?)(\d{3})-([\dkK])\b`),
+            func(c []string) string {
+                return strings.Repeat("*", len(c[1])) + c[2] + "***" + c[4] + c[5] + "-" + c[6]
+            },
+        }},
+    },
+}
+
+// Redactor enmascara datos sensibles antes de escribirlos en el log.
+type Redactor struct {
+    campos   map[string]bool // Nombres de campo normalizados cuyo valor se oculta por completo
+    patrones []patron        // Patrones que se enmascaran dentro de cualquier texto
+}
+
+// NuevoRedactor crea un redactor con las reglas indicadas.
+// Parámetros:
+//   - nombresReglas: Reglas activas (ticket, contrasena, clave_api, base64, rut).
+//   - camposAdicionales: Nombres de campo adicionales cuyo valor se oculta por completo.
+// Retorna el redactor o un error si alguna regla no existe.
+func NuevoRedactor(nombresReglas []string, camposAdicionales []string) (*Redactor, error) {
+    redactor := &Redactor{campos: make(map[string]bool)}
+    for _, nombre := range nombresReglas {
+        r, existe := reglas[nombre]
+        if !existe {
+            return nil, fmt.Errorf("regla de redacción desconocida: %s", nombre)
+        }
+        for _, campo := range r.campos {
+            redactor.campos[normalizarCampo(campo)] = true
+        }
+        redactor.patrones = append(redactor.patrones, r.patrones...)
+    }
+    for _, campo := range camposAdicionales {
+        redactor.campos[normalizarCampo(campo)] = true
+    }
+    return redactor, nil
+}
+
+// normalizarCampo compara nombres sin distinguir mayúsculas, guiones ni guiones bajos (api_key = API-Key = apikey).
+func normalizarCampo(campo string) string {
+    return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(campo))
+}
+
+// Campo retorna el valor a registrar para un campo: oculto si el nombre es sensible, o con los patrones
+// enmascarados en caso contrario.
+func (r *Redactor) Campo(nombre, valor string) string {
+    if r == nil {
+        return valor
+    }
+    if r.campos[normalizarCampo(nombre)] {
+        return Oculto
+    }
+    return r.Texto(valor)
+}
+
+// Texto enmascara los patrones sensibles dentro de un texto libre (mensajes, errores, URLs).
+func (r *Redactor) Texto(texto string) string {
+    if r == nil {
+        return texto
+    }
+    for _, p := range r.patrones {
+        texto = p.expresion.ReplaceAllStringFunc(texto, func(coincidencia string) string {
+            return p.reemplazo(p.expresion.FindStringSubmatch(coincidencia))
+        })
+    }
+    return texto
+}
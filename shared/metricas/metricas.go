@@ -0,0 +1,213 @@
+package metricas
+
+import (
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/collectors"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Espacio de nombres de todas las series (regapi_http_..., regapi_alfresco_..., ...).
+const espacio = "regapi"
+
+// Direcciones de transferencia de documentos.
+const (
+    DireccionSubida   = "subida"
+    DireccionDescarga = "descarga"
+)
+
+// Resultados de una operación.
+const (
+    ResultadoExito = "exito"
+    ResultadoError = "error"
+)
+
+// Registro contiene todas las series expuestas en /metrics. Es propio de la aplicación (no el registro
+// global de Prometheus) para que las pruebas lean exactamente lo que se expone.
+var Registro = prometheus.NewRegistry()
+
+var (
+    duracionHTTP = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Namespace: espacio,
+        Subsystem: "http",
+        Name:      "solicitud_duracion_segundos",
+        Help:      "Duración de las solicitudes HTTP por método, ruta y código de estado.",
+        Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+    }, []string{"metodo", "ruta", "estado"})
+
+    duracionAlfresco = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Namespace: espacio,
+        Subsystem: "alfresco",
+        Name:      "llamada_duracion_segundos",
+        Help:      "Duración de las llamadas a Alfresco por operación, incluidos los reintentos.",
+        Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+    }, []string{"operacion"})
+
+    erroresAlfresco = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: espacio,
+        Subsystem: "alfresco",
+        Name:      "errores_total",
+        Help:      "Llamadas a Alfresco fallidas por operación y categoría de error.",
+    }, []string{"operacion", "categoria"})
+
+    duracionMongo = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Namespace: espacio,
+        Subsystem: "mongo",
+        Name:      "operacion_duracion_segundos",
+        Help:      "Duración de los comandos de MongoDB por comando y resultado.",
+        Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+    }, []string{"comando", "resultado"})
+
+    tamanoLote = prometheus.NewHistogram(prometheus.HistogramOpts{
+        Namespace: espacio,
+        Subsystem: "lote",
+        Name:      "documentos",
+        Help:      "Cantidad de documentos por lote recibido.",
+        Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200},
+    })
+
+    bytesDocumentos = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: espacio,
+        Subsystem: "documentos",
+        Name:      "bytes_total",
+        Help:      "Bytes de documentos transferidos por dirección (subida o descarga).",
+    }, []string{"direccion"})
+
+    renovacionesTicket = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: espacio,
+        Subsystem: "ticket",
+        Name:      "renovaciones_total",
+        Help:      "Inicios de sesión de la autenticación interna por resultado.",
+    }, []string{"resultado"})
+
+    rechazosLimite = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: espacio,
+        Subsystem: "limites",
+        Name:      "rechazos_total",
+        Help:      "Solicitudes rechazadas con 429 por ruta y motivo (tasa, subida o descarga).",
+    }, []string{"ruta", "motivo"})
+)
+
+// circuito expone el estado del circuito de Alfresco, leído en cada consulta a /metrics.
+var circuito = &colectorCircuito{
+    estado: prometheus.NewDesc(
+        prometheus.BuildFQName(espacio, "alfresco", "circuito_estado"),
+        "Estado del circuito de Alfresco: 1 en el estado actual, 0 en los demás.",
+        []string{"estado"}, nil,
+    ),
+    aperturas: prometheus.NewDesc(
+        prometheus.BuildFQName(espacio, "alfresco", "circuito_aperturas_total"),
+        "Veces que el circuito de Alfresco se ha abierto desde el inicio.",
+        nil, nil,
+    ),
+}
+
+func init() {
+    Registro.MustRegister(
+        collectors.NewGoCollector(),
+        collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+        duracionHTTP, duracionAlfresco, erroresAlfresco, duracionMongo,
+        tamanoLote, bytesDocumentos, renovacionesTicket, rechazosLimite,
+        circuito,
+    )
+}
+
+// colectorCircuito consulta el circuito al momento de exponer, en lugar de mantener una copia de su estado.
+type colectorCircuito struct {
+    mu        sync.RWMutex
+    estados   []string                                // Estados posibles; todos se exponen para que las alertas vean el 0
+    leer      func() (estado string, aperturas int64) // Lectura del circuito; nil mientras no se haya establecido
+    estado    *prometheus.Desc
+    aperturas *prometheus.Desc
+}
+
+// Describe envía las descripciones de las series del circuito.
+func (c *colectorCircuito) Describe(descripciones chan<- *prometheus.Desc) {
+    descripciones <- c.estado
+    descripciones <- c.aperturas
+}
+
+// Collect lee el circuito y envía sus series. No envía nada si aún no se estableció el circuito.
+func (c *colectorCircuito) Collect(series chan<- prometheus.Metric) {
+    c.mu.RLock()
+    estados, leer := c.estados, c.leer
+    c.mu.RUnlock()
+    if leer == nil {
+        return
+    }
+
+    actual, aperturas := leer()
+    for _, estado := range estados {
+        valor := 0.0
+        if estado == actual {
+            valor = 1
+        }
+        series <- prometheus.MustNewConstMetric(c.estado, prometheus.GaugeValue, valor, estado)
+    }
+    series <- prometheus.MustNewConstMetric(c.aperturas, prometheus.CounterValue, float64(aperturas))
+}
+
+// EstablecerCircuitoAlfresco indica cómo leer el circuito de Alfresco y cuáles son sus estados posibles.
+// Reemplaza la lectura anterior, de modo que solo se expone el último circuito establecido.
+func EstablecerCircuitoAlfresco(estados []string, leer func() (estado string, aperturas int64)) {
+    circuito.mu.Lock()
+    defer circuito.mu.Unlock()
+    circuito.estados = estados
+    circuito.leer = leer
+}
+
+// Manejador expone el registro en formato de exposición de Prometheus.
+func Manejador() http.Handler {
+    return promhttp.HandlerFor(Registro, promhttp.HandlerOpts{Registry: Registro})
+}
+
+// ObservarSolicitudHTTP registra la duración de una solicitud. La ruta debe ser la plantilla
+// (ej. /documentos/:id) y no la URL, para acotar la cantidad de series.
+func ObservarSolicitudHTTP(metodo, ruta string, estado int, duracion time.Duration) {
+    duracionHTTP.WithLabelValues(metodo, ruta, strconv.Itoa(estado)).Observe(duracion.Seconds())
+}
+
+// ObservarAlfresco registra la duración de una llamada a Alfresco y, si falló, su categoría de error.
+// Una categoría vacía indica éxito.
+func ObservarAlfresco(operacion string, duracion time.Duration, categoria string) {
+    duracionAlfresco.WithLabelValues(operacion).Observe(duracion.Seconds())
+    if categoria != "" {
+        erroresAlfresco.WithLabelValues(operacion, categoria).Inc()
+    }
+}
+
+// ObservarMongo registra la duración de un comando de MongoDB.
+func ObservarMongo(comando string, duracion time.Duration, exito bool) {
+    resultado := ResultadoExito
+    if !exito {
+        resultado = ResultadoError
+    }
+    duracionMongo.WithLabelValues(comando, resultado).Observe(duracion.Seconds())
+}
+
+// ObservarLote registra la cantidad de documentos de un lote.
+func ObservarLote(documentos int) {
+    tamanoLote.Observe(float64(documentos))
+}
+
+// SumarBytes acumula los bytes de documentos transferidos en la dirección indicada.
+func SumarBytes(direccion string, bytes int) {
+    bytesDocumentos.WithLabelValues(direccion).Add(float64(bytes))
+}
+
+// ContarRenovacionTicket cuenta un inicio de sesión de la autenticación interna.
+func ContarRenovacionTicket(exito bool) {
+    resultado := ResultadoExito
+    if !exito {
+        resultado = ResultadoError
+    }
+    renovacionesTicket.WithLabelValues(resultado).Inc()
+}
+
+// ContarRechazoLimite cuenta una solicitud rechazada por el limitador. El motivo es "tasa" o la dirección de la cuota.
+func ContarRechazoLimite(ruta, motivo string) {
+    rechazosLimite.WithLabelValues(ruta, motivo).Inc()
+}
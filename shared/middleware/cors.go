@@ -0,0 +1,111 @@
+package middleware
+
+import (
+    "strconv"
+    "strings"
+    "github.com/gin-contrib/cors"
+    "github.com/gin-gonic/gin"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+)
+
+// MiddlewareCORS aplica la política CORS configurada. Las solicitudes de un origen no permitido se rechazan
+// con 403; las que no traen Origin (clientes de servidor) o vienen del mismo host no se ven afectadas.
+// Sin CORS_ORIGENES no se agregan encabezados CORS y los navegadores bloquean las llamadas de otros orígenes.
+// Parámetros:
+//   - cfg: Configuración de la aplicación.
+// Retorna una función de middleware para Gin.
+func MiddlewareCORS(cfg *config.Config) gin.HandlerFunc {
+    if len(cfg.CORSOrigenes) == 0 {
+        return func(c *gin.Context) { c.Next() }
+    }
+
+    politica := cors.Config{
+        AllowMethods:     cfg.CORSMetodos,
+        AllowHeaders:     cfg.CORSEncabezados,
+        ExposeHeaders:    cfg.CORSEncabezadosExpuestos,
+        AllowCredentials: cfg.CORSCredenciales,
+        MaxAge:           cfg.CORSMaxAge,
+    }
+    for _, origen := range cfg.CORSOrigenes {
+        if origen == "*" {
+            politica.AllowAllOrigins = true
+        }
+    }
+    if !politica.AllowAllOrigins {
+        politica.AllowOriginFunc = OrigenPermitido(cfg.CORSOrigenes)
+    }
+    return cors.New(politica)
+}
+
+// OrigenPermitido retorna una función que indica si un origen coincide con alguno de los configurados, ya sea
+// exacto o por un patrón de subdominios: https://*.ejemplo.cl acepta https://app.ejemplo.cl y
+// https://a.b.ejemplo.cl, pero no https://ejemplo.cl ni https://otroejemplo.cl.
+func OrigenPermitido(origenes []string) func(string) bool {
+    exactos := make(map[string]bool)
+    var patrones [][2]string // Prefijo (esquema://) y sufijo (.dominio[:puerto]) de cada patrón
+    for _, origen := range origenes {
+        if prefijo, sufijo, esPatron := strings.Cut(origen, "*"); esPatron {
+            patrones = append(patrones, [2]string{prefijo, sufijo})
+            continue
+        }
+        exactos[origen] = true
+    }
+
+    return func(origen string) bool {
+        origen = strings.ToLower(origen)
+        if exactos[origen] {
+            return true
+        }
+        for _, patron := range patrones {
+            if len(origen) > len(patron[0])+len(patron[1]) && strings.HasPrefix(origen, patron[0]) && strings.HasSuffix(origen, patron[1]) &&
+                subdominioValido(origen[len(patron[0]):len(origen)-len(patron[1])]) {
+                return true
+            }
+        }
+        return false
+    }
+}
+
+// subdominioValido indica si el texto que reemplaza al * son etiquetas DNS (sin puerto, ruta ni credenciales).
+func subdominioValido(etiquetas string) bool {
+    for _, etiqueta := range strings.Split(etiquetas, ".") {
+        if etiqueta == "" || strings.HasPrefix(etiqueta, "-") || strings.HasSuffix(etiqueta, "-") {
+            return false
+        }
+        for _, caracter := range etiqueta {
+            if (caracter < 'a' || caracter > 'z') && (caracter < '0' || caracter > '9') && caracter != '-' {
+                return false
+            }
+        }
+    }
+    return true
+}
+
+// MiddlewareSeguridad agrega encabezados de seguridad estándar a todas las respuestas: nosniff, para que el
+// navegador respete el Content-Type de los documentos; DENY, para que la API no se muestre en marcos; y HSTS,
+// que los navegadores solo aplican cuando la respuesta llega por HTTPS.
+// Parámetros:
+//   - cfg: Configuración de la aplicación.
+// Retorna una función de middleware para Gin.
+func MiddlewareSeguridad(cfg *config.Config) gin.HandlerFunc {
+    if !cfg.SeguridadEncabezados {
+        return func(c *gin.Context) { c.Next() }
+    }
+
+    hsts := ""
+    if cfg.SeguridadHSTSMaxAge > 0 {
+        hsts = "max-age=" + strconv.FormatInt(cfg.SeguridadHSTSMaxAge, 10)
+        if cfg.SeguridadHSTSSubdominios {
+            hsts += "; includeSubDomains"
+        }
+    }
+    return func(c *gin.Context) {
+        encabezados := c.Writer.Header()
+        encabezados.Set("X-Content-Type-Options", "nosniff")
+        encabezados.Set("X-Frame-Options", "DENY")
+        if hsts != "" {
+            encabezados.Set("Strict-Transport-Security", hsts)
+        }
+        c.Next()
+    }
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+    "crypto/sha256"
+    "crypto/subtle"
+    "encoding/hex"
+    "net/http"
+    "slices"
+    "strings"
+    "github.com/gin-gonic/gin"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/solicitud"
+)
+
+// Roles reconocidos para los clientes de la API.
+const (
+    RolOperador      = "operador"      // Rol por defecto: operaciones habituales sobre documentos
+    RolAdministrador = "administrador" // Rol elevado: operaciones destructivas como la eliminación definitiva
+)
+
+// HeaderActor identifica a la persona o sistema que origina la solicitud, para auditoría.
+const HeaderActor = "X-Usuario"
+
+// MiddlewareIdentidad resuelve el rol y el actor del cliente que origina la solicitud.
+// El rol administrador se otorga cuando el header ADFTannerServices coincide con ADMIN_API_KEY, o cuando el
+// sujeto del certificado de cliente verificado por mTLS está en TLS_CLIENTES_ADMINISTRADORES.
+// El actor es el cliente autenticado (certificado o API Key configurada), seguido del usuario que declara en el
+// header X-Usuario; un cliente no autenticado se identifica por su rol y su X-Usuario se ignora.
+// Parámetros:
+//   - cfg: Configuración de la aplicación.
+// Retorna una función de middleware para Gin.
+func MiddlewareIdentidad(cfg *config.Config) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        rol := RolOperador
+
+        // Comparar en tiempo constante para no filtrar información de la clave
+        clave := c.GetHeader("ADFTannerServices")
+        if claveAdmin := cfg.ClaveAdmin(); claveAdmin != "" && subtle.ConstantTimeCompare([]byte(clave), []byte(claveAdmin)) == 1 {
+            rol = RolAdministrador
+        }
+
+        // El certificado verificado en el handshake identifica al cliente con más certeza que la API Key
+        sujeto := SujetoCertificado(c.Request)
+        if sujeto != "" && slices.Contains(cfg.TLSClientesAdministradores, sujeto) {
+            rol = RolAdministrador
+        }
+
+        cliente := clienteSolicitante(cfg, clave, c.ClientIP())
+        if sujeto != "" {
+            cliente = "cert:" + sujeto
+        }
+        actor := actorSolicitante(cliente, rol, strings.TrimSpace(c.GetHeader(HeaderActor)))
+
+        c.Set("rolSolicitante", rol)
+        c.Set("actorSolicitante", actor)
+        c.Set("clienteSolicitante", cliente)
+
+        // Propagar la identidad en el contexto estándar para las capas que no conocen Gin
+        datos := solicitud.DesdeContexto(c.Request.Context())
+        datos.Actor, datos.Rol, datos.IP = actor, rol, c.ClientIP()
+        c.Request = c.Request.WithContext(solicitud.ConDatos(c.Request.Context(), datos))
+
+        c.Next()
+    }
+}
+
+// actorSolicitante construye el actor de auditoría. X-Usuario no está autenticado, por lo que solo se acepta de
+// un cliente identificado por certificado o API Key, y se registra junto a ese cliente (ej. cert:backoffice/ana):
+// quien lea la auditoría sabe qué sistema respondió por el usuario declarado.
+func actorSolicitante(cliente, rol, usuario string) string {
+    if strings.HasPrefix(cliente, "ip:") {
+        return "api:" + rol
+    }
+    if usuario == "" {
+        return cliente
+    }
+    return cliente + "/" + usuario
+}
+
+// SujetoCertificado retorna el CN del certificado de cliente verificado por mTLS, o el sujeto completo si no
+// tiene CN. Retorna vacío si la conexión no es TLS o el cliente no presentó un certificado verificado.
+func SujetoCertificado(r *http.Request) string {
+    if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+        return ""
+    }
+    sujeto := r.TLS.VerifiedChains[0][0].Subject
+    if sujeto.CommonName != "" {
+        return sujeto.CommonName
+    }
+    return sujeto.String()
+}
+
+// clienteSolicitante identifica al cliente para los límites: por la huella de su API Key, que no se expone en
+// logs ni configuración, o por su IP. La huella solo se usa si la clave es una de las configuradas (API_KEY,
+// ADMIN_API_KEY o un cliente de LIMITES_CLIENTES); de lo contrario, rotar el header daría un bucket y una
+// cuota nuevos en cada solicitud y llenaría el almacén de contadores.
+func clienteSolicitante(cfg *config.Config, clave, ip string) string {
+    if clave == "" {
+        return "ip:" + ip
+    }
+    suma := sha256.Sum256([]byte(clave))
+    huella := "clave:" + hex.EncodeToString(suma[:])[:12]
+    if !claveConocida(cfg, clave, huella) {
+        return "ip:" + ip
+    }
+    return huella
+}
+
+// claveConocida indica si la API Key coincide con API_KEY o ADMIN_API_KEY, o si su huella tiene reglas en
+// LIMITES_CLIENTES (por sí sola o en una ruta).
+func claveConocida(cfg *config.Config, clave, huella string) bool {
+    for _, configurada := range []string{cfg.ApiKey, cfg.ClaveAdmin()} {
+        if configurada != "" && subtle.ConstantTimeCompare([]byte(clave), []byte(configurada)) == 1 {
+            return true
+        }
+    }
+    for nombre := range cfg.LimitesClientes {
+        if cliente, _, _ := strings.Cut(nombre, "@"); cliente == huella {
+            return true
+        }
+    }
+    return false
+}
+
+// RolSolicitante retorna el rol resuelto por MiddlewareIdentidad, o RolOperador si no se resolvió.
+func RolSolicitante(c *gin.Context) string {
+    if rol := c.GetString("rolSolicitante"); rol != "" {
+        return rol
+    }
+    return RolOperador
+}
+
+// ActorSolicitante retorna el actor resuelto por MiddlewareIdentidad, o "api:" más el rol si no se resolvió.
+func ActorSolicitante(c *gin.Context) string {
+    if actor := c.GetString("actorSolicitante"); actor != "" {
+        return actor
+    }
+    return "api:" + RolSolicitante(c)
+}
+
+// ClienteSolicitante retorna el cliente resuelto por MiddlewareIdentidad (cert:<sujeto>, clave:<huella> o ip:<dirección>).
+// Sin MiddlewareIdentidad la API Key no puede validarse, por lo que el cliente se identifica por su IP.
+func ClienteSolicitante(c *gin.Context) string {
+    if cliente := c.GetString("clienteSolicitante"); cliente != "" {
+        return cliente
+    }
+    if sujeto := SujetoCertificado(c.Request); sujeto != "" {
+        return "cert:" + sujeto
+    }
+    return "ip:" + c.ClientIP()
+}
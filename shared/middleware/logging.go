@@ -4,6 +4,8 @@ import (
     "time"
     "github.com/gin-gonic/gin"
     "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/shared/metricas"
+    "github.com/CamiloScript/REGAPIGO/shared/solicitud"
     "github.com/google/uuid"
 )
 
@@ -13,9 +15,18 @@ import (
 // Retorna una función de middleware para Gin.
 func MiddlewareRegistro(registro *logger.Registrador) gin.HandlerFunc {
     return func(c *gin.Context) {
-        // Generar un ID único para la solicitud
-        idSolicitud := uuid.New().String()
+        // Usar el ID enviado por el cliente o un balanceador, o generar uno; se devuelve en la respuesta
+        idSolicitud := c.GetHeader(solicitud.EncabezadoID)
+        if !idSolicitudValido(idSolicitud) {
+            idSolicitud = uuid.New().String()
+        }
         c.Set("idSolicitud", idSolicitud)
+        c.Header(solicitud.EncabezadoID, idSolicitud)
+
+        // Propagar el ID en el contexto estándar para las capas que no conocen Gin
+        datos := solicitud.DesdeContexto(c.Request.Context())
+        datos.ID = idSolicitud
+        c.Request = c.Request.WithContext(solicitud.ConDatos(c.Request.Context(), datos))
 
         // Registrar el momento de inicio de la solicitud
         inicio := time.Now()
@@ -26,9 +37,15 @@ func MiddlewareRegistro(registro *logger.Registrador) gin.HandlerFunc {
         // Calcular la duración total de la solicitud
         duracion := time.Since(inicio)
 
+        // Registrar la métrica con la plantilla de la ruta (ej. /documentos/:id) para acotar las series
+        plantilla := c.FullPath()
+        if plantilla == "" {
+            plantilla = "sin_ruta"
+        }
+        metricas.ObservarSolicitudHTTP(c.Request.Method, plantilla, c.Writer.Status(), duracion)
+
         // Construir un mapa con los datos de la solicitud y la respuesta para el registro
         contextoRegistro := map[string]interface{}{
-            "id_solicitud":     idSolicitud,           // ID único de la solicitud
             "duracion":         duracion.String(),     // Duración total del procesamiento en formato de cadena
             "estado":           c.Writer.Status(),     // Código de estado HTTP devuelto en la respuesta
             "ip_cliente":       c.ClientIP(),          // Dirección IP del cliente que hizo la solicitud
@@ -47,17 +64,40 @@ func MiddlewareRegistro(registro *logger.Registrador) gin.HandlerFunc {
             contextoRegistro["referencia"] = referencia
         }
 
+        // Incluir el ID de la solicitud y el trace_id del span iniciado por MiddlewareTrazas
+        registroSolicitud := registro.ConContexto(c.Request.Context())
+
         // Determinar el nivel de registro basado en el código de estado HTTP de la respuesta
         switch {
         case c.Writer.Status() >= 500:
             // Registra un error si el código de estado es 500 o superior (errores del servidor)
-            registro.Error("Solicitud Finalizada, Error del Servidor", contextoRegistro)
+            registroSolicitud.Error("Solicitud Finalizada, Error del Servidor", contextoRegistro)
         case c.Writer.Status() >= 400:
             // Registra una advertencia si el código de estado está entre 400 y 499 (errores del cliente)
-            registro.Warn("Solicitud Finalizada, Error del Servicio", contextoRegistro)
+            registroSolicitud.Warn("Solicitud Finalizada, Error del Servicio", contextoRegistro)
         default:
             // Registra información estándar para respuestas exitosas (códigos 200-399)
-            registro.Info("Solicitud Completada Exitosamente", contextoRegistro)
+            registroSolicitud.Info("Solicitud Completada Exitosamente", contextoRegistro)
+        }
+    }
+}
+
+// longitudMaximaID acota el ID recibido del cliente, que se repite en cada línea de log.
+const longitudMaximaID = 128
+
+// idSolicitudValido acepta IDs de hasta 128 caracteres alfanuméricos o . _ : -, para que un encabezado
+// malicioso no inyecte saltos de línea ni texto arbitrario en los logs.
+func idSolicitudValido(id string) bool {
+    if id == "" || len(id) > longitudMaximaID {
+        return false
+    }
+    for _, caracter := range id {
+        switch {
+        case caracter >= 'a' && caracter <= 'z', caracter >= 'A' && caracter <= 'Z', caracter >= '0' && caracter <= '9':
+        case caracter == '.', caracter == '_', caracter == ':', caracter == '-':
+        default:
+            return false
         }
     }
-}
\ No newline at end of file
+    return true
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+    "net/http"
+    "strings"
+    "github.com/gin-gonic/gin"
+    "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// MiddlewareTrazas inicia el span de cada solicitud, continuando la traza del cliente si envía traceparent.
+// El span viaja en c.Request.Context() hasta Alfresco y MongoDB. Debe registrarse antes de MiddlewareRegistro
+// para que el log de la solicitud incluya el trace_id.
+// Parámetros:
+//   - servicio: Nombre del servidor en los atributos del span.
+// Retorna una función de middleware para Gin.
+func MiddlewareTrazas(servicio string) gin.HandlerFunc {
+    return otelgin.Middleware(servicio, otelgin.WithFilter(conTraza))
+}
+
+// conTraza excluye las sondas de salud y el scraping de métricas, que solo agregarían ruido a las trazas.
+func conTraza(r *http.Request) bool {
+    return r.URL.Path != "/metrics" && r.URL.Path != "/health" && !strings.HasPrefix(r.URL.Path, "/health/")
+}
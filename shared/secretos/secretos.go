@@ -0,0 +1,118 @@
+package secretos
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "sync"
+)
+
+// Esquemas de referencia soportados.
+const (
+    EsquemaArchivo = "file://" // file:///run/secrets/alfresco_api_key
+    EsquemaEntorno = "env:"    // env:ALFRESCO_API_KEY
+)
+
+// Proveedor resuelve una referencia a un secreto (SecretProvider). Se consulta al cargar la configuración
+// y cada vez que se revisa la rotación, por lo que debe retornar siempre el valor vigente.
+type Proveedor interface {
+    Resolver(referencia string) (string, error)
+}
+
+// EsReferencia indica si el valor es una referencia a un secreto en lugar del secreto mismo.
+func EsReferencia(valor string) bool {
+    return strings.HasPrefix(valor, EsquemaArchivo) || strings.HasPrefix(valor, EsquemaEntorno)
+}
+
+// ProveedorArchivo lee secretos desde archivos, como los montados por Docker o Kubernetes en /run/secrets.
+type ProveedorArchivo struct{}
+
+// Resolver retorna el contenido del archivo sin el salto de línea final.
+func (ProveedorArchivo) Resolver(referencia string) (string, error) {
+    ruta := strings.TrimPrefix(referencia, EsquemaArchivo)
+    if ruta == "" || ruta == referencia {
+        return "", fmt.Errorf("referencia de archivo inválida: %s", referencia)
+    }
+    contenido, err := os.ReadFile(ruta)
+    if err != nil {
+        return "", fmt.Errorf("no se pudo leer el secreto %s: %w", ruta, err)
+    }
+    return strings.TrimRight(string(contenido), "\r\n"), nil
+}
+
+// ProveedorEntorno lee secretos desde variables de entorno.
+type ProveedorEntorno struct{}
+
+// Resolver retorna el valor de la variable indicada; una variable inexistente es un error.
+func (ProveedorEntorno) Resolver(referencia string) (string, error) {
+    nombre := strings.TrimPrefix(referencia, EsquemaEntorno)
+    if nombre == "" || nombre == referencia {
+        return "", fmt.Errorf("referencia de entorno inválida: %s", referencia)
+    }
+    valor, existe := os.LookupEnv(nombre)
+    if !existe {
+        return "", fmt.Errorf("la variable de entorno %s no está definida", nombre)
+    }
+    return valor, nil
+}
+
+// Resolutor delega cada referencia en el proveedor de su esquema.
+type Resolutor struct {
+    proveedores map[string]Proveedor // Proveedor por esquema
+}
+
+// NuevoResolutor crea un resolutor con los proveedores indicados por esquema.
+func NuevoResolutor(proveedores map[string]Proveedor) *Resolutor {
+    return &Resolutor{proveedores: proveedores}
+}
+
+// ResolutorPorDefecto resuelve referencias file:// y env:.
+func ResolutorPorDefecto() *Resolutor {
+    return NuevoResolutor(map[string]Proveedor{
+        EsquemaArchivo: ProveedorArchivo{},
+        EsquemaEntorno: ProveedorEntorno{},
+    })
+}
+
+// Resolver implementa Proveedor.
+func (r *Resolutor) Resolver(referencia string) (string, error) {
+    for esquema, proveedor := range r.proveedores {
+        if strings.HasPrefix(referencia, esquema) {
+            return proveedor.Resolver(referencia)
+        }
+    }
+    return "", fmt.Errorf("esquema de secreto no soportado: %s", referencia)
+}
+
+// ProveedorMemoria es un doble de prueba que resuelve referencias desde un mapa y permite simular rotaciones.
+type ProveedorMemoria struct {
+    mu      sync.RWMutex
+    valores map[string]string
+}
+
+// NuevoProveedorMemoria crea el proveedor con los valores iniciales por referencia.
+func NuevoProveedorMemoria(valores map[string]string) *ProveedorMemoria {
+    copia := make(map[string]string, len(valores))
+    for referencia, valor := range valores {
+        copia[referencia] = valor
+    }
+    return &ProveedorMemoria{valores: copia}
+}
+
+// Establecer cambia el valor de una referencia, como lo haría una rotación.
+func (p *ProveedorMemoria) Establecer(referencia, valor string) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.valores[referencia] = valor
+}
+
+// Resolver implementa Proveedor.
+func (p *ProveedorMemoria) Resolver(referencia string) (string, error) {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+    valor, existe := p.valores[referencia]
+    if !existe {
+        return "", fmt.Errorf("secreto no encontrado: %s", referencia)
+    }
+    return valor, nil
+}
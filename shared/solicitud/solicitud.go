@@ -0,0 +1,31 @@
+package solicitud
+
+import "context"
+
+// EncabezadoID es el encabezado HTTP con el ID de la solicitud. Se acepta del cliente, se devuelve en la respuesta
+// y se reenvía a Alfresco para correlacionar los logs de ambos lados.
+const EncabezadoID = "X-Request-ID"
+
+// Datos identifica la solicitud en curso y a quien la origina.
+// Viaja en context.Context para que las capas de dominio y aplicación no dependan de Gin.
+type Datos struct {
+    ID    string // ID único de la solicitud
+    Actor string // Identidad de quien origina la solicitud
+    Rol   string // Rol del solicitante
+    IP    string // IP de origen
+}
+
+// claveDatos es la clave privada bajo la que se guardan los datos en el contexto.
+type claveDatos struct{}
+
+// ConDatos retorna un contexto derivado que transporta los datos de la solicitud.
+func ConDatos(ctx context.Context, datos Datos) context.Context {
+    return context.WithValue(ctx, claveDatos{}, datos)
+}
+
+// DesdeContexto retorna los datos de la solicitud, o valores vacíos si el contexto no los tiene
+// (por ejemplo, en tareas en segundo plano).
+func DesdeContexto(ctx context.Context) Datos {
+    datos, _ := ctx.Value(claveDatos{}).(Datos)
+    return datos
+}
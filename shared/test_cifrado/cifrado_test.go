@@ -0,0 +1,127 @@
+package test_cifrado
+
+import (
+    "bytes"
+    "errors"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/shared/cifrado"
+    "github.com/stretchr/testify/assert"
+)
+
+// clave retorna una clave de 32 bytes rellena con el byte indicado.
+func clave(b byte) []byte {
+    return bytes.Repeat([]byte{b}, cifrado.TamanoClave)
+}
+
+// nuevoCifrador crea un cifrador con las claves indicadas, activa la última.
+func nuevoCifrador(t *testing.T, ids ...string) *cifrado.Cifrador {
+    claves := make(map[string][]byte, len(ids))
+    for i, id := range ids {
+        claves[id] = clave(byte(i + 1))
+    }
+    cifrador, err := cifrado.NuevoCifrador(claves, ids[len(ids)-1], clave(0xAA))
+    if err != nil {
+        t.Fatalf("Error al crear el cifrador: %v", err)
+    }
+    return cifrador
+}
+
+// TestCifrarYDescifrar verifica el formato con id de clave, que el valor no queda en claro y que el texto vacío se conserva.
+func TestCifrarYDescifrar(t *testing.T) {
+    cifrador := nuevoCifrador(t, "k1")
+
+    valor, err := cifrador.Cifrar("rut_cliente", "12345678-9")
+    assert.NoError(t, err)
+    assert.True(t, strings.HasPrefix(valor, "enc:v1:k1:"))
+    assert.NotContains(t, valor, "12345678")
+    assert.True(t, cifrado.EsCifrado(valor))
+    assert.True(t, cifrador.Vigente(valor))
+
+    otro, _ := cifrador.Cifrar("rut_cliente", "12345678-9")
+    assert.NotEqual(t, valor, otro, "cada cifrado usa un nonce nuevo")
+
+    texto, err := cifrador.Descifrar("rut_cliente", valor)
+    assert.NoError(t, err)
+    assert.Equal(t, "12345678-9", texto)
+
+    vacio, _ := cifrador.Cifrar("rut_cliente", "")
+    assert.Equal(t, "", vacio)
+
+    // Los valores en claro (anteriores a habilitar el cifrado) se leen tal cual
+    texto, err = cifrador.Descifrar("rut_cliente", "11111111-1")
+    assert.NoError(t, err)
+    assert.Equal(t, "11111111-1", texto)
+    assert.False(t, cifrador.Vigente("11111111-1"))
+}
+
+// TestRotacionDeClaves verifica que se cifra con la clave activa y se descifra con las anteriores.
+func TestRotacionDeClaves(t *testing.T) {
+    anterior, _ := nuevoCifrador(t, "k1").Cifrar("razon_social_cliente", "Comercial Uno SpA")
+    rotado := nuevoCifrador(t, "k1", "k2")
+
+    texto, err := rotado.Descifrar("razon_social_cliente", anterior)
+    assert.NoError(t, err)
+    assert.Equal(t, "Comercial Uno SpA", texto)
+    assert.False(t, rotado.Vigente(anterior))
+
+    nuevo, _ := rotado.Cifrar("razon_social_cliente", texto)
+    assert.True(t, strings.HasPrefix(nuevo, "enc:v1:k2:"))
+    assert.True(t, rotado.Vigente(nuevo))
+
+    // Sin la clave anterior el valor ya no puede leerse
+    _, err = nuevoCifrador(t, "k2").Descifrar("razon_social_cliente", anterior)
+    assert.True(t, errors.Is(err, cifrado.ErrClaveDesconocida))
+}
+
+// TestValorAlterado verifica que un valor modificado o copiado a otro campo no se descifra.
+func TestValorAlterado(t *testing.T) {
+    cifrador := nuevoCifrador(t, "k1")
+    valor, _ := cifrador.Cifrar("rut_cliente", "12345678-9")
+
+    _, err := cifrador.Descifrar("razon_social_cliente", valor)
+    assert.Error(t, err)
+
+    alterado := valor[:len(valor)-2] + "AA"
+    if alterado == valor {
+        alterado = valor[:len(valor)-2] + "BB"
+    }
+    _, err = cifrador.Descifrar("rut_cliente", alterado)
+    assert.Error(t, err)
+
+    _, err = cifrador.Descifrar("rut_cliente", "enc:v1:k1:no-es-base64!")
+    assert.Error(t, err)
+}
+
+// TestIndiceCiego verifica que el índice es determinista, independiente de la clave de cifrado y distinto por campo.
+func TestIndiceCiego(t *testing.T) {
+    cifrador, rotado := nuevoCifrador(t, "k1"), nuevoCifrador(t, "k1", "k2")
+
+    indice := cifrador.IndiceCiego("rut_cliente", "12345678-9")
+    assert.Len(t, indice, 64)
+    assert.Equal(t, indice, rotado.IndiceCiego("rut_cliente", "12345678-9"))
+    assert.NotEqual(t, indice, cifrador.IndiceCiego("rut_cliente", "12345678-8"))
+    assert.NotEqual(t, indice, cifrador.IndiceCiego("razon_social_cliente", "12345678-9"))
+    assert.Equal(t, "", cifrador.IndiceCiego("rut_cliente", ""))
+
+    otraClave, err := cifrado.NuevoCifrador(map[string][]byte{"k1": clave(1)}, "k1", clave(0xBB))
+    if assert.NoError(t, err) {
+        assert.NotEqual(t, indice, otraClave.IndiceCiego("rut_cliente", "12345678-9"))
+    }
+}
+
+// TestClavesInvalidas verifica las validaciones al crear el cifrador.
+func TestClavesInvalidas(t *testing.T) {
+    casos := map[string]func() (*cifrado.Cifrador, error){
+        "clave corta":           func() (*cifrado.Cifrador, error) { return cifrado.NuevoCifrador(map[string][]byte{"k1": []byte("corta")}, "k1", clave(0xAA)) },
+        "activa inexistente":    func() (*cifrado.Cifrador, error) { return cifrado.NuevoCifrador(map[string][]byte{"k1": clave(1)}, "k2", clave(0xAA)) },
+        "id con separador":      func() (*cifrado.Cifrador, error) { return cifrado.NuevoCifrador(map[string][]byte{"k:1": clave(1)}, "k:1", clave(0xAA)) },
+        "clave de índice corta": func() (*cifrado.Cifrador, error) { return cifrado.NuevoCifrador(map[string][]byte{"k1": clave(1)}, "k1", []byte("corta")) },
+    }
+    for nombre, crear := range casos {
+        t.Run(nombre, func(t *testing.T) {
+            _, err := crear()
+            assert.Error(t, err)
+        })
+    }
+}
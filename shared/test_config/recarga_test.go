@@ -0,0 +1,82 @@
+package test_config
+
+import (
+    "os"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/secretos"
+    "github.com/stretchr/testify/assert"
+)
+
+// reescribir reemplaza el contenido del archivo de configuración, como lo haría un operador.
+func reescribir(t *testing.T, ruta, contenido string) {
+    if err := os.WriteFile(ruta, []byte(contenido), 0o600); err != nil {
+        t.Fatalf("Error al escribir el archivo de configuración: %v", err)
+    }
+}
+
+// TestRecargaAplicaClavesRecargables verifica que la recarga aplica nivel de log, tipos MIME y URL de Alfresco,
+// y que informa aparte las claves que requieren reiniciar.
+func TestRecargaAplicaClavesRecargables(t *testing.T) {
+    ruta := archivoConfiguracion(t, completo)
+    inicial, err := config.CargarConfiguracion(ruta)
+    if !assert.NoError(t, err) {
+        return
+    }
+    var aplicada *config.Config
+    recargador := config.NuevoRecargador(ruta, secretos.ResolutorPorDefecto(), inicial, func(nueva *config.Config) { aplicada = nueva })
+
+    // 1. Sin cambios no se aplica nada
+    cambios, requierenReinicio, err := recargador.Recargar()
+    assert.NoError(t, err)
+    assert.Empty(t, cambios)
+    assert.Empty(t, requierenReinicio)
+    assert.Nil(t, aplicada)
+
+    // 2. Cambiar claves recargables y una que requiere reiniciar
+    reescribir(t, ruta, strings.NewReplacer(
+        `"PORT": 6000`, `"PORT": 6001, "LOG_LEVEL": "DEBUG", "TIPOS_MIME_PERMITIDOS": "application/pdf,image/gif"`,
+        "https://alfresco.local/adf", "https://alfresco-dr.local/adf",
+    ).Replace(completo))
+    cambios, requierenReinicio, err = recargador.Recargar()
+    if !assert.NoError(t, err) {
+        return
+    }
+    claves := make([]string, 0, len(cambios))
+    for _, cambio := range cambios {
+        claves = append(claves, cambio.Clave)
+    }
+    assert.Equal(t, []string{"ALFRESCO_BASE_URL", "LOG_LEVEL", "TIPOS_MIME_PERMITIDOS"}, claves)
+    assert.Equal(t, []string{"PORT"}, requierenReinicio)
+    if assert.NotNil(t, aplicada) {
+        assert.Equal(t, "DEBUG", aplicada.LogLevel)
+        assert.Equal(t, []string{"application/pdf", "image/gif"}, aplicada.TiposMimePermitidos)
+        assert.Equal(t, "https://alfresco-dr.local/adf", aplicada.AlfrescoBaseURL)
+    }
+
+    // 3. La vigente conserva el puerto en uso
+    assert.Equal(t, "DEBUG", recargador.Vigente().LogLevel)
+    assert.Equal(t, "6000", recargador.Vigente().Puerto)
+}
+
+// TestRecargaRechazaConfiguracionInvalida verifica que una configuración inválida no se aplica.
+func TestRecargaRechazaConfiguracionInvalida(t *testing.T) {
+    ruta := archivoConfiguracion(t, completo)
+    inicial, err := config.CargarConfiguracion(ruta)
+    if !assert.NoError(t, err) {
+        return
+    }
+    aplicaciones := 0
+    recargador := config.NuevoRecargador(ruta, secretos.ResolutorPorDefecto(), inicial, func(*config.Config) { aplicaciones++ })
+
+    reescribir(t, ruta, strings.Replace(completo, `"PORT": 6000`, `"PORT": 6000, "LOG_LEVEL": "TODO", "TIPOS_MIME_PERMITIDOS": "pdf"`, 1))
+    _, _, err = recargador.Recargar()
+
+    var errConfiguracion *config.ErrorConfiguracion
+    if assert.ErrorAs(t, err, &errConfiguracion) {
+        assert.Len(t, errConfiguracion.Problemas, 2)
+    }
+    assert.Equal(t, 0, aplicaciones)
+    assert.Same(t, inicial, recargador.Vigente())
+}
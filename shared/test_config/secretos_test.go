@@ -0,0 +1,101 @@
+package test_config
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/secretos"
+    "github.com/stretchr/testify/assert"
+)
+
+// conReferencias usa referencias para la contraseña interna y la API Key de Alfresco.
+var conReferencias = strings.NewReplacer(
+    `"clave-alfresco"`, `"env:PRUEBA_CLAVE_ALFRESCO"`,
+    `"contraseña-super-secreta"`, `"file:///run/secrets/auth_password"`,
+).Replace(completo)
+
+// TestProveedoresArchivoYEntorno verifica la lectura de secretos desde archivos y variables de entorno.
+func TestProveedoresArchivoYEntorno(t *testing.T) {
+    ruta := filepath.Join(t.TempDir(), "auth_password")
+    if err := os.WriteFile(ruta, []byte("desde-archivo\n"), 0o600); err != nil {
+        t.Fatalf("Error al escribir el secreto: %v", err)
+    }
+    t.Setenv("PRUEBA_SECRETO", "desde-entorno")
+    resolutor := secretos.ResolutorPorDefecto()
+
+    valor, err := resolutor.Resolver(secretos.EsquemaArchivo + ruta)
+    assert.NoError(t, err)
+    assert.Equal(t, "desde-archivo", valor)
+
+    valor, err = resolutor.Resolver("env:PRUEBA_SECRETO")
+    assert.NoError(t, err)
+    assert.Equal(t, "desde-entorno", valor)
+
+    _, err = resolutor.Resolver("env:PRUEBA_NO_DEFINIDA")
+    assert.Error(t, err)
+    _, err = resolutor.Resolver("vault://secreto")
+    assert.Error(t, err)
+}
+
+// TestSecretosResueltosAlCargar verifica que las referencias se resuelven al cargar y no aparecen en el resumen como valor.
+func TestSecretosResueltosAlCargar(t *testing.T) {
+    proveedor := secretos.NuevoProveedorMemoria(map[string]string{
+        "env:PRUEBA_CLAVE_ALFRESCO":          "clave-v1",
+        "file:///run/secrets/auth_password": "contraseña-v1",
+    })
+
+    cfg, err := config.CargarConfiguracionConSecretos(archivoConfiguracion(t, conReferencias), proveedor)
+    if !assert.NoError(t, err) {
+        return
+    }
+    assert.Equal(t, "clave-v1", cfg.ClaveAPIAlfresco())
+    assert.Equal(t, "contraseña-v1", cfg.ContrasenaAuth())
+    assert.NotContains(t, cfg.Resumen()["AUTH_PASSWORD"], "contraseña-v1")
+}
+
+// TestSecretoNoResuelto verifica que una referencia que no se puede resolver impide iniciar.
+func TestSecretoNoResuelto(t *testing.T) {
+    proveedor := secretos.NuevoProveedorMemoria(map[string]string{
+        "env:PRUEBA_CLAVE_ALFRESCO": "clave-v1",
+    })
+
+    _, err := config.CargarConfiguracionConSecretos(archivoConfiguracion(t, conReferencias), proveedor)
+    if assert.Error(t, err) {
+        assert.Contains(t, err.Error(), "AUTH_PASSWORD")
+    }
+}
+
+// TestRotacionDeSecretos verifica que la recarga aplica los valores nuevos y reporta solo las claves que cambiaron.
+func TestRotacionDeSecretos(t *testing.T) {
+    proveedor := secretos.NuevoProveedorMemoria(map[string]string{
+        "env:PRUEBA_CLAVE_ALFRESCO":          "clave-v1",
+        "file:///run/secrets/auth_password": "contraseña-v1",
+    })
+    cfg, err := config.CargarConfiguracionConSecretos(archivoConfiguracion(t, conReferencias), proveedor)
+    if !assert.NoError(t, err) {
+        return
+    }
+
+    // 1. Sin cambios no se reporta nada
+    cambiadas, err := cfg.RecargarSecretos()
+    assert.NoError(t, err)
+    assert.Empty(t, cambiadas)
+
+    // 2. Rotar la contraseña
+    proveedor.Establecer("file:///run/secrets/auth_password", "contraseña-v2")
+    cambiadas, err = cfg.RecargarSecretos()
+    assert.NoError(t, err)
+    assert.Equal(t, []string{"AUTH_PASSWORD"}, cambiadas)
+    assert.Equal(t, "contraseña-v2", cfg.ContrasenaAuth())
+    assert.Equal(t, "clave-v1", cfg.ClaveAPIAlfresco())
+
+    // 3. Una rotación incompleta no se aplica a medias
+    proveedor.Establecer("env:PRUEBA_CLAVE_ALFRESCO", "clave-v2")
+    proveedor.Establecer("file:///run/secrets/auth_password", "")
+    _, err = cfg.RecargarSecretos()
+    assert.Error(t, err)
+    assert.Equal(t, "contraseña-v2", cfg.ContrasenaAuth())
+    assert.Equal(t, "clave-v1", cfg.ClaveAPIAlfresco())
+}
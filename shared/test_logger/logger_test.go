@@ -0,0 +1,103 @@
+package test_logger
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/stretchr/testify/assert"
+)
+
+// redactor crea un redactor con las reglas indicadas, fallando la prueba si alguna no existe.
+func redactor(t *testing.T, reglas []string, campos ...string) *logger.Redactor {
+    r, err := logger.NuevoRedactor(reglas, campos)
+    if err != nil {
+        t.Fatalf("Error al crear el redactor: %v", err)
+    }
+    return r
+}
+
+// TestRedaccionDeTextos verifica cada regla sobre textos libres (mensajes, errores, URLs).
+func TestRedaccionDeTextos(t *testing.T) {
+    documento := strings.Repeat("JVBERi0xLjQKJcfsj6IKNSAwIG9iago8PC9MZW5ndGggNiAwIFI+PgpzdHJlYW0K", 3)
+    casos := []struct {
+        nombre   string
+        regla    string
+        texto    string
+        esperado string
+    }{
+        {"ticket de Alfresco", logger.ReglaTicket, "ticket TICKET_4f9a0c2e inválido", "ticket TICKET_*** inválido"},
+        {"encabezado Authorization", logger.ReglaTicket, "Authorization: Basic VElDS0VUXzEyMw==", "Authorization: Basic ***"},
+        {"par ticket", logger.ReglaTicket, `{"ticket":"abc123"}`, `{"ticket":"***"}`},
+        {"contraseña en URL", logger.ReglaContrasena, "mongodb://api?password=s3cr3t&tls=true", "mongodb://api?password=***&tls=true"},
+        {"clave API", logger.ReglaClaveAPI, "ADFTannerServices: 9b1e-77", "ADFTannerServices: ***"},
+        {"base64", logger.ReglaBase64, "contenido " + documento, "contenido [base64 omitido, 192 caracteres]"},
+        {"RUT con puntos", logger.ReglaRUT, "cliente 12.345.678-9 sin contrato", "cliente **.***.678-9 sin contrato"},
+        {"RUT sin puntos", logger.ReglaRUT, "rut=9876543-K", "rut=****543-K"},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            assert.Equal(t, caso.esperado, redactor(t, []string{caso.regla}).Texto(caso.texto))
+        })
+    }
+}
+
+// TestRedaccionDeCampos verifica que los campos sensibles se ocultan sin importar mayúsculas ni guiones,
+// incluidos los configurados, y que las reglas inactivas no enmascaran.
+func TestRedaccionDeCampos(t *testing.T) {
+    r := redactor(t, logger.ReglasPorDefecto, "numero_tarjeta")
+    assert.Equal(t, logger.Oculto, r.Campo("Ticket", "TICKET_123"))
+    assert.Equal(t, logger.Oculto, r.Campo("API-Key", "9b1e-77"))
+    assert.Equal(t, logger.Oculto, r.Campo("password", "s3cr3t"))
+    assert.Equal(t, logger.Oculto, r.Campo("numeroTarjeta", "4111111111111111"))
+    assert.Equal(t, "carga.pdf", r.Campo("nombre", "carga.pdf"))
+
+    soloRUT := redactor(t, []string{logger.ReglaRUT})
+    assert.Equal(t, "TICKET_123", soloRUT.Campo("ticket", "TICKET_123"))
+
+    _, err := logger.NuevoRedactor([]string{"nombres"}, nil)
+    assert.Error(t, err)
+}
+
+// TestArchivoJSONConRedaccion verifica que el archivo de log recibe JSON ya enmascarado, con el nivel configurado.
+func TestArchivoJSONConRedaccion(t *testing.T) {
+    archivo := filepath.Join(t.TempDir(), "regapi.log")
+    log := logger.NuevoRegistradorConOpciones("TEST", logger.Opciones{
+        Formato:         logger.FormatoJSON,
+        Nivel:           "INFO",
+        Archivo:         archivo,
+        ArchivoTamanoMB: 1,
+        Redactor:        redactor(t, logger.ReglasPorDefecto),
+    })
+    t.Cleanup(func() { log.EstablecerNivel("INFO") })
+
+    log.Debug("No debe escribirse", nil)
+    log.Info("Autenticación de 12.345.678-9", map[string]interface{}{"ticket": "TICKET_abc", "intentos": 2})
+    log.Vaciar()
+
+    contenido, err := os.ReadFile(archivo)
+    if !assert.NoError(t, err) {
+        return
+    }
+    lineas := strings.Split(strings.TrimSpace(string(contenido)), "\n")
+    if !assert.Len(t, lineas, 1) {
+        return
+    }
+    var registro map[string]interface{}
+    assert.NoError(t, json.Unmarshal([]byte(lineas[0]), &registro))
+    assert.Equal(t, "Autenticación de **.***.678-9", registro["message"])
+    assert.Equal(t, logger.Oculto, registro["ticket"])
+    assert.Equal(t, "2", registro["intentos"])
+    assert.Equal(t, "TEST", registro["servicio"])
+}
+
+// TestConsolaConValoresNoTextuales verifica que el formato consola acepta campos que no son texto
+// (antes FormatFieldValue entraba en pánico con i.(string)).
+func TestConsolaConValoresNoTextuales(t *testing.T) {
+    log := logger.NuevoRegistrador("TEST", "|")
+    assert.NotPanics(t, func() {
+        log.Logger.Info().Int("reintentos", 3).Bool("cache", true).Msg("Campos tipados")
+    })
+}
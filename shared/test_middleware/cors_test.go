@@ -0,0 +1,162 @@
+package test_middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// configuracionCORS retorna una política como la de los valores por defecto, con los orígenes indicados.
+func configuracionCORS(origenes ...string) *config.Config {
+    return &config.Config{
+        CORSOrigenes:             origenes,
+        CORSMetodos:              []string{"GET", "POST", "DELETE", "OPTIONS"},
+        CORSEncabezados:          []string{"Content-Type", "ADFTannerServices", "X-Request-ID"},
+        CORSEncabezadosExpuestos: []string{"X-Request-ID", "Retry-After"},
+        CORSMaxAge:               12 * time.Hour,
+        SeguridadEncabezados:     true,
+        SeguridadHSTSMaxAge:      31536000,
+        SeguridadHSTSSubdominios: true,
+    }
+}
+
+// routerCORS arma una API con los middlewares de seguridad y CORS y una ruta POST /documentos/listar.
+func routerCORS(cfg *config.Config) *gin.Engine {
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareSeguridad(cfg), middleware.MiddlewareCORS(cfg))
+    router.POST("/documentos/listar", func(c *gin.Context) {
+        c.Header("X-Request-ID", "id-1")
+        c.Status(http.StatusOK)
+    })
+    return router
+}
+
+// preflight envía un OPTIONS como el de un navegador antes de un POST con API Key.
+func preflight(router *gin.Engine, origen string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest("OPTIONS", "/documentos/listar", nil)
+    req.Header.Set("Origin", origen)
+    req.Header.Set("Access-Control-Request-Method", "POST")
+    req.Header.Set("Access-Control-Request-Headers", "content-type,adftannerservices")
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+    return w
+}
+
+// TestPreflightPermitido verifica la respuesta a un preflight de un origen exacto y de un subdominio permitido.
+func TestPreflightPermitido(t *testing.T) {
+    router := routerCORS(configuracionCORS("https://portal.ejemplo.cl", "https://*.clientes.ejemplo.cl"))
+
+    for _, origen := range []string{"https://portal.ejemplo.cl", "https://acme.clientes.ejemplo.cl", "https://App.Norte.clientes.ejemplo.cl"} {
+        t.Run(origen, func(t *testing.T) {
+            w := preflight(router, origen)
+            assert.Equal(t, http.StatusNoContent, w.Code)
+            assert.Equal(t, origen, w.Header().Get("Access-Control-Allow-Origin"))
+            assert.Equal(t, "GET,POST,DELETE,OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+            assert.Equal(t, "Content-Type,Adftannerservices,X-Request-Id", w.Header().Get("Access-Control-Allow-Headers"))
+            assert.Equal(t, "43200", w.Header().Get("Access-Control-Max-Age"))
+            assert.Contains(t, w.Header().Values("Vary"), "Origin")
+            assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+        })
+    }
+}
+
+// TestPreflightRechazado verifica que los orígenes fuera de la política, incluidos los parecidos a un patrón,
+// se rechazan sin encabezados CORS.
+func TestPreflightRechazado(t *testing.T) {
+    router := routerCORS(configuracionCORS("https://portal.ejemplo.cl", "https://*.clientes.ejemplo.cl"))
+
+    for _, origen := range []string{
+        "https://otro.cl",
+        "http://portal.ejemplo.cl",          // Otro esquema
+        "https://portal.ejemplo.cl:8443",    // Otro puerto
+        "https://clientes.ejemplo.cl",       // El dominio del patrón no es un subdominio
+        "https://atacante-clientes.ejemplo.cl",
+        "https://x.clientes.ejemplo.cl.atacante.cl",
+        "https://user@x.clientes.ejemplo.cl",
+    } {
+        t.Run(origen, func(t *testing.T) {
+            w := preflight(router, origen)
+            assert.Equal(t, http.StatusForbidden, w.Code)
+            assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+        })
+    }
+}
+
+// TestSolicitudConOrigen verifica que las respuestas a un origen permitido exponen X-Request-ID y, si se
+// configuran credenciales, lo informan; y que las solicitudes sin Origin no se ven afectadas.
+func TestSolicitudConOrigen(t *testing.T) {
+    cfg := configuracionCORS("https://portal.ejemplo.cl")
+    cfg.CORSCredenciales = true
+    router := routerCORS(cfg)
+
+    req := httptest.NewRequest("POST", "/documentos/listar", nil)
+    req.Header.Set("Origin", "https://portal.ejemplo.cl")
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+    assert.Equal(t, http.StatusOK, w.Code)
+    assert.Equal(t, "https://portal.ejemplo.cl", w.Header().Get("Access-Control-Allow-Origin"))
+    assert.Equal(t, "X-Request-Id,Retry-After", w.Header().Get("Access-Control-Expose-Headers"))
+    assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+    sinOrigen := httptest.NewRecorder()
+    router.ServeHTTP(sinOrigen, httptest.NewRequest("POST", "/documentos/listar", nil))
+    assert.Equal(t, http.StatusOK, sinOrigen.Code)
+    assert.Empty(t, sinOrigen.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// TestTodosLosOrigenes verifica que * responde con Access-Control-Allow-Origin: * a cualquier origen.
+func TestTodosLosOrigenes(t *testing.T) {
+    w := preflight(routerCORS(configuracionCORS("*")), "https://cualquiera.cl")
+    assert.Equal(t, http.StatusNoContent, w.Code)
+    assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// TestSinOrigenesConfigurados verifica que sin CORS_ORIGENES no se responden preflights ni se agregan encabezados CORS.
+func TestSinOrigenesConfigurados(t *testing.T) {
+    router := routerCORS(configuracionCORS())
+
+    w := preflight(router, "https://portal.ejemplo.cl")
+    assert.Equal(t, http.StatusNotFound, w.Code)
+    assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+    req := httptest.NewRequest("POST", "/documentos/listar", nil)
+    req.Header.Set("Origin", "https://portal.ejemplo.cl")
+    simple := httptest.NewRecorder()
+    router.ServeHTTP(simple, req)
+    assert.Equal(t, http.StatusOK, simple.Code)
+    assert.Empty(t, simple.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// TestEncabezadosSeguridad verifica nosniff, DENY y HSTS en respuestas normales, de error y de preflight.
+func TestEncabezadosSeguridad(t *testing.T) {
+    router := routerCORS(configuracionCORS("https://portal.ejemplo.cl"))
+
+    rechazado := preflight(router, "https://otro.cl")
+    noEncontrado := httptest.NewRecorder()
+    router.ServeHTTP(noEncontrado, httptest.NewRequest("GET", "/no-existe", nil))
+
+    for nombre, w := range map[string]*httptest.ResponseRecorder{"preflight rechazado": rechazado, "404": noEncontrado} {
+        assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"), nombre)
+        assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"), nombre)
+        assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"), nombre)
+    }
+
+    // HSTS se omite con max-age 0 y todos los encabezados si se desactivan
+    cfg := configuracionCORS()
+    cfg.SeguridadHSTSMaxAge = 0
+    w := httptest.NewRecorder()
+    routerCORS(cfg).ServeHTTP(w, httptest.NewRequest("POST", "/documentos/listar", nil))
+    assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+    assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+    cfg.SeguridadEncabezados = false
+    w = httptest.NewRecorder()
+    routerCORS(cfg).ServeHTTP(w, httptest.NewRequest("POST", "/documentos/listar", nil))
+    assert.Empty(t, w.Header().Get("X-Frame-Options"))
+}
@@ -0,0 +1,84 @@
+package test_middleware
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/middleware"
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+)
+
+// huella retorna el identificador de cliente de una API Key, como se escribe en LIMITES_CLIENTES.
+func huella(clave string) string {
+    suma := sha256.Sum256([]byte(clave))
+    return "clave:" + hex.EncodeToString(suma[:])[:12]
+}
+
+// identidad envía una solicitud con la API Key y el X-Usuario indicados y retorna el rol, el actor y el cliente resueltos.
+func identidad(t *testing.T, cfg *config.Config, clave, usuario string) map[string]string {
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(middleware.MiddlewareIdentidad(cfg))
+    router.GET("/identidad", func(c *gin.Context) {
+        c.JSON(http.StatusOK, map[string]string{
+            "rol":     middleware.RolSolicitante(c),
+            "actor":   middleware.ActorSolicitante(c),
+            "cliente": middleware.ClienteSolicitante(c),
+        })
+    })
+
+    req := httptest.NewRequest("GET", "/identidad", nil)
+    req.RemoteAddr = "10.0.0.5:4000"
+    if clave != "" {
+        req.Header.Set("ADFTannerServices", clave)
+    }
+    if usuario != "" {
+        req.Header.Set(middleware.HeaderActor, usuario)
+    }
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    var resultado map[string]string
+    if err := json.Unmarshal(w.Body.Bytes(), &resultado); err != nil {
+        t.Fatalf("Respuesta inválida: %v", err)
+    }
+    return resultado
+}
+
+// TestIdentidadSoloConClavesConfiguradas verifica que solo una API Key configurada identifica al cliente y
+// respalda el X-Usuario declarado; un cliente no autenticado no puede hacerse pasar por otro usuario.
+func TestIdentidadSoloConClavesConfiguradas(t *testing.T) {
+    cfg := &config.Config{
+        ApiKey:          "clave-api",
+        AdminApiKey:     "clave-admin",
+        LimitesClientes: map[string]config.ReglaLimite{huella("clave-socio") + "@/documentos/subir": {}},
+    }
+
+    casos := []struct {
+        nombre  string
+        clave   string
+        usuario string
+        rol     string
+        actor   string
+        cliente string
+    }{
+        {"sin clave", "", "", middleware.RolOperador, "api:operador", "ip:10.0.0.5"},
+        {"clave desconocida", "inventada", "ana", middleware.RolOperador, "api:operador", "ip:10.0.0.5"},
+        {"API_KEY", "clave-api", "ana", middleware.RolOperador, huella("clave-api") + "/ana", huella("clave-api")},
+        {"ADMIN_API_KEY", "clave-admin", "", middleware.RolAdministrador, huella("clave-admin"), huella("clave-admin")},
+        {"LIMITES_CLIENTES", "clave-socio", "luis", middleware.RolOperador, huella("clave-socio") + "/luis", huella("clave-socio")},
+    }
+    for _, caso := range casos {
+        t.Run(caso.nombre, func(t *testing.T) {
+            resultado := identidad(t, cfg, caso.clave, caso.usuario)
+            assert.Equal(t, caso.rol, resultado["rol"])
+            assert.Equal(t, caso.actor, resultado["actor"])
+            assert.Equal(t, caso.cliente, resultado["cliente"])
+        })
+    }
+}
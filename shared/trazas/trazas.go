@@ -0,0 +1,77 @@
+package trazas
+
+import (
+    "context"
+    "fmt"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+    "go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+    "go.opentelemetry.io/otel/propagation"
+    "go.opentelemetry.io/otel/sdk/resource"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+    "go.opentelemetry.io/otel/trace"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+)
+
+// Instrumentacion identifica a los spans creados por la aplicación (los de Gin y MongoDB los crean sus librerías).
+const Instrumentacion = "github.com/CamiloScript/REGAPIGO"
+
+// Exportadores de spans disponibles en TRAZAS_EXPORTADOR.
+const (
+    ExportadorNinguno = "ninguno" // No se exportan spans; el traceparent recibido se sigue propagando
+    ExportadorStdout  = "stdout"  // Spans en JSON por la salida estándar, para desarrollo
+    ExportadorOTLP    = "otlp"    // Spans por OTLP/HTTP a un collector (Jaeger, Tempo, ...)
+)
+
+// Configurar instala el proveedor de trazas y el propagador W3C (traceparent y baggage) globales.
+// Parámetros:
+//   - cfg: Configuración con el exportador, el endpoint OTLP y la proporción de muestreo.
+//   - servicio: Nombre del servicio en los spans exportados.
+// Retorna la función que vacía los spans pendientes al apagar, o un error si el exportador no pudo crearse.
+func Configurar(cfg *config.Config, servicio string) (func(context.Context) error, error) {
+    // 1. Propagar siempre el contexto de traza, aunque no se exporte, para no cortar la traza del cliente
+    otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+    // 2. Construir el exportador configurado
+    var exportador sdktrace.SpanExporter
+    var err error
+    switch cfg.TrazasExportador {
+    case ExportadorStdout:
+        exportador, err = stdouttrace.New()
+    case ExportadorOTLP:
+        opciones := []otlptracehttp.Option{}
+        if cfg.TrazasEndpoint != "" {
+            opciones = append(opciones, otlptracehttp.WithEndpointURL(cfg.TrazasEndpoint))
+        }
+        exportador, err = otlptracehttp.New(context.Background(), opciones...)
+    default:
+        return func(context.Context) error { return nil }, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("error al crear el exportador de trazas %s: %w", cfg.TrazasExportador, err)
+    }
+
+    // 3. Instalar el proveedor; respeta la decisión de muestreo del llamador y muestrea las trazas nuevas
+    proveedor := sdktrace.NewTracerProvider(
+        sdktrace.WithBatcher(exportador),
+        sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TrazasMuestreo))),
+        sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(servicio))),
+    )
+    otel.SetTracerProvider(proveedor)
+    return proveedor.Shutdown, nil
+}
+
+// Iniciar crea un span hijo del que viaja en ctx. Quien lo llama debe terminarlo con span.End().
+func Iniciar(ctx context.Context, nombre string, opciones ...trace.SpanStartOption) (context.Context, trace.Span) {
+    return otel.Tracer(Instrumentacion).Start(ctx, nombre, opciones...)
+}
+
+// Identificadores retorna los IDs de traza y span en hexadecimal, o cadenas vacías si ctx no tiene traza.
+func Identificadores(ctx context.Context) (string, string) {
+    contexto := trace.SpanContextFromContext(ctx)
+    if !contexto.IsValid() {
+        return "", ""
+    }
+    return contexto.TraceID().String(), contexto.SpanID().String()
+}
@@ -4,6 +4,7 @@ import (
     "bytes"
     "encoding/base64"
     "errors"
+    "net/http"
     "strings"
 )
 
@@ -111,6 +112,12 @@ func DetectMimeTypeFromContent(content []byte) string {
         }
     }
     
+    // Otros tipos según la detección de la biblioteca estándar, sin parámetros (ej. "; charset=utf-8")
+    if len(content) > 0 {
+        tipo, _, _ := strings.Cut(http.DetectContentType(content), ";")
+        return tipo
+    }
+
     // Por defecto
     return "application/octet-stream"
 }
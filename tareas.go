@@ -0,0 +1,206 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
+    "github.com/CamiloScript/REGAPIGO/shared/secretos"
+    "github.com/CamiloScript/REGAPIGO/application/documento"
+    "github.com/CamiloScript/REGAPIGO/application/notificacion"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/webhook"
+    infraeventos "github.com/CamiloScript/REGAPIGO/infraestructure/eventos"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/servicio"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/routes"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/servidor"
+    "github.com/CamiloScript/REGAPIGO/shared/config"
+    "github.com/CamiloScript/REGAPIGO/shared/logger"
+    "github.com/CamiloScript/REGAPIGO/infraestructure/persistence/db/mongo"
+)
+
+// iniciarTareas inicia las tareas en segundo plano habilitadas en la configuración.
+func iniciarTareas(srv *servidor.Servidor, cfg *config.Config, log *logger.Registrador, deps routes.Dependencias, rutaConfiguracion string, proveedorSecretos secretos.Proveedor) {
+    // 1. Drenar el outbox de eventos hacia el receptor webhook
+    if cfg.EventosPublicador == infraeventos.PublicadorTipoOutbox {
+        iniciarRelayOutbox(srv, cfg, log, deps)
+    }
+
+    // 2. Aplicar rotaciones de secretos referenciados con file:// o env: sin reiniciar
+    srv.IniciarTarea(func(ctx context.Context) { vigilarSecretos(ctx, cfg, log, deps.ConexionAlfresco) })
+
+    // 3. Recargar nivel de log, tipos MIME y URL de Alfresco con SIGHUP o al modificar el archivo
+    recargador := config.NuevoRecargador(rutaConfiguracion, proveedorSecretos, cfg, func(nueva *config.Config) {
+        log.EstablecerNivel(nueva.LogLevel)
+        deps.ServicioDocumentos.EstablecerTiposPermitidos(nueva.TiposMimePermitidos)
+        deps.ConexionAlfresco.EstablecerURLBase(nueva.AlfrescoBaseURL)
+    })
+    srv.IniciarTarea(func(ctx context.Context) {
+        vigilarConfiguracion(ctx, recargador, config.RutaArchivo(rutaConfiguracion), cfg.ConfigIntervalo, log)
+    })
+
+    // 4. Programador de vencimiento de documentos (una sola réplica mediante bloqueo en MongoDB)
+    if cfg.VigenciaHabilitada {
+        iniciarProgramadorVigencia(srv, cfg, log, deps)
+    }
+
+    // 5. Notificador de documentos por vencer (webhooks)
+    if cfg.WebhookHabilitado {
+        iniciarNotificadorVencimientos(srv, cfg, log, deps)
+    }
+}
+
+// vigilarSecretos vuelve a resolver los secretos periódicamente y aplica los que rotaron.
+// La contraseña interna y la API Key de administrador se leen de la configuración en cada uso; la API Key
+// de Alfresco se propaga a la conexión. MONGODB_URI solo se resuelve al iniciar.
+func vigilarSecretos(ctx context.Context, cfg *config.Config, log *logger.Registrador, conexionAlfresco *servicio.ConexionAlfresco) {
+    temporizador := time.NewTicker(cfg.SecretosIntervalo)
+    defer temporizador.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-temporizador.C:
+        }
+
+        cambiadas, err := cfg.RecargarSecretos()
+        if err != nil {
+            log.Warn("No se pudieron recargar los secretos; se mantienen los anteriores", map[string]interface{}{"error": err.Error()})
+            continue
+        }
+        if len(cambiadas) == 0 {
+            continue
+        }
+        conexionAlfresco.EstablecerClaveAPI(cfg.ClaveAPIAlfresco())
+        log.Info("Secretos rotados", map[string]interface{}{"claves": cambiadas})
+    }
+}
+
+// vigilarConfiguracion recarga la configuración al recibir SIGHUP o al cambiar la fecha de modificación del
+// archivo, y registra qué cambió. Una configuración inválida se rechaza y se mantiene la vigente.
+func vigilarConfiguracion(ctx context.Context, recargador *config.Recargador, ruta string, intervalo time.Duration, log *logger.Registrador) {
+    senales := make(chan os.Signal, 1)
+    signal.Notify(senales, syscall.SIGHUP)
+    defer signal.Stop(senales)
+    temporizador := time.NewTicker(intervalo)
+    defer temporizador.Stop()
+    modificado := fechaModificacion(ruta)
+
+    for {
+        // 1. Esperar SIGHUP o un cambio en el archivo
+        motivo := "SIGHUP"
+        select {
+        case <-ctx.Done():
+            return
+        case <-senales:
+        case <-temporizador.C:
+            actual := fechaModificacion(ruta)
+            if actual.Equal(modificado) {
+                continue
+            }
+            modificado = actual
+            motivo = "archivo modificado"
+        }
+
+        // 2. Recargar; los problemas se informan igual que al iniciar
+        cambios, requierenReinicio, err := recargador.Recargar()
+        if err != nil {
+            var errConfiguracion *config.ErrorConfiguracion
+            if errors.As(err, &errConfiguracion) {
+                for _, problema := range errConfiguracion.Problemas {
+                    log.Error("Problema de configuración", map[string]interface{}{"detalle": problema})
+                }
+            }
+            log.Error("Configuración recargada inválida; se mantiene la vigente", map[string]interface{}{"motivo": motivo, "error": err.Error()})
+            continue
+        }
+
+        // 3. Registrar la diferencia
+        if len(requierenReinicio) > 0 {
+            log.Warn("Claves modificadas que solo se aplican al reiniciar", map[string]interface{}{"claves": requierenReinicio})
+        }
+        if len(cambios) == 0 {
+            log.Info("Configuración recargada sin cambios aplicables", map[string]interface{}{"motivo": motivo})
+            continue
+        }
+        diferencia := make(map[string]interface{}, len(cambios)+1)
+        diferencia["motivo"] = motivo
+        for _, cambio := range cambios {
+            diferencia[cambio.Clave] = cambio.Anterior + " -> " + cambio.Nuevo
+        }
+        log.Info("Configuración recargada", diferencia)
+    }
+}
+
+// fechaModificacion retorna la fecha de modificación del archivo, o cero si no existe.
+func fechaModificacion(ruta string) time.Time {
+    informacion, err := os.Stat(ruta)
+    if err != nil {
+        return time.Time{}
+    }
+    return informacion.ModTime()
+}
+
+// iniciarProgramadorVigencia construye el programador de vencimiento y lo ejecuta en segundo plano.
+func iniciarProgramadorVigencia(srv *servidor.Servidor, cfg *config.Config, log *logger.Registrador, deps routes.Dependencias) {
+    clienteMongo := deps.ClienteMongo
+    indice := mongo.NuevoIndiceVigencia(clienteMongo, cfg)
+    indice.EstablecerCifrador(deps.Cifrador)
+    if err := indice.CrearIndices(context.Background()); err != nil {
+        log.Warn("No se pudo crear el índice de vigencia", map[string]interface{}{"error": err.Error()})
+    }
+
+    programador := documento.NuevoProgramadorVigencia(
+        indice,
+        deps.ServicioDocumentos,
+        mongo.NuevoBloqueo(clienteMongo, cfg, "programador-vigencia", propietarioReplica),
+        deps.AuthInterna,
+        cfg.VigenciaIntervalo,
+        log,
+    )
+    srv.IniciarTarea(programador.Iniciar)
+}
+
+// iniciarNotificadorVencimientos construye el notificador de webhooks y lo ejecuta en segundo plano.
+func iniciarNotificadorVencimientos(srv *servidor.Servidor, cfg *config.Config, log *logger.Registrador, deps routes.Dependencias) {
+    clienteMongo := deps.ClienteMongo
+    indice := mongo.NuevoIndiceVigencia(clienteMongo, cfg)
+    indice.EstablecerCifrador(deps.Cifrador)
+    suscripciones := mongo.NuevoRepositorioSuscripciones(clienteMongo, cfg)
+    suscripciones.EstablecerCifrador(deps.Cifrador)
+    entregas := mongo.NuevoRepositorioEntregas(clienteMongo, cfg)
+    if err := entregas.CrearIndices(context.Background()); err != nil {
+        log.Warn("No se pudo crear el índice de entregas", map[string]interface{}{"error": err.Error()})
+    }
+
+    notificador := notificacion.NuevoNotificadorVencimientos(
+        suscripciones,
+        entregas,
+        indice,
+        webhook.NuevoClienteWebhook(cfg.WebhookReintentos, cfg.WebhookEsperaBase, log),
+        mongo.NuevoBloqueo(clienteMongo, cfg, "notificador-vencimientos", propietarioReplica),
+        cfg.WebhookIntervalo,
+        log,
+    )
+    notificador.EstablecerEnviosMaximos(cfg.WebhookEnviosMaximos)
+    srv.IniciarTarea(notificador.Iniciar)
+}
+
+// iniciarRelayOutbox drena el outbox de eventos hacia el receptor webhook configurado.
+func iniciarRelayOutbox(srv *servidor.Servidor, cfg *config.Config, log *logger.Registrador, deps routes.Dependencias) {
+    destino, err := infraeventos.NuevoPublicadorWebhookDesdeConfiguracion(cfg, log)
+    if err != nil {
+        log.Fatal("Configuración del relay de eventos inválida", map[string]interface{}{"error": err.Error()})
+    }
+    outbox := mongo.NuevoOutbox(deps.ClienteMongo, cfg)
+    outbox.EstablecerCifrador(deps.Cifrador)
+    relay := infraeventos.NuevoRelayOutbox(
+        outbox,
+        destino,
+        mongo.NuevoBloqueo(deps.ClienteMongo, cfg, "relay-outbox", propietarioReplica),
+        cfg.EventosRelayIntervalo,
+        log,
+    )
+    srv.IniciarTarea(relay.Iniciar)
+}